@@ -0,0 +1,49 @@
+// Command jwtkey 生成 JWT 非对称签名密钥，用于启用 RS256/EdDSA 或轮换密钥。
+//
+// 新密钥写入 -dir/<kid>.pem。轮换步骤：
+//  1. 生成新密钥，同时在配置里把 jwt.active_kid 固定为当前密钥，重启各实例——
+//     新公钥先出现在 JWKS 里，给下游缓存留出刷新时间；
+//  2. 把 jwt.active_kid 改为新 kid（或置空取最新），再次重启，开始用新密钥签发；
+//  3. 等过了 refresh_token_exp，旧密钥签发的 token 全部过期后，删除旧的 .pem 文件。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+)
+
+func main() {
+	dir := flag.String("dir", "conf/jwt_keys", "key directory (jwt.keys_dir)")
+	alg := flag.String("alg", jwtauth.AlgEdDSA, "signing algorithm: RS256 | EdDSA")
+	list := flag.Bool("list", false, "list keys in -dir instead of generating a new one")
+	flag.Parse()
+
+	if *list {
+		keys, err := jwtauth.LoadKeySet(*dir, *alg, "")
+		if err != nil {
+			fail(err)
+		}
+		for _, key := range keys.JWKS().Keys {
+			marker := " "
+			if key.Kid == keys.ActiveKID() {
+				marker = "*"
+			}
+			fmt.Printf("%s %s\t%s\n", marker, key.Kid, key.Alg)
+		}
+		return
+	}
+
+	kid, err := jwtauth.GenerateKeyFile(*dir, *alg)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("jwtkey: generated %s key %s in %s\n", *alg, kid, *dir)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "jwtkey: %v\n", err)
+	os.Exit(1)
+}
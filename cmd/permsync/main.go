@@ -0,0 +1,56 @@
+// Command permsync 按路由表（// @route 生成的 routes_gen.go）同步接口权限：
+// 为还没有权限记录的接口在所属模块的分组菜单下新建一条，并授予 permission_sync.grant_roles；
+// 已有接口权限匹配不到任何路由时标记 orphaned。只增不删。
+//
+//	go run ./cmd/permsync            # 同步
+//	go run ./cmd/permsync -dry-run   # 只打印差异
+//
+// 也可以设置 permission_sync.on_startup: true 在服务启动时自动执行。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ayxworxfr/go_admin/internal/bootstrap"
+	"github.com/ayxworxfr/go_admin/internal/platform/config"
+	"github.com/ayxworxfr/go_admin/pkg/pathutil"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	configPath := flag.String("config", "conf/config.yaml", "config file")
+	dryRun := flag.Bool("dry-run", false, "print the changes without writing them")
+	flag.Parse()
+
+	cfg, err := config.Load(pathutil.AbsPath(*configPath))
+	if err != nil {
+		fail(err)
+	}
+	result, err := bootstrap.SyncPermissions(context.Background(), cfg, *dryRun)
+	if err != nil {
+		fail(err)
+	}
+
+	show := func(label string, items []string) {
+		for _, item := range items {
+			fmt.Printf("%s\t%s\n", label, item)
+		}
+	}
+	show("module", result.Modules)
+	show("created", result.Created)
+	show("orphaned", result.Orphaned)
+	show("restored", result.Restored)
+	if *dryRun {
+		fmt.Println("permsync: dry run, nothing written")
+		return
+	}
+	fmt.Printf("permsync: %d created, %d orphaned, %d restored\n", len(result.Created), len(result.Orphaned), len(result.Restored))
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "permsync: %v\n", err)
+	os.Exit(1)
+}
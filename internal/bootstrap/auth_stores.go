@@ -0,0 +1,302 @@
+package bootstrap
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	iamcache "github.com/ayxworxfr/go_admin/internal/modules/iam/cache"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/loginguard"
+	iamservice "github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/session"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	userservice "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/internal/platform/config"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/ayxworxfr/go_admin/pkg/notifier"
+	"github.com/ayxworxfr/go_admin/pkg/oidc"
+	"github.com/ayxworxfr/go_admin/pkg/pathutil"
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+)
+
+// AuthStores 认证相关、可在 memory / redis 间切换的存储，由 Run 按配置构造后交给 Container
+type AuthStores struct {
+	Token           tokenstore.TokenStore
+	Session         session.Store
+	LoginGuard      loginguard.Store
+	LoginPolicy     loginguard.Policy
+	PermissionCache iamcache.PermissionCache
+}
+
+// newAuthStores 按 jwt.token_store / jwt.session_store / login_guard / permission_cache 的驱动分别选择策略；
+// 任一选了 redis 才建连接，且共用同一个连接池。
+// client 仅建了连接时非 nil，供组合根在退出时关闭。
+func newAuthStores(cfg *config.Config) (*AuthStores, *pkgredis.Client, error) {
+	policy, err := toLoginPolicy(cfg.LoginGuard)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var client *pkgredis.Client
+	if needsRedis(cfg) {
+		client, err = pkgredis.New(toRedisOptions(cfg.Redis))
+		if err != nil {
+			return nil, nil, fmt.Errorf("init redis for auth stores: %w", err)
+		}
+	}
+	fail := func(err error) (*AuthStores, *pkgredis.Client, error) {
+		if client != nil {
+			_ = client.Close()
+		}
+		return nil, nil, err
+	}
+
+	tokenStore, err := tokenstore.New(tokenstore.Options{
+		Driver:    cfg.JWT.TokenStore.Driver,
+		KeyPrefix: cfg.JWT.TokenStore.KeyPrefix,
+		Redis:     client,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	sessionStore, err := session.New(session.Options{
+		Driver:    cfg.JWT.SessionStore.Driver,
+		KeyPrefix: cfg.JWT.SessionStore.KeyPrefix,
+		Redis:     client,
+	})
+	if err != nil {
+		return fail(fmt.Errorf("init session store: %w", err))
+	}
+
+	guardStore, err := loginguard.New(loginguard.Options{
+		Driver:    cfg.LoginGuard.Driver,
+		KeyPrefix: cfg.LoginGuard.KeyPrefix,
+		Redis:     client,
+	})
+	if err != nil {
+		return fail(fmt.Errorf("init login guard: %w", err))
+	}
+
+	permCache, err := newPermissionCache(cfg.PermissionCache, client)
+	if err != nil {
+		return fail(fmt.Errorf("init permission cache: %w", err))
+	}
+
+	return &AuthStores{
+		Token:           tokenStore,
+		Session:         sessionStore,
+		LoginGuard:      guardStore,
+		LoginPolicy:     policy,
+		PermissionCache: permCache,
+	}, client, nil
+}
+
+func needsRedis(cfg *config.Config) bool {
+	for _, driver := range []string{cfg.JWT.TokenStore.Driver, cfg.JWT.SessionStore.Driver, cfg.LoginGuard.Driver, cfg.PermissionCache.Driver} {
+		if tokenstore.NormalizeDriver(driver) == tokenstore.DriverRedis {
+			return true
+		}
+	}
+	return false
+}
+
+// newPermissionCache 解析 permission_cache 的时长配置并按驱动创建权限缓存
+func newPermissionCache(cfg config.PermissionCacheConfig, client *pkgredis.Client) (iamcache.PermissionCache, error) {
+	ttl, err := time.ParseDuration(cfg.TTL)
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("invalid permission_cache.ttl %q", cfg.TTL)
+	}
+	var nearTTL time.Duration
+	if cfg.NearTTL != "" {
+		if nearTTL, err = time.ParseDuration(cfg.NearTTL); err != nil {
+			return nil, fmt.Errorf("invalid permission_cache.near_ttl: %w", err)
+		}
+	}
+	return iamcache.New(iamcache.Options{
+		Driver:    cfg.Driver,
+		KeyPrefix: cfg.KeyPrefix,
+		TTL:       ttl,
+		NearTTL:   nearTTL,
+		Redis:     client,
+	})
+}
+
+// toLoginPolicy 解析 login_guard 配置；enable=false 时返回零值策略（即关闭防护）
+func toLoginPolicy(cfg config.LoginGuardConfig) (loginguard.Policy, error) {
+	if !cfg.Enable {
+		return loginguard.Policy{}, nil
+	}
+	window, err := time.ParseDuration(cfg.Window)
+	if err != nil {
+		return loginguard.Policy{}, fmt.Errorf("invalid login_guard.window: %w", err)
+	}
+	lockout, err := time.ParseDuration(cfg.LockoutDuration)
+	if err != nil {
+		return loginguard.Policy{}, fmt.Errorf("invalid login_guard.lockout_duration: %w", err)
+	}
+	maxLockout, err := time.ParseDuration(cfg.MaxLockoutDuration)
+	if err != nil {
+		return loginguard.Policy{}, fmt.Errorf("invalid login_guard.max_lockout_duration: %w", err)
+	}
+	return loginguard.Policy{
+		MaxAttempts:        cfg.MaxAttempts,
+		Window:             window,
+		LockoutDuration:    lockout,
+		MaxLockoutDuration: maxLockout,
+	}, nil
+}
+
+// newJWT 按 jwt.algorithm 选择签名密钥：HS256 直接用 secret；非对称算法从 keys_dir 加载
+// 私钥，secret 仍配置着时保留为只验签的旧密钥，让切换前签发的 token 自然过期而不是全部失效
+func newJWT(cfg config.JWTConfig) (*jwtauth.JWT, error) {
+	if cfg.Algorithm == "" || cfg.Algorithm == jwtauth.AlgHS256 {
+		return jwtauth.NewJWT(cfg.Secret, cfg.AccessTokenExp, cfg.RefreshTokenExp)
+	}
+	dir := cfg.KeysDir
+	if !filepath.IsAbs(dir) {
+		dir = pathutil.AbsPath(dir)
+	}
+	keys, err := jwtauth.LoadKeySet(dir, cfg.Algorithm, cfg.ActiveKID)
+	if err != nil {
+		return nil, fmt.Errorf("load jwt keys: %w", err)
+	}
+	return jwtauth.NewJWTWithKeys(keys.WithLegacyHMAC(cfg.Secret), cfg.AccessTokenExp, cfg.RefreshTokenExp)
+}
+
+// toMFAOptions 解析 mfa 配置；secret_key 为空时用 jwt.secret 派生加密密钥
+func toMFAOptions(cfg *config.Config) (iamservice.MFAOptions, error) {
+	ttl, err := time.ParseDuration(cfg.MFA.ChallengeTTL)
+	if err != nil {
+		return iamservice.MFAOptions{}, fmt.Errorf("invalid mfa.challenge_ttl: %w", err)
+	}
+	secretKey := cfg.MFA.SecretKey
+	if secretKey == "" {
+		secretKey = cfg.JWT.Secret
+	}
+	return iamservice.MFAOptions{
+		Issuer:       cfg.MFA.Issuer,
+		SecretKey:    secretKey,
+		ChallengeTTL: ttl,
+		Skew:         cfg.MFA.Skew,
+	}, nil
+}
+
+// toImpersonationOptions 解析 impersonation 配置
+func toImpersonationOptions(cfg *config.Config) (iamservice.ImpersonationOptions, error) {
+	ttl, err := time.ParseDuration(cfg.Impersonation.TTL)
+	if err != nil {
+		return iamservice.ImpersonationOptions{}, fmt.Errorf("invalid impersonation.ttl: %w", err)
+	}
+	if ttl <= 0 {
+		return iamservice.ImpersonationOptions{}, fmt.Errorf("impersonation.ttl must be positive")
+	}
+	return iamservice.ImpersonationOptions{TTL: ttl, Routes: catalogRoutes()}, nil
+}
+
+// toOIDCOptions 解析 oidc 配置；未启用时返回 nil，Container 不创建 OIDCService
+func toOIDCOptions(cfg *config.Config) (*iamservice.OIDCOptions, error) {
+	oc := cfg.OIDC
+	if !oc.Enable {
+		return nil, nil
+	}
+	if oc.Issuer == "" || oc.ClientID == "" || oc.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc.issuer, oidc.client_id and oidc.redirect_url are required")
+	}
+	ttl, err := time.ParseDuration(oc.StateTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid oidc.state_ttl: %w", err)
+	}
+	stateKey := oc.StateKey
+	if stateKey == "" {
+		stateKey = cfg.JWT.Secret
+	}
+	if stateKey == "" {
+		return nil, fmt.Errorf("oidc.state_key is required when jwt.secret is empty")
+	}
+
+	mappings := make([]iamservice.OIDCRoleMapping, 0, len(oc.RoleMappings))
+	for _, m := range oc.RoleMappings {
+		mappings = append(mappings, iamservice.OIDCRoleMapping{Claim: m.Claim, Value: m.Value, Role: m.Role})
+	}
+	return &iamservice.OIDCOptions{
+		Provider: oidc.Config{
+			Issuer:       oc.Issuer,
+			ClientID:     oc.ClientID,
+			ClientSecret: oc.ClientSecret,
+			RedirectURL:  oc.RedirectURL,
+			Scopes:       oc.Scopes,
+		},
+		UsernameClaim:     oc.UsernameClaim,
+		EmailClaim:        oc.EmailClaim,
+		AutoProvision:     oc.AutoProvision,
+		LinkExistingUsers: oc.LinkExistingUsers,
+		RoleMappings:      mappings,
+		DefaultRoles:      oc.DefaultRoles,
+		SyncRoles:         oc.SyncRoles,
+		StateKey:          stateKey,
+		StateTTL:          ttl,
+	}, nil
+}
+
+// toPasswordResetOptions 解析 password_reset 配置；未启用时返回 nil，Container 不创建找回密码服务
+func toPasswordResetOptions(cfg *config.Config) (*userservice.PasswordResetOptions, error) {
+	pc := cfg.PasswordReset
+	if !pc.Enable {
+		return nil, nil
+	}
+	if pc.ResetURL == "" {
+		return nil, fmt.Errorf("password_reset.reset_url is required")
+	}
+	ttl, err := time.ParseDuration(pc.TokenTTL)
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("invalid password_reset.token_ttl %q", pc.TokenTTL)
+	}
+	secret := pc.SecretKey
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+	if secret == "" {
+		return nil, fmt.Errorf("password_reset.secret_key is required when jwt.secret is empty")
+	}
+	return &userservice.PasswordResetOptions{Secret: secret, TokenTTL: ttl, ResetURL: pc.ResetURL}, nil
+}
+
+// newNotifier 按 mail.driver 创建邮件投递器
+func newNotifier(cfg config.MailConfig) (notifier.Notifier, error) {
+	var timeout time.Duration
+	if cfg.SMTP.Timeout != "" {
+		d, err := time.ParseDuration(cfg.SMTP.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mail.smtp.timeout: %w", err)
+		}
+		timeout = d
+	}
+	dir := cfg.Dir
+	if dir != "" && !filepath.IsAbs(dir) {
+		dir = pathutil.AbsPath(dir)
+	}
+	return notifier.New(notifier.Options{
+		Driver: cfg.Driver,
+		From:   cfg.From,
+		Dir:    dir,
+		SMTP: notifier.SMTPOptions{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			TLS:      cfg.SMTP.TLS,
+			Timeout:  timeout,
+		},
+	})
+}
+
+func toRedisOptions(cfg config.RedisConfig) pkgredis.Options {
+	return pkgredis.Options{
+		Addr:         cfg.Addr(),
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+	}
+}
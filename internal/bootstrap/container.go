@@ -1,26 +1,27 @@
 package bootstrap
 
 import (
-	"time"
-
-	iamcache "github.com/ayxworxfr/go_admin/internal/modules/iam/cache"
+	filemodel "github.com/ayxworxfr/go_admin/internal/modules/file/model"
+	fileservice "github.com/ayxworxfr/go_admin/internal/modules/file/service"
 	iammodel "github.com/ayxworxfr/go_admin/internal/modules/iam/model"
 	iamservice "github.com/ayxworxfr/go_admin/internal/modules/iam/service"
 	iamtokenstore "github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	orgmodel "github.com/ayxworxfr/go_admin/internal/modules/org/model"
+	orgservice "github.com/ayxworxfr/go_admin/internal/modules/org/service"
 	ssmodel "github.com/ayxworxfr/go_admin/internal/modules/systemsetting/model"
 	ssservice "github.com/ayxworxfr/go_admin/internal/modules/systemsetting/service"
+	tenantmodel "github.com/ayxworxfr/go_admin/internal/modules/tenant/model"
+	tenantservice "github.com/ayxworxfr/go_admin/internal/modules/tenant/service"
 	usermodel "github.com/ayxworxfr/go_admin/internal/modules/user/model"
 	userservice "github.com/ayxworxfr/go_admin/internal/modules/user/service"
 	"github.com/ayxworxfr/go_admin/pkg/crypter"
 	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/ayxworxfr/go_admin/pkg/notifier"
 	"github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/storage"
 	"xorm.io/xorm"
 )
 
-// permissionCacheTTL 用户权限缓存的存活时间，与旧版 `cacheExpiration` 字段
-// 想表达但从未生效的语义一致——这里是真正会被读取判断的 TTL。
-const permissionCacheTTL = 1 * time.Hour
-
 // Container 是全项目唯一的显式依赖装配点，取代原来"构造完就塞进全局变量"
 // 的一堆 XxxInstance。它不是 DI 框架——只是把原来隐藏在各处的 `New*` 调用
 // 集中到一个地方按依赖顺序显式串联，任何人看这一个文件就能看出完整的依赖图。
@@ -28,46 +29,97 @@ type Container struct {
 	Engine *xorm.Engine
 
 	User          *userservice.Service
+	PasswordReset *userservice.PasswordResetService // 未启用找回密码时为 nil
+	UserBulk      *userservice.BulkService
 	Role          *iamservice.RoleService
 	Permission    *iamservice.PermissionService
 	UserRole      *iamservice.UserRoleService
 	Checker       *iamservice.PermissionChecker
+	DataScope     *iamservice.DataScopeService
 	Auth          *iamservice.AuthService
+	MFA           *iamservice.MFAService
+	Session       *iamservice.SessionService
+	PersonalToken *iamservice.PersonalTokenService
+	Impersonation *iamservice.ImpersonationService
+	OIDC          *iamservice.OIDCService // 未启用 OIDC 登录时为 nil
+	LoginGuard    *iamservice.LoginGuard
 	SystemSetting *ssservice.Service
+	Tenant        *tenantservice.Service
+	Org           *orgservice.Service
+	File          *fileservice.Service
 
 	JWT        *jwtauth.JWT
 	TokenStore iamtokenstore.TokenStore
 }
 
-// NewContainer 按依赖顺序装配全部服务。engine/hasher/jwt/tokenStore 由 Run 在
-// 创建基础设施后传入，Container 本身不关心它们是怎么来的。
-func NewContainer(engine *xorm.Engine, hasher crypter.PasswordHasher, jwt *jwtauth.JWT, tokenStore iamtokenstore.TokenStore) *Container {
+// NewContainer 按依赖顺序装配全部服务。engine/hasher/jwt/stores/mailer 及各项 Options 由 Run 在
+// 创建基础设施后传入，Container 本身不关心它们是怎么来的；oidcOpts / resetOpts 为 nil
+// 分别表示不启用 OIDC 登录、找回密码；fileStore 为上传文件的存储后端。
+func NewContainer(engine *xorm.Engine, hasher crypter.PasswordHasher, jwt *jwtauth.JWT, stores *AuthStores,
+	mfaOpts iamservice.MFAOptions, impersonationOpts iamservice.ImpersonationOptions, oidcOpts *iamservice.OIDCOptions, mailer notifier.Notifier, resetOpts *userservice.PasswordResetOptions,
+	fileStore storage.Storage, fileOpts fileservice.Options,
+) *Container {
 	db := repository.New(engine)
 
-	userSvc := userservice.NewService(db, hasher)
+	// SessionService 只依赖各类存储，先于 user.Service 构造，作为 TokenRevoker 注入：
+	// 停用/删除用户时由 user 回调 iam 吊销令牌，而 user 包不需要 import iam
+	sessionSvc := iamservice.NewSessionService(stores.Session, stores.Token, jwt)
+	// user 读密码策略用只读的 Reader；完整的 systemsetting.Service 依赖 user.UserFinder，放在后面构造
+	settingsReader := ssservice.NewReader(db)
+	// 物理删除用户时各模块清理自己的关联数据；清理器只依赖 db，同样先于 user.Service 构造
+	userSvc := userservice.NewService(db, hasher, sessionSvc, settingsReader,
+		iamservice.NewUserPurger(db), fileservice.NewUserPurger(db), orgservice.NewUserPurger(db))
+	var resetSvc *userservice.PasswordResetService
+	if resetOpts != nil {
+		resetSvc = userservice.NewPasswordResetService(userSvc, mailer, *resetOpts)
+	}
 
 	roleSvc := iamservice.NewRoleService(db)
 	permSvc := iamservice.NewPermissionService(db)
 	userRoleSvc := iamservice.NewUserRoleService(db, roleSvc, userSvc)
+	orgSvc := orgservice.NewService(db, userSvc, userSvc)
+	userBulkSvc := userservice.NewBulkService(userSvc, userRoleSvc)
 
-	permCache := iamcache.NewInMemoryCache(permissionCacheTTL)
-	checker := iamservice.NewPermissionChecker(userRoleSvc, roleSvc, permCache)
+	checker := iamservice.NewPermissionChecker(userRoleSvc, roleSvc, stores.PermissionCache)
+	dataScopeSvc := iamservice.NewDataScopeService(userRoleSvc, roleSvc, userSvc, orgSvc)
 
-	authSvc := iamservice.NewAuthService(userSvc, userSvc, userRoleSvc, tokenStore, jwt)
+	loginGuard := iamservice.NewLoginGuard(stores.LoginGuard, stores.LoginPolicy)
+	mfaSvc := iamservice.NewMFAService(db, userRoleSvc, hasher, mfaOpts)
+	authSvc := iamservice.NewAuthService(userSvc, userSvc, userSvc, userRoleSvc, sessionSvc, loginGuard, mfaSvc, stores.Token, jwt)
+	personalTokenSvc := iamservice.NewPersonalTokenService(db, userSvc)
+	impersonationSvc := iamservice.NewImpersonationService(db, userSvc, authSvc, checker, stores.Token, jwt, impersonationOpts)
+	var oidcSvc *iamservice.OIDCService
+	if oidcOpts != nil {
+		oidcSvc = iamservice.NewOIDCService(db, userSvc, userSvc, userRoleSvc, checker, authSvc, stores.Token, *oidcOpts)
+	}
 
 	ssSvc := ssservice.NewService(db, userSvc)
+	tenantSvc := tenantservice.NewService(db)
+	fileSvc := fileservice.NewService(db, fileStore, userSvc, fileOpts)
 
 	return &Container{
 		Engine:        engine,
 		User:          userSvc,
+		PasswordReset: resetSvc,
+		UserBulk:      userBulkSvc,
 		Role:          roleSvc,
 		Permission:    permSvc,
 		UserRole:      userRoleSvc,
 		Checker:       checker,
+		DataScope:     dataScopeSvc,
 		Auth:          authSvc,
+		MFA:           mfaSvc,
+		Session:       sessionSvc,
+		PersonalToken: personalTokenSvc,
+		Impersonation: impersonationSvc,
+		OIDC:          oidcSvc,
+		LoginGuard:    loginGuard,
 		SystemSetting: ssSvc,
+		Tenant:        tenantSvc,
+		Org:           orgSvc,
+		File:          fileSvc,
 		JWT:           jwt,
-		TokenStore:    tokenStore,
+		TokenStore:    stores.Token,
 	}
 }
 
@@ -76,10 +128,22 @@ func NewContainer(engine *xorm.Engine, hasher crypter.PasswordHasher, jwt *jwtau
 func (c *Container) Models() []any {
 	return []any{
 		new(usermodel.User),
+		new(usermodel.PasswordHistory),
 		new(iammodel.Role),
 		new(iammodel.Permission),
 		new(iammodel.UserRole),
 		new(iammodel.RolePermission),
+		new(iammodel.RoleParent),
+		new(iammodel.RoleDept),
+		new(iammodel.UserMFA),
+		new(iammodel.PersonalToken),
+		new(iammodel.UserIdentity),
+		new(iammodel.ImpersonationSession),
 		new(ssmodel.SystemSetting),
+		new(ssmodel.SystemSettingOverride),
+		new(tenantmodel.Tenant),
+		new(orgmodel.Dept),
+		new(filemodel.File),
+		new(filemodel.FileLink),
 	}
 }
@@ -0,0 +1,52 @@
+package bootstrap
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	fileservice "github.com/ayxworxfr/go_admin/internal/modules/file/service"
+	"github.com/ayxworxfr/go_admin/internal/platform/config"
+	"github.com/ayxworxfr/go_admin/pkg/pathutil"
+	"github.com/ayxworxfr/go_admin/pkg/storage"
+)
+
+// newFileStorage 按 file.storage.driver 创建上传文件的存储后端
+func newFileStorage(cfg config.FileStorageConfig) (storage.Storage, error) {
+	var timeout time.Duration
+	if cfg.S3.Timeout != "" {
+		d, err := time.ParseDuration(cfg.S3.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file.storage.s3.timeout: %w", err)
+		}
+		timeout = d
+	}
+	root := cfg.Root
+	if root != "" && !filepath.IsAbs(root) {
+		root = pathutil.AbsPath(root)
+	}
+	return storage.New(storage.Options{
+		Driver: cfg.Driver,
+		Root:   root,
+		S3: storage.S3Options{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+			Timeout:   timeout,
+		},
+	})
+}
+
+// toFileOptions 把上传限制换算成 file 服务的参数
+func toFileOptions(cfg *config.Config) (fileservice.Options, error) {
+	if cfg.File.MaxSizeMB <= 0 {
+		return fileservice.Options{}, fmt.Errorf("file.max_size_mb must be positive")
+	}
+	return fileservice.Options{
+		MaxSize:      cfg.File.MaxSizeMB << 20,
+		AllowedTypes: cfg.File.AllowedTypes,
+	}, nil
+}
@@ -2,8 +2,11 @@ package bootstrap
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	myapp "github.com/ayxworxfr/go_admin/internal/platform/app"
+	"github.com/ayxworxfr/go_admin/internal/platform/config"
 	platformcron "github.com/ayxworxfr/go_admin/internal/platform/cron"
 	"github.com/ayxworxfr/go_admin/internal/platform/middleware/sentinel"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
@@ -15,9 +18,9 @@ import (
 // registerInfra 注册启动/退出钩子：cron 失败即阻断启动；
 // Sentinel 失败只记日志（可观测性/防护不应拖垮业务端口）。
 // OpenTelemetry 必须在 NewApp 之前初始化，见 Run。
-func registerInfra(app *myapp.App) {
+func registerInfra(app *myapp.App, c *Container, purgeRetention time.Duration) {
 	app.RegisterInit(func() error {
-		if err := initCronTask(app); err != nil {
+		if err := initCronTask(app, c, purgeRetention); err != nil {
 			return errors.Wrap(err, "failed to initialize cron task")
 		}
 		initSentinel(context.Background())
@@ -25,9 +28,9 @@ func registerInfra(app *myapp.App) {
 	})
 }
 
-func initCronTask(app *myapp.App) error {
+func initCronTask(app *myapp.App, c *Container, purgeRetention time.Duration) error {
 	var result *multierror.Error
-	if taskManager, err := platformcron.InitCronTask(); err != nil {
+	if taskManager, err := platformcron.InitCronTask(c.Checker, purgeRetention, c.User, c.Role, c.Permission); err != nil {
 		result = multierror.Append(result, err)
 	} else {
 		app.RegisterExit(func() error {
@@ -38,6 +41,18 @@ func initCronTask(app *myapp.App) error {
 	return result.ErrorOrNil()
 }
 
+// toSoftDeleteRetention 解析回收站保留期
+func toSoftDeleteRetention(cfg *config.Config) (time.Duration, error) {
+	retention, err := time.ParseDuration(cfg.SoftDelete.Retention)
+	if err != nil {
+		return 0, fmt.Errorf("invalid soft_delete.retention: %w", err)
+	}
+	if retention <= 0 {
+		return 0, fmt.Errorf("soft_delete.retention must be positive")
+	}
+	return retention, nil
+}
+
 func initSentinel(ctx context.Context) {
 	configPath := pathutil.AbsPath("conf/sentinel.yaml")
 	if err := sentinel.InitSentinel(configPath); err != nil {
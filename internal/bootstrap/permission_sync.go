@@ -0,0 +1,63 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	iamdto "github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	iamservice "github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	myapp "github.com/ayxworxfr/go_admin/internal/platform/app"
+	"github.com/ayxworxfr/go_admin/internal/platform/config"
+	"github.com/ayxworxfr/go_admin/internal/platform/db"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/repository"
+)
+
+// catalogRoutes 业务路由表。Describe 只看 Handler 的类型，这里用零值 Container 构造 Handler，
+// 不需要装配任何服务，命令行同步因此不依赖 Redis、JWT 密钥等运行时配置
+func catalogRoutes() []iamdto.CatalogRoute {
+	routes := myapp.ProtectedRoutes(businessHandlers(&Container{})...)
+	result := make([]iamdto.CatalogRoute, 0, len(routes))
+	for _, rt := range routes {
+		result = append(result, iamdto.CatalogRoute{
+			Module: rt.Module,
+			Name:   rt.Handler,
+			Method: rt.Method.String(),
+			Path:   rt.Path,
+		})
+	}
+	return result
+}
+
+// syncPermissionCatalog 启动时同步接口权限。失败只记日志不阻止启动：多实例同时启动时
+// 可能撞上唯一键，由先完成的那个实例生效即可
+func syncPermissionCatalog(ctx context.Context, c *Container, cfg config.PermissionSyncConfig) {
+	result, err := c.Permission.SyncCatalog(ctx, catalogRoutes(), iamservice.CatalogSyncOptions{GrantRoles: cfg.GrantRoles})
+	if err != nil {
+		logger.Error(ctx, "Failed to sync permission catalog", logger.Err(err))
+		return
+	}
+	if len(result.Created) > 0 {
+		c.Checker.InvalidateAll()
+	}
+	logger.Info(ctx, "Permission catalog synced",
+		logger.Strings("created", result.Created),
+		logger.Strings("orphaned", result.Orphaned),
+		logger.Strings("restored", result.Restored))
+}
+
+// SyncPermissions 供 cmd/permsync 调用：只连数据库，按路由表同步接口权限。
+// 运行中的实例各自缓存了用户权限，新授予的权限在缓存 TTL 过后生效
+func SyncPermissions(ctx context.Context, cfg *config.Config, dryRun bool) (*iamdto.CatalogSyncResult, error) {
+	engine, err := db.NewEngine(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database engine: %w", err)
+	}
+	defer engine.Close()
+
+	permSvc := iamservice.NewPermissionService(repository.New(engine))
+	return permSvc.SyncCatalog(ctx, catalogRoutes(), iamservice.CatalogSyncOptions{
+		GrantRoles: cfg.PermissionSync.GrantRoles,
+		DryRun:     dryRun,
+	})
+}
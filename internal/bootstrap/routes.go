@@ -1,8 +1,11 @@
 package bootstrap
 
 import (
+	filehandler "github.com/ayxworxfr/go_admin/internal/modules/file/handler"
 	iamhandler "github.com/ayxworxfr/go_admin/internal/modules/iam/handler"
+	orghandler "github.com/ayxworxfr/go_admin/internal/modules/org/handler"
 	sshandler "github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler"
+	tenanthandler "github.com/ayxworxfr/go_admin/internal/modules/tenant/handler"
 	userhandler "github.com/ayxworxfr/go_admin/internal/modules/user/handler"
 	myapp "github.com/ayxworxfr/go_admin/internal/platform/app"
 	"github.com/ayxworxfr/go_admin/internal/platform/middleware"
@@ -12,15 +15,32 @@ import (
 // App.SetupRoutes 挂载路由。这是组合根里唯一"知道所有模块存在"的地方之一
 // （另一个是 Container 本身）。
 func setupRoutes(app *myapp.App, c *Container) {
+	jwksHandler := iamhandler.NewJWKSHandler(c.JWT)
 	authHandler := iamhandler.NewAuthHandler(c.Auth, c.JWT)
-	jwtMiddleware := middleware.NewJWTMiddleware(c.JWT, c.Checker, c.TokenStore)
+	oidcHandler := iamhandler.NewOIDCHandler(c.OIDC)
+	passwordResetHandler := userhandler.NewPasswordResetHandler(c.PasswordReset)
+	jwtMiddleware := middleware.NewJWTMiddleware(c.JWT, c.Checker, c.TokenStore, c.PersonalToken, c.DataScope)
 
-	userHandler := userhandler.NewHandler(c.User, c.UserRole, c.Checker)
-	roleHandler := iamhandler.NewRoleHandler(c.Role, c.Checker)
-	permissionHandler := iamhandler.NewPermissionHandler(c.Permission, c.Checker)
-	userRoleHandler := iamhandler.NewUserRoleHandler(c.UserRole, c.Checker)
-	systemSettingHandler := sshandler.NewHandler(c.SystemSetting)
+	app.SetupRoutes(jwksHandler, []any{authHandler, oidcHandler, passwordResetHandler}, jwtMiddleware, businessHandlers(c)...)
+}
 
-	app.SetupRoutes(authHandler, jwtMiddleware,
-		userHandler, roleHandler, permissionHandler, userRoleHandler, systemSettingHandler)
+// businessHandlers 挂在 /api/protected 下的业务 Handler。路由挂载和权限目录同步共用这一份列表，
+// 新增模块只需加在这里，同步就能看到它的接口
+func businessHandlers(c *Container) []any {
+	return []any{
+		userhandler.NewHandler(c.User, c.UserRole, c.Checker, c.Org),
+		userhandler.NewBulkHandler(c.UserBulk, c.Org),
+		iamhandler.NewRoleHandler(c.Role, c.Checker),
+		iamhandler.NewPermissionHandler(c.Permission, c.Checker),
+		iamhandler.NewUserRoleHandler(c.UserRole, c.Checker),
+		iamhandler.NewSessionHandler(c.Session, c.User),
+		iamhandler.NewLoginGuardHandler(c.LoginGuard),
+		iamhandler.NewMFAHandler(c.MFA),
+		iamhandler.NewPersonalTokenHandler(c.PersonalToken, c.User),
+		iamhandler.NewImpersonationHandler(c.Impersonation),
+		sshandler.NewHandler(c.SystemSetting),
+		tenanthandler.NewHandler(c.Tenant),
+		orghandler.NewHandler(c.Org),
+		filehandler.NewHandler(c.File),
+	}
 }
@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,7 +15,6 @@ import (
 	"github.com/ayxworxfr/go_admin/internal/platform/middleware"
 	"github.com/ayxworxfr/go_admin/internal/platform/middleware/sentinel"
 	"github.com/ayxworxfr/go_admin/pkg/crypter"
-	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
 )
 
@@ -23,7 +23,7 @@ const shutdownTimeout = 3 * time.Second
 // Run 是进程级组合根：创建基础设施 → 装配 Container → 挂中间件与路由 →
 // 阻塞监听信号并优雅退出。cmd/main 只负责加载配置与日志后调用本函数。
 func Run(cfg *config.Config) error {
-	jwt, err := jwtauth.NewJWT(cfg.JWT.Secret, cfg.JWT.AccessTokenExp, cfg.JWT.RefreshTokenExp)
+	jwt, err := newJWT(cfg.JWT)
 	if err != nil {
 		return fmt.Errorf("failed to initialize JWT: %w", err)
 	}
@@ -33,9 +33,49 @@ func Run(cfg *config.Config) error {
 		return fmt.Errorf("failed to initialize database engine: %w", err)
 	}
 
-	tokenStore, tokenStoreCloser, err := newTokenStore(cfg)
+	authStores, redisClient, err := newAuthStores(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize token store: %w", err)
+		return fmt.Errorf("failed to initialize auth stores: %w", err)
+	}
+
+	mfaOpts, err := toMFAOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize MFA: %w", err)
+	}
+
+	impersonationOpts, err := toImpersonationOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize impersonation: %w", err)
+	}
+
+	purgeRetention, err := toSoftDeleteRetention(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize soft delete: %w", err)
+	}
+
+	oidcOpts, err := toOIDCOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize OIDC: %w", err)
+	}
+
+	resetOpts, err := toPasswordResetOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize password reset: %w", err)
+	}
+
+	mailer, err := newNotifier(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("failed to initialize mail notifier: %w", err)
+	}
+
+	fileStore, err := newFileStorage(cfg.File.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to initialize file storage: %w", err)
+	}
+
+	fileOpts, err := toFileOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to initialize file upload: %w", err)
 	}
 
 	// 必须在 NewApp（内部 NewServerTracer）之前安装 TracerProvider，
@@ -45,7 +85,7 @@ func Run(cfg *config.Config) error {
 		logger.Error(context.Background(), "Failed to initialize OpenTelemetry", logger.Err(err))
 	}
 
-	container := NewContainer(engine, crypter.NewArgon2Hasher(), jwt, tokenStore)
+	container := NewContainer(engine, crypter.NewArgon2Hasher(), jwt, authStores, mfaOpts, impersonationOpts, oidcOpts, mailer, resetOpts, fileStore, fileOpts)
 	app := myapp.NewApp(cfg)
 
 	if otelProvider != nil {
@@ -57,13 +97,23 @@ func Run(cfg *config.Config) error {
 			return nil
 		})
 	}
-	if tokenStoreCloser != nil {
+	if redisClient != nil {
 		app.RegisterExit(func() error {
-			return tokenStoreCloser.Close()
+			// redis 权限缓存持有订阅连接，先退订再关连接池
+			if closer, ok := authStores.PermissionCache.(io.Closer); ok {
+				_ = closer.Close()
+			}
+			return redisClient.Close()
 		})
 	}
 
-	registerInfra(app)
+	registerInfra(app, container, purgeRetention)
+	if cfg.PermissionSync.OnStartup {
+		app.RegisterInit(func() error {
+			syncPermissionCatalog(context.Background(), container, cfg.PermissionSync)
+			return nil
+		})
+	}
 
 	// 中间件（装饰器链，顺序即执行顺序）
 	app.Use(middleware.CorsMiddleware())
@@ -72,6 +122,8 @@ func Run(cfg *config.Config) error {
 	app.Use(middleware.GlobalErrorHandlerMiddleware())
 	app.Use(middleware.LogMiddleware())
 	app.Use(middleware.TraceContextMiddleware())
+	// 按 X-Tenant 请求头挂上租户，登录等未认证接口靠它确定租户；已认证请求由 JWT 中间件按令牌改写
+	app.Use(middleware.TenantMiddleware(container.Tenant))
 
 	setupRoutes(app, container)
 
@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/pkg/apiparam"
+)
+
+// UploadFileRequest 上传文件（multipart 字段 file）
+type UploadFileRequest struct {
+	File *multipart.FileHeader `form:"file"`
+}
+
+// GetFileRequest 获取文件信息请求
+type GetFileRequest struct {
+	ID uint64 `query:"id" vd:"$>0"`
+}
+
+// GetFileListRequest 获取文件列表请求
+type GetFileListRequest struct {
+	apiparam.Page
+	Name       string `query:"name" vd:"len($)<255" xorm:"name op=like"`
+	MimeType   string `query:"mime_type" vd:"len($)<100" xorm:"mime_type op=eq"`
+	UploaderID uint64 `query:"uploader_id" xorm:"uploader_id op=eq"`
+}
+
+// DownloadFileRequest 下载文件请求，inline=true 时让浏览器直接打开而不是保存
+type DownloadFileRequest struct {
+	ID     uint64 `query:"id" vd:"$>0"`
+	Inline bool   `query:"inline"`
+}
+
+// DeleteFileRequest 删除文件请求
+type DeleteFileRequest struct {
+	ID uint64 `json:"id" vd:"$>0"`
+}
+
+// SetAvatarRequest 把自己上传的一张图片设为头像，file_id 为 0 表示清除头像
+type SetAvatarRequest struct {
+	FileID uint64 `json:"file_id"`
+}
+
+// GetAvatarRequest 获取用户头像请求
+type GetAvatarRequest struct {
+	UserID uint64 `query:"user_id" vd:"$>0"`
+}
+
+// FileResponse 文件视图对象
+type FileResponse struct {
+	ID         uint64    `json:"id"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	MimeType   string    `json:"mime_type"`
+	Hash       string    `json:"hash"`
+	UploaderID uint64    `json:"uploader_id"`
+	CreateTime time.Time `json:"create_time"`
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/file/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/file/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// Handler 文件接口：上传、查询、下载、删除，以及用户头像
+type Handler struct {
+	svc *service.Service
+}
+
+// NewHandler 创建文件处理器
+func NewHandler(svc *service.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// fileError 不存在按 404，仍被引用按冲突，大小与类型不合规属于参数错误，其余按数据库错误处理
+func fileError(err error) *api.Response {
+	switch {
+	case errors.Is(err, service.ErrFileNotFound), errors.Is(err, service.ErrAvatarNotSet):
+		return api.NotFound(err)
+	case errors.Is(err, service.ErrFileInUse):
+		return api.Conflict(err)
+	case errors.Is(err, service.ErrEmptyFile), errors.Is(err, service.ErrFileTooLarge),
+		errors.Is(err, service.ErrFileTypeNotAllowed), errors.Is(err, service.ErrNotImage):
+		return api.ParamError(err)
+	default:
+		return api.DatabaseError(err)
+	}
+}
+
+// @route Post /file/upload
+// UploadFile 上传文件（multipart 字段 file）。类型按内容判断；同一用户重复上传相同内容时返回已有的文件
+func (h *Handler) UploadFile(c *api.Context, req *dto.UploadFileRequest) *api.Response {
+	if req.File == nil {
+		return api.ParamError("file is required")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	f, err := req.File.Open()
+	if err != nil {
+		return api.ParamError(err)
+	}
+	defer f.Close()
+
+	result, err := h.svc.Upload(c.Context(), userID, req.File.Filename, f, req.File.Size)
+	if err != nil {
+		return fileError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Get /file
+// GetFile 获取文件信息
+func (h *Handler) GetFile(c *api.Context, req *dto.GetFileRequest) *api.Response {
+	result, err := h.svc.Get(c.Context(), req.ID)
+	if err != nil {
+		return fileError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Get /file/list
+// GetFileList 分页查询文件
+func (h *Handler) GetFileList(c *api.Context, req *dto.GetFileListRequest) *api.Response {
+	result, total, err := h.svc.List(c.Context(), req)
+	if err != nil {
+		return api.DatabaseError(err)
+	}
+	return api.PageSuccess(result, total)
+}
+
+// @route Get /file/download
+// DownloadFile 以流的方式下发文件内容
+func (h *Handler) DownloadFile(c *api.Context, req *dto.DownloadFileRequest) *api.Response {
+	file, rc, err := h.svc.Open(c.Context(), req.ID)
+	if err != nil {
+		return fileError(err)
+	}
+	serveFile(c, file, rc, req.Inline)
+	return nil
+}
+
+// @route Delete /file
+// DeleteFile 删除文件，正被用作头像等的文件不能删除
+func (h *Handler) DeleteFile(c *api.Context, req *dto.DeleteFileRequest) *api.Response {
+	if err := h.svc.Delete(c.Context(), req.ID); err != nil {
+		return fileError(err)
+	}
+	return api.NoContent()
+}
+
+// 以下头像接口在 JWT 中间件的 ExcludePaths 里免于 RBAC 路径校验：设置头像只作用于调用方自己，
+// 查看头像对同一租户的所有登录用户开放
+
+// @route Put /file/current/avatar
+// SetCurrentAvatar 把自己上传的一张图片设为头像，file_id 为 0 时清除
+func (h *Handler) SetCurrentAvatar(c *api.Context, req *dto.SetAvatarRequest) *api.Response {
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	if err := h.svc.SetAvatar(c.Context(), userID, req.FileID); err != nil {
+		return fileError(err)
+	}
+	return api.NoContent()
+}
+
+// @route Get /file/avatar
+// GetAvatar 下发用户的头像图片
+func (h *Handler) GetAvatar(c *api.Context, req *dto.GetAvatarRequest) *api.Response {
+	file, rc, err := h.svc.OpenAvatar(c.Context(), req.UserID)
+	if err != nil {
+		return fileError(err)
+	}
+	serveFile(c, file, rc, true)
+	return nil
+}
+
+// serveFile 设置响应头并把 body 作为响应体，框架写完后负责关闭它。
+// 类型取自上传时的嗅探结果并禁止浏览器再次嗅探，避免用户上传的内容被当成页面执行
+func serveFile(c *api.Context, file *dto.FileResponse, body io.ReadCloser, inline bool) {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	rc := c.Request()
+	rc.Response.Header.SetContentType(file.MimeType)
+	rc.Response.Header.Set("Content-Disposition", contentDisposition(disposition, file.Name))
+	rc.Response.Header.Set("X-Content-Type-Options", "nosniff")
+	rc.SetBodyStream(body, int(file.Size))
+}
+
+// contentDisposition 同时给出 ASCII 文件名和 RFC 5987 编码的原始文件名，兼容中文文件名
+func contentDisposition(disposition, name string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	return disposition + `; filename="` + fallback + `"; filename*=UTF-8''` + url.PathEscape(name)
+}
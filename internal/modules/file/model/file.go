@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// File 上传文件的元数据，内容按 StorageKey 存在 storage.Storage 里。
+// StorageKey 由内容的 SHA-256 派生，内容相同的文件（不论哪个租户上传）共用同一个对象，
+// 只有最后一条引用它的记录删除后对象才会被删除。
+//
+// UploaderID 标注为数据范围的归属人："仅本人"范围的用户只看得到自己上传的文件
+type File struct {
+	ID         uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	TenantID   uint64    `xorm:"bigint unsigned notnull default 1 index 'tenant_id'" json:"tenant_id" tenant:"id"`
+	Name       string    `xorm:"varchar(255) notnull 'name'" json:"name"` // 上传时的原始文件名，只用于展示和下载
+	Size       int64     `xorm:"bigint notnull 'size'" json:"size"`
+	MimeType   string    `xorm:"varchar(100) notnull 'mime_type'" json:"mime_type"` // 按内容嗅探，不信任客户端声明的类型
+	Hash       string    `xorm:"char(64) notnull index 'hash'" json:"hash"`         // 内容 SHA-256 十六进制
+	StorageKey string    `xorm:"varchar(255) notnull index 'storage_key'" json:"-"`
+	UploaderID uint64    `xorm:"bigint unsigned notnull index 'uploader_id'" json:"uploader_id" datascope:"owner"`
+	CreateTime time.Time `xorm:"created" json:"create_time"`
+}
+
+// 文件与用户关联的用途
+const (
+	PurposeAvatar = "avatar"
+)
+
+// FileLink 把文件挂到用户身上（如头像），每个用户每种用途最多一个文件。
+// 被关联的文件不能删除
+type FileLink struct {
+	ID         uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	TenantID   uint64    `xorm:"bigint unsigned notnull default 1 index 'tenant_id'" json:"tenant_id" tenant:"id"`
+	FileID     uint64    `xorm:"bigint unsigned notnull index 'file_id'" json:"file_id"`
+	UserID     uint64    `xorm:"bigint unsigned notnull unique(uk_user_purpose) 'user_id'" json:"user_id"`
+	Purpose    string    `xorm:"varchar(32) notnull unique(uk_user_purpose) 'purpose'" json:"purpose"`
+	CreateTime time.Time `xorm:"created" json:"create_time"`
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/file/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/file/model"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/storage"
+	"github.com/jinzhu/copier"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrFileNotFound 文件不存在（或属于别的租户、不在数据范围内）
+	ErrFileNotFound = errors.New("file not found")
+	// ErrEmptyFile 上传的文件没有内容
+	ErrEmptyFile = errors.New("file is empty")
+	// ErrFileTooLarge 超过 file.max_size_mb
+	ErrFileTooLarge = errors.New("file exceeds the size limit")
+	// ErrFileTypeNotAllowed 嗅探出的类型不在 file.allowed_types 里
+	ErrFileTypeNotAllowed = errors.New("file type is not allowed")
+	// ErrFileInUse 文件仍被用户关联（如作为头像），不能删除
+	ErrFileInUse = errors.New("file is still in use")
+	// ErrNotImage 只有图片能设为头像
+	ErrNotImage = errors.New("file is not an image")
+	// ErrAvatarNotSet 用户没有设置头像
+	ErrAvatarNotSet = errors.New("avatar not set")
+)
+
+// avatarURLFormat 写进用户资料的头像地址。v 随文件变化，换头像后浏览器缓存自然失效
+const avatarURLFormat = "/api/protected/file/avatar?user_id=%d&v=%d"
+
+// sniffLen http.DetectContentType 最多只看这么多字节
+const sniffLen = 512
+
+// Options 上传限制
+type Options struct {
+	// MaxSize 单个文件的字节数上限
+	MaxSize int64
+	// AllowedTypes 允许的 MIME 类型，"image/*" 匹配一整类；为空时不限制
+	AllowedTypes []string
+}
+
+// Service 文件服务：元数据按租户落在 file 表，内容交给 storage.Storage。
+// 内容按 SHA-256 去重：同一用户重复上传同样的内容直接返回已有记录，
+// 不同记录内容相同时共用同一个存储对象。
+type Service struct {
+	repo     *pkgrepo.Repository[model.File]
+	linkRepo *pkgrepo.Repository[model.FileLink]
+	store    storage.Storage
+	avatars  usersvc.AvatarSetter
+	opts     Options
+}
+
+// NewService 创建文件服务
+func NewService(db *pkgrepo.DB, store storage.Storage, avatars usersvc.AvatarSetter, opts Options) *Service {
+	return &Service{
+		repo:     pkgrepo.NewRepository[model.File](db),
+		linkRepo: pkgrepo.NewRepository[model.FileLink](db),
+		store:    store,
+		avatars:  avatars,
+		opts:     opts,
+	}
+}
+
+// Upload 保存 uploaderID 上传的文件。src 需要读两遍：先嗅探类型、计算哈希，
+// 确认是新内容后再从头写入存储
+func (s *Service) Upload(ctx context.Context, uploaderID uint64, name string, src io.ReadSeeker, size int64) (*dto.FileResponse, error) {
+	if size == 0 {
+		return nil, ErrEmptyFile
+	}
+	if s.opts.MaxSize > 0 && size > s.opts.MaxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, errors.Wrap(err, "failed to read upload")
+	}
+	mimeType := detectType(head[:n])
+	if !s.allowed(mimeType) {
+		return nil, errors.Wrap(ErrFileTypeNotAllowed, mimeType)
+	}
+
+	hash, err := hashFrom(head[:n], src)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.repo.QueryBuilder().Eq("uploader_id", uploaderID).Eq("hash", hash).First(pkgrepo.WithoutDataScope(ctx))
+	switch {
+	case err == nil:
+		// 对象可能在与 Delete 的竞争中被删掉了，借这次上传补回来
+		if err := s.putContent(ctx, existing, src); err != nil {
+			return nil, err
+		}
+		return toResponse(existing)
+	case !errors.Is(err, pkgrepo.ErrNotFound):
+		return nil, errors.Wrap(err, "failed to check existing file")
+	}
+
+	f := &model.File{
+		Name:       cleanName(name),
+		Size:       size,
+		MimeType:   mimeType,
+		Hash:       hash,
+		StorageKey: storageKey(hash),
+		UploaderID: uploaderID,
+	}
+	if err := s.putContent(ctx, f, src); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, f); err != nil {
+		logger.Error(ctx, "Failed to create file", logger.Err(err), logger.String("hash", hash))
+		return nil, errors.Wrap(err, "failed to create file")
+	}
+	return toResponse(f)
+}
+
+// putContent 确保存储里有这份内容。已有记录引用同一个对象时通常不必再写，但对象可能在与 Delete 的
+// 竞争中被删掉了（见 Delete），所以还要确认对象确实存在，不在就重新写入。
+// 键由内容哈希派生，重复写入的结果相同。引用的判断跨租户进行，因为存储对象本身不分租户
+func (s *Service) putContent(ctx context.Context, f *model.File, src io.ReadSeeker) error {
+	count, err := s.repo.QueryBuilder().Eq("storage_key", f.StorageKey).Count(allFiles(ctx))
+	if err != nil {
+		return errors.Wrap(err, "failed to check stored content")
+	}
+	if count > 0 {
+		stored, err := s.stored(ctx, f.StorageKey)
+		if err != nil || stored {
+			return err
+		}
+		logger.Warn(ctx, "Stored file is missing, writing it again", logger.String("key", f.StorageKey))
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to rewind upload")
+	}
+	if err := s.store.Put(ctx, f.StorageKey, src, f.Size, f.MimeType); err != nil {
+		logger.Error(ctx, "Failed to store file", logger.Err(err), logger.String("key", f.StorageKey))
+		return errors.Wrap(err, "failed to store file")
+	}
+	return nil
+}
+
+// stored 存储里是否有 key 对应的对象
+func (s *Service) stored(ctx context.Context, key string) (bool, error) {
+	rc, err := s.store.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check stored file")
+	}
+	rc.Close()
+	return true, nil
+}
+
+// Get 获取文件信息
+func (s *Service) Get(ctx context.Context, id uint64) (*dto.FileResponse, error) {
+	f, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(f)
+}
+
+// List 分页查询文件
+func (s *Service) List(ctx context.Context, req *dto.GetFileListRequest) ([]*dto.FileResponse, int64, error) {
+	files, total, err := s.repo.FindPage(ctx, req, req.Limit, req.Offset)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve files", logger.Err(err))
+		return nil, 0, errors.Wrap(err, "failed to retrieve files")
+	}
+	result := make([]*dto.FileResponse, 0, len(files))
+	for i := range files {
+		resp, err := toResponse(&files[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, resp)
+	}
+	return result, total, nil
+}
+
+// Open 打开文件内容，调用方负责关闭
+func (s *Service) Open(ctx context.Context, id uint64) (*dto.FileResponse, io.ReadCloser, error) {
+	f, err := s.find(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, f)
+}
+
+// Delete 删除文件记录；已经没有任何记录引用的存储对象一并删除
+func (s *Service) Delete(ctx context.Context, id uint64) error {
+	f, err := s.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	linked, err := s.linkRepo.QueryBuilder().Eq("file_id", id).Count(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to check file links")
+	}
+	if linked > 0 {
+		return ErrFileInUse
+	}
+	if err := s.repo.DeleteByID(ctx, id); err != nil {
+		logger.Error(ctx, "Failed to delete file", logger.Err(err), logger.Uint64("file_id", id))
+		return errors.Wrap(err, "failed to delete file")
+	}
+
+	// 与此同时上传同样内容的请求可能已经看到这条记录而跳过了写入，这种竞争下对象会被删掉，
+	// 那条新记录暂时读不到内容。Upload 命中已有记录时会确认对象还在、不在就补写，
+	// 所以上传者重新上传同样的内容即可恢复
+	refs, err := s.repo.QueryBuilder().Eq("storage_key", f.StorageKey).Count(allFiles(ctx))
+	if err != nil {
+		logger.Error(ctx, "Failed to count file references", logger.Err(err), logger.String("key", f.StorageKey))
+		return nil
+	}
+	if refs == 0 {
+		if err := s.store.Delete(ctx, f.StorageKey); err != nil {
+			// 记录已经删除，残留的对象不影响使用，只记日志
+			logger.Error(ctx, "Failed to delete stored file", logger.Err(err), logger.String("key", f.StorageKey))
+		}
+	}
+	return nil
+}
+
+// SetAvatar 把 userID 自己上传的一张图片设为头像，fileID 为 0 时清除头像
+func (s *Service) SetAvatar(ctx context.Context, userID, fileID uint64) error {
+	url := ""
+	if fileID != 0 {
+		f, err := s.repo.QueryBuilder().Eq("id", fileID).Eq("uploader_id", userID).First(pkgrepo.WithoutDataScope(ctx))
+		if errors.Is(err, pkgrepo.ErrNotFound) {
+			return ErrFileNotFound
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve file")
+		}
+		if !strings.HasPrefix(f.MimeType, "image/") {
+			return ErrNotImage
+		}
+		url = fmt.Sprintf(avatarURLFormat, userID, fileID)
+	}
+
+	err := s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		err := s.linkRepo.QueryBuilder().Eq("user_id", userID).Eq("purpose", model.PurposeAvatar).Delete(txCtx)
+		if err != nil {
+			return errors.Wrap(err, "failed to remove old avatar link")
+		}
+		if fileID != 0 {
+			link := &model.FileLink{FileID: fileID, UserID: userID, Purpose: model.PurposeAvatar}
+			if err := s.linkRepo.Create(txCtx, link); err != nil {
+				return errors.Wrap(err, "failed to link avatar")
+			}
+		}
+		return s.avatars.SetAvatarURL(txCtx, userID, url)
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to set avatar", logger.Err(err), logger.Uint64("user_id", userID), logger.Uint64("file_id", fileID))
+		return err
+	}
+	return nil
+}
+
+// OpenAvatar 打开用户的头像。头像对同一租户的所有用户可见，不受数据范围限制
+func (s *Service) OpenAvatar(ctx context.Context, userID uint64) (*dto.FileResponse, io.ReadCloser, error) {
+	link, err := s.linkRepo.QueryBuilder().Eq("user_id", userID).Eq("purpose", model.PurposeAvatar).First(ctx)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, nil, ErrAvatarNotSet
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to retrieve avatar link")
+	}
+	f, err := s.find(pkgrepo.WithoutDataScope(ctx), link.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, f)
+}
+
+func (s *Service) open(ctx context.Context, f *model.File) (*dto.FileResponse, io.ReadCloser, error) {
+	rc, err := s.store.Open(ctx, f.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		logger.Error(ctx, "Stored file is missing", logger.Uint64("file_id", f.ID), logger.String("key", f.StorageKey))
+		return nil, nil, ErrFileNotFound
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to open stored file", logger.Err(err), logger.Uint64("file_id", f.ID))
+		return nil, nil, errors.Wrap(err, "failed to open stored file")
+	}
+	resp, err := toResponse(f)
+	if err != nil {
+		rc.Close()
+		return nil, nil, err
+	}
+	return resp, rc, nil
+}
+
+func (s *Service) find(ctx context.Context, id uint64) (*model.File, error) {
+	f, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve file")
+	}
+	return f, nil
+}
+
+// allowed 按 AllowedTypes 判断类型，"image/*" 匹配全部图片
+func (s *Service) allowed(mimeType string) bool {
+	if len(s.opts.AllowedTypes) == 0 {
+		return true
+	}
+	for _, t := range s.opts.AllowedTypes {
+		if t == mimeType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// allFiles 跨租户、不受数据范围限制地查 file 表，用于判断存储对象是否仍被引用
+func allFiles(ctx context.Context) context.Context {
+	return pkgrepo.WithAllTenants(pkgrepo.WithoutDataScope(ctx))
+}
+
+// detectType 按内容嗅探 MIME 类型，去掉 charset 之类的参数
+func detectType(head []byte) string {
+	detected := http.DetectContentType(head)
+	mediaType, _, err := mime.ParseMediaType(detected)
+	if err != nil {
+		return detected
+	}
+	return mediaType
+}
+
+// hashFrom 计算 head 与 r 中剩余内容拼接后的哈希，head 是嗅探类型时已经读出的部分
+func hashFrom(head []byte, r io.Reader) (string, error) {
+	h := sha256.New()
+	h.Write(head)
+	if _, err := io.Copy(h, r); err != nil {
+		return "", errors.Wrap(err, "failed to hash upload")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// storageKey 按哈希前两个字节分两级目录，避免单个目录下文件过多
+func storageKey(hash string) string {
+	return "sha256/" + hash[:2] + "/" + hash[2:4] + "/" + hash
+}
+
+// cleanName 只保留文件名本身，截断到 255 个字符
+func cleanName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "unnamed"
+	}
+	if utf8.RuneCountInString(name) > 255 {
+		name = string([]rune(name)[:255])
+	}
+	return name
+}
+
+func toResponse(f *model.File) (*dto.FileResponse, error) {
+	resp := &dto.FileResponse{}
+	if err := copier.Copy(resp, f); err != nil {
+		return nil, errors.Wrap(err, "failed to convert file")
+	}
+	return resp, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/file/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/ayxworxfr/go_admin/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader 足够让 http.DetectContentType 识别为 image/png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// avatarRecorder 记下最后一次写入的头像地址
+type avatarRecorder struct {
+	urls map[uint64]string
+}
+
+func (a *avatarRecorder) SetAvatarURL(_ context.Context, userID uint64, url string) error {
+	a.urls[userID] = url
+	return nil
+}
+
+func newFileFixture(t *testing.T) (*Service, storage.Storage, *avatarRecorder) {
+	t.Helper()
+	db := repotest.NewDB(t, new(model.File), new(model.FileLink))
+	store := storage.NewLocal(t.TempDir())
+	avatars := &avatarRecorder{urls: make(map[uint64]string)}
+	return NewService(db, store, avatars, Options{}), store, avatars
+}
+
+func upload(t *testing.T, svc *Service, ctx context.Context, uploaderID uint64, name string, content []byte) uint64 {
+	t.Helper()
+	resp, err := svc.Upload(ctx, uploaderID, name, bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	return resp.ID
+}
+
+func readStored(t *testing.T, store storage.Storage, key string) ([]byte, error) {
+	t.Helper()
+	rc, err := store.Open(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func TestUpload_SharesObjectAcrossTenants(t *testing.T) {
+	svc, store, _ := newFileFixture(t)
+	content := []byte("same content in two tenants")
+	ctxA := pkgrepo.WithTenant(context.Background(), 1)
+	ctxB := pkgrepo.WithTenant(context.Background(), 2)
+
+	idA := upload(t, svc, ctxA, 10, "a.txt", content)
+	idB := upload(t, svc, ctxB, 20, "b.txt", content)
+	assert.NotEqual(t, idA, idB)
+
+	fileA, err := svc.repo.FindByID(ctxA, idA)
+	require.NoError(t, err)
+	fileB, err := svc.repo.FindByID(ctxB, idB)
+	require.NoError(t, err)
+	assert.Equal(t, fileA.StorageKey, fileB.StorageKey)
+
+	got, err := readStored(t, store, fileA.StorageKey)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
+
+func TestDelete_KeepsObjectWhileReferenced(t *testing.T) {
+	svc, store, _ := newFileFixture(t)
+	content := []byte("shared until the last reference goes")
+	ctxA := pkgrepo.WithTenant(context.Background(), 1)
+	ctxB := pkgrepo.WithTenant(context.Background(), 2)
+	idA := upload(t, svc, ctxA, 10, "a.txt", content)
+	idB := upload(t, svc, ctxB, 20, "b.txt", content)
+	f, err := svc.repo.FindByID(ctxA, idA)
+	require.NoError(t, err)
+
+	// 另一个租户的记录仍引用同一个对象
+	require.NoError(t, svc.Delete(ctxA, idA))
+	_, err = readStored(t, store, f.StorageKey)
+	require.NoError(t, err)
+	_, rc, err := svc.Open(ctxB, idB)
+	require.NoError(t, err)
+	rc.Close()
+
+	// 最后一条引用删除后对象一并删除
+	require.NoError(t, svc.Delete(ctxB, idB))
+	_, err = readStored(t, store, f.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestDelete_RejectsAvatar(t *testing.T) {
+	svc, _, _ := newFileFixture(t)
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	id := upload(t, svc, ctx, 10, "me.png", append(pngHeader, "avatar"...))
+	require.NoError(t, svc.SetAvatar(ctx, 10, id))
+
+	assert.ErrorIs(t, svc.Delete(ctx, id), ErrFileInUse)
+
+	// 清除头像后可以删除
+	require.NoError(t, svc.SetAvatar(ctx, 10, 0))
+	assert.NoError(t, svc.Delete(ctx, id))
+}
+
+func TestSetAvatar(t *testing.T) {
+	svc, _, avatars := newFileFixture(t)
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	image := upload(t, svc, ctx, 10, "me.png", append(pngHeader, "avatar"...))
+	text := upload(t, svc, ctx, 10, "notes.txt", []byte("not an image"))
+
+	t.Run("other user's file", func(t *testing.T) {
+		assert.ErrorIs(t, svc.SetAvatar(ctx, 20, image), ErrFileNotFound)
+		assert.Empty(t, avatars.urls[20])
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		assert.ErrorIs(t, svc.SetAvatar(ctx, 10, text), ErrNotImage)
+		assert.Empty(t, avatars.urls[10])
+	})
+
+	t.Run("own image", func(t *testing.T) {
+		require.NoError(t, svc.SetAvatar(ctx, 10, image))
+		assert.Contains(t, avatars.urls[10], "user_id=10")
+	})
+}
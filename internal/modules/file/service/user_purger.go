@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/file/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/pkg/errors"
+)
+
+// UserPurger 实现 user.RelatedDataPurger：用户被物理删除时删除挂在其身上的文件关联（如头像）。
+// 文件本身属于租户，不随用户删除，解除关联后可以照常删除。只依赖 *DB，先于 user.Service 构造
+type UserPurger struct {
+	links *pkgrepo.Repository[model.FileLink]
+}
+
+// NewUserPurger 创建文件关联清理器
+func NewUserPurger(db *pkgrepo.DB) *UserPurger {
+	return &UserPurger{links: pkgrepo.NewRepository[model.FileLink](db)}
+}
+
+// PurgeUsers 实现 user.RelatedDataPurger
+func (p *UserPurger) PurgeUsers(ctx context.Context, userIDs []uint64) error {
+	if err := p.links.QueryBuilder().In("user_id", userIDs).Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete file links")
+	}
+	return nil
+}
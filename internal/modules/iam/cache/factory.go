@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+)
+
+// Options 构造 PermissionCache 的运行时参数，驱动名沿用 tokenstore 的取值（memory / redis）。
+type Options struct {
+	Driver    string
+	KeyPrefix string
+	TTL       time.Duration
+	NearTTL   time.Duration // 仅 redis 驱动使用
+	Redis     *pkgredis.Client
+}
+
+// New 按驱动名创建权限缓存。driver 为空时回落 memory。
+// redis 驱动返回的缓存持有订阅连接，实现了 io.Closer，退出时应先于 Redis 连接关闭。
+func New(opts Options) (PermissionCache, error) {
+	switch tokenstore.NormalizeDriver(opts.Driver) {
+	case tokenstore.DriverMemory:
+		return NewInMemoryCache(opts.TTL), nil
+	case tokenstore.DriverRedis:
+		if opts.Redis == nil {
+			return nil, fmt.Errorf("permission cache driver %q requires redis client", tokenstore.DriverRedis)
+		}
+		return NewRedisCache(opts.Redis, RedisCacheOptions{
+			KeyPrefix: opts.KeyPrefix,
+			TTL:       opts.TTL,
+			NearTTL:   opts.NearTTL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown permission cache driver %q (want %q or %q)",
+			opts.Driver, tokenstore.DriverMemory, tokenstore.DriverRedis)
+	}
+}
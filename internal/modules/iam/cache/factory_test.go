@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Memory(t *testing.T) {
+	c, err := New(Options{Driver: tokenstore.DriverMemory, TTL: time.Minute})
+	require.NoError(t, err)
+	_, ok := c.(*InMemoryCache)
+	require.True(t, ok)
+}
+
+func TestNew_DefaultMemory(t *testing.T) {
+	c, err := New(Options{TTL: time.Minute})
+	require.NoError(t, err)
+	_, ok := c.(*InMemoryCache)
+	require.True(t, ok)
+}
+
+func TestNew_RedisRequiresClient(t *testing.T) {
+	_, err := New(Options{Driver: tokenstore.DriverRedis})
+	require.Error(t, err)
+}
+
+func TestNew_Redis(t *testing.T) {
+	client, _ := newTestRedisClient(t)
+	c, err := New(Options{
+		Driver:    tokenstore.DriverRedis,
+		KeyPrefix: "x:",
+		TTL:       time.Minute,
+		Redis:     client,
+	})
+	require.NoError(t, err)
+	rc, ok := c.(*RedisCache)
+	require.True(t, ok)
+	require.NoError(t, rc.Close())
+}
+
+func TestNew_UnknownDriver(t *testing.T) {
+	_, err := New(Options{Driver: "mongo"})
+	require.Error(t, err)
+}
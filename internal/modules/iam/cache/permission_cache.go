@@ -1,57 +1,139 @@
 package cache
 
 import (
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
 	"github.com/ayxworxfr/go_admin/pkg/store"
 )
 
 // PermissionCache 用户权限路径缓存的策略接口。旧版本把 `permissionCache
 // map[uint64]map[string]bool` 直接裸露在 PermissionService 里，`cacheExpiration`
 // 字段声明了却从未被读取——缓存实际永不过期。接口化之后：
-//   - InMemoryCache 是本次的默认实现，真正实现了 TTL；
-//   - 以后要接 Redis 支持多实例一致性，只需新增一个实现，PermissionChecker 不用改。
+//   - InMemoryCache 是默认实现，真正实现了 TTL；
+//   - RedisCache 供多实例部署，失效操作经 pub/sub 广播到所有实例，PermissionChecker 不用改。
+//
+// 缓存的是编译好的匹配器而不是规则列表，命中缓存的请求不用再建树；
+// RedisCache 只在 Redis 里存 Matcher.Rules()，取出时重新编译。
+//
+// 未命中时调用方回源数据库再写回，期间如果发生了失效，写回的就是失效前的旧权限。
+// 因此写入要带上回源之前读到的 Version，版本已变（期间有过失效）的写入直接丢弃。
 type PermissionCache interface {
-	// Get 返回用户的权限路径映射；ok=false 表示未命中（不存在或已过期）
-	Get(userID uint64) (map[string]bool, bool)
-	// Set 写入用户的权限路径映射，从写入时刻起计时 TTL
-	Set(userID uint64, perms map[string]bool)
-	// InvalidateUser 清除单个用户的缓存（分配角色/权限变更后调用）
+	// Get 返回用户的权限匹配器；ok=false 表示未命中（不存在或已过期）
+	Get(key Key) (*pathmatch.Matcher, bool)
+	// Version 用户缓存的当前版本，每次 InvalidateUser / InvalidateAll 都会改变它。
+	// 回源数据库之前读取，写回时原样交给 Set / SetUntil
+	Version(userID uint64) Version
+	// Set 写入用户的权限匹配器，从写入时刻起计时 TTL；version 已过时则不写入
+	Set(key Key, version Version, perms *pathmatch.Matcher)
+	// SetUntil 同 Set，但最迟在 deadline 失效（TTL 先到则按 TTL）。
+	// 用户有临时角色时 deadline 是下一次角色生效/到期的时刻
+	SetUntil(key Key, version Version, perms *pathmatch.Matcher, deadline time.Time)
+	// InvalidateUser 清除单个用户的全部缓存，不论扮演哪个角色（分配角色/权限变更后调用）
 	InvalidateUser(userID uint64)
 	// InvalidateAll 清除所有用户的缓存（角色-权限关系整体变更后调用）
 	InvalidateAll()
 }
 
+// Key 缓存键。同一用户切换到不同角色时权限不同，按 (用户, 扮演的角色) 分别缓存；
+// Role 为空表示按用户全部角色合并计算的权限
+type Key struct {
+	UserID uint64
+	Role   string
+}
+
+// Version 用户缓存的版本，只用来比较是否相等。local 是进程内缓存的版本，
+// generation / user 是 RedisCache 在 Redis 里的代数与用户版本（shared 表示读取成功）
+type Version struct {
+	local      string
+	shared     bool
+	generation string
+	user       string
+}
+
 // InMemoryCache 进程内权限缓存实现，带真实的 TTL 过期判断。
-// 不解决多实例一致性问题（见重构文档 Non-Goals）——每个实例各自维护一份，
-// 靠 TTL 兜底最终一致，而不是靠 ClearAll 广播。
+// 不解决多实例一致性问题——每个实例各自维护一份，靠 TTL 兜底最终一致；
+// 多实例部署改用 RedisCache（permission_cache.driver: redis）。
 //
-// 底层复用 pkg/store.Memory，本类型只保留权限域语义（userID → 权限路径集）。
+// 底层复用 pkg/store.Memory，本类型只保留权限域语义（Key → 权限匹配器）。
 type InMemoryCache struct {
-	store *store.Memory[uint64, map[string]bool]
+	store *store.Memory[Key, *pathmatch.Matcher]
+	ttl   time.Duration
+
+	// mu 保证版本比较与写入、版本递增与清除各自原子
+	mu         sync.Mutex
+	generation uint64
+	// versions 用户自己的失效次数，与缓存条目一样按 TTL 过期，失效过的用户不会一直占着内存。
+	// 版本过期归零后，只有回源耗时超过一个 TTL 的旧写入才可能混进来，且同样在 TTL 后过期
+	versions *store.Memory[uint64, uint64]
 }
 
 // NewInMemoryCache 创建带 TTL 的进程内缓存
 func NewInMemoryCache(ttl time.Duration) *InMemoryCache {
-	return &InMemoryCache{store: store.NewMemory[uint64, map[string]bool](ttl)}
+	return &InMemoryCache{
+		store:    store.NewMemory[Key, *pathmatch.Matcher](ttl),
+		ttl:      ttl,
+		versions: store.NewMemory[uint64, uint64](ttl),
+	}
 }
 
 // Get 命中且未过期才返回 true；已过期的条目会被顺手清除，避免内存堆积
-func (c *InMemoryCache) Get(userID uint64) (map[string]bool, bool) {
-	return c.store.Get(userID)
+func (c *InMemoryCache) Get(key Key) (*pathmatch.Matcher, bool) {
+	return c.store.Get(key)
+}
+
+// Version 由全局代数与用户自己的失效次数组成
+func (c *InMemoryCache) Version(userID uint64) Version {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Version{local: c.localVersionLocked(userID)}
+}
+
+func (c *InMemoryCache) localVersionLocked(userID uint64) string {
+	version, _ := c.versions.Get(userID)
+	return strconv.FormatUint(c.generation, 10) + ":" + strconv.FormatUint(version, 10)
 }
 
 // Set 写入缓存并重置过期时间
-func (c *InMemoryCache) Set(userID uint64, perms map[string]bool) {
-	c.store.Set(userID, perms)
+func (c *InMemoryCache) Set(key Key, version Version, perms *pathmatch.Matcher) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version.local != c.localVersionLocked(key.UserID) {
+		return
+	}
+	c.store.Set(key, perms)
+}
+
+// SetUntil 取 deadline 与 TTL 中较早的一个作为过期时间
+func (c *InMemoryCache) SetUntil(key Key, version Version, perms *pathmatch.Matcher, deadline time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version.local != c.localVersionLocked(key.UserID) {
+		return
+	}
+	if c.ttl > 0 && time.Now().Add(c.ttl).Before(deadline) {
+		c.store.Set(key, perms)
+		return
+	}
+	c.store.SetUntil(key, perms, deadline)
 }
 
-// InvalidateUser 清除单个用户的缓存
+// InvalidateUser 清除单个用户各个角色下的缓存
 func (c *InMemoryCache) InvalidateUser(userID uint64) {
-	c.store.Delete(userID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	version, _ := c.versions.Get(userID)
+	c.versions.Set(userID, version+1)
+	c.store.DeleteFunc(func(key Key) bool { return key.UserID == userID })
 }
 
-// InvalidateAll 清除所有用户的缓存
+// InvalidateAll 清除所有用户的缓存。代数递增后各用户的版本从头计数
 func (c *InMemoryCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.versions.Clear()
 	c.store.Clear()
 }
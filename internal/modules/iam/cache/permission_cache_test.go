@@ -4,39 +4,123 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
 	"github.com/stretchr/testify/require"
 )
 
+func matcher(t *testing.T, rules ...string) *pathmatch.Matcher {
+	m, err := pathmatch.Compile(rules)
+	require.NoError(t, err)
+	return m
+}
+
 func TestInMemoryCache_Basic(t *testing.T) {
 	c := NewInMemoryCache(time.Minute)
-	_, ok := c.Get(1)
+	_, ok := c.Get(Key{UserID: 1})
 	require.False(t, ok)
 
-	c.Set(1, map[string]bool{"GET:/api/x": true})
-	perms, ok := c.Get(1)
+	c.Set(Key{UserID: 1}, c.Version(1), matcher(t, "GET:/api/x"))
+	perms, ok := c.Get(Key{UserID: 1})
 	require.True(t, ok)
-	require.True(t, perms["GET:/api/x"])
+	require.True(t, perms.Match("GET", "/api/x"))
 
 	c.InvalidateUser(1)
-	_, ok = c.Get(1)
+	_, ok = c.Get(Key{UserID: 1})
 	require.False(t, ok)
 }
 
 func TestInMemoryCache_InvalidateAll(t *testing.T) {
 	c := NewInMemoryCache(time.Minute)
-	c.Set(1, map[string]bool{"a": true})
-	c.Set(2, map[string]bool{"b": true})
+	c.Set(Key{UserID: 1}, c.Version(1), matcher(t, "GET:/a"))
+	c.Set(Key{UserID: 2}, c.Version(2), matcher(t, "GET:/b"))
 	c.InvalidateAll()
-	_, ok1 := c.Get(1)
-	_, ok2 := c.Get(2)
+	_, ok1 := c.Get(Key{UserID: 1})
+	_, ok2 := c.Get(Key{UserID: 2})
 	require.False(t, ok1)
 	require.False(t, ok2)
 }
 
 func TestInMemoryCache_TTL(t *testing.T) {
 	c := NewInMemoryCache(20 * time.Millisecond)
-	c.Set(9, map[string]bool{"x": true})
+	c.Set(Key{UserID: 9}, c.Version(9), matcher(t, "GET:/x"))
 	time.Sleep(30 * time.Millisecond)
-	_, ok := c.Get(9)
+	_, ok := c.Get(Key{UserID: 9})
 	require.False(t, ok)
 }
+
+func TestInMemoryCache_VersionsExpire(t *testing.T) {
+	c := NewInMemoryCache(20 * time.Millisecond)
+	for userID := uint64(1); userID <= 3; userID++ {
+		c.InvalidateUser(userID)
+	}
+	require.Equal(t, 3, c.versions.Len())
+
+	// 版本与缓存条目一起过期，不会随着失效过的用户越来越多而一直增长
+	time.Sleep(30 * time.Millisecond)
+	c.InvalidateUser(4)
+	require.Equal(t, 1, c.versions.Len())
+}
+
+func TestInMemoryCache_SetUntil(t *testing.T) {
+	c := NewInMemoryCache(time.Minute)
+	c.SetUntil(Key{UserID: 1}, c.Version(1), matcher(t, "GET:/x"), time.Now().Add(20*time.Millisecond))
+	c.SetUntil(Key{UserID: 2}, c.Version(2), matcher(t, "GET:/y"), time.Now().Add(time.Hour))
+	_, ok := c.Get(Key{UserID: 1})
+	require.True(t, ok)
+
+	time.Sleep(30 * time.Millisecond)
+	_, ok = c.Get(Key{UserID: 1})
+	require.False(t, ok, "到达 deadline 即失效")
+	_, ok = c.Get(Key{UserID: 2})
+	require.True(t, ok)
+
+	// deadline 晚于 TTL 时按 TTL 过期
+	short := NewInMemoryCache(20 * time.Millisecond)
+	short.SetUntil(Key{UserID: 3}, short.Version(3), matcher(t, "GET:/z"), time.Now().Add(time.Hour))
+	time.Sleep(30 * time.Millisecond)
+	_, ok = short.Get(Key{UserID: 3})
+	require.False(t, ok)
+}
+
+func TestInMemoryCache_ActingRole(t *testing.T) {
+	c := NewInMemoryCache(time.Minute)
+	c.Set(Key{UserID: 1}, c.Version(1), matcher(t, "GET:/a", "GET:/b"))
+	c.Set(Key{UserID: 1, Role: "auditor"}, c.Version(1), matcher(t, "GET:/a"))
+	c.Set(Key{UserID: 2, Role: "auditor"}, c.Version(2), matcher(t, "GET:/a"))
+
+	perms, ok := c.Get(Key{UserID: 1, Role: "auditor"})
+	require.True(t, ok)
+	require.False(t, perms.Match("GET", "/b"), "扮演角色时与合并权限分开缓存")
+
+	c.InvalidateUser(1)
+	_, ok1 := c.Get(Key{UserID: 1})
+	_, ok2 := c.Get(Key{UserID: 1, Role: "auditor"})
+	_, ok3 := c.Get(Key{UserID: 2, Role: "auditor"})
+	require.False(t, ok1)
+	require.False(t, ok2)
+	require.True(t, ok3)
+}
+
+// TestInMemoryCache_StaleWriteDiscarded 回源期间发生失效时，带着旧版本的写回被丢弃
+func TestInMemoryCache_StaleWriteDiscarded(t *testing.T) {
+	c := NewInMemoryCache(time.Minute)
+
+	stale := c.Version(1)
+	c.InvalidateUser(1)
+	c.Set(Key{UserID: 1}, stale, matcher(t, "GET:/old"))
+	_, ok := c.Get(Key{UserID: 1})
+	require.False(t, ok)
+
+	stale = c.Version(1)
+	c.InvalidateAll()
+	c.SetUntil(Key{UserID: 1}, stale, matcher(t, "GET:/old"), time.Now().Add(time.Hour))
+	_, ok = c.Get(Key{UserID: 1})
+	require.False(t, ok)
+
+	// 其他用户的失效不影响
+	fresh := c.Version(1)
+	c.InvalidateUser(2)
+	c.Set(Key{UserID: 1}, fresh, matcher(t, "GET:/new"))
+	_, ok = c.Get(Key{UserID: 1})
+	require.True(t, ok)
+}
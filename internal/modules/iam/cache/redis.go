@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+)
+
+const (
+	defaultKeyPrefix = "go_admin:perm:"
+	defaultNearTTL   = time.Minute
+	// allRolesField 哈希里"全部角色合并"那一份的字段名；角色 code 不会是这个值
+	allRolesField = "*"
+	// invalidateAllMessage 广播 InvalidateAll 的消息体，其余消息为用户 ID
+	invalidateAllMessage = "all"
+)
+
+// RedisCacheOptions 构造 RedisCache 的参数
+type RedisCacheOptions struct {
+	// KeyPrefix 键前缀，广播频道为 KeyPrefix + "invalidate"
+	KeyPrefix string
+	// TTL Redis 中条目的存活时间
+	TTL time.Duration
+	// NearTTL 进程内近端缓存的存活时间。失效广播丢失时（pub/sub 不保证送达，
+	// 如订阅连接重连期间）靠它兜底，所以应明显短于 TTL
+	NearTTL time.Duration
+}
+
+// RedisCache 多实例共享的权限缓存：Redis 里存规则列表，各实例取出后重新编译；
+// 前面挂一层短 TTL 的进程内近端缓存，命中时不访问 Redis。
+//
+// InvalidateUser / InvalidateAll 除了清 Redis，还通过 pub/sub 广播给所有实例清各自的近端缓存，
+// 角色变更因此在所有实例上立即生效，而不是等到 TTL 过期。
+//
+// 每个用户的各角色条目放在同一个哈希里，InvalidateUser 删一个键即可；InvalidateAll
+// 不扫描键空间，而是自增代数让旧键全部失效，旧键随 TTL 自然过期。
+//
+// InvalidateUser 同时自增用户的版本号。回源写回时用脚本比较代数与用户版本，
+// 与回源之前读到的不一致就不写，失效之前加载的旧权限不会被写回 Redis。
+type RedisCache struct {
+	client    *pkgredis.Client
+	keyPrefix string
+	ttl       time.Duration
+	near      *InMemoryCache
+	sub       *pkgredis.Subscription
+}
+
+// cachedRules Redis 中条目的编码
+type cachedRules struct {
+	Rules     []string `json:"rules"`
+	ExpiresAt int64    `json:"exp"` // Unix 毫秒，SetUntil 的 deadline 早于 TTL 时用它判断
+}
+
+// NewRedisCache 创建 Redis 权限缓存并订阅失效广播，退出时需调用 Close 退订
+func NewRedisCache(client *pkgredis.Client, opts RedisCacheOptions) (*RedisCache, error) {
+	prefix := opts.KeyPrefix
+	if prefix == "" {
+		prefix = defaultKeyPrefix
+	}
+	if !strings.HasSuffix(prefix, ":") {
+		prefix += ":"
+	}
+	nearTTL := opts.NearTTL
+	if nearTTL <= 0 {
+		nearTTL = defaultNearTTL
+	}
+
+	c := &RedisCache{
+		client:    client,
+		keyPrefix: prefix,
+		ttl:       opts.TTL,
+		near:      NewInMemoryCache(nearTTL),
+	}
+	sub, err := client.Subscribe(context.Background(), c.channel(), c.onInvalidate)
+	if err != nil {
+		return nil, err
+	}
+	c.sub = sub
+	return c, nil
+}
+
+func (c *RedisCache) channel() string {
+	return c.keyPrefix + "invalidate"
+}
+
+func (c *RedisCache) generationKey() string {
+	return c.keyPrefix + "gen"
+}
+
+func (c *RedisCache) userKey(generation string, userID uint64) string {
+	if generation == "" {
+		generation = "0"
+	}
+	return c.keyPrefix + generation + ":" + strconv.FormatUint(userID, 10)
+}
+
+// versionKey 用户版本号，不随代数变化
+func (c *RedisCache) versionKey(userID uint64) string {
+	return c.keyPrefix + "ver:" + strconv.FormatUint(userID, 10)
+}
+
+func field(role string) string {
+	if role == "" {
+		return allRolesField
+	}
+	return role
+}
+
+// generation 当前代数，键不存在时为空串（键名里按 0 处理）
+func (c *RedisCache) generation(ctx context.Context) (string, error) {
+	v, _, err := c.client.Get(ctx, c.generationKey())
+	return v, err
+}
+
+// Get 先查近端缓存，未命中再读 Redis 并回填近端缓存。Redis 出错按未命中处理，
+// 调用方会回源数据库，鉴权不因缓存故障失败
+func (c *RedisCache) Get(key Key) (*pathmatch.Matcher, bool) {
+	if m, ok := c.near.Get(key); ok {
+		return m, true
+	}
+	// 读 Redis 期间收到失效广播时，不把读到的旧条目回填近端缓存
+	nearVersion := c.near.Version(key.UserID)
+
+	ctx := context.Background()
+	generation, err := c.generation(ctx)
+	if err != nil {
+		logger.Warn(ctx, "Failed to read permission cache generation", logger.Err(err))
+		return nil, false
+	}
+	raw, ok, err := c.client.HGet(ctx, c.userKey(generation, key.UserID), field(key.Role))
+	if err != nil {
+		logger.Warn(ctx, "Failed to read permission cache", logger.Err(err), logger.Uint64("user_id", key.UserID))
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var cached cachedRules
+	if err := json.Unmarshal([]byte(raw), &cached); err != nil {
+		return nil, false
+	}
+	deadline := time.UnixMilli(cached.ExpiresAt)
+	if !time.Now().Before(deadline) {
+		return nil, false
+	}
+	m, err := pathmatch.Compile(cached.Rules)
+	if err != nil {
+		return nil, false
+	}
+	c.near.SetUntil(key, nearVersion, m, deadline)
+	return m, true
+}
+
+// Version 近端缓存的版本加上 Redis 里的代数与用户版本。Redis 读取失败时只带近端版本，
+// 之后的写入只进近端缓存
+func (c *RedisCache) Version(userID uint64) Version {
+	version := c.near.Version(userID)
+
+	ctx := context.Background()
+	generation, err := c.generation(ctx)
+	var user string
+	if err == nil {
+		user, _, err = c.client.Get(ctx, c.versionKey(userID))
+	}
+	if err != nil {
+		logger.Warn(ctx, "Failed to read permission cache version", logger.Err(err), logger.Uint64("user_id", userID))
+		return version
+	}
+	version.shared, version.generation, version.user = true, generation, user
+	return version
+}
+
+// Set 写入近端缓存与 Redis，从写入时刻起计时 TTL
+func (c *RedisCache) Set(key Key, version Version, perms *pathmatch.Matcher) {
+	c.SetUntil(key, version, perms, time.Now().Add(c.ttl))
+}
+
+// SetUntil 同 Set，但最迟在 deadline 失效。代数或用户版本已变时两级缓存都不写；
+// Redis 写入失败只记日志，近端缓存照常生效
+func (c *RedisCache) SetUntil(key Key, version Version, perms *pathmatch.Matcher, deadline time.Time) {
+	if limit := time.Now().Add(c.ttl); limit.Before(deadline) {
+		deadline = limit
+	}
+
+	ctx := context.Background()
+	raw, err := json.Marshal(cachedRules{Rules: perms.Rules(), ExpiresAt: deadline.UnixMilli()})
+	if err != nil || !version.shared {
+		c.near.SetUntil(key, version, perms, deadline)
+		return
+	}
+	written, err := c.client.HSetIfMatch(ctx, c.userKey(version.generation, key.UserID), field(key.Role), string(raw), c.ttl,
+		map[string]string{c.generationKey(): version.generation, c.versionKey(key.UserID): version.user})
+	if err != nil {
+		logger.Warn(ctx, "Failed to write permission cache", logger.Err(err), logger.Uint64("user_id", key.UserID))
+	}
+	if err != nil || written {
+		c.near.SetUntil(key, version, perms, deadline)
+	}
+}
+
+// InvalidateUser 自增用户版本、删除用户在 Redis 中的条目并广播，各实例清掉该用户的近端缓存
+func (c *RedisCache) InvalidateUser(userID uint64) {
+	c.near.InvalidateUser(userID)
+
+	ctx := context.Background()
+	// 版本号比条目活得久即可：TTL 之后，失效之前写入的条目也已过期
+	_, err := c.client.Incr(ctx, c.versionKey(userID))
+	if err == nil && c.ttl > 0 {
+		err = c.client.Expire(ctx, c.versionKey(userID), c.ttl)
+	}
+	var generation string
+	if err == nil {
+		generation, err = c.generation(ctx)
+	}
+	if err == nil {
+		err = c.client.Del(ctx, c.userKey(generation, userID))
+	}
+	if err == nil {
+		err = c.client.Publish(ctx, c.channel(), strconv.FormatUint(userID, 10))
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to invalidate permission cache", logger.Err(err), logger.Uint64("user_id", userID))
+	}
+}
+
+// InvalidateAll 自增代数使 Redis 中的条目全部失效并广播，各实例清空近端缓存
+func (c *RedisCache) InvalidateAll() {
+	c.near.InvalidateAll()
+
+	ctx := context.Background()
+	_, err := c.client.Incr(ctx, c.generationKey())
+	if err == nil {
+		err = c.client.Publish(ctx, c.channel(), invalidateAllMessage)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to invalidate permission cache", logger.Err(err))
+	}
+}
+
+// onInvalidate 处理失效广播，自己发出的消息同样会收到，重复清理无害
+func (c *RedisCache) onInvalidate(payload string) {
+	if payload == invalidateAllMessage {
+		c.near.InvalidateAll()
+		return
+	}
+	userID, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		return
+	}
+	c.near.InvalidateUser(userID)
+}
+
+// Close 退订失效广播
+func (c *RedisCache) Close() error {
+	return c.sub.Close()
+}
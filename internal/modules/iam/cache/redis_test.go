@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisClient(t *testing.T) (*pkgredis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := pkgredis.New(pkgredis.Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client, mr
+}
+
+func newTestRedisCache(t *testing.T, client *pkgredis.Client) *RedisCache {
+	t.Helper()
+	c, err := NewRedisCache(client, RedisCacheOptions{KeyPrefix: "test:perm", TTL: time.Hour, NearTTL: time.Minute})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestRedisCache_SharedAcrossInstances(t *testing.T) {
+	client, mr := newTestRedisClient(t)
+	a := newTestRedisCache(t, client)
+	b := newTestRedisCache(t, client)
+
+	a.Set(Key{UserID: 1}, a.Version(1), matcher(t, "GET:/api/x"))
+	a.Set(Key{UserID: 1, Role: "AUDITOR"}, a.Version(1), matcher(t, "GET:/api/log"))
+	require.True(t, mr.Exists("test:perm:0:1"))
+	require.Greater(t, mr.TTL("test:perm:0:1"), time.Duration(0))
+
+	perms, ok := b.Get(Key{UserID: 1})
+	require.True(t, ok, "另一实例应能从 Redis 读到")
+	require.True(t, perms.Match("GET", "/api/x"))
+	perms, ok = b.Get(Key{UserID: 1, Role: "AUDITOR"})
+	require.True(t, ok)
+	require.False(t, perms.Match("GET", "/api/x"))
+
+	_, ok = b.Get(Key{UserID: 2})
+	require.False(t, ok)
+}
+
+func TestRedisCache_InvalidateUserBroadcast(t *testing.T) {
+	client, mr := newTestRedisClient(t)
+	a := newTestRedisCache(t, client)
+	b := newTestRedisCache(t, client)
+
+	a.Set(Key{UserID: 1}, a.Version(1), matcher(t, "GET:/a"))
+	a.Set(Key{UserID: 2}, a.Version(2), matcher(t, "GET:/b"))
+	_, ok := b.Get(Key{UserID: 1})
+	require.True(t, ok)
+	_, ok = b.near.Get(Key{UserID: 1})
+	require.True(t, ok, "读取后应回填近端缓存")
+
+	a.InvalidateUser(1)
+	require.False(t, mr.Exists("test:perm:0:1"))
+	require.Eventually(t, func() bool {
+		_, ok := b.near.Get(Key{UserID: 1})
+		return !ok
+	}, time.Second, 10*time.Millisecond, "广播应清掉其他实例的近端缓存")
+
+	_, ok = b.Get(Key{UserID: 1})
+	require.False(t, ok)
+	_, ok = b.Get(Key{UserID: 2})
+	require.True(t, ok, "其他用户不受影响")
+}
+
+func TestRedisCache_InvalidateAll(t *testing.T) {
+	client, _ := newTestRedisClient(t)
+	a := newTestRedisCache(t, client)
+	b := newTestRedisCache(t, client)
+
+	a.Set(Key{UserID: 1}, a.Version(1), matcher(t, "GET:/a"))
+	_, ok := b.Get(Key{UserID: 1})
+	require.True(t, ok)
+
+	a.InvalidateAll()
+	_, ok = a.Get(Key{UserID: 1})
+	require.False(t, ok)
+	require.Eventually(t, func() bool {
+		_, ok := b.near.Get(Key{UserID: 1})
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	_, ok = b.Get(Key{UserID: 1})
+	require.False(t, ok, "代数变了，旧条目不再可见")
+
+	a.Set(Key{UserID: 1}, a.Version(1), matcher(t, "GET:/c"))
+	perms, ok := b.Get(Key{UserID: 1})
+	require.True(t, ok)
+	require.True(t, perms.Match("GET", "/c"))
+}
+
+func TestRedisCache_SetUntil(t *testing.T) {
+	client, _ := newTestRedisClient(t)
+	a := newTestRedisCache(t, client)
+	b := newTestRedisCache(t, client)
+
+	a.SetUntil(Key{UserID: 1}, a.Version(1), matcher(t, "GET:/a"), time.Now().Add(50*time.Millisecond))
+	_, ok := b.Get(Key{UserID: 1})
+	require.True(t, ok)
+
+	time.Sleep(80 * time.Millisecond)
+	_, ok = a.Get(Key{UserID: 1})
+	require.False(t, ok, "deadline 到了即失效，不等 TTL")
+	_, ok = b.Get(Key{UserID: 1})
+	require.False(t, ok)
+}
+
+// TestRedisCache_StaleWriteDiscarded 实例 a 回源期间实例 b 做了失效，a 的写回不能进 Redis，
+// 也不能进 a 自己的近端缓存
+func TestRedisCache_StaleWriteDiscarded(t *testing.T) {
+	client, mr := newTestRedisClient(t)
+	a := newTestRedisCache(t, client)
+	b := newTestRedisCache(t, client)
+
+	stale := a.Version(1)
+	b.InvalidateUser(1)
+	a.Set(Key{UserID: 1}, stale, matcher(t, "GET:/old"))
+	require.False(t, mr.Exists("test:perm:0:1"))
+	_, ok := a.Get(Key{UserID: 1})
+	require.False(t, ok)
+
+	stale = a.Version(1)
+	b.InvalidateAll()
+	a.Set(Key{UserID: 1}, stale, matcher(t, "GET:/old"))
+	_, ok = b.Get(Key{UserID: 1})
+	require.False(t, ok)
+
+	a.Set(Key{UserID: 1}, a.Version(1), matcher(t, "GET:/new"))
+	perms, ok := b.Get(Key{UserID: 1})
+	require.True(t, ok)
+	require.True(t, perms.Match("GET", "/new"))
+}
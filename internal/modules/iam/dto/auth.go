@@ -1,9 +1,12 @@
 package dto
 
+import "time"
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" vd:"len($)>0"`
 	Password string `json:"password" vd:"len($)>0"`
+	Device   string `json:"device" vd:"len($)<64"` // 可选：客户端自报的设备名，展示在会话列表里
 }
 
 // RefreshTokenRequest 刷新令牌请求
@@ -18,10 +21,52 @@ type TokenResponse struct {
 	ExpiresAt    int64  `json:"expires_at"`
 }
 
-// LoginResult 登录结果，在令牌之外附带前端需要的登录态展示信息
+// SwitchRoleRequest 切换扮演的角色，Role 为空表示恢复按全部角色鉴权
+type SwitchRoleRequest struct {
+	Role string `json:"role" vd:"len($)<50"`
+}
+
+// SwitchRoleResponse 切换角色后签发的令牌对及新的角色信息
+type SwitchRoleResponse struct {
+	TokenResponse
+	CurrentAuthority string   `json:"currentAuthority"`
+	Roles            []string `json:"roles"`
+	ActingRole       string   `json:"acting_role"`
+}
+
+// 登录结果状态
+const (
+	LoginStatusOK          = "ok"
+	LoginStatusMFARequired = "mfa_required" // 密码正确，还需提交两步验证码
+)
+
+// LoginResult 登录结果，在令牌之外附带前端需要的登录态展示信息。
+// Status 为 mfa_required 时令牌字段为空，客户端凭 MFAToken 调用 /login/mfa 完成第二步
 type LoginResult struct {
 	TokenResponse
 	Status           string `json:"status"`
 	Type             string `json:"type"`
 	CurrentAuthority string `json:"currentAuthority"`
+
+	MFAToken string `json:"mfa_token,omitempty"`
+	// MFAEnrollRequired 角色要求两步验证但用户尚未绑定：先调用 /login/mfa/setup 绑定再提交验证码
+	MFAEnrollRequired bool `json:"mfa_enroll_required,omitempty"`
+	// RecoveryCodes 登录流程中完成绑定时一并返回的恢复码，只出现这一次
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// MustChangePassword 密码已超过策略规定的有效期，前端应先引导用户修改密码
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+}
+
+// LoginLockRequest 按用户名查看/解除登录锁定
+type LoginLockRequest struct {
+	Username string `query:"username" json:"username" vd:"len($)>0"`
+}
+
+// LoginLockResponse 某个来源 IP 的登录失败状态
+type LoginLockResponse struct {
+	IP          string     `json:"ip"`
+	Failures    int        `json:"failures"`
+	Lockouts    int        `json:"lockouts"`
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
 }
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ayxworxfr/go_admin/pkg/apiparam"
+)
+
+// StartImpersonationRequest 以指定用户的身份登录，Reason 写入审计记录
+type StartImpersonationRequest struct {
+	UserID uint64 `json:"user_id" vd:"$>0"`
+	Reason string `json:"reason" vd:"len($)>0&&len($)<=255"`
+}
+
+// ImpersonationResponse 扮演令牌。只有 access token，到期后不能续期；
+// 客户端应保留扮演者自己的令牌，结束扮演后切回
+type ImpersonationResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresAt   int64  `json:"expires_at"`
+	UserID      uint64 `json:"user_id"`
+	Username    string `json:"username"`
+}
+
+// GetImpersonationListRequest 查询扮演记录，ActorID/UserID 为 0 表示不限
+type GetImpersonationListRequest struct {
+	apiparam.Page
+	ActorID uint64 `query:"actor_id"`
+	UserID  uint64 `query:"user_id"`
+}
+
+// ImpersonationSessionResponse 扮演记录视图对象
+type ImpersonationSessionResponse struct {
+	ID         uint64     `json:"id"`
+	ActorID    uint64     `json:"actor_id"`
+	ActorName  string     `json:"actor_name"`
+	UserID     uint64     `json:"user_id"`
+	Username   string     `json:"username"`
+	Reason     string     `json:"reason"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	EndedAt    *time.Time `json:"ended_at"` // 主动结束的时刻，到期自然结束或仍在进行中为 null
+	CreateTime time.Time  `json:"create_time"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+// MFALoginRequest 登录第二步：提交挑战令牌与验证码（TOTP 口令或恢复码）
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" vd:"len($)>0"`
+	Code     string `json:"code" vd:"len($)>0&&len($)<32"`
+	Device   string `json:"device" vd:"len($)<64"`
+}
+
+// MFAChallengeRequest 角色要求两步验证但尚未绑定时，凭挑战令牌生成绑定密钥
+type MFAChallengeRequest struct {
+	MFAToken string `json:"mfa_token" vd:"len($)>0"`
+}
+
+// MFACodeRequest 已登录用户启用/停用两步验证、重置恢复码时提交的验证码
+type MFACodeRequest struct {
+	Code string `json:"code" vd:"len($)>0&&len($)<32"`
+}
+
+// MFASetupResponse 绑定信息：前端把 URI 渲染成二维码，Secret 供无法扫码时手动输入
+type MFASetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFAStatusResponse 当前用户的两步验证状态
+type MFAStatusResponse struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"` // 所属角色是否强制要求
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// MFARecoveryCodesResponse 新生成的恢复码明文，只在生成时返回这一次
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
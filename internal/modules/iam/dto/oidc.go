@@ -0,0 +1,15 @@
+package dto
+
+// OIDCAuthorizeResponse 跳转 IdP 所需的信息。授权上下文同时写进 HttpOnly Cookie，
+// 回调必须由发起登录的同一个浏览器提交（前后端跨域部署时请求需带上凭据），state 只能用一次
+type OIDCAuthorizeResponse struct {
+	URL   string `json:"url"`
+	State string `json:"state"`
+}
+
+// OIDCCallbackRequest IdP 重定向回前端后，前端转交的授权码与 state
+type OIDCCallbackRequest struct {
+	Code   string `json:"code" vd:"len($)>0&&len($)<2048"`
+	State  string `json:"state" vd:"len($)>0&&len($)<1024"`
+	Device string `json:"device" vd:"len($)<64"`
+}
@@ -15,6 +15,7 @@ type CreatePermissionRequest struct {
 	Type        int    `json:"type"` // 1:菜单,2:按钮,3:接口
 	Path        string `json:"path" vd:"len($)<255"`
 	Method      string `json:"method" vd:"len($)<50"`
+	Effect      string `json:"effect"` // allow（默认）/ deny
 	Status      int    `json:"status"`
 }
 
@@ -33,6 +34,7 @@ type UpdatePermissionRequest struct {
 	Type        int     `json:"type"`
 	Path        string  `json:"path" vd:"len($)<255"`
 	Method      string  `json:"method" vd:"len($)<50"`
+	Effect      string  `json:"effect"` // 空表示不修改
 	Status      int     `json:"status"`
 }
 
@@ -41,6 +43,11 @@ type DeletePermissionRequest struct {
 	IDs []uint64 `json:"ids" vd:"len($)>0"`
 }
 
+// RestorePermissionRequest 从回收站恢复权限请求
+type RestorePermissionRequest struct {
+	IDs []uint64 `json:"ids" vd:"len($)>0"`
+}
+
 // GetPermissionRequest 获取权限请求
 type GetPermissionRequest struct {
 	ID uint64 `query:"id" vd:"$>0"`
@@ -54,7 +61,10 @@ type GetPermissionListRequest struct {
 	Type   int    `query:"type" xorm:"type op=eq"`
 	Path   string `query:"path" vd:"len($)>=0&&len($)<255" xorm:"path op=like"`
 	Method string `query:"method" xorm:"method op=eq"`
+	Effect string `query:"effect" xorm:"effect op=eq"`
 	Status int    `query:"status" xorm:"status op=eq"`
+	// Deleted 为 true 时只查已删除的权限（回收站）
+	Deleted bool `query:"deleted"`
 }
 
 // PermissionResponse 权限视图对象
@@ -67,7 +77,46 @@ type PermissionResponse struct {
 	Type        int       `json:"type"`
 	Path        string    `json:"path"`
 	Method      string    `json:"method"`
+	Effect      string    `json:"effect"`
 	Status      int       `json:"status"`
+	Orphaned    bool      `json:"orphaned"`
 	CreateTime  time.Time `json:"create_time"`
 	UpdateTime  time.Time `json:"update_time"`
+	DeleteTime  time.Time `json:"delete_time"` // 只有回收站里的权限非零
+}
+
+// ExplainPermissionRequest 查询某用户访问某接口的鉴权结论
+type ExplainPermissionRequest struct {
+	UserID uint64 `query:"user_id" vd:"$>0"`
+	Method string `query:"method" vd:"len($)>0&&len($)<10"`
+	Path   string `query:"path" vd:"len($)>0&&len($)<255"` // 完整路径，如 /api/protected/user/list
+}
+
+// ExplainPermissionResponse 鉴权结论及起决定作用的权限
+type ExplainPermissionResponse struct {
+	Allowed bool `json:"allowed"`
+	// Decision allow / deny；no_match 表示没有任何权限匹配，按拒绝处理
+	Decision   string              `json:"decision"`
+	Rule       string              `json:"rule,omitempty"` // 决定性规则，拒绝规则带 "!" 前缀
+	Permission *PermissionResponse `json:"permission,omitempty"`
+	// Roles 用户经由哪些角色（含继承来的祖先角色）获得这条权限，值为角色 code
+	Roles []string `json:"roles"`
+}
+
+// CatalogRoute 参与权限目录同步的一条接口路由
+type CatalogRoute struct {
+	Module string
+	// Name 处理器方法，如 Handler.GetUserList，用作新建权限的名称
+	Name   string
+	Method string
+	Path   string
+}
+
+// CatalogSyncResult 权限目录同步结果，条目均为 "METHOD:path"
+type CatalogSyncResult struct {
+	Created  []string `json:"created"`
+	Orphaned []string `json:"orphaned"`
+	Restored []string `json:"restored"`
+	// Modules 新建的模块分组菜单（权限 code）
+	Modules []string `json:"modules"`
 }
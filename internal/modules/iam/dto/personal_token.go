@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+// CreatePersonalTokenRequest 创建个人访问令牌。Scopes 为 "METHOD:path" 模式
+// （如 "GET:/api/protected/user/*"），留空表示与本人权限一致
+type CreatePersonalTokenRequest struct {
+	Name          string   `json:"name" vd:"len($)>0&&len($)<=50"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days" vd:"$>0&&$<=365"`
+}
+
+// PersonalTokenResponse 个人访问令牌视图对象，不含明文
+type PersonalTokenResponse struct {
+	ID         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"` // 从未使用过为 null
+	CreateTime time.Time  `json:"create_time"`
+}
+
+// PersonalTokenCreatedResponse 创建结果，Token 为明文，只在这一次返回
+type PersonalTokenCreatedResponse struct {
+	PersonalTokenResponse
+	Token string `json:"token"`
+}
+
+// RevokePersonalTokenRequest 撤销个人访问令牌
+type RevokePersonalTokenRequest struct {
+	ID uint64 `json:"id" vd:"$>0"`
+}
+
+// GetUserPersonalTokensRequest 管理员查看指定用户的个人访问令牌
+type GetUserPersonalTokensRequest struct {
+	UserID uint64 `query:"user_id" vd:"$>0"`
+}
+
+// RevokeUserPersonalTokenRequest 管理员撤销指定用户的个人访问令牌
+type RevokeUserPersonalTokenRequest struct {
+	UserID uint64 `json:"user_id" vd:"$>0"`
+	ID     uint64 `json:"id" vd:"$>0"`
+}
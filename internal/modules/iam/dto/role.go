@@ -12,7 +12,11 @@ type CreateRoleRequest struct {
 	Code          string   `json:"code" vd:"len($)>0&&len($)<50"`
 	Description   string   `json:"description" vd:"len($)<255"`
 	Status        int      `json:"status"`
+	RequireMFA    bool     `json:"require_mfa"`
 	PermissionIDs []uint64 `json:"permission_ids"`
+	ParentIDs     []uint64 `json:"parent_ids"`                 // 父角色，继承其全部权限
+	DataScope     int      `json:"data_scope" vd:"$>=0&&$<=5"` // 数据范围，0 按全部数据处理
+	DeptIDs       []uint64 `json:"dept_ids"`                   // data_scope 为自定义时可见的部门
 }
 
 // CreateRolesRequest 批量创建角色请求
@@ -27,7 +31,11 @@ type UpdateRoleRequest struct {
 	Code          string    `json:"code" vd:"len($)>=0&&len($)<50"`
 	Description   string    `json:"description" vd:"len($)<255"`
 	Status        int       `json:"status"`
-	PermissionIDs *[]uint64 `json:"permission_ids"` // 指针区分未设置和空数组
+	RequireMFA    *bool     `json:"require_mfa" copier:"-"`     // 指针区分未设置和 false，由 Service 显式写入
+	PermissionIDs *[]uint64 `json:"permission_ids"`             // 指针区分未设置和空数组
+	ParentIDs     *[]uint64 `json:"parent_ids"`                 // 同上，空数组表示取消全部继承
+	DataScope     int       `json:"data_scope" vd:"$>=0&&$<=5"` // 0 表示不修改
+	DeptIDs       *[]uint64 `json:"dept_ids"`
 }
 
 // DeleteRoleRequest 删除角色请求
@@ -35,6 +43,11 @@ type DeleteRoleRequest struct {
 	IDs []uint64 `json:"ids" vd:"len($)>0"`
 }
 
+// RestoreRoleRequest 从回收站恢复角色请求
+type RestoreRoleRequest struct {
+	IDs []uint64 `json:"ids" vd:"len($)>0"`
+}
+
 // GetRoleRequest 获取角色请求
 type GetRoleRequest struct {
 	ID uint64 `query:"id" vd:"$>0"`
@@ -47,6 +60,8 @@ type GetRoleListRequest struct {
 	Code   string `query:"code" vd:"len($)>=0&&len($)<50" xorm:"code op=startswith"`
 	Status int    `query:"status" xorm:"status op=eq"`
 	Flags  int    `query:"flags"` // 是否附带角色的权限列表，见 dto.INCLUDE_PERMISSION
+	// Deleted 为 true 时只查已删除的角色（回收站）
+	Deleted bool `query:"deleted"`
 }
 
 // GetRolePermissionsRequest 获取角色权限请求
@@ -67,7 +82,14 @@ type RoleResponse struct {
 	Code        string                `json:"code"`
 	Description string                `json:"description"`
 	Status      int                   `json:"status"`
+	RequireMFA  bool                  `json:"require_mfa"`
+	DataScope   int                   `json:"data_scope"`
+	DeptIDs     []uint64              `json:"dept_ids,omitempty"` // 自定义数据范围的部门
 	CreateTime  time.Time             `json:"create_time"`
 	UpdateTime  time.Time             `json:"update_time"`
-	Permissions []*PermissionResponse `json:"permissions,omitempty"`
+	DeleteTime  time.Time             `json:"delete_time"` // 只有回收站里的角色非零
+	ParentIDs   []uint64              `json:"parent_ids"`
+	Permissions []*PermissionResponse `json:"permissions,omitempty"` // 直接分配的权限
+	// InheritedPermissions 从祖先角色继承来的权限，已去掉与 Permissions 重复的
+	InheritedPermissions []*PermissionResponse `json:"inherited_permissions,omitempty"`
 }
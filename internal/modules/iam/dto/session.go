@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// ClientInfo 签发令牌时记录的客户端信息，由 handler 从请求中提取后下传
+type ClientInfo struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
+
+// SessionResponse 会话视图对象
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	Device      string    `json:"device"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	IssuedAt    time.Time `json:"issued_at"`
+	LastRefresh time.Time `json:"last_refresh"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"` // 是否为发起本次请求的会话
+}
+
+// RevokeSessionRequest 撤销自己的某个会话
+type RevokeSessionRequest struct {
+	ID string `json:"id" vd:"len($)>0"`
+}
+
+// GetUserSessionsRequest 管理员查看指定用户的会话
+type GetUserSessionsRequest struct {
+	UserID uint64 `query:"user_id" vd:"$>0"`
+}
+
+// ForceOfflineRequest 管理员强制用户下线
+type ForceOfflineRequest struct {
+	UserID uint64 `json:"user_id" vd:"$>0"`
+}
@@ -1,11 +1,21 @@
 package dto
 
+import "time"
+
 // AssignRolesRequest 分配角色请求
 type AssignRolesRequest struct {
 	UserID  uint64   `json:"user_id" vd:"$>0"`
 	RoleIDs []uint64 `json:"role_ids"`
 }
 
+// GrantTemporaryRoleRequest 为用户设置带有效期的角色
+type GrantTemporaryRoleRequest struct {
+	UserID     uint64     `json:"user_id" vd:"$>0"`
+	RoleID     uint64     `json:"role_id" vd:"$>0"`
+	ValidFrom  *time.Time `json:"valid_from"`  // 为空表示立即生效
+	ValidUntil *time.Time `json:"valid_until"` // 为空表示长期有效
+}
+
 // GetUserRolesRequest 获取用户角色请求
 type GetUserRolesRequest struct {
 	UserID uint64 `query:"user_id" vd:"$>0"`
@@ -28,4 +38,14 @@ type UserRolesResponse struct {
 	Username string          `json:"username"`
 	Email    string          `json:"email"`
 	Roles    []*RoleResponse `json:"roles"`
+	// Temporary 带有效期的角色分配，包括尚未生效的；Roles 只含当前生效的角色
+	Temporary []*UserRoleWindow `json:"temporary"`
+}
+
+// UserRoleWindow 一条临时角色分配的有效期
+type UserRoleWindow struct {
+	RoleID     uint64     `json:"role_id"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+	Active     bool       `json:"active"`
 }
@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
 	"github.com/ayxworxfr/go_admin/pkg/api"
@@ -19,35 +23,56 @@ func NewAuthHandler(authSvc *service.AuthService, jwt *jwtauth.JWT) *AuthHandler
 }
 
 // @route POST /login
+// Login 登录第一步；开启两步验证的用户拿到的是挑战令牌而不是正式令牌
 func (h *AuthHandler) Login(c *api.Context) *api.Response {
 	var req dto.LoginRequest
 	if err := c.BindAndValidate(&req); err != nil {
 		return api.ParamError(err)
 	}
 
-	token, err := h.authSvc.Login(c.Context(), req.Username, req.Password)
+	result, err := h.authSvc.Login(c.Context(), req.Username, req.Password, clientInfo(c, req.Device))
 	if err != nil {
-		return api.Unauthorized(err)
+		return loginError(c, err)
 	}
+	return api.Success(result)
+}
 
-	claims, err := h.jwt.ParseToken(token.AccessToken)
-	if err != nil {
-		return api.Unauthorized("Invalid token")
+// @route POST /login/mfa
+// LoginMFA 登录第二步：提交 TOTP 口令或恢复码换取正式令牌
+func (h *AuthHandler) LoginMFA(c *api.Context) *api.Response {
+	var req dto.MFALoginRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		return api.ParamError(err)
 	}
 
-	result := dto.LoginResult{
-		TokenResponse: dto.TokenResponse{
-			AccessToken:  token.AccessToken,
-			RefreshToken: token.RefreshToken,
-			ExpiresAt:    token.ExpiresAt,
-		},
-		Status:           "ok",
-		Type:             "account",
-		CurrentAuthority: claims.RoleKey,
+	result, err := h.authSvc.VerifyMFA(c.Context(), req.MFAToken, req.Code, clientInfo(c, req.Device))
+	if err != nil {
+		if errors.Is(err, service.ErrMFASetupRequired) {
+			return api.ParamError(err)
+		}
+		return loginError(c, err)
 	}
 	return api.Success(result)
 }
 
+// @route POST /login/mfa/setup
+// SetupLoginMFA 角色强制两步验证、用户尚未绑定时，登录过程中生成绑定密钥
+func (h *AuthHandler) SetupLoginMFA(c *api.Context) *api.Response {
+	var req dto.MFAChallengeRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		return api.ParamError(err)
+	}
+
+	setup, err := h.authSvc.SetupMFA(c.Context(), req.MFAToken)
+	if err != nil {
+		if errors.Is(err, service.ErrMFAAlreadyEnabled) {
+			return api.Conflict(err)
+		}
+		return api.Unauthorized(err)
+	}
+	return api.Success(setup)
+}
+
 // @route POST /refresh/token
 // RefreshToken 刷新令牌
 func (h *AuthHandler) RefreshToken(c *api.Context) *api.Response {
@@ -56,13 +81,37 @@ func (h *AuthHandler) RefreshToken(c *api.Context) *api.Response {
 		return api.ParamError(err)
 	}
 
-	token, err := h.authSvc.RefreshToken(c.Context(), req.RefreshToken)
+	token, err := h.authSvc.RefreshToken(c.Context(), req.RefreshToken, clientInfo(c, ""))
 	if err != nil {
 		return api.Unauthorized(err.Error())
 	}
 	return api.Success(token)
 }
 
+// @route POST /switch/role
+// SwitchRole 持有多个角色的用户切换当前扮演的角色，返回新的令牌对。
+// 与 Logout 一样在公开路由组，自行解析 Bearer access token：切换角色是登录态本身的操作，
+// 不应受制于当前扮演角色的接口权限
+func (h *AuthHandler) SwitchRole(c *api.Context) *api.Response {
+	tokenString := c.BearerToken()
+	if tokenString == "" {
+		return api.Unauthorized("No token provided")
+	}
+	var req dto.SwitchRoleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		return api.ParamError(err)
+	}
+
+	result, err := h.authSvc.SwitchRole(c.Context(), tokenString, req.Role, clientInfo(c, ""))
+	if err != nil {
+		if errors.Is(err, service.ErrRoleNotHeld) || errors.Is(err, service.ErrAccountDisabled) || errors.Is(err, service.ErrImpersonationRestricted) {
+			return api.Forbidden(err)
+		}
+		return api.Unauthorized(err)
+	}
+	return api.Success(result)
+}
+
 // @route POST /logout
 // 该接口在公开路由组，不走 JWT 中间件，因此在此自行解析 Bearer access token。
 func (h *AuthHandler) Logout(c *api.Context) *api.Response {
@@ -83,3 +132,26 @@ func (h *AuthHandler) Logout(c *api.Context) *api.Response {
 	}
 	return api.Success("Logout")
 }
+
+// loginError 把登录两个步骤的错误映射为响应：临时锁定带 Retry-After，停用账号为 403，其余按未认证处理
+func loginError(c *api.Context, err error) *api.Response {
+	var lockedErr *service.AccountLockedError
+	if errors.As(err, &lockedErr) {
+		c.Request().Response.Header.Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		return api.AccountLocked(err)
+	}
+	if errors.Is(err, service.ErrAccountDisabled) {
+		return api.Forbidden(err)
+	}
+	return api.Unauthorized(err)
+}
+
+// clientInfo 提取登记会话用的客户端信息；device 由客户端自报，刷新时留空沿用登录时的值
+func clientInfo(c *api.Context, device string) dto.ClientInfo {
+	rc := c.Request()
+	return dto.ClientInfo{
+		Device:    device,
+		IP:        rc.ClientIP(),
+		UserAgent: string(rc.UserAgent()),
+	}
+}
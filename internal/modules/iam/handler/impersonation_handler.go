@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// ImpersonationHandler 扮演用户：发起、结束扮演，以及查看扮演记录
+type ImpersonationHandler struct {
+	impersonationSvc *service.ImpersonationService
+}
+
+// NewImpersonationHandler 创建扮演处理器
+func NewImpersonationHandler(impersonationSvc *service.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{impersonationSvc: impersonationSvc}
+}
+
+// @route Post /impersonation
+// StartImpersonation 以指定用户的身份登录，返回短期有效、不能续期的扮演令牌
+func (h *ImpersonationHandler) StartImpersonation(c *api.Context, req *dto.StartImpersonationRequest) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	result, err := h.impersonationSvc.Start(c.Context(), claims, req, clientInfo(c, ""))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImpersonationRestricted):
+			return api.Forbidden(err)
+		case errors.Is(err, service.ErrInvalidImpersonationTarget):
+			return api.ParamError(err)
+		default:
+			return api.InternalError(err)
+		}
+	}
+	return api.Success(result)
+}
+
+// @route Delete /impersonation
+// StopImpersonation 结束扮演，须携带扮演令牌调用；之后客户端切回扮演者自己的令牌
+func (h *ImpersonationHandler) StopImpersonation(c *api.Context) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	if err := h.impersonationSvc.Stop(c.Context(), claims); err != nil {
+		if errors.Is(err, service.ErrNotImpersonating) {
+			return api.ParamError(err)
+		}
+		return api.InternalError(err)
+	}
+	return api.NoContent()
+}
+
+// rejectImpersonation 扮演令牌不能改动被扮演用户的登录凭证（两步验证、会话、个人访问令牌），
+// 否则扮演者可以借此在扮演结束后继续以对方身份登录。返回 nil 表示可以继续
+func rejectImpersonation(c *api.Context) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	if claims.Impersonating() {
+		return api.Forbidden(service.ErrImpersonationRestricted)
+	}
+	return nil
+}
+
+// @route Get /impersonation/list
+// GetImpersonationList 分页查询扮演记录
+func (h *ImpersonationHandler) GetImpersonationList(c *api.Context, req *dto.GetImpersonationListRequest) *api.Response {
+	result, total, err := h.impersonationSvc.List(c.Context(), req)
+	if err != nil {
+		return api.DatabaseError(err)
+	}
+	return api.PageSuccess(result, total)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestContext(claims *jwtauth.Claims) *api.Context {
+	rc := app.NewContext(0)
+	rc.Set(jwtauth.ClaimsKey, claims)
+	return api.New(context.Background(), rc)
+}
+
+// TestSelfServiceRejectsImpersonation 扮演令牌动不了被扮演用户的凭证，也不能分配角色；被拒绝时还没有走到服务层，
+// 所以这里的处理器不需要注入服务
+func TestSelfServiceRejectsImpersonation(t *testing.T) {
+	impersonating := &jwtauth.Claims{Identity: "2", Actor: &jwtauth.Actor{Identity: "1", Nice: "admin"}}
+	mfa := NewMFAHandler(nil)
+	sessions := NewSessionHandler(nil, nil)
+	tokens := NewPersonalTokenHandler(nil, nil)
+	userRoles := NewUserRoleHandler(nil, nil)
+	code := &dto.MFACodeRequest{Code: "123456"}
+
+	calls := map[string]func(c *api.Context) *api.Response{
+		"SetupMFA":                func(c *api.Context) *api.Response { return mfa.SetupMFA(c) },
+		"EnableMFA":               func(c *api.Context) *api.Response { return mfa.EnableMFA(c, code) },
+		"DisableMFA":              func(c *api.Context) *api.Response { return mfa.DisableMFA(c, code) },
+		"RegenerateRecoveryCodes": func(c *api.Context) *api.Response { return mfa.RegenerateRecoveryCodes(c, code) },
+		"RevokeSession": func(c *api.Context) *api.Response {
+			return sessions.RevokeSession(c, &dto.RevokeSessionRequest{ID: "s"})
+		},
+		"RevokeAllSessions": func(c *api.Context) *api.Response { return sessions.RevokeAllSessions(c) },
+		"CreatePersonalToken": func(c *api.Context) *api.Response {
+			return tokens.CreatePersonalToken(c, &dto.CreatePersonalTokenRequest{})
+		},
+		"RevokePersonalToken": func(c *api.Context) *api.Response {
+			return tokens.RevokePersonalToken(c, &dto.RevokePersonalTokenRequest{ID: 1})
+		},
+		"UserAssignRoles": func(c *api.Context) *api.Response {
+			return userRoles.UserAssignRoles(c, &dto.AssignRolesRequest{UserID: 1, RoleIDs: []uint64{1}})
+		},
+		"GrantTemporaryRole": func(c *api.Context) *api.Response {
+			return userRoles.GrantTemporaryRole(c, &dto.GrantTemporaryRoleRequest{UserID: 1, RoleID: 1})
+		},
+	}
+	for name, call := range calls {
+		resp := call(newTestContext(impersonating))
+		if assert.NotNil(t, resp, name) {
+			assert.Equal(t, 403, resp.HTTPStatus(), name)
+		}
+	}
+
+	assert.Nil(t, rejectImpersonation(newTestContext(&jwtauth.Claims{Identity: "2"})))
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// jwksMaxAge 下游服务缓存 JWKS 的时长（秒）。轮换时新公钥要先发布、再启用，
+// 两步之间的间隔应大于该值
+const jwksMaxAge = "300"
+
+// JWKSHandler 发布验签公钥，挂在根路径下（不在 /api 前缀里），其它内部服务据此
+// 校验本服务签发的 token，无需持有签名密钥
+type JWKSHandler struct {
+	jwt *jwtauth.JWT
+}
+
+// NewJWKSHandler 创建 JWKS 处理器
+func NewJWKSHandler(jwt *jwtauth.JWT) *JWKSHandler {
+	return &JWKSHandler{jwt: jwt}
+}
+
+// @route Get /.well-known/jwks.json
+// GetJWKS 按 RFC 7517 原样输出 {"keys":[...]}，不套统一响应体——标准 JWKS 客户端只认这个格式。
+// 使用 HS256 时没有可公开的密钥，keys 为空数组
+func (h *JWKSHandler) GetJWKS(c *api.Context) {
+	c.Request().Response.Header.Set("Cache-Control", "public, max-age="+jwksMaxAge)
+	c.JSON(consts.StatusOK, h.jwt.JWKS())
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// LoginGuardHandler 登录锁定管理：查看用户名的失败/锁定状态、管理员解锁
+type LoginGuardHandler struct {
+	guard *service.LoginGuard
+}
+
+// NewLoginGuardHandler 创建登录锁定处理器
+func NewLoginGuardHandler(guard *service.LoginGuard) *LoginGuardHandler {
+	return &LoginGuardHandler{guard: guard}
+}
+
+// @route Get /user/lock
+// GetLoginLock 查看用户名在各来源 IP 上的登录失败与锁定状态
+func (h *LoginGuardHandler) GetLoginLock(c *api.Context, req *dto.LoginLockRequest) *api.Response {
+	status, err := h.guard.Status(c.Context(), req.Username)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(status)
+}
+
+// @route Delete /user/lock
+// UnlockUser 管理员解除用户名的登录锁定（清除全部来源 IP 的失败记录）
+func (h *LoginGuardHandler) UnlockUser(c *api.Context, req *dto.LoginLockRequest) *api.Response {
+	if err := h.guard.Unlock(c.Context(), req.Username); err != nil {
+		return api.InternalError(err)
+	}
+	return api.NoContent()
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/loginguard"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/constant"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tenantContext(tenantID uint64) *api.Context {
+	return api.New(pkgrepo.WithTenant(context.Background(), tenantID), app.NewContext(0))
+}
+
+// TestUnlockUser_TenantScoped 租户管理员看得到并能解除本租户用户的锁定，碰不到平台上的同名账号
+func TestUnlockUser_TenantScoped(t *testing.T) {
+	guard := service.NewLoginGuard(loginguard.NewInMemoryStore(), loginguard.Policy{
+		MaxAttempts: 1, Window: time.Minute, LockoutDuration: time.Minute, MaxLockoutDuration: time.Hour,
+	})
+	h := NewLoginGuardHandler(guard)
+	const tenantB = 2
+	tenantCtx := pkgrepo.WithTenant(context.Background(), tenantB)
+	platformCtx := pkgrepo.WithTenant(context.Background(), constant.PlatformTenantID)
+	require.Error(t, guard.RecordFailure(tenantCtx, "alice", "10.0.0.1"))
+	require.Error(t, guard.RecordFailure(platformCtx, "alice", "10.0.0.2"))
+	req := &dto.LoginLockRequest{Username: "alice"}
+
+	resp := h.GetLoginLock(tenantContext(tenantB), req)
+	require.Equal(t, 200, resp.HTTPStatus())
+	status, ok := resp.Data.([]*dto.LoginLockResponse)
+	require.True(t, ok)
+	require.Len(t, status, 1)
+	assert.Equal(t, "10.0.0.1", status[0].IP)
+	assert.True(t, status[0].Locked)
+
+	resp = h.UnlockUser(tenantContext(tenantB), req)
+	require.Equal(t, 200, resp.HTTPStatus())
+	assert.NoError(t, guard.Check(tenantCtx, "alice", "10.0.0.1"))
+
+	// 平台账号的锁定不受租户管理员的解锁影响
+	var locked *service.AccountLockedError
+	assert.ErrorAs(t, guard.Check(platformCtx, "alice", "10.0.0.2"), &locked)
+}
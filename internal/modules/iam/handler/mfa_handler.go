@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// MFAHandler 当前用户管理自己的两步验证：绑定、停用、重置恢复码
+type MFAHandler struct {
+	mfaSvc *service.MFAService
+}
+
+// NewMFAHandler 创建两步验证处理器
+func NewMFAHandler(mfaSvc *service.MFAService) *MFAHandler {
+	return &MFAHandler{mfaSvc: mfaSvc}
+}
+
+// @route Get /mfa
+// GetMFAStatus 查看是否已开启两步验证、角色是否强制要求、剩余恢复码数量
+func (h *MFAHandler) GetMFAStatus(c *api.Context) *api.Response {
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	status, err := h.mfaSvc.Status(c.Context(), userID)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(status)
+}
+
+// @route Post /mfa/setup
+// SetupMFA 生成绑定密钥与 otpauth 链接，需再调用 /mfa/enable 提交口令确认
+func (h *MFAHandler) SetupMFA(c *api.Context) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	setup, err := h.mfaSvc.Setup(c.Context(), userID, claims.Nice)
+	if err != nil {
+		return mfaError(err)
+	}
+	return api.Success(setup)
+}
+
+// @route Post /mfa/enable
+// EnableMFA 提交验证器上的口令确认绑定，返回恢复码（只显示这一次）
+func (h *MFAHandler) EnableMFA(c *api.Context, req *dto.MFACodeRequest) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	codes, err := h.mfaSvc.Enable(c.Context(), userID, req.Code)
+	if err != nil {
+		return mfaError(err)
+	}
+	return api.Success(dto.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// @route Delete /mfa
+// DisableMFA 提交口令或恢复码后停用两步验证
+func (h *MFAHandler) DisableMFA(c *api.Context, req *dto.MFACodeRequest) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	if err := h.mfaSvc.Disable(c.Context(), userID, req.Code); err != nil {
+		return mfaError(err)
+	}
+	return api.NoContent()
+}
+
+// @route Post /mfa/recovery-codes
+// RegenerateRecoveryCodes 重新生成恢复码，旧的全部作废
+func (h *MFAHandler) RegenerateRecoveryCodes(c *api.Context, req *dto.MFACodeRequest) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	codes, err := h.mfaSvc.RegenerateRecoveryCodes(c.Context(), userID, req.Code)
+	if err != nil {
+		return mfaError(err)
+	}
+	return api.Success(dto.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// mfaError 把两步验证的业务错误映射为响应码，其余视为服务端错误
+func mfaError(err error) *api.Response {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode),
+		errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFASetupRequired):
+		return api.ParamError(err)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		return api.Conflict(err)
+	case errors.Is(err, service.ErrMFARequired):
+		return api.Forbidden(err)
+	}
+	return api.InternalError(err)
+}
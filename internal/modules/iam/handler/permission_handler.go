@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	tenantsvc "github.com/ayxworxfr/go_admin/internal/modules/tenant/service"
 	"github.com/ayxworxfr/go_admin/pkg/api"
 )
 
@@ -17,12 +20,24 @@ func NewPermissionHandler(permSvc *service.PermissionService, checker *service.P
 	return &PermissionHandler{permSvc: permSvc, checker: checker}
 }
 
+// permissionError 规则语法错误是请求参数问题，非平台租户改权限目录属于越权，其余按数据库错误返回
+func permissionError(err error) *api.Response {
+	switch {
+	case errors.Is(err, service.ErrInvalidPermissionRule):
+		return api.ParamError(err)
+	case errors.Is(err, tenantsvc.ErrPlatformOnly):
+		return api.Forbidden(err)
+	default:
+		return api.DatabaseError(err)
+	}
+}
+
 // @route Post /permission
 // CreatePermission 创建权限
 func (h *PermissionHandler) CreatePermission(c *api.Context, req *dto.CreatePermissionRequest) *api.Response {
 	permission, err := h.permSvc.CreatePermission(c.Context(), req)
 	if err != nil {
-		return api.DatabaseError(err)
+		return permissionError(err)
 	}
 	h.checker.InvalidateAll()
 	return api.Success(permission)
@@ -32,7 +47,7 @@ func (h *PermissionHandler) CreatePermission(c *api.Context, req *dto.CreatePerm
 // CreatePermissionBatch 批量创建权限
 func (h *PermissionHandler) CreatePermissionBatch(c *api.Context, req *dto.CreatePermissionsRequest) *api.Response {
 	if err := h.permSvc.CreatePermissions(c.Context(), req); err != nil {
-		return api.DatabaseError(err)
+		return permissionError(err)
 	}
 	h.checker.InvalidateAll()
 	return api.Success(nil)
@@ -43,7 +58,7 @@ func (h *PermissionHandler) CreatePermissionBatch(c *api.Context, req *dto.Creat
 func (h *PermissionHandler) UpdatePermission(c *api.Context, req *dto.UpdatePermissionRequest) *api.Response {
 	permission, err := h.permSvc.UpdatePermission(c.Context(), req)
 	if err != nil {
-		return api.DatabaseError(err)
+		return permissionError(err)
 	}
 	h.checker.InvalidateAll()
 	return api.Success(permission)
@@ -53,7 +68,17 @@ func (h *PermissionHandler) UpdatePermission(c *api.Context, req *dto.UpdatePerm
 // DeletePermission 删除权限
 func (h *PermissionHandler) DeletePermission(c *api.Context, req *dto.DeletePermissionRequest) *api.Response {
 	if err := h.permSvc.DeletePermissionBatch(c.Context(), req.IDs); err != nil {
-		return api.DatabaseError(err)
+		return permissionError(err)
+	}
+	h.checker.InvalidateAll()
+	return api.NoContent()
+}
+
+// @route Put /permission/restore
+// RestorePermission 从回收站恢复权限
+func (h *PermissionHandler) RestorePermission(c *api.Context, req *dto.RestorePermissionRequest) *api.Response {
+	if err := h.permSvc.RestorePermissionBatch(c.Context(), req.IDs); err != nil {
+		return permissionError(err)
 	}
 	h.checker.InvalidateAll()
 	return api.NoContent()
@@ -78,3 +103,13 @@ func (h *PermissionHandler) GetPermissionList(c *api.Context, req *dto.GetPermis
 	}
 	return api.PageSuccess(permissions, total)
 }
+
+// @route Get /permission/explain
+// ExplainPermission 说明某用户访问某接口为何被允许或拒绝
+func (h *PermissionHandler) ExplainPermission(c *api.Context, req *dto.ExplainPermissionRequest) *api.Response {
+	result, err := h.checker.Explain(c.Context(), req.UserID, req.Method, req.Path)
+	if err != nil {
+		return api.DatabaseError(err)
+	}
+	return api.Success(result)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+)
+
+// PersonalTokenHandler 个人访问令牌管理：创建/查看/撤销自己的令牌，以及管理员查看/撤销指定用户的令牌。
+// 令牌按租户隔离；自己的令牌固定在令牌所属租户里读写，平台管理员用 X-Tenant 进入别的租户时也是如此
+type PersonalTokenHandler struct {
+	tokenSvc *service.PersonalTokenService
+	users    usersvc.UserFinder
+}
+
+// NewPersonalTokenHandler 创建个人访问令牌处理器；users 用于确认管理员操作的用户在其租户与数据范围内
+func NewPersonalTokenHandler(tokenSvc *service.PersonalTokenService, users usersvc.UserFinder) *PersonalTokenHandler {
+	return &PersonalTokenHandler{tokenSvc: tokenSvc, users: users}
+}
+
+// @route Post /personal-token
+// CreatePersonalToken 创建个人访问令牌，响应中的 token 只返回这一次
+func (h *PersonalTokenHandler) CreatePersonalToken(c *api.Context, req *dto.CreatePersonalTokenRequest) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	// 令牌不能再派生令牌：否则一个泄露的短期令牌就能给自己续出长期令牌
+	if claims.Type == jwtauth.PersonalTokenType {
+		return api.Forbidden("Personal access tokens cannot create other tokens")
+	}
+	// 扮演者不能替被扮演用户留下一个长期有效、不带扮演标记的凭证
+	if claims.Impersonating() {
+		return api.Forbidden("Impersonation tokens cannot create personal access tokens")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	token, err := h.tokenSvc.Create(pkgrepo.WithTenant(c.Context(), claims.Tenant()), userID, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTokenScope) {
+			return api.ParamError(err.Error())
+		}
+		return api.InternalError(err)
+	}
+	return api.Success(token)
+}
+
+// @route Get /personal-token/list
+// ListPersonalTokens 列出当前用户的个人访问令牌
+func (h *PersonalTokenHandler) ListPersonalTokens(c *api.Context) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	tokens, err := h.tokenSvc.List(pkgrepo.WithTenant(c.Context(), claims.Tenant()), userID)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(tokens)
+}
+
+// @route Delete /personal-token
+// RevokePersonalToken 撤销当前用户的某个个人访问令牌
+func (h *PersonalTokenHandler) RevokePersonalToken(c *api.Context, req *dto.RevokePersonalTokenRequest) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	return h.revoke(pkgrepo.WithTenant(c.Context(), claims.Tenant()), userID, req.ID)
+}
+
+// @route Get /user/personal-token/list
+// GetUserPersonalTokens 管理员查看指定用户的个人访问令牌
+func (h *PersonalTokenHandler) GetUserPersonalTokens(c *api.Context, req *dto.GetUserPersonalTokensRequest) *api.Response {
+	if resp := checkTargetUser(c, h.users, req.UserID); resp != nil {
+		return resp
+	}
+	tokens, err := h.tokenSvc.List(c.Context(), req.UserID)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(tokens)
+}
+
+// @route Delete /user/personal-token
+// RevokeUserPersonalToken 管理员撤销指定用户的个人访问令牌
+func (h *PersonalTokenHandler) RevokeUserPersonalToken(c *api.Context, req *dto.RevokeUserPersonalTokenRequest) *api.Response {
+	if resp := checkTargetUser(c, h.users, req.UserID); resp != nil {
+		return resp
+	}
+	return h.revoke(c.Context(), req.UserID, req.ID)
+}
+
+func (h *PersonalTokenHandler) revoke(ctx context.Context, userID, id uint64) *api.Response {
+	if err := h.tokenSvc.Revoke(ctx, userID, id); err != nil {
+		if errors.Is(err, service.ErrPersonalTokenNotFound) {
+			return api.NotFound("Personal access token not found")
+		}
+		return api.InternalError(err)
+	}
+	return api.NoContent()
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
 	"github.com/ayxworxfr/go_admin/pkg/api"
@@ -17,12 +19,20 @@ func NewRoleHandler(roleSvc *service.RoleService, checker *service.PermissionChe
 	return &RoleHandler{roleSvc: roleSvc, checker: checker}
 }
 
+// roleError 继承关系不合法属于参数错误，其余按数据库错误处理
+func roleError(err error) *api.Response {
+	if errors.Is(err, service.ErrRoleCycle) || errors.Is(err, service.ErrInvalidRoleParent) {
+		return api.ParamError(err)
+	}
+	return api.DatabaseError(err)
+}
+
 // @route Post /role
 // CreateRole 创建角色
 func (h *RoleHandler) CreateRole(c *api.Context, req *dto.CreateRoleRequest) *api.Response {
 	role, err := h.roleSvc.CreateRole(c.Context(), req)
 	if err != nil {
-		return api.DatabaseError(err)
+		return roleError(err)
 	}
 	h.checker.InvalidateAll()
 	return api.Success(role)
@@ -35,7 +45,11 @@ func (h *RoleHandler) CreateRoleBatch(c *api.Context, req *dto.CreateRolesReques
 	for _, roleReq := range req.Roles {
 		role, err := h.roleSvc.CreateRole(c.Context(), roleReq)
 		if err != nil {
-			return api.DatabaseError(err)
+			// 前面已经建好的角色照样生效
+			if len(result) > 0 {
+				h.checker.InvalidateAll()
+			}
+			return roleError(err)
 		}
 		result = append(result, role)
 	}
@@ -48,7 +62,7 @@ func (h *RoleHandler) CreateRoleBatch(c *api.Context, req *dto.CreateRolesReques
 func (h *RoleHandler) UpdateRole(c *api.Context, req *dto.UpdateRoleRequest) *api.Response {
 	role, err := h.roleSvc.UpdateRole(c.Context(), req)
 	if err != nil {
-		return api.DatabaseError(err)
+		return roleError(err)
 	}
 	h.checker.InvalidateAll()
 	return api.Success(role)
@@ -57,10 +71,28 @@ func (h *RoleHandler) UpdateRole(c *api.Context, req *dto.UpdateRoleRequest) *ap
 // @route Delete /role
 // DeleteRole 删除角色
 func (h *RoleHandler) DeleteRole(c *api.Context, req *dto.DeleteRoleRequest) *api.Response {
-	if err := h.roleSvc.DeleteRoleBatch(c.Context(), req.IDs); err != nil {
+	// 部分失败时其余角色已经删除，缓存照样要失效
+	deleted, err := h.roleSvc.DeleteRoleBatch(c.Context(), req.IDs)
+	if deleted > 0 {
+		h.checker.InvalidateAll()
+	}
+	if err != nil {
 		return api.DatabaseError(err)
 	}
-	h.checker.InvalidateAll()
+	return api.NoContent()
+}
+
+// @route Put /role/restore
+// RestoreRole 从回收站恢复角色，角色原有的权限、继承关系与数据范围随之生效；
+// 继承关系重新生效后会成环的角色不予恢复
+func (h *RoleHandler) RestoreRole(c *api.Context, req *dto.RestoreRoleRequest) *api.Response {
+	restored, err := h.roleSvc.RestoreRoleBatch(c.Context(), req.IDs)
+	if restored > 0 {
+		h.checker.InvalidateAll()
+	}
+	if err != nil {
+		return roleError(err)
+	}
 	return api.NoContent()
 }
 
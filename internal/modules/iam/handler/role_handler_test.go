@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/cache"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRoleBatch_PartialFailureInvalidatesCache 批量删除、恢复部分失败时，已经生效的那部分角色
+// 同样要让权限缓存失效，再把错误返回给调用方
+func TestRoleBatch_PartialFailureInvalidatesCache(t *testing.T) {
+	db := repotest.NewDB(t, new(model.Role), new(model.RoleParent), new(model.UserRole),
+		new(model.Permission), new(model.RolePermission), new(model.RoleDept))
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	roleSvc := service.NewRoleService(db)
+	permCache := cache.NewInMemoryCache(time.Minute)
+	h := NewRoleHandler(roleSvc, service.NewPermissionChecker(service.NewUserRoleService(db, roleSvc, nil), roleSvc, permCache))
+	role, err := roleSvc.CreateRole(ctx, &dto.CreateRoleRequest{Name: "Auditor", Code: "AUDITOR", Status: 1})
+	require.NoError(t, err)
+
+	perms, err := pathmatch.Compile([]string{"GET:/api/protected/user/list"})
+	require.NoError(t, err)
+	cached := func() bool {
+		_, ok := permCache.Get(cache.Key{UserID: 1})
+		return ok
+	}
+	newContext := func() *api.Context { return api.New(ctx, app.NewContext(0)) }
+	const missing = 999
+
+	permCache.Set(cache.Key{UserID: 1}, permCache.Version(1), perms)
+	resp := h.DeleteRole(newContext(), &dto.DeleteRoleRequest{IDs: []uint64{role.ID, missing}})
+	require.NotNil(t, resp)
+	assert.NotEqual(t, 200, resp.HTTPStatus())
+	assert.False(t, cached(), "删除成功的角色已经不再生效")
+
+	permCache.Set(cache.Key{UserID: 1}, permCache.Version(1), perms)
+	resp = h.RestoreRole(newContext(), &dto.RestoreRoleRequest{IDs: []uint64{role.ID, missing}})
+	require.NotNil(t, resp)
+	assert.NotEqual(t, 200, resp.HTTPStatus())
+	assert.False(t, cached(), "恢复成功的角色重新生效")
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/session"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// SessionHandler 登录会话管理：查看/撤销自己的会话，以及管理员强制用户下线
+type SessionHandler struct {
+	sessionSvc *service.SessionService
+	users      usersvc.UserFinder
+}
+
+// NewSessionHandler 创建会话处理器；users 用于确认管理员操作的用户在其租户与数据范围内
+func NewSessionHandler(sessionSvc *service.SessionService, users usersvc.UserFinder) *SessionHandler {
+	return &SessionHandler{sessionSvc: sessionSvc, users: users}
+}
+
+// @route Get /session/list
+// ListSessions 列出当前用户的活跃会话
+func (h *SessionHandler) ListSessions(c *api.Context) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	sessions, err := h.sessionSvc.List(c.Context(), userID, claims.FamilyID)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(sessions)
+}
+
+// @route Delete /session
+// RevokeSession 撤销当前用户的某个会话（如踢掉一台丢失的设备）
+func (h *SessionHandler) RevokeSession(c *api.Context, req *dto.RevokeSessionRequest) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	if err := h.sessionSvc.Revoke(c.Context(), userID, req.ID); err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			return api.NotFound("Session not found")
+		}
+		return api.InternalError(err)
+	}
+	return api.NoContent()
+}
+
+// @route Delete /session/all
+// RevokeAllSessions 退出所有设备（包括当前会话）
+func (h *SessionHandler) RevokeAllSessions(c *api.Context) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	count, err := h.sessionSvc.RevokeAll(c.Context(), userID)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(map[string]int{"revoked": count})
+}
+
+// @route Get /user/session/list
+// GetUserSessions 管理员查看指定用户的活跃会话
+func (h *SessionHandler) GetUserSessions(c *api.Context, req *dto.GetUserSessionsRequest) *api.Response {
+	if resp := checkTargetUser(c, h.users, req.UserID); resp != nil {
+		return resp
+	}
+	sessions, err := h.sessionSvc.List(c.Context(), req.UserID, "")
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(sessions)
+}
+
+// @route Delete /user/session
+// ForceOffline 管理员强制用户下线：撤销其全部会话
+func (h *SessionHandler) ForceOffline(c *api.Context, req *dto.ForceOfflineRequest) *api.Response {
+	if resp := checkTargetUser(c, h.users, req.UserID); resp != nil {
+		return resp
+	}
+	count, err := h.sessionSvc.RevokeAll(c.Context(), req.UserID)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(map[string]int{"revoked": count})
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"errors"
+
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+)
+
+// checkTargetUser 管理员按用户 ID 操作会话、令牌之前，先在请求的租户与数据范围内查出该用户。
+// 会话与令牌的存储只按用户 ID 索引，不经过这一步，租户管理员就能动到平台或别的租户的账号；
+// 查不到的用户一律按不存在处理，不区分"不存在"和"无权访问"
+func checkTargetUser(c *api.Context, users usersvc.UserFinder, userID uint64) *api.Response {
+	if _, err := users.FindByID(c.Context(), userID); err != nil {
+		if errors.Is(err, pkgrepo.ErrNotFound) {
+			return api.NotFound("User not found")
+		}
+		return api.DatabaseError(err)
+	}
+	return nil
+}
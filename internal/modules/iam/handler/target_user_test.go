@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	usermodel "github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// invisibleUsers 模拟请求租户与数据范围内查不到任何用户
+type invisibleUsers struct{}
+
+func (invisibleUsers) FindByID(context.Context, uint64) (*usermodel.User, error) {
+	return nil, pkgrepo.ErrNotFound
+}
+
+func (invisibleUsers) FindByUsername(context.Context, string) (*usermodel.User, error) {
+	return nil, pkgrepo.ErrNotFound
+}
+
+func (invisibleUsers) VerifyPassword(*usermodel.User, string) bool { return false }
+
+// TestAdminUserEndpoints_RequireVisibleUser 看不到的用户（别的租户、平台账号、数据范围外）按不存在处理，
+// 不会走到只按用户 ID 索引的会话与令牌存储，所以这里不需要注入服务
+func TestAdminUserEndpoints_RequireVisibleUser(t *testing.T) {
+	sessions := NewSessionHandler(nil, invisibleUsers{})
+	tokens := NewPersonalTokenHandler(nil, invisibleUsers{})
+
+	calls := map[string]func(c *api.Context) *api.Response{
+		"GetUserSessions": func(c *api.Context) *api.Response {
+			return sessions.GetUserSessions(c, &dto.GetUserSessionsRequest{UserID: 1})
+		},
+		"ForceOffline": func(c *api.Context) *api.Response {
+			return sessions.ForceOffline(c, &dto.ForceOfflineRequest{UserID: 1})
+		},
+		"GetUserPersonalTokens": func(c *api.Context) *api.Response {
+			return tokens.GetUserPersonalTokens(c, &dto.GetUserPersonalTokensRequest{UserID: 1})
+		},
+		"RevokeUserPersonalToken": func(c *api.Context) *api.Response {
+			return tokens.RevokeUserPersonalToken(c, &dto.RevokeUserPersonalTokenRequest{UserID: 1, ID: 1})
+		},
+	}
+	for name, call := range calls {
+		resp := call(newTestContext(&jwtauth.Claims{Identity: "2"}))
+		if assert.NotNil(t, resp, name) {
+			assert.Equal(t, 404, resp.HTTPStatus(), name)
+		}
+	}
+}
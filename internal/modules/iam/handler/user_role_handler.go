@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/service"
 	"github.com/ayxworxfr/go_admin/pkg/api"
 	"github.com/jinzhu/copier"
 )
 
-// UserRoleHandler 用户-角色分配、用户权限查询接口
+// UserRoleHandler 用户-角色分配、用户权限查询接口。扮演令牌不能分配角色：
+// 否则扮演者能把被扮演用户的角色授予自己的账号，扮演结束后仍然留着
 type UserRoleHandler struct {
 	userRoleSvc *service.UserRoleService
 	checker     *service.PermissionChecker
@@ -21,7 +24,34 @@ func NewUserRoleHandler(userRoleSvc *service.UserRoleService, checker *service.P
 // @route Post /user/assign/roles
 // UserAssignRoles 为用户分配角色
 func (h *UserRoleHandler) UserAssignRoles(c *api.Context, req *dto.AssignRolesRequest) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
 	if err := h.userRoleSvc.AssignRoles(c.Context(), req.UserID, req.RoleIDs); err != nil {
+		if errors.Is(err, service.ErrRoleNotFound) {
+			return api.ParamError(err)
+		}
+		return api.DatabaseError(err)
+	}
+	h.checker.InvalidateUser(req.UserID)
+
+	user, err := h.userRoleSvc.GetUserRoles(c.Context(), req.UserID, dto.ALL_AUTH_FLAGS)
+	if err != nil {
+		return api.DatabaseError(err)
+	}
+	return api.Success(user)
+}
+
+// @route Post /user/grant/role
+// GrantTemporaryRole 为用户设置带有效期的角色，到期后自动失去该角色
+func (h *UserRoleHandler) GrantTemporaryRole(c *api.Context, req *dto.GrantTemporaryRoleRequest) *api.Response {
+	if resp := rejectImpersonation(c); resp != nil {
+		return resp
+	}
+	if err := h.userRoleSvc.GrantTemporaryRole(c.Context(), req.UserID, req.RoleID, req.ValidFrom, req.ValidUntil); err != nil {
+		if errors.Is(err, service.ErrInvalidRoleWindow) || errors.Is(err, service.ErrRoleNotFound) {
+			return api.ParamError(err)
+		}
 		return api.DatabaseError(err)
 	}
 	h.checker.InvalidateUser(req.UserID)
@@ -0,0 +1,31 @@
+package loginguard
+
+import (
+	"fmt"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+)
+
+// Options 构造 Store 的运行时参数，驱动名沿用 tokenstore 的取值（memory / redis）。
+type Options struct {
+	Driver    string
+	KeyPrefix string
+	Redis     *pkgredis.Client
+}
+
+// New 按驱动名创建登录失败状态存储。driver 为空时回落 memory。
+func New(opts Options) (Store, error) {
+	switch tokenstore.NormalizeDriver(opts.Driver) {
+	case tokenstore.DriverMemory:
+		return NewInMemoryStore(), nil
+	case tokenstore.DriverRedis:
+		if opts.Redis == nil {
+			return nil, fmt.Errorf("login guard driver %q requires redis client", tokenstore.DriverRedis)
+		}
+		return NewRedisStore(opts.Redis, opts.KeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown login guard driver %q (want %q or %q)",
+			opts.Driver, tokenstore.DriverMemory, tokenstore.DriverRedis)
+	}
+}
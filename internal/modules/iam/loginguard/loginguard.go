@@ -0,0 +1,40 @@
+package loginguard
+
+import (
+	"context"
+	"time"
+)
+
+// State 某个 用户名+IP 组合的登录失败状态
+type State struct {
+	// Failures 当前统计窗口内的连续失败次数
+	Failures int `json:"failures"`
+	// WindowStart 当前统计窗口的起点（窗口内第一次失败的时间）
+	WindowStart time.Time `json:"window_start"`
+	// Lockouts 已触发锁定的次数，决定下一次锁定时长（指数退避）
+	Lockouts int `json:"lockouts"`
+	// LockedUntil 锁定截止时间，零值表示未锁定
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Locked 在 now 时刻是否处于锁定中
+func (s State) Locked(now time.Time) bool {
+	return now.Before(s.LockedUntil)
+}
+
+// Store 登录失败状态的存储策略接口，按用户名聚合、IP 细分：
+// 管理员解锁按用户名一次清掉所有 IP 的状态。
+//   - InMemoryStore：单机进程内
+//   - RedisStore：多实例共享，避免换一个副本就能绕过计数
+type Store interface {
+	// Get 读取状态，不存在返回零值
+	Get(ctx context.Context, username, ip string) (State, error)
+	// List 读取用户名下所有 IP 的状态（管理台展示用）
+	List(ctx context.Context, username string) (map[string]State, error)
+	// Update 原子地读-改-写状态，记录至少保留 ttl；返回写入后的状态
+	Update(ctx context.Context, username, ip string, ttl time.Duration, fn func(State) State) (State, error)
+	// Delete 清除单个 用户名+IP 的状态（登录成功时调用）
+	Delete(ctx context.Context, username, ip string) error
+	// DeleteUser 清除用户名下全部状态（管理员解锁）
+	DeleteUser(ctx context.Context, username string) error
+}
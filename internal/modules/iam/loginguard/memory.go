@@ -0,0 +1,97 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/pkg/store"
+)
+
+// userStates 单个用户名下各 IP 的状态；expiresAt 冗余记录整表过期时间，
+// 删除单个 IP 后按原过期时间写回，不会延长也不会丢失剩余 TTL
+type userStates struct {
+	ips       map[string]State
+	expiresAt time.Time
+}
+
+// InMemoryStore 进程内实现：每个用户名一张 IP→State 的表，整表随最近一次写入续期。
+type InMemoryStore struct {
+	mu    sync.Mutex // 保护对单个用户名状态表的读-改-写
+	users *store.Memory[string, userStates]
+}
+
+// NewInMemoryStore 创建进程内登录失败状态存储
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{users: store.NewMemory[string, userStates](0)}
+}
+
+// Get 读取状态
+func (s *InMemoryStore) Get(_ context.Context, username, ip string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, _ := s.users.Get(username)
+	return entry.ips[ip], nil
+}
+
+// List 读取用户名下所有 IP 的状态
+func (s *InMemoryStore) List(_ context.Context, username string) (map[string]State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, _ := s.users.Get(username)
+	result := make(map[string]State, len(entry.ips))
+	for ip, st := range entry.ips {
+		result[ip] = st
+	}
+	return result, nil
+}
+
+// Update 在锁内完成读-改-写
+func (s *InMemoryStore) Update(_ context.Context, username, ip string, ttl time.Duration, fn func(State) State) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, _ := s.users.Get(username)
+	ips := make(map[string]State, len(old.ips)+1)
+	for k, v := range old.ips {
+		ips[k] = v
+	}
+	next := fn(ips[ip])
+	ips[ip] = next
+
+	expiresAt := time.Now().Add(ttl)
+	s.users.SetUntil(username, userStates{ips: ips, expiresAt: expiresAt}, expiresAt)
+	return next, nil
+}
+
+// Delete 清除单个 用户名+IP 的状态
+func (s *InMemoryStore) Delete(_ context.Context, username, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.users.Get(username)
+	if !ok {
+		return nil
+	}
+	if _, exists := old.ips[ip]; !exists {
+		return nil
+	}
+	if len(old.ips) == 1 {
+		s.users.Delete(username)
+		return nil
+	}
+	ips := make(map[string]State, len(old.ips)-1)
+	for k, v := range old.ips {
+		if k != ip {
+			ips[k] = v
+		}
+	}
+	s.users.SetUntil(username, userStates{ips: ips, expiresAt: old.expiresAt}, old.expiresAt)
+	return nil
+}
+
+// DeleteUser 清除用户名下全部状态
+func (s *InMemoryStore) DeleteUser(_ context.Context, username string) error {
+	s.users.Delete(username)
+	return nil
+}
@@ -0,0 +1,54 @@
+package loginguard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryStore_UpdateAndDelete(t *testing.T) {
+	s := NewInMemoryStore()
+	ctx := context.Background()
+	incr := func(st State) State { st.Failures++; return st }
+
+	st, err := s.Update(ctx, "alice", "1.1.1.1", time.Hour, incr)
+	require.NoError(t, err)
+	require.Equal(t, 1, st.Failures)
+	_, err = s.Update(ctx, "alice", "1.1.1.1", time.Hour, incr)
+	require.NoError(t, err)
+	_, err = s.Update(ctx, "alice", "2.2.2.2", time.Hour, incr)
+	require.NoError(t, err)
+
+	st, err = s.Get(ctx, "alice", "1.1.1.1")
+	require.NoError(t, err)
+	require.Equal(t, 2, st.Failures)
+
+	all, err := s.List(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+
+	require.NoError(t, s.Delete(ctx, "alice", "1.1.1.1"))
+	st, err = s.Get(ctx, "alice", "1.1.1.1")
+	require.NoError(t, err)
+	require.Zero(t, st.Failures)
+
+	require.NoError(t, s.DeleteUser(ctx, "alice"))
+	all, err = s.List(ctx, "alice")
+	require.NoError(t, err)
+	require.Empty(t, all)
+}
+
+func TestInMemoryStore_Expiry(t *testing.T) {
+	s := NewInMemoryStore()
+	ctx := context.Background()
+
+	_, err := s.Update(ctx, "bob", "1.1.1.1", 20*time.Millisecond, func(st State) State { st.Failures++; return st })
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	st, err := s.Get(ctx, "bob", "1.1.1.1")
+	require.NoError(t, err)
+	require.Zero(t, st.Failures)
+}
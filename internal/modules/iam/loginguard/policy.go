@@ -0,0 +1,55 @@
+package loginguard
+
+import "time"
+
+// Policy 登录防爆破策略：Window 内连续失败 MaxAttempts 次即锁定，
+// 第 n 次锁定时长为 LockoutDuration * 2^(n-1)，封顶 MaxLockoutDuration。
+// 锁定解除后若在 MaxLockoutDuration 内没有再次被锁，退避等级清零。
+type Policy struct {
+	MaxAttempts        int
+	Window             time.Duration
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+}
+
+// Enabled MaxAttempts<=0 视为关闭防护
+func (p Policy) Enabled() bool {
+	return p.MaxAttempts > 0
+}
+
+// Fail 计算一次失败之后的新状态
+func (p Policy) Fail(st State, now time.Time) State {
+	if st.WindowStart.IsZero() || now.Sub(st.WindowStart) > p.Window {
+		st.Failures = 0
+		st.WindowStart = now
+	}
+	if st.Lockouts > 0 && now.Sub(st.LockedUntil) > p.MaxLockoutDuration {
+		st.Lockouts = 0
+	}
+
+	st.Failures++
+	if st.Failures >= p.MaxAttempts {
+		st.Lockouts++
+		st.LockedUntil = now.Add(p.lockoutFor(st.Lockouts))
+		st.Failures = 0
+		st.WindowStart = time.Time{}
+	}
+	return st
+}
+
+// Retention 状态记录需要保留的时长：覆盖一个统计窗口、最长一次锁定，
+// 以及锁定结束后用于判断退避等级是否清零的观察期
+func (p Policy) Retention() time.Duration {
+	return p.Window + 2*p.MaxLockoutDuration
+}
+
+func (p Policy) lockoutFor(lockouts int) time.Duration {
+	d := p.LockoutDuration
+	for i := 1; i < lockouts && d < p.MaxLockoutDuration; i++ {
+		d *= 2
+	}
+	if p.MaxLockoutDuration > 0 && d > p.MaxLockoutDuration {
+		d = p.MaxLockoutDuration
+	}
+	return d
+}
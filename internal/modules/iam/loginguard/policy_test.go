@@ -0,0 +1,76 @@
+package loginguard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = Policy{
+	MaxAttempts:        3,
+	Window:             10 * time.Minute,
+	LockoutDuration:    time.Minute,
+	MaxLockoutDuration: 5 * time.Minute,
+}
+
+func TestPolicy_LockAfterMaxAttempts(t *testing.T) {
+	now := time.Now()
+	var st State
+	st = testPolicy.Fail(st, now)
+	st = testPolicy.Fail(st, now)
+	require.False(t, st.Locked(now))
+
+	st = testPolicy.Fail(st, now)
+	require.True(t, st.Locked(now))
+	require.Equal(t, now.Add(time.Minute), st.LockedUntil)
+	require.Zero(t, st.Failures, "锁定后重新计数")
+}
+
+func TestPolicy_WindowResetsFailures(t *testing.T) {
+	now := time.Now()
+	st := testPolicy.Fail(State{}, now)
+	st = testPolicy.Fail(st, now)
+
+	// 超出统计窗口后的失败重新从 1 开始
+	later := now.Add(11 * time.Minute)
+	st = testPolicy.Fail(st, later)
+	require.Equal(t, 1, st.Failures)
+	require.False(t, st.Locked(later))
+}
+
+func TestPolicy_ExponentialBackoff(t *testing.T) {
+	now := time.Now()
+	var st State
+	lock := func() time.Duration {
+		for i := 0; i < testPolicy.MaxAttempts; i++ {
+			st = testPolicy.Fail(st, now)
+		}
+		d := st.LockedUntil.Sub(now)
+		now = st.LockedUntil
+		return d
+	}
+
+	require.Equal(t, time.Minute, lock())
+	require.Equal(t, 2*time.Minute, lock())
+	require.Equal(t, 4*time.Minute, lock())
+	require.Equal(t, 5*time.Minute, lock(), "封顶 MaxLockoutDuration")
+	require.Equal(t, 5*time.Minute, lock())
+}
+
+func TestPolicy_BackoffDecays(t *testing.T) {
+	now := time.Now()
+	var st State
+	for i := 0; i < testPolicy.MaxAttempts; i++ {
+		st = testPolicy.Fail(st, now)
+	}
+	require.Equal(t, 1, st.Lockouts)
+
+	// 锁定结束后超过观察期才再次失败：退避等级清零，回到基础锁定时长
+	now = st.LockedUntil.Add(testPolicy.MaxLockoutDuration + time.Second)
+	for i := 0; i < testPolicy.MaxAttempts; i++ {
+		st = testPolicy.Fail(st, now)
+	}
+	require.Equal(t, 1, st.Lockouts)
+	require.Equal(t, now.Add(time.Minute), st.LockedUntil)
+}
@@ -0,0 +1,104 @@
+package loginguard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+)
+
+const defaultKeyPrefix = "go_admin:login_guard:"
+
+// RedisStore 基于 Redis 的实现：每个用户名一个 Hash，field 为 IP，value 为 State 的 JSON。
+// Update 的读-改-写用 pkg/redis 的分布式锁按用户名串行化，多副本并发猜密码时计数不会丢。
+type RedisStore struct {
+	client    *pkgredis.Client
+	keyPrefix string
+}
+
+// NewRedisStore 创建 Redis 存储。client 必须非 nil；prefix 为空时用默认前缀。
+func NewRedisStore(client *pkgredis.Client, keyPrefix string) *RedisStore {
+	if keyPrefix == "" {
+		keyPrefix = defaultKeyPrefix
+	}
+	if !strings.HasSuffix(keyPrefix, ":") {
+		keyPrefix += ":"
+	}
+	return &RedisStore{client: client, keyPrefix: keyPrefix}
+}
+
+func (s *RedisStore) key(username string) string {
+	return s.keyPrefix + username
+}
+
+// Get 读取状态
+func (s *RedisStore) Get(ctx context.Context, username, ip string) (State, error) {
+	raw, ok, err := s.client.HGet(ctx, s.key(username), ip)
+	if err != nil || !ok {
+		return State{}, err
+	}
+	return decodeState(raw)
+}
+
+// List 读取用户名下所有 IP 的状态
+func (s *RedisStore) List(ctx context.Context, username string) (map[string]State, error) {
+	all, err := s.client.HGetAll(ctx, s.key(username))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]State, len(all))
+	for ip, raw := range all {
+		st, err := decodeState(raw)
+		if err != nil {
+			return nil, err
+		}
+		result[ip] = st
+	}
+	return result, nil
+}
+
+// Update 持用户名粒度的分布式锁完成读-改-写，并把整张表的 TTL 顺延到 ttl
+func (s *RedisStore) Update(ctx context.Context, username, ip string, ttl time.Duration, fn func(State) State) (State, error) {
+	key := s.key(username)
+	var next State
+	err := s.client.WithLock(ctx, key, func(ctx context.Context) error {
+		current, err := s.Get(ctx, username, ip)
+		if err != nil {
+			return err
+		}
+		next = fn(current)
+		data, err := json.Marshal(next)
+		if err != nil {
+			return fmt.Errorf("marshal login state: %w", err)
+		}
+		if err := s.client.HSet(ctx, key, ip, string(data)); err != nil {
+			return err
+		}
+		return s.client.Expire(ctx, key, ttl)
+	}, pkgredis.WithTTL(5*time.Second), pkgredis.WithMaxRetries(40))
+	if err != nil {
+		return State{}, err
+	}
+	return next, nil
+}
+
+// Delete 清除单个 用户名+IP 的状态
+func (s *RedisStore) Delete(ctx context.Context, username, ip string) error {
+	return s.client.HDel(ctx, s.key(username), ip)
+}
+
+// DeleteUser 清除用户名下全部状态
+func (s *RedisStore) DeleteUser(ctx context.Context, username string) error {
+	return s.client.Del(ctx, s.key(username))
+}
+
+func decodeState(raw string) (State, error) {
+	var st State
+	if err := json.Unmarshal([]byte(raw), &st); err != nil {
+		return State{}, fmt.Errorf("unmarshal login state: %w", err)
+	}
+	return st, nil
+}
@@ -0,0 +1,70 @@
+package loginguard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisClient(t *testing.T) (*pkgredis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := pkgredis.New(pkgredis.Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client, mr
+}
+
+func TestRedisStore_UpdateAndDelete(t *testing.T) {
+	client, mr := newTestRedisClient(t)
+	s := NewRedisStore(client, "test:guard")
+	ctx := context.Background()
+	now := time.Now()
+
+	st, err := s.Update(ctx, "alice", "1.1.1.1", time.Hour, func(st State) State {
+		return testPolicy.Fail(st, now)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, st.Failures)
+	require.Equal(t, time.Hour, mr.TTL("test:guard:alice"))
+
+	got, err := s.Get(ctx, "alice", "1.1.1.1")
+	require.NoError(t, err)
+	require.Equal(t, 1, got.Failures)
+	require.True(t, got.WindowStart.Equal(st.WindowStart))
+
+	all, err := s.List(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+
+	require.NoError(t, s.Delete(ctx, "alice", "1.1.1.1"))
+	got, err = s.Get(ctx, "alice", "1.1.1.1")
+	require.NoError(t, err)
+	require.Zero(t, got.Failures)
+
+	_, err = s.Update(ctx, "alice", "2.2.2.2", time.Hour, func(st State) State { st.Failures++; return st })
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteUser(ctx, "alice"))
+	require.False(t, mr.Exists("test:guard:alice"))
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(Options{})
+	require.NoError(t, err)
+	require.IsType(t, &InMemoryStore{}, store)
+
+	_, err = New(Options{Driver: "redis"})
+	require.Error(t, err)
+
+	client, _ := newTestRedisClient(t)
+	store, err = New(Options{Driver: "redis", Redis: client})
+	require.NoError(t, err)
+	require.IsType(t, &RedisStore{}, store)
+}
@@ -2,30 +2,69 @@ package model
 
 import "time"
 
-// Role 角色模型
+// Role 角色模型。角色按租户隔离，名称与 code 只在租户内唯一。
+// 删除只打上 DeleteTime，权限、继承与自定义部门的关联原样保留，恢复后角色与删除前一致
 type Role struct {
 	ID          uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
-	Name        string    `xorm:"varchar(50) notnull unique 'name'" json:"name"`
-	Code        string    `xorm:"varchar(50) notnull unique 'code'" json:"code"`
+	TenantID    uint64    `xorm:"bigint unsigned notnull default 1 unique(uk_tenant_role_name) unique(uk_tenant_role_code) 'tenant_id'" json:"tenant_id" tenant:"id"`
+	Name        string    `xorm:"varchar(50) notnull unique(uk_tenant_role_name) 'name'" json:"name"`
+	Code        string    `xorm:"varchar(50) notnull unique(uk_tenant_role_code) 'code'" json:"code"`
 	Description string    `xorm:"varchar(255) 'description'" json:"description"`
-	Status      int       `xorm:"int 'status'" json:"status"` // 1=启用，0=禁用
+	Status      int       `xorm:"int 'status'" json:"status"`            // 1=启用，0=禁用
+	RequireMFA  bool      `xorm:"bool 'require_mfa'" json:"require_mfa"` // 持有该角色的用户必须开启两步验证才能登录
+	DataScope   int       `xorm:"int 'data_scope'" json:"data_scope"`    // 数据范围，取值见 DataScopeXxx 常量
 	CreateTime  time.Time `xorm:"created" json:"create_time"`
 	UpdateTime  time.Time `xorm:"updated" json:"update_time"`
+	DeleteTime  time.Time `xorm:"deleted index 'delete_time'" json:"delete_time"` // 软删除时间，为空表示未删除
 }
 
+// 角色数据范围：决定持有者在列表、详情等接口里能看到哪些行。
+// 0 是引入数据范围之前就存在的角色，按全部数据处理
+const (
+	DataScopeAll          = 1 // 全部数据
+	DataScopeDeptAndBelow = 2 // 本部门及下级部门
+	DataScopeDept         = 3 // 仅本部门
+	DataScopeSelf         = 4 // 仅本人
+	DataScopeCustom       = 5 // 自定义部门，见 RoleDept
+)
+
+// 权限类型
+const (
+	PermissionTypeMenu   = 1
+	PermissionTypeButton = 2
+	PermissionTypeAPI    = 3
+)
+
+// 权限效果：拒绝用来在大范围的允许里扣掉个别接口，优先级见 pkg/pathmatch。
+// 空值是引入该字段前的存量权限，按允许处理
+const (
+	PermissionEffectAllow = "allow"
+	PermissionEffectDeny  = "deny"
+)
+
 // Permission 权限模型
 type Permission struct {
-	ID          uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
-	Name        string    `xorm:"varchar(50) notnull unique 'name'" json:"name"`
-	Code        string    `xorm:"varchar(50) notnull unique 'code'" json:"code"`
-	Description string    `xorm:"varchar(255) 'description'" json:"description"`
-	ParentID    uint64    `xorm:"int 'parent_id'" json:"parent_id"`
-	Type        int       `xorm:"int 'type'" json:"type"` // 1: 菜单, 2: 按钮, 3: 接口
-	Path        string    `xorm:"varchar(255) 'path'" json:"path"`
-	Method      string    `xorm:"varchar(50) 'method'" json:"method"`
-	Status      int       `xorm:"int 'status'" json:"status"` // 1=启用，0=禁用
-	CreateTime  time.Time `xorm:"created" json:"create_time"`
-	UpdateTime  time.Time `xorm:"updated" json:"update_time"`
+	ID          uint64 `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	Name        string `xorm:"varchar(50) notnull unique 'name'" json:"name"`
+	Code        string `xorm:"varchar(50) notnull unique 'code'" json:"code"`
+	Description string `xorm:"varchar(255) 'description'" json:"description"`
+	ParentID    uint64 `xorm:"int 'parent_id'" json:"parent_id"`
+	Type        int    `xorm:"int 'type'" json:"type"` // 1: 菜单, 2: 按钮, 3: 接口
+	Path        string `xorm:"varchar(255) 'path'" json:"path"`
+	Method      string `xorm:"varchar(50) 'method'" json:"method"`
+	Effect      string `xorm:"varchar(10) 'effect'" json:"effect"` // allow / deny，只对带 method+path 的权限有意义
+	Status      int    `xorm:"int 'status'" json:"status"`         // 1=启用，0=禁用
+	// Orphaned 接口权限已匹配不到任何路由（接口被删除或改了路径），由权限目录同步标记，留给管理员确认后删除
+	Orphaned   bool      `xorm:"bool 'orphaned'" json:"orphaned"`
+	CreateTime time.Time `xorm:"created" json:"create_time"`
+	UpdateTime time.Time `xorm:"updated" json:"update_time"`
+	// DeleteTime 软删除时间，为空表示未删除。名称与 code 全局唯一，已删除的权限清理之前仍占用
+	DeleteTime time.Time `xorm:"deleted index 'delete_time'" json:"delete_time"`
+}
+
+// Deleted 权限是否已被软删除
+func (p *Permission) Deleted() bool {
+	return !p.DeleteTime.IsZero()
 }
 
 // RolePermission 角色权限关联模型
@@ -35,9 +74,109 @@ type RolePermission struct {
 	PermissionID uint64 `xorm:"bigint unsigned notnull index 'permission_id'" json:"permission_id"`
 }
 
-// UserRole 用户角色关联模型
-type UserRole struct {
+// RoleParent 角色继承关系：RoleID 继承 ParentID 的全部权限，一个角色可以有多个父角色。
+// 继承可以多层传递，分配时拒绝成环
+type RoleParent struct {
+	ID       uint64 `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	RoleID   uint64 `xorm:"bigint unsigned notnull unique(uk_role_parent) 'role_id'" json:"role_id"`
+	ParentID uint64 `xorm:"bigint unsigned notnull unique(uk_role_parent) index 'parent_id'" json:"parent_id"`
+}
+
+// RoleDept 数据范围为 DataScopeCustom 的角色可见的部门
+type RoleDept struct {
 	ID     uint64 `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
-	UserID uint64 `xorm:"bigint unsigned notnull index 'user_id'" json:"user_id"`
-	RoleID uint64 `xorm:"bigint unsigned notnull index 'role_id'" json:"role_id"`
+	RoleID uint64 `xorm:"bigint unsigned notnull unique(uk_role_dept) 'role_id'" json:"role_id"`
+	DeptID uint64 `xorm:"bigint unsigned notnull unique(uk_role_dept) 'dept_id'" json:"dept_id"`
+}
+
+// UserRole 用户角色关联模型。ValidFrom/ValidUntil 为空表示不限，
+// 两者构成左闭右开的有效期 [ValidFrom, ValidUntil)，用于临时授权。TenantID 与用户、角色所在租户一致
+type UserRole struct {
+	ID         uint64     `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	TenantID   uint64     `xorm:"bigint unsigned notnull default 1 index 'tenant_id'" json:"tenant_id" tenant:"id"`
+	UserID     uint64     `xorm:"bigint unsigned notnull index 'user_id'" json:"user_id"`
+	RoleID     uint64     `xorm:"bigint unsigned notnull index 'role_id'" json:"role_id"`
+	ValidFrom  *time.Time `xorm:"datetime null 'valid_from'" json:"valid_from"`
+	ValidUntil *time.Time `xorm:"datetime null index 'valid_until'" json:"valid_until"`
+}
+
+// ActiveAt 分配在 t 时刻是否生效
+func (ur *UserRole) ActiveAt(t time.Time) bool {
+	if ur.ValidFrom != nil && t.Before(*ur.ValidFrom) {
+		return false
+	}
+	return ur.ValidUntil == nil || t.Before(*ur.ValidUntil)
+}
+
+// NextChange t 之后分配第一次改变生效状态的时刻（开始生效或到期），没有则返回零值
+func (ur *UserRole) NextChange(t time.Time) time.Time {
+	if ur.ValidFrom != nil && t.Before(*ur.ValidFrom) {
+		return *ur.ValidFrom
+	}
+	if ur.ValidUntil != nil && t.Before(*ur.ValidUntil) {
+		return *ur.ValidUntil
+	}
+	return time.Time{}
+}
+
+// UserMFA 用户两步验证（TOTP）绑定信息，每个用户至多一条。
+// Enabled=false 表示已生成密钥、尚未用验证码确认的待绑定状态。
+type UserMFA struct {
+	UserID uint64 `xorm:"pk bigint unsigned 'user_id'" json:"user_id"`
+	// Secret AES-GCM 加密后再 base64 的 TOTP 密钥，数据库泄露不至于直接泄露验证码种子
+	Secret  string `xorm:"varchar(255) notnull 'secret'" json:"-"`
+	Enabled bool   `xorm:"bool 'enabled'" json:"enabled"`
+	// RecoveryCodes 恢复码哈希的 JSON 数组，每个恢复码用过即从数组中移除
+	RecoveryCodes string `xorm:"text 'recovery_codes'" json:"-"`
+	// LastUsedStep 最近一次验证通过的 TOTP 时间步，不接受同一步及更早的口令，防止重放
+	LastUsedStep int64     `xorm:"bigint 'last_used_step'" json:"-"`
+	CreateTime   time.Time `xorm:"created" json:"create_time"`
+	UpdateTime   time.Time `xorm:"updated" json:"update_time"`
+}
+
+// PersonalToken 个人访问令牌（PAT），供脚本、CI 等机器客户端以用户身份调用接口。
+// 明文只在创建时返回一次，库里只存 SHA-256 摘要：令牌本身是高熵随机串，
+// 不需要 Argon2 这类慢哈希，而等值查找正好要求摘要确定。
+type PersonalToken struct {
+	ID        uint64 `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	TenantID  uint64 `xorm:"bigint unsigned notnull default 1 index 'tenant_id'" json:"tenant_id" tenant:"id"` // 与所属用户一致
+	UserID    uint64 `xorm:"bigint unsigned notnull index 'user_id'" json:"user_id"`
+	Name      string `xorm:"varchar(50) notnull 'name'" json:"name"`
+	TokenHash string `xorm:"varchar(64) notnull unique 'token_hash'" json:"-"`
+	// Hint 明文末尾几位，列表里帮用户辨认是哪一个令牌
+	Hint string `xorm:"varchar(16) 'hint'" json:"hint"`
+	// Scopes "METHOD:path" 模式的 JSON 数组，为空表示继承用户的全部权限
+	Scopes     string    `xorm:"text 'scopes'" json:"-"`
+	ExpiresAt  time.Time `xorm:"datetime notnull 'expires_at'" json:"expires_at"`
+	LastUsedAt time.Time `xorm:"datetime 'last_used_at'" json:"last_used_at"`
+	CreateTime time.Time `xorm:"created" json:"create_time"`
+}
+
+// UserIdentity 外部身份（OIDC 的 iss + sub）与本地用户的绑定。同一个 IdP 账号只对应
+// 一个本地用户；一个本地用户可以绑定多个 IdP 的账号
+type UserIdentity struct {
+	ID         uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	UserID     uint64    `xorm:"bigint unsigned notnull index 'user_id'" json:"user_id"`
+	Issuer     string    `xorm:"varchar(255) notnull unique(uk_issuer_subject) 'issuer'" json:"issuer"`
+	Subject    string    `xorm:"varchar(255) notnull unique(uk_issuer_subject) 'subject'" json:"subject"`
+	CreateTime time.Time `xorm:"created" json:"create_time"`
+}
+
+// ImpersonationSession 扮演记录：谁、以什么理由、在什么时候以哪个用户的身份登录，
+// 每签发一个扮演令牌记一条。扮演期间的每个请求另由访问日志标记扮演者，两者按 FamilyID 关联
+type ImpersonationSession struct {
+	ID        uint64 `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	TenantID  uint64 `xorm:"bigint unsigned notnull default 1 index 'tenant_id'" json:"tenant_id" tenant:"id"` // 被扮演用户所在租户
+	ActorID   uint64 `xorm:"bigint unsigned notnull index 'actor_id'" json:"actor_id"`
+	ActorName string `xorm:"varchar(50) notnull 'actor_name'" json:"actor_name"`
+	UserID    uint64 `xorm:"bigint unsigned notnull index 'user_id'" json:"user_id"`
+	Username  string `xorm:"varchar(50) notnull 'username'" json:"username"`
+	Reason    string `xorm:"varchar(255) notnull 'reason'" json:"reason"`
+	// FamilyID 扮演令牌的令牌家族，结束扮演时据此撤销
+	FamilyID   string     `xorm:"varchar(64) notnull unique 'family_id'" json:"-"`
+	IP         string     `xorm:"varchar(64) 'ip'" json:"ip"`
+	UserAgent  string     `xorm:"varchar(255) 'user_agent'" json:"user_agent"`
+	ExpiresAt  time.Time  `xorm:"datetime notnull 'expires_at'" json:"expires_at"`
+	EndedAt    *time.Time `xorm:"datetime null 'ended_at'" json:"ended_at"` // 主动结束的时刻，到期自然结束的为空
+	CreateTime time.Time  `xorm:"created" json:"create_time"`
 }
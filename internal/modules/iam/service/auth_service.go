@@ -7,73 +7,258 @@ import (
 
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	usermodel "github.com/ayxworxfr/go_admin/internal/modules/user/model"
 	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
 	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
 	"github.com/pkg/errors"
 )
 
-// AuthService 认证服务：登录、刷新令牌、登出。依赖的都是模块导出的最小接口
-// （user.UserFinder/user.LoginRecorder）或同模块内的协作对象（UserRoleService），
-// JWT 管理器通过构造函数注入。
+var (
+	// ErrAccountDisabled 账号已被管理员禁用或锁定，不能登录或续期
+	ErrAccountDisabled = errors.New("account is disabled or locked")
+	// ErrRoleNotHeld 要切换到的角色不在用户当前生效的角色里
+	ErrRoleNotHeld = errors.New("role is not assigned to the user")
+
+	// errInvalidCredentials 用户名不存在与密码错误统一返回的错误
+	errInvalidCredentials = errors.New("invalid credentials")
+)
+
+// AuthService 认证服务：登录（含两步验证）、刷新令牌、登出。依赖的都是模块导出的最小接口
+// （user.UserFinder/user.LoginRecorder/user.PasswordAging）或同模块内的协作对象（UserRoleService、
+// SessionService、MFAService），JWT 管理器通过构造函数注入。
 type AuthService struct {
 	userFinder    usersvc.UserFinder
 	loginRecorder usersvc.LoginRecorder
+	passwordAging usersvc.PasswordAging
 	userRoleSvc   *UserRoleService
+	sessions      *SessionService
+	guard         *LoginGuard
+	mfa           *MFAService
 	tokenStore    tokenstore.TokenStore
 	jwt           *jwtauth.JWT
 }
 
 // NewAuthService 创建认证服务
-func NewAuthService(userFinder usersvc.UserFinder, loginRecorder usersvc.LoginRecorder, userRoleSvc *UserRoleService, tokenStore tokenstore.TokenStore, jwt *jwtauth.JWT) *AuthService {
+func NewAuthService(userFinder usersvc.UserFinder, loginRecorder usersvc.LoginRecorder, passwordAging usersvc.PasswordAging, userRoleSvc *UserRoleService, sessions *SessionService, guard *LoginGuard, mfa *MFAService, tokenStore tokenstore.TokenStore, jwt *jwtauth.JWT) *AuthService {
 	return &AuthService{
 		userFinder:    userFinder,
 		loginRecorder: loginRecorder,
+		passwordAging: passwordAging,
 		userRoleSvc:   userRoleSvc,
+		sessions:      sessions,
+		guard:         guard,
+		mfa:           mfa,
 		tokenStore:    tokenStore,
 		jwt:           jwt,
 	}
 }
 
-// Login 用户登录：校验密码 -> 取角色 -> 生成令牌
-func (s *AuthService) Login(ctx context.Context, username, password string) (*dto.TokenResponse, error) {
+// Login 用户登录第一步：检查锁定 -> 校验密码 -> 检查账号状态 -> 需要两步验证时下发挑战令牌，
+// 否则直接签发令牌。锁定中或本次失败触发锁定时返回 *AccountLockedError，其余凭证错误统一为
+// invalid credentials；账号状态放在密码校验之后，避免未持有密码的人借此探测账号是否被停用。
+func (s *AuthService) Login(ctx context.Context, username, password string, client dto.ClientInfo) (*dto.LoginResult, error) {
+	if err := s.guard.Check(ctx, username, client.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userFinder.FindByUsername(ctx, username)
 	if err != nil {
 		logger.Error(ctx, "Login failed", logger.Err(err), logger.String("username", username))
-		return nil, errors.New("invalid credentials")
+		// 不存在的用户名同样计数，否则能通过"会不会被锁"探测用户名是否存在
+		return nil, s.loginFailed(ctx, username, client.IP, errInvalidCredentials)
 	}
 
 	if !s.userFinder.VerifyPassword(user, password) {
 		logger.Warn(ctx, "Invalid password", logger.String("username", username))
-		return nil, errors.New("invalid credentials")
+		return nil, s.loginFailed(ctx, username, client.IP, errInvalidCredentials)
+	}
+	return s.authenticated(ctx, user, client)
+}
+
+// authenticated 第一因素（密码或外部身份）已通过后的共同流程：检查账号状态 ->
+// 需要两步验证时下发挑战令牌，否则直接签发令牌
+func (s *AuthService) authenticated(ctx context.Context, user *usermodel.User, client dto.ClientInfo) (*dto.LoginResult, error) {
+	if !user.CanLogin() {
+		logger.Warn(ctx, "Login rejected for inactive user", logger.String("username", user.Username), logger.Int("status", user.Status))
+		return nil, ErrAccountDisabled
+	}
+
+	enabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.mfa.Required(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled || required {
+		// 此时不清零失败计数：第二步的错码与密码错误共用同一个计数器，
+		// 否则攻击者拿到密码后可以靠反复走第一步无限次猜验证码
+		return s.mfaChallenge(ctx, user, !enabled)
+	}
+	return s.completeLogin(ctx, user, client)
+}
+
+// mfaChallenge 签发两步验证挑战令牌，enroll 表示用户需要先绑定验证器。
+// 令牌带上用户的租户，第二步据此在同一租户里继续
+func (s *AuthService) mfaChallenge(ctx context.Context, user *usermodel.User, enroll bool) (*dto.LoginResult, error) {
+	token, err := s.jwt.GenerateMFAToken(strconv.FormatUint(user.ID, 10), user.Username, s.mfa.ChallengeTTL(), jwtauth.WithTenant(user.TenantID))
+	if err != nil {
+		logger.Error(ctx, "Failed to generate mfa token", logger.Err(err), logger.Uint64("user_id", user.ID))
+		return nil, errors.Wrap(err, "failed to generate mfa token")
 	}
+	return &dto.LoginResult{
+		Status:            dto.LoginStatusMFARequired,
+		Type:              "account",
+		MFAToken:          token,
+		MFAEnrollRequired: enroll,
+	}, nil
+}
 
-	roleCode, err := s.resolveRoleCode(ctx, user.ID)
+// SetupMFA 登录第二步之前的绑定：角色要求两步验证而用户尚未绑定时，凭挑战令牌生成密钥
+func (s *AuthService) SetupMFA(ctx context.Context, mfaToken string) (*dto.MFASetupResponse, error) {
+	claims, userID, err := s.parseMFAToken(ctx, mfaToken)
 	if err != nil {
 		return nil, err
 	}
+	return s.mfa.Setup(pkgrepo.WithTenant(ctx, claims.Tenant()), userID, claims.Nice)
+}
 
-	tokenPair, err := s.jwt.GenerateToken(strconv.FormatUint(user.ID, 10), user.Username, roleCode)
+// VerifyMFA 登录第二步：校验挑战令牌与验证码后签发正式令牌。用户尚未绑定时，
+// 验证码用于确认绑定，恢复码随登录结果一并返回。错码与密码错误共用失败计数与锁定
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string, client dto.ClientInfo) (*dto.LoginResult, error) {
+	claims, userID, err := s.parseMFAToken(ctx, mfaToken)
 	if err != nil {
-		logger.Error(ctx, "Failed to generate token", logger.Err(err), logger.Uint64("user_id", user.ID))
+		return nil, err
+	}
+	ctx = pkgrepo.WithTenant(ctx, claims.Tenant())
+	username := claims.Nice
+	if err := s.guard.Check(ctx, username, client.IP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userFinder.FindByID(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user for mfa login", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to retrieve user")
+	}
+	if !user.CanLogin() {
+		return nil, ErrAccountDisabled
+	}
+
+	enabled, err := s.mfa.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var recoveryCodes []string
+	if enabled {
+		err = s.mfa.Verify(ctx, userID, code)
+	} else {
+		recoveryCodes, err = s.mfa.Enable(ctx, userID, code)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		logger.Warn(ctx, "Invalid mfa code", logger.String("username", username))
+		return nil, s.loginFailed(ctx, username, client.IP, ErrInvalidMFACode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 挑战令牌只能换一次正式令牌
+	if err := s.tokenStore.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		logger.Error(ctx, "Failed to revoke mfa token", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to revoke mfa token")
+	}
+
+	result, err := s.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	result.RecoveryCodes = recoveryCodes
+	return result, nil
+}
+
+// parseMFAToken 解析挑战令牌：类型必须是 mfa，且没有被用过
+func (s *AuthService) parseMFAToken(ctx context.Context, mfaToken string) (*jwtauth.Claims, uint64, error) {
+	claims, err := s.jwt.ParseToken(mfaToken)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "invalid mfa token")
+	}
+	if claims.Type != jwtauth.MFATokenType || claims.ExpiresAt == nil {
+		return nil, 0, errors.New("not a mfa token")
+	}
+	revoked, err := s.tokenStore.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		logger.Error(ctx, "Failed to check mfa token", logger.Err(err), logger.String("jti", claims.ID))
+		return nil, 0, errors.Wrap(err, "failed to check mfa token")
+	}
+	if revoked {
+		return nil, 0, errors.New("mfa token has been used")
+	}
+	userID, err := strconv.ParseUint(claims.Identity, 10, 64)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "invalid user ID in token")
+	}
+	return claims, userID, nil
+}
+
+// completeLogin 认证全部通过后的收尾：清零失败计数 -> 取角色 -> 生成令牌 -> 登记会话。
+// 密码已过期时照常签发令牌，只在结果里标记，由前端引导用户先改密码
+func (s *AuthService) completeLogin(ctx context.Context, user *usermodel.User, client dto.ClientInfo) (*dto.LoginResult, error) {
+	userID, username := user.ID, user.Username
+	if err := s.guard.RecordSuccess(ctx, username, client.IP); err != nil {
+		logger.Warn(ctx, "Failed to reset login attempts", logger.Err(err), logger.String("username", username))
+	}
+
+	roles, err := s.resolveRoleClaims(ctx, userID, user.TenantID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	tokenPair, err := s.jwt.GenerateToken(strconv.FormatUint(userID, 10), username, roles.key, roles.options()...)
+	if err != nil {
+		logger.Error(ctx, "Failed to generate token", logger.Err(err), logger.Uint64("user_id", userID))
 		return nil, errors.Wrap(err, "failed to generate token")
 	}
+	// 会话登记失败要让登录失败：没登记的会话无法在"退出所有设备"时被撤销
+	if err := s.sessions.Start(ctx, userID, tokenPair.FamilyID, client); err != nil {
+		return nil, err
+	}
 
 	// 回写登录时间不应该让整个登录流程失败：这是一次审计性质的旁路写入，
 	// 失败只记录日志，用户拿到的令牌依旧有效。
-	if err := s.loginRecorder.UpdateLastLoginTime(ctx, user.ID); err != nil {
-		logger.Warn(ctx, "Failed to update last login time", logger.Err(err), logger.Uint64("user_id", user.ID))
+	if err := s.loginRecorder.UpdateLastLoginTime(ctx, userID); err != nil {
+		logger.Warn(ctx, "Failed to update last login time", logger.Err(err), logger.Uint64("user_id", userID))
 	}
 
-	logger.Info(ctx, "Login successful", logger.String("username", user.Username))
-	return &dto.TokenResponse{
-		AccessToken:  tokenPair.AccessToken,
-		RefreshToken: tokenPair.RefreshToken,
-		ExpiresAt:    tokenPair.ExpiresAt,
+	logger.Info(ctx, "Login successful", logger.String("username", username))
+	return &dto.LoginResult{
+		TokenResponse: dto.TokenResponse{
+			AccessToken:  tokenPair.AccessToken,
+			RefreshToken: tokenPair.RefreshToken,
+			ExpiresAt:    tokenPair.ExpiresAt,
+		},
+		Status:             dto.LoginStatusOK,
+		Type:               "account",
+		CurrentAuthority:   roles.key,
+		MustChangePassword: s.passwordAging.PasswordExpired(ctx, user),
 	}, nil
 }
 
-// RefreshToken 使用 refresh token 换取新的令牌对。
+// loginFailed 记录失败并给出对外错误：触发锁定时返回锁定错误，否则返回 cause
+func (s *AuthService) loginFailed(ctx context.Context, username, ip string, cause error) error {
+	// 计数写入失败（已在 LoginGuard 内记录日志）不改变对外结果，仍按 cause 返回
+	var lockedErr *AccountLockedError
+	if err := s.guard.RecordFailure(ctx, username, ip); errors.As(err, &lockedErr) {
+		return err
+	}
+	return cause
+}
+
+// RefreshToken 使用 refresh token 换取新的令牌对。角色信息按当前分配重新计算，
+// 角色的增减不必等重新登录；扮演中的角色已被收回时退出扮演。
 //
 // 刻意不在这里回写 last_login_time：该字段语义是"用户上一次真正输入凭证登录
 // 的时间"，用于安全审计（如"这个账号最近有没有人登录过"），而刷新令牌是
@@ -81,7 +266,7 @@ func (s *AuthService) Login(ctx context.Context, username, password string) (*dt
 // 更新，这一列就退化成了"最后一次活跃时间"，会掩盖真实的登录行为，且给
 // 高频路径多引入一次不必要的写放大。如果需要"最后活跃时间"这类语义，
 // 应该新增单独字段，不要复用 last_login_time。
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*dto.TokenResponse, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client dto.ClientInfo) (*dto.TokenResponse, error) {
 	if refreshToken == "" {
 		return nil, errors.New("refresh token is required")
 	}
@@ -93,22 +278,37 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*d
 	if claims.Type != jwtauth.RefreshTokenType {
 		return nil, errors.New("not a refresh token")
 	}
+	ctx = pkgrepo.WithTenant(ctx, claims.Tenant())
 
 	userID, err := strconv.ParseUint(claims.Identity, 10, 64)
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid user ID in token")
 	}
+	if err := s.ensureActive(ctx, userID, claims); err != nil {
+		return nil, err
+	}
+	if err := s.ensureFamilyActive(ctx, claims); err != nil {
+		return nil, err
+	}
 
-	roleCode, err := s.resolveRoleCode(ctx, userID)
+	roles, err := s.resolveRoleClaims(ctx, userID, claims.Tenant(), claims.ActingRole)
 	if err != nil {
 		logger.Warn(ctx, "Failed to resolve role for token refresh", logger.Err(err), logger.Uint64("user_id", userID))
-		roleCode = "guest"
+		roles = roleClaims{key: "guest", tenant: claims.Tenant()}
 	}
 
-	newToken, err := s.jwt.GenerateToken(claims.Identity, claims.Nice, roleCode)
+	newToken, err := s.jwt.RotateToken(claims.FamilyID, claims.Identity, claims.Nice, roles.key, roles.options()...)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not generate new token")
 	}
+	if err := s.sessions.Touch(ctx, userID, newToken.FamilyID, client); err != nil {
+		return nil, err
+	}
+	// 新令牌对已经签发、会话也已登记才消费旧 jti：前面任何一步失败时旧 token 仍然可用，
+	// 客户端重试不会被当成重放而撤销整个家族
+	if err := s.consumeRefreshToken(ctx, userID, claims); err != nil {
+		return nil, err
+	}
 
 	return &dto.TokenResponse{
 		AccessToken:  newToken.AccessToken,
@@ -117,7 +317,141 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*d
 	}, nil
 }
 
-// Logout 撤销当前 access token，使其在过期前立即失效
+// SwitchRole 凭当前 access token 切换扮演的角色：在同一令牌家族内签发新令牌对，之后的鉴权
+// 只按该角色计算，role 为空则恢复按全部角色合并。旧 access token 随即撤销，
+// 免得客户端继续拿着它按原来的角色访问
+func (s *AuthService) SwitchRole(ctx context.Context, accessToken, role string, client dto.ClientInfo) (*dto.SwitchRoleResponse, error) {
+	claims, err := s.jwt.ParseToken(accessToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid access token")
+	}
+	if claims.Type != jwtauth.AccessTokenType || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, errors.New("access token required")
+	}
+	// 轮换出的是普通令牌对，扮演令牌拿去切换就成了不带扮演者、还能续期的被扮演用户令牌
+	if claims.Impersonating() {
+		return nil, errors.WithStack(ErrImpersonationRestricted)
+	}
+	ctx = pkgrepo.WithTenant(ctx, claims.Tenant())
+	userID, err := strconv.ParseUint(claims.Identity, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid user ID in token")
+	}
+
+	// 该接口不经过 JWT 中间件，撤销检查在这里补齐
+	revoked, err := s.tokenStore.IsRevoked(ctx, claims.ID)
+	if err == nil && !revoked {
+		revoked, err = s.tokenStore.IsFamilyRevoked(ctx, claims.FamilyID)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to check token revocation", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to check access token")
+	}
+	if revoked {
+		return nil, errors.New("access token has been revoked")
+	}
+	if err := s.ensureActive(ctx, userID, claims); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.resolveRoleClaims(ctx, userID, claims.Tenant(), role)
+	if err != nil {
+		return nil, err
+	}
+	if roles.acting != role {
+		return nil, errors.Wrapf(ErrRoleNotHeld, "role %q", role)
+	}
+
+	newToken, err := s.jwt.RotateToken(claims.FamilyID, claims.Identity, claims.Nice, roles.key, roles.options()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not generate new token")
+	}
+	if err := s.tokenStore.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		logger.Error(ctx, "Failed to revoke access token", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to revoke access token")
+	}
+	if err := s.sessions.Touch(ctx, userID, newToken.FamilyID, client); err != nil {
+		return nil, err
+	}
+
+	logger.Info(ctx, "Acting role switched", logger.Uint64("user_id", userID), logger.String("role", role))
+	return &dto.SwitchRoleResponse{
+		TokenResponse: dto.TokenResponse{
+			AccessToken:  newToken.AccessToken,
+			RefreshToken: newToken.RefreshToken,
+			ExpiresAt:    newToken.ExpiresAt,
+		},
+		CurrentAuthority: roles.key,
+		Roles:            roles.codes,
+		ActingRole:       roles.acting,
+	}, nil
+}
+
+// ensureActive 续期前确认账号仍可用：先查用户级撤销（停用/删除时写入，不用打库），
+// 再以数据库中的当前状态为准——直接改库停用的账号同样无法续期
+func (s *AuthService) ensureActive(ctx context.Context, userID uint64, claims *jwtauth.Claims) error {
+	revoked, err := s.tokenStore.IsUserRevoked(ctx, claims.Identity, claims.IssuedTime())
+	if err != nil {
+		logger.Error(ctx, "Failed to check user revocation", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to check refresh token")
+	}
+	if revoked {
+		return errors.New("refresh token has been revoked")
+	}
+
+	user, err := s.userFinder.FindByID(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user for token refresh", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to retrieve user")
+	}
+	if !user.CanLogin() {
+		return ErrAccountDisabled
+	}
+	return nil
+}
+
+// ensureFamilyActive 令牌家族已撤销（退出登录、检测到重放）时拒绝续期
+func (s *AuthService) ensureFamilyActive(ctx context.Context, claims *jwtauth.Claims) error {
+	revoked, err := s.tokenStore.IsFamilyRevoked(ctx, claims.FamilyID)
+	if err != nil {
+		logger.Error(ctx, "Failed to check token family", logger.Err(err), logger.String("family_id", claims.FamilyID))
+		return errors.Wrap(err, "failed to check refresh token")
+	}
+	if revoked {
+		return errors.New("refresh token has been revoked")
+	}
+	return nil
+}
+
+// consumeRefreshToken 让 refresh token 一次性生效：jti 首次使用则登记为已消费；若发现同一 jti
+// 被再次提交，说明 token 已泄露（合法客户端轮换后只会持有新 token），此时撤销整个家族，
+// 攻击者与受害者手里的令牌（包括这次刚签发的）一并作废，逼迫重新登录。
+func (s *AuthService) consumeRefreshToken(ctx context.Context, userID uint64, claims *jwtauth.Claims) error {
+	var exp time.Time
+	if claims.ExpiresAt != nil {
+		exp = claims.ExpiresAt.Time
+	} else {
+		exp = time.Now().Add(s.jwt.RefreshTokenExpiration())
+	}
+	first, err := s.tokenStore.ConsumeRefresh(ctx, claims.ID, exp)
+	if err != nil {
+		logger.Error(ctx, "Failed to consume refresh token", logger.Err(err), logger.String("jti", claims.ID))
+		return errors.Wrap(err, "failed to consume refresh token")
+	}
+	if first {
+		return nil
+	}
+
+	logger.Warn(ctx, "Refresh token reuse detected, revoking family",
+		logger.Uint64("user_id", userID), logger.String("family_id", claims.FamilyID), logger.String("jti", claims.ID))
+	if err := s.sessions.End(ctx, userID, claims.FamilyID); err != nil {
+		return err
+	}
+	return errors.New("refresh token reuse detected")
+}
+
+// Logout 撤销当前 access token 及其所属家族：access token 立即失效，
+// 同一次登录签发的 refresh token 也不能再用来续期
 func (s *AuthService) Logout(ctx context.Context, claims *jwtauth.Claims) error {
 	if claims == nil || claims.ID == "" {
 		return nil
@@ -128,17 +462,47 @@ func (s *AuthService) Logout(ctx context.Context, claims *jwtauth.Claims) error
 	} else {
 		exp = time.Now().Add(24 * time.Hour)
 	}
-	return s.tokenStore.Revoke(ctx, claims.ID, exp)
+	if err := s.tokenStore.Revoke(ctx, claims.ID, exp); err != nil {
+		return err
+	}
+	userID, err := strconv.ParseUint(claims.Identity, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "invalid user ID in token")
+	}
+	return s.sessions.End(ctx, userID, claims.FamilyID)
+}
+
+// roleClaims 写进令牌的角色信息
+type roleClaims struct {
+	key    string   // RoleKey：扮演中的角色，否则为优先级最高的角色，无角色时为 guest
+	codes  []string // 用户当前生效的全部角色
+	acting string   // 扮演中的角色
+	tenant uint64   // 用户所在租户
 }
 
-// resolveRoleCode 取用户优先级最高角色的 code，无角色时退化为 guest
-func (s *AuthService) resolveRoleCode(ctx context.Context, userID uint64) (string, error) {
-	roles, err := s.userRoleSvc.RetrieveRoleResponsesByUserID(ctx, userID, 0)
+func (r roleClaims) options() []jwtauth.TokenOption {
+	return []jwtauth.TokenOption{jwtauth.WithRoles(r.codes), jwtauth.WithActingRole(r.acting), jwtauth.WithTenant(r.tenant)}
+}
+
+// resolveRoleClaims 按用户当前的角色分配计算令牌的角色信息。acting 是希望扮演的角色，
+// 用户不再持有它时不扮演（结果里 acting 为空），由调用方决定是报错还是接受
+func (s *AuthService) resolveRoleClaims(ctx context.Context, userID, tenantID uint64, acting string) (roleClaims, error) {
+	roles, err := s.userRoleSvc.RetrieveRoleResponsesByUserID(pkgrepo.WithTenant(ctx, tenantID), userID, 0)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to retrieve user roles")
+		return roleClaims{}, errors.Wrap(err, "failed to retrieve user roles")
+	}
+
+	result := roleClaims{key: "guest", codes: make([]string, 0, len(roles)), tenant: tenantID}
+	for _, role := range roles {
+		result.codes = append(result.codes, role.Code)
+		if acting != "" && role.Code == acting {
+			result.acting = acting
+		}
 	}
-	if role := fetchHighestPriorityRole(roles); role != nil {
-		return role.Code, nil
+	if result.acting != "" {
+		result.key = result.acting
+	} else if role := fetchHighestPriorityRole(roles); role != nil {
+		result.key = role.Code
 	}
-	return "guest", nil
+	return result, nil
 }
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/session"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	usermodel "github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakySessionStore fail 为 true 时写入失败，模拟会话存储暂时不可用
+type flakySessionStore struct {
+	*session.InMemoryStore
+	fail bool
+}
+
+func (s *flakySessionStore) Save(ctx context.Context, sess *session.Session) error {
+	if s.fail {
+		return errors.New("session store unavailable")
+	}
+	return s.InMemoryStore.Save(ctx, sess)
+}
+
+// TestRefreshToken_RetryAfterFailure 续期中途失败时旧 refresh token 没有被消费，重试照常成功；
+// 成功之后再提交同一个 token 才算重放，整个家族随之撤销
+func TestRefreshToken_RetryAfterFailure(t *testing.T) {
+	db := repotest.NewDB(t, new(usermodel.User), new(usermodel.PasswordHistory), new(model.Role), new(model.RoleParent), new(model.UserRole))
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	require.NoError(t, pkgrepo.NewRepository[usermodel.User](db).Create(ctx, &usermodel.User{ID: 1, Username: "alice", Email: "alice@example.com", Status: 1}))
+
+	jwt, err := jwtauth.NewJWT("test-secret-key", "15m", "30d")
+	require.NoError(t, err)
+	tokens := tokenstore.NewInMemoryTokenStore()
+	store := &flakySessionStore{InMemoryStore: session.NewInMemoryStore()}
+	roleSvc := NewRoleService(db)
+	svc := NewAuthService(usersvc.NewService(db, nil, nil, nil, nil), nil, nil, NewUserRoleService(db, roleSvc, nil),
+		NewSessionService(store, tokens, jwt), nil, nil, tokens, jwt)
+
+	pair, err := jwt.GenerateToken("1", "alice", "guest")
+	require.NoError(t, err)
+
+	store.fail = true
+	_, err = svc.RefreshToken(ctx, pair.RefreshToken, dto.ClientInfo{})
+	require.Error(t, err)
+
+	store.fail = false
+	_, err = svc.RefreshToken(ctx, pair.RefreshToken, dto.ClientInfo{})
+	require.NoError(t, err)
+
+	_, err = svc.RefreshToken(ctx, pair.RefreshToken, dto.ClientInfo{})
+	require.Error(t, err)
+	revoked, err := tokens.IsFamilyRevoked(ctx, pair.FamilyID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveDataScope_ActingRole(t *testing.T) {
+	db := repotest.NewDB(t, new(model.Role), new(model.UserRole), new(model.RoleDept))
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	roleSvc := NewRoleService(db)
+	svc := NewDataScopeService(NewUserRoleService(db, roleSvc, nil), roleSvc, nil, nil)
+
+	roles := pkgrepo.NewRepository[model.Role](db)
+	userRoles := pkgrepo.NewRepository[model.UserRole](db)
+	admin := &model.Role{Name: "admin", Code: "ADMIN", Status: 1, DataScope: model.DataScopeAll}
+	staff := &model.Role{Name: "staff", Code: "STAFF", Status: 1, DataScope: model.DataScopeSelf}
+	auditor := &model.Role{Name: "auditor", Code: "AUDITOR", Status: 1, DataScope: model.DataScopeAll}
+	for _, r := range []*model.Role{admin, staff, auditor} {
+		require.NoError(t, roles.Create(ctx, r))
+	}
+	const userID = 7
+	for _, r := range []*model.Role{admin, staff} {
+		require.NoError(t, userRoles.Create(ctx, &model.UserRole{UserID: userID, RoleID: r.ID}))
+	}
+
+	// 不切换角色时取全部角色的并集
+	scope, err := svc.ResolveDataScope(ctx, userID, "")
+	require.NoError(t, err)
+	assert.True(t, scope.All)
+
+	// 切换到 STAFF 后 ADMIN 的全部数据不再生效
+	scope, err = svc.ResolveDataScope(ctx, userID, "STAFF")
+	require.NoError(t, err)
+	assert.False(t, scope.All)
+	assert.Equal(t, []uint64{userID}, scope.UserIDs)
+	assert.Empty(t, scope.DeptIDs)
+
+	// 令牌里的角色已不再持有：什么都看不到
+	scope, err = svc.ResolveDataScope(ctx, userID, "AUDITOR")
+	require.NoError(t, err)
+	assert.Equal(t, &pkgrepo.DataScope{}, scope)
+}
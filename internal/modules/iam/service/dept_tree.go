@@ -0,0 +1,12 @@
+package service
+
+import "context"
+
+// DeptTree 是数据范围"本部门及下级部门"需要的部门树查询（消费方视角），
+// 由 org 模块的 Service 实现。未装配时按"仅本部门"处理，不会因此放宽可见范围。
+//
+// 依赖方向：iam -> DeptTree，iam 不关心部门数据存在哪里。
+type DeptTree interface {
+	// DescendantIDs 返回 deptID 及其全部下级部门的 ID
+	DescendantIDs(ctx context.Context, deptID uint64) ([]uint64, error)
+}
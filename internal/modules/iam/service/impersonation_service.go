@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/jinzhu/copier"
+	"github.com/pkg/errors"
+)
+
+// impersonatePath 发起扮演的接口，与 ImpersonationHandler.StartImpersonation 的路由一致。
+// 持有该接口权限的用户不能被扮演，见 ImpersonationService.Start
+const impersonatePath = "/api/protected/impersonation"
+
+// 扮演的业务错误
+var (
+	ErrImpersonationRestricted    = errors.New("operation is not allowed with an impersonation token")
+	ErrInvalidImpersonationTarget = errors.New("user cannot be impersonated")
+	ErrNotImpersonating           = errors.New("current token is not an impersonation token")
+)
+
+// ImpersonationOptions 扮演配置
+type ImpersonationOptions struct {
+	// TTL 扮演令牌有效期
+	TTL time.Duration
+	// Routes 受保护接口的路由表。发起扮演时逐个接口比较双方的权限，见 ImpersonationService.Start
+	Routes []dto.CatalogRoute
+}
+
+// ImpersonationService 支持人员以其他用户的身份登录排查问题。扮演令牌只有 access token、
+// 不能续期，载荷里同时带着被扮演用户与扮演者（jwtauth.Claims.Actor），
+// 鉴权、数据范围都按被扮演用户计算；每次扮演记一条 ImpersonationSession 备查。
+type ImpersonationService struct {
+	repo       *pkgrepo.Repository[model.ImpersonationSession]
+	userFinder usersvc.UserFinder
+	auth       *AuthService
+	checker    *PermissionChecker
+	tokenStore tokenstore.TokenStore
+	jwt        *jwtauth.JWT
+	opts       ImpersonationOptions
+}
+
+// NewImpersonationService 创建扮演服务
+func NewImpersonationService(db *pkgrepo.DB, userFinder usersvc.UserFinder, auth *AuthService, checker *PermissionChecker,
+	tokenStore tokenstore.TokenStore, jwt *jwtauth.JWT, opts ImpersonationOptions,
+) *ImpersonationService {
+	return &ImpersonationService{
+		repo:       pkgrepo.NewRepository[model.ImpersonationSession](db),
+		userFinder: userFinder,
+		auth:       auth,
+		checker:    checker,
+		tokenStore: tokenStore,
+		jwt:        jwt,
+		opts:       opts,
+	}
+}
+
+// Start 以 req.UserID 的身份签发扮演令牌。被扮演用户须在当前租户内、账号可用，且不能是扮演者
+// 自己；本身就能发起扮演的用户也不能被扮演，否则扮演者可以借他的身份再扮演别人，绕开审计。
+// 被扮演用户的权限也不能超出扮演者：扮演令牌带着对方的全部权限，有一个接口对方能访问而扮演者不能，
+// 扮演者就能借它改掉别人的密码、给自己分配角色，在扮演结束后留下越权
+func (s *ImpersonationService) Start(ctx context.Context, actor *jwtauth.Claims, req *dto.StartImpersonationRequest, client dto.ClientInfo) (*dto.ImpersonationResponse, error) {
+	if actor.Impersonating() {
+		return nil, errors.WithStack(ErrImpersonationRestricted)
+	}
+	actorID, err := strconv.ParseUint(actor.Identity, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid user ID in token")
+	}
+	if actorID == req.UserID {
+		return nil, errors.Wrap(ErrInvalidImpersonationTarget, "cannot impersonate yourself")
+	}
+
+	target, err := s.userFinder.FindByID(ctx, req.UserID)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, errors.Wrapf(ErrInvalidImpersonationTarget, "user %d not found", req.UserID)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve impersonation target", logger.Err(err), logger.Uint64("user_id", req.UserID))
+		return nil, errors.Wrap(err, "failed to retrieve user")
+	}
+	if !target.CanLogin() {
+		return nil, errors.Wrapf(ErrInvalidImpersonationTarget, "user %d is disabled", req.UserID)
+	}
+
+	targetCtx := pkgrepo.WithTenant(ctx, target.TenantID)
+	privileged, err := s.checker.HasPermission(targetCtx, target.ID, "", http.MethodPost, impersonatePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check target permissions")
+	}
+	if privileged {
+		return nil, errors.Wrapf(ErrInvalidImpersonationTarget, "user %d can impersonate others", req.UserID)
+	}
+	exceeding, err := s.exceedingRoute(ctx, actor, actorID, target.ID, target.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if exceeding != "" {
+		return nil, errors.Wrapf(ErrInvalidImpersonationTarget, "user %d can access %s which the actor cannot", req.UserID, exceeding)
+	}
+
+	roles, err := s.auth.resolveRoleClaims(targetCtx, target.ID, target.TenantID, "")
+	if err != nil {
+		return nil, err
+	}
+	opts := append(roles.options(), jwtauth.WithActor(actor.Identity, actor.Nice))
+	token, err := s.jwt.GenerateAccessToken(strconv.FormatUint(target.ID, 10), target.Username, roles.key, s.opts.TTL, opts...)
+	if err != nil {
+		logger.Error(ctx, "Failed to generate impersonation token", logger.Err(err), logger.Uint64("user_id", target.ID))
+		return nil, errors.Wrap(err, "failed to generate token")
+	}
+
+	record := &model.ImpersonationSession{
+		ActorID:   actorID,
+		ActorName: actor.Nice,
+		UserID:    target.ID,
+		Username:  target.Username,
+		Reason:    req.Reason,
+		FamilyID:  token.FamilyID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: time.Unix(token.ExpiresAt, 0),
+	}
+	// 记录落不了库就不发令牌：没有审计记录的扮演不允许发生
+	if err := s.repo.Create(targetCtx, record); err != nil {
+		logger.Error(ctx, "Failed to record impersonation", logger.Err(err), logger.Uint64("actor_id", actorID), logger.Uint64("user_id", target.ID))
+		return nil, errors.Wrap(err, "failed to record impersonation")
+	}
+
+	logger.Info(ctx, "Impersonation started", logger.Uint64("actor_id", actorID), logger.Uint64("user_id", target.ID),
+		logger.String("reason", req.Reason), logger.Uint64("session_id", record.ID))
+	return &dto.ImpersonationResponse{
+		AccessToken: token.AccessToken,
+		ExpiresAt:   token.ExpiresAt,
+		UserID:      target.ID,
+		Username:    target.Username,
+	}, nil
+}
+
+// exceedingRoute 在路由表里找被扮演用户能访问、扮演者却不能访问的第一个接口，返回 "METHOD:path"，
+// 没有时返回空串。按实际存在的接口逐个比较，不去推算通配规则之间的包含关系；
+// 扮演者按令牌里的租户与切换到的角色计算，被扮演用户按签发扮演令牌时的全部角色计算
+func (s *ImpersonationService) exceedingRoute(ctx context.Context, actor *jwtauth.Claims, actorID, targetID, targetTenant uint64) (string, error) {
+	actorCtx := pkgrepo.WithTenant(ctx, actor.Tenant())
+	targetCtx := pkgrepo.WithTenant(ctx, targetTenant)
+	for _, route := range s.opts.Routes {
+		allowed, err := s.checker.HasPermission(targetCtx, targetID, "", route.Method, route.Path)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to check target permissions")
+		}
+		if !allowed {
+			continue
+		}
+		allowed, err = s.checker.HasPermission(actorCtx, actorID, actor.ActingRole, route.Method, route.Path)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to check actor permissions")
+		}
+		if !allowed {
+			return route.Method + ":" + route.Path, nil
+		}
+	}
+	return "", nil
+}
+
+// Stop 结束扮演：撤销扮演令牌所在的家族并在记录上标注结束时刻。必须拿扮演令牌本身调用
+func (s *ImpersonationService) Stop(ctx context.Context, claims *jwtauth.Claims) error {
+	if !claims.Impersonating() || claims.FamilyID == "" || claims.ExpiresAt == nil {
+		return errors.WithStack(ErrNotImpersonating)
+	}
+	if err := s.tokenStore.RevokeFamily(ctx, claims.FamilyID, claims.ExpiresAt.Time); err != nil {
+		logger.Error(ctx, "Failed to revoke impersonation token", logger.Err(err), logger.String("family_id", claims.FamilyID))
+		return errors.Wrap(err, "failed to revoke impersonation token")
+	}
+
+	// 令牌已经作废，记录没能更新只影响审计里的结束时间，不让接口失败
+	ctx = pkgrepo.WithTenant(ctx, claims.Tenant())
+	record, err := s.repo.Find(ctx, &model.ImpersonationSession{FamilyID: claims.FamilyID})
+	if err == nil {
+		now := time.Now()
+		record.EndedAt = &now
+		err = s.repo.Update(ctx, record)
+	}
+	if err != nil {
+		logger.Warn(ctx, "Failed to mark impersonation ended", logger.Err(err), logger.String("family_id", claims.FamilyID))
+	}
+
+	logger.Info(ctx, "Impersonation stopped", logger.String("actor_id", claims.Actor.Identity), logger.String("user_id", claims.Identity))
+	return nil
+}
+
+// List 分页查询扮演记录，最近的在前
+func (s *ImpersonationService) List(ctx context.Context, req *dto.GetImpersonationListRequest) ([]*dto.ImpersonationSessionResponse, int64, error) {
+	query := func() *pkgrepo.QueryBuilder[model.ImpersonationSession] {
+		q := s.repo.QueryBuilder()
+		if req.ActorID != 0 {
+			q = q.Eq("actor_id", req.ActorID)
+		}
+		if req.UserID != 0 {
+			q = q.Eq("user_id", req.UserID)
+		}
+		return q
+	}
+	records, err := query().OrderBy("id DESC").Limit(req.Limit).Offset(req.Offset).Find(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve impersonation sessions", logger.Err(err))
+		return nil, 0, errors.Wrap(err, "failed to retrieve impersonation sessions")
+	}
+	total, err := query().Count(ctx)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to count impersonation sessions")
+	}
+
+	result := make([]*dto.ImpersonationSessionResponse, 0, len(records))
+	if err := copier.Copy(&result, &records); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to convert impersonation sessions")
+	}
+	return result, total, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/cache"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExceedingRoute 被扮演用户能访问而扮演者不能访问的接口，哪怕只有一个也要找出来
+func TestExceedingRoute(t *testing.T) {
+	db := repotest.NewDB(t, new(model.Role), new(model.RoleParent), new(model.UserRole),
+		new(model.Permission), new(model.RolePermission))
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	roleSvc := NewRoleService(db)
+	checker := NewPermissionChecker(NewUserRoleService(db, roleSvc, nil), roleSvc, cache.NewInMemoryCache(time.Minute))
+	svc := NewImpersonationService(db, nil, nil, checker, nil, nil, ImpersonationOptions{Routes: []dto.CatalogRoute{
+		{Method: http.MethodGet, Path: "/api/protected/user/list"},
+		{Method: http.MethodPut, Path: "/api/protected/user"},
+	}})
+
+	// support 只能查用户，manager 还能改用户
+	grants := map[string][]string{
+		"SUPPORT": {"GET:/api/protected/user/*"},
+		"MANAGER": {"GET:/api/protected/user/*", "PUT:/api/protected/user"},
+	}
+	users := map[string]uint64{"SUPPORT": 1, "MANAGER": 2}
+	for code, rules := range grants {
+		role := &model.Role{Name: code, Code: code, Status: 1}
+		require.NoError(t, pkgrepo.NewRepository[model.Role](db).Create(ctx, role))
+		for _, rule := range rules {
+			method, path, _ := strings.Cut(rule, ":")
+			perm := &model.Permission{Name: code + rule, Code: code + rule, Type: 3, Method: method, Path: path,
+				Effect: model.PermissionEffectAllow, Status: 1}
+			require.NoError(t, pkgrepo.NewRepository[model.Permission](db).Create(ctx, perm))
+			require.NoError(t, pkgrepo.NewRepository[model.RolePermission](db).Create(ctx, &model.RolePermission{RoleID: role.ID, PermissionID: perm.ID}))
+		}
+		require.NoError(t, pkgrepo.NewRepository[model.UserRole](db).Create(ctx, &model.UserRole{UserID: users[code], RoleID: role.ID}))
+	}
+	claims := func(userID string) *jwtauth.Claims { return &jwtauth.Claims{Identity: userID} }
+
+	route, err := svc.exceedingRoute(ctx, claims("1"), users["SUPPORT"], users["MANAGER"], 1)
+	require.NoError(t, err)
+	assert.Equal(t, "PUT:/api/protected/user", route)
+
+	route, err = svc.exceedingRoute(ctx, claims("2"), users["MANAGER"], users["SUPPORT"], 1)
+	require.NoError(t, err)
+	assert.Empty(t, route)
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/ayxworxfr/go_admin/pkg/crypter"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/totp"
+	"github.com/pkg/errors"
+)
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10 // 不含连字符
+)
+
+// 两步验证的业务错误，handler 据此区分参数错误与鉴权失败
+var (
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFASetupRequired  = errors.New("two-factor authentication setup has not been started")
+	ErrMFARequired       = errors.New("two-factor authentication is required by your role")
+	ErrInvalidMFACode    = errors.New("invalid verification code")
+)
+
+// MFAOptions 两步验证参数，由组合根从配置映射而来
+type MFAOptions struct {
+	Issuer string
+	// SecretKey 加密 TOTP 密钥的口令，经 SHA-256 派生为 AES-256 密钥
+	SecretKey    string
+	ChallengeTTL time.Duration
+	Skew         int
+}
+
+// MFAService 两步验证（TOTP + 恢复码）：绑定、校验、停用，以及按角色判断是否强制开启。
+// 登录流程本身在 AuthService，这里只回答"这个用户要不要第二步、验证码对不对"。
+type MFAService struct {
+	repo        *pkgrepo.Repository[model.UserMFA]
+	userRoleSvc *UserRoleService
+	hasher      crypter.PasswordHasher
+	cipher      *crypter.AESCrypter
+	opts        MFAOptions
+}
+
+// NewMFAService 创建两步验证服务。恢复码与密码一样只存哈希，复用同一个 PasswordHasher
+func NewMFAService(db *pkgrepo.DB, userRoleSvc *UserRoleService, hasher crypter.PasswordHasher, opts MFAOptions) *MFAService {
+	key := sha256.Sum256([]byte(opts.SecretKey))
+	return &MFAService{
+		repo:        pkgrepo.NewRepository[model.UserMFA](db),
+		userRoleSvc: userRoleSvc,
+		hasher:      hasher,
+		cipher:      crypter.NewAESCrypter(key[:]),
+		opts:        opts,
+	}
+}
+
+// ChallengeTTL 登录挑战令牌的有效期
+func (s *MFAService) ChallengeTTL() time.Duration {
+	return s.opts.ChallengeTTL
+}
+
+// Required 用户是否持有要求两步验证的角色
+func (s *MFAService) Required(ctx context.Context, userID uint64) (bool, error) {
+	roles, err := s.userRoleSvc.RetrieveRolesByUserID(ctx, userID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to retrieve user roles")
+	}
+	for _, role := range roles {
+		if role.RequireMFA {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Enabled 用户是否已完成绑定
+func (s *MFAService) Enabled(ctx context.Context, userID uint64) (bool, error) {
+	rec, err := s.find(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return rec != nil && rec.Enabled, nil
+}
+
+// Status 查询用户的两步验证状态
+func (s *MFAService) Status(ctx context.Context, userID uint64) (*dto.MFAStatusResponse, error) {
+	rec, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	required, err := s.Required(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.MFAStatusResponse{Required: required}
+	if rec != nil && rec.Enabled {
+		hashes, err := decodeRecoveryCodes(rec.RecoveryCodes)
+		if err != nil {
+			return nil, err
+		}
+		resp.Enabled = true
+		resp.RecoveryCodesLeft = len(hashes)
+	}
+	return resp, nil
+}
+
+// Setup 生成新的 TOTP 密钥并保存为待绑定状态，account 为显示在验证器 App 里的账号名。
+// 已启用时拒绝，避免误操作覆盖正在使用的密钥；待绑定状态下重复调用会换一个新密钥
+func (s *MFAService) Setup(ctx context.Context, userID uint64, account string) (*dto.MFASetupResponse, error) {
+	rec, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rec != nil && rec.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if rec == nil {
+		err = s.repo.Create(ctx, &model.UserMFA{UserID: userID, Secret: encrypted})
+	} else {
+		rec.Secret = encrypted
+		err = s.repo.Update(ctx, rec)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to save mfa secret", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to save mfa secret")
+	}
+
+	return &dto.MFASetupResponse{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(s.opts.Issuer, account, secret),
+	}, nil
+}
+
+// Enable 用待绑定密钥生成的口令确认绑定，返回恢复码明文（只此一次）
+func (s *MFAService) Enable(ctx context.Context, userID uint64, code string) ([]string, error) {
+	var codes []string
+	err := s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		rec, err := s.lock(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			return ErrMFASetupRequired
+		}
+		if rec.Enabled {
+			return ErrMFAAlreadyEnabled
+		}
+		step, ok, err := s.validateTOTP(rec, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		var hashes string
+		codes, hashes, err = s.newRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		rec.Enabled = true
+		rec.RecoveryCodes = hashes
+		rec.LastUsedStep = step
+		return s.save(txCtx, rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify 校验 TOTP 口令或恢复码；恢复码用过即作废
+func (s *MFAService) Verify(ctx context.Context, userID uint64, code string) error {
+	return s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		rec, err := s.lock(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if rec == nil || !rec.Enabled {
+			return ErrMFANotEnabled
+		}
+		return s.verifyLocked(txCtx, rec, code)
+	})
+}
+
+// Disable 校验验证码后解除绑定。所属角色要求两步验证时拒绝，否则用户下次登录仍会被要求重新绑定
+func (s *MFAService) Disable(ctx context.Context, userID uint64, code string) error {
+	required, err := s.Required(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+
+	return s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		rec, err := s.lock(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if rec == nil || !rec.Enabled {
+			return ErrMFANotEnabled
+		}
+		if err := s.verifyLocked(txCtx, rec, code); err != nil {
+			return err
+		}
+		if err := s.repo.DeleteByID(txCtx, userID); err != nil {
+			logger.Error(txCtx, "Failed to delete mfa", logger.Err(err), logger.Uint64("user_id", userID))
+			return errors.Wrap(err, "failed to delete mfa")
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成一组恢复码，旧的全部作废
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error) {
+	var codes []string
+	err := s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		rec, err := s.lock(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if rec == nil || !rec.Enabled {
+			return ErrMFANotEnabled
+		}
+		if err := s.verifyLocked(txCtx, rec, code); err != nil {
+			return err
+		}
+
+		var hashes string
+		codes, hashes, err = s.newRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		rec.RecoveryCodes = hashes
+		return s.save(txCtx, rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifyLocked 在已加行锁的记录上校验验证码：优先按 TOTP 校验并推进 LastUsedStep，
+// 不匹配再逐个比对恢复码
+func (s *MFAService) verifyLocked(ctx context.Context, rec *model.UserMFA, code string) error {
+	step, ok, err := s.validateTOTP(rec, code)
+	if err != nil {
+		return err
+	}
+	if ok {
+		rec.LastUsedStep = step
+		return s.save(ctx, rec)
+	}
+
+	hashes, err := decodeRecoveryCodes(rec.RecoveryCodes)
+	if err != nil {
+		return err
+	}
+	// 长度不对的输入直接判错：每比对一个恢复码都是一次 Argon2 运算，不为明显的错码付这个代价
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return ErrInvalidMFACode
+	}
+	for i, hashed := range hashes {
+		if !s.hasher.Verify(normalized, hashed) {
+			continue
+		}
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		encoded, err := json.Marshal(remaining)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode recovery codes")
+		}
+		rec.RecoveryCodes = string(encoded)
+		logger.Info(ctx, "Recovery code used", logger.Uint64("user_id", rec.UserID), logger.Int("remaining", len(remaining)))
+		return s.save(ctx, rec)
+	}
+	return ErrInvalidMFACode
+}
+
+// validateTOTP 校验口令并拒绝已用过的时间步
+func (s *MFAService) validateTOTP(rec *model.UserMFA, code string) (int64, bool, error) {
+	secret, err := s.decryptSecret(rec.Secret)
+	if err != nil {
+		return 0, false, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), s.opts.Skew)
+	if !ok || step <= rec.LastUsedStep {
+		return 0, false, nil
+	}
+	return step, true, nil
+}
+
+func (s *MFAService) find(ctx context.Context, userID uint64) (*model.UserMFA, error) {
+	rec, err := s.repo.FindByID(ctx, userID)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve mfa", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to retrieve mfa")
+	}
+	return rec, nil
+}
+
+// lock 在事务内加行锁读取，串行化同一用户的并发校验，保证恢复码和时间步都只能用一次
+func (s *MFAService) lock(ctx context.Context, userID uint64) (*model.UserMFA, error) {
+	rec, err := s.repo.QueryBuilder().Eq("user_id", userID).ForUpdate().First(ctx)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve mfa", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to retrieve mfa")
+	}
+	return rec, nil
+}
+
+func (s *MFAService) save(ctx context.Context, rec *model.UserMFA) error {
+	if err := s.repo.Update(ctx, rec, "enabled", "recovery_codes"); err != nil {
+		logger.Error(ctx, "Failed to update mfa", logger.Err(err), logger.Uint64("user_id", rec.UserID))
+		return errors.Wrap(err, "failed to update mfa")
+	}
+	return nil
+}
+
+func (s *MFAService) encryptSecret(secret string) (string, error) {
+	ciphertext, err := s.cipher.Encrypt([]byte(secret))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encrypt mfa secret")
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (s *MFAService) decryptSecret(encrypted string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode mfa secret")
+	}
+	plain, err := s.cipher.Decrypt(ciphertext)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt mfa secret")
+	}
+	return string(plain), nil
+}
+
+// newRecoveryCodes 生成一组恢复码，返回明文（展示给用户）与哈希数组的 JSON（落库）
+func (s *MFAService) newRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, "", err
+		}
+		hashed, err := s.hasher.Hash(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to hash recovery code")
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashed)
+	}
+	encoded, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to encode recovery codes")
+	}
+	return codes, string(encoded), nil
+}
+
+func decodeRecoveryCodes(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var hashes []string
+	if err := json.Unmarshal([]byte(raw), &hashes); err != nil {
+		return nil, errors.Wrap(err, "failed to decode recovery codes")
+	}
+	return hashes, nil
+}
+
+// randomRecoveryCode 生成形如 abcde-fghij 的恢复码（50 bit 熵）
+func randomRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "failed to generate recovery code")
+	}
+	s := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:recoveryCodeLength]
+	return s[:recoveryCodeLength/2] + "-" + s[recoveryCodeLength/2:], nil
+}
+
+// normalizeRecoveryCode 忽略大小写、连字符与空白，用户照抄时不必在意格式
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	userdto "github.com/ayxworxfr/go_admin/internal/modules/user/dto"
+	usermodel "github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/crypter"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/oidc"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+const (
+	// oidcRandomBytes state、nonce 与 PKCE code_verifier 的熵
+	oidcRandomBytes = 32
+	// oidcStateKeyPrefix 已用过的 state 记在令牌存储里，与 refresh jti 区分开
+	oidcStateKeyPrefix = "oidc-state:"
+	// maxUsernameLength 与 user.CreateUserRequest 的用户名长度校验一致
+	maxUsernameLength = 49
+)
+
+// OIDC 登录的业务错误
+var (
+	ErrInvalidOIDCState = errors.New("invalid or expired oidc state")
+	// ErrOIDCUserNotFound 外部身份没有绑定本地用户，且未开启自动开户
+	ErrOIDCUserNotFound = errors.New("no local user for this identity")
+	// ErrOIDCUsernameTaken 自动开户时用户名已被本地账号占用，且未开启按用户名关联
+	ErrOIDCUsernameTaken = errors.New("username from identity provider is already taken")
+)
+
+// OIDCRoleMapping 一条角色映射规则：id_token 的 Claim（字符串或字符串数组）包含 Value 时授予 Role（角色 code）
+type OIDCRoleMapping struct {
+	Claim string
+	Value string
+	Role  string
+}
+
+// OIDCOptions OIDC 登录配置，由 bootstrap 从 config 转换而来
+type OIDCOptions struct {
+	Provider oidc.Config
+	// UsernameClaim / EmailClaim 自动开户时取用户名、邮箱的声明
+	UsernameClaim string
+	EmailClaim    string
+	// AutoProvision 外部身份首次登录且没有对应本地用户时自动开户
+	AutoProvision bool
+	// LinkExistingUsers 首次登录时按用户名关联已存在的本地用户。只应在用户名声明
+	// 由 IdP 管理员维护、用户自己改不了时开启，否则任何人都能冒充同名本地账号
+	LinkExistingUsers bool
+	// RoleMappings 按声明授予角色；一条都没命中时授予 DefaultRoles
+	RoleMappings []OIDCRoleMapping
+	DefaultRoles []string
+	// SyncRoles 每次登录都以映射结果覆盖用户的角色，IdP 成为角色的唯一来源；
+	// 关闭时只在开户时授予一次，之后由管理员在本系统维护
+	SyncRoles bool
+	// StateKey 加密授权上下文 Cookie 的口令，多实例部署时必须一致
+	StateKey string
+	StateTTL time.Duration
+}
+
+// oidcState 授权请求的上下文。加密后放进发起登录的浏览器的 HttpOnly Cookie，
+// 交给 IdP 的 state 参数只是其中的随机 ID：截获回调地址的人拿不到 code_verifier，
+// 也没法把自己的 code 塞给别人的浏览器去完成登录
+type oidcState struct {
+	ID        string `json:"s"`
+	Verifier  string `json:"v"`
+	Nonce     string `json:"n"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// OIDCService OpenID Connect 登录：生成授权地址，回调时校验 id_token、找到（或开户）
+// 本地用户并按规则同步角色，之后与密码登录走同一套两步验证与令牌签发流程。
+type OIDCService struct {
+	provider     *oidc.Provider
+	identityRepo *pkgrepo.Repository[model.UserIdentity]
+	roleRepo     *pkgrepo.Repository[model.Role]
+	userFinder   usersvc.UserFinder
+	userCreator  usersvc.UserCreator
+	userRoleSvc  *UserRoleService
+	checker      *PermissionChecker
+	auth         *AuthService
+	tokenStore   tokenstore.TokenStore
+	cipher       *crypter.AESCrypter
+	opts         OIDCOptions
+}
+
+// NewOIDCService 创建 OIDC 登录服务。IdP 的发现配置在第一次登录时才拉取；
+// tokenStore 记录已用过的 state，多实例部署时应与撤销名单一样选 redis
+func NewOIDCService(db *pkgrepo.DB, userFinder usersvc.UserFinder, userCreator usersvc.UserCreator, userRoleSvc *UserRoleService, checker *PermissionChecker, auth *AuthService, tokenStore tokenstore.TokenStore, opts OIDCOptions) *OIDCService {
+	key := sha256.Sum256([]byte(opts.StateKey))
+	return &OIDCService{
+		provider:     oidc.NewProvider(opts.Provider, nil),
+		identityRepo: pkgrepo.NewRepository[model.UserIdentity](db),
+		roleRepo:     newRepositories(db).role,
+		userFinder:   userFinder,
+		userCreator:  userCreator,
+		userRoleSvc:  userRoleSvc,
+		checker:      checker,
+		auth:         auth,
+		tokenStore:   tokenStore,
+		cipher:       crypter.NewAESCrypter(key[:]),
+		opts:         opts,
+	}
+}
+
+// AuthorizeURL 生成一次授权请求。返回的 binding 是加密的授权上下文，由 handler 写进
+// HttpOnly Cookie，回调时原样交回 Callback
+func (s *OIDCService) AuthorizeURL(ctx context.Context) (result *dto.OIDCAuthorizeResponse, binding string, err error) {
+	var state oidcState
+	for _, v := range []*string{&state.ID, &state.Verifier, &state.Nonce} {
+		if *v, err = oidc.RandomString(oidcRandomBytes); err != nil {
+			return nil, "", err
+		}
+	}
+	state.ExpiresAt = time.Now().Add(s.opts.StateTTL).Unix()
+	binding, err = s.sealState(state)
+	if err != nil {
+		return nil, "", err
+	}
+
+	url, err := s.provider.AuthCodeURL(ctx, state.ID, state.Nonce, oidc.S256Challenge(state.Verifier))
+	if err != nil {
+		logger.Error(ctx, "Failed to build oidc authorize url", logger.Err(err))
+		return nil, "", errors.Wrap(err, "failed to build authorize url")
+	}
+	return &dto.OIDCAuthorizeResponse{URL: url, State: state.ID}, binding, nil
+}
+
+// StateTTL 授权上下文的有效期，handler 据此设置 Cookie 的存活时间
+func (s *OIDCService) StateTTL() time.Duration {
+	return s.opts.StateTTL
+}
+
+// Callback 授权码回调：核对 state 与浏览器 Cookie 里的授权上下文 -> 换取并校验 id_token ->
+// 找到或开户本地用户 -> 同步角色 -> 登录。返回值与密码登录一致，需要两步验证的用户同样拿到挑战令牌
+func (s *OIDCService) Callback(ctx context.Context, code, rawState, binding string, client dto.ClientInfo) (*dto.LoginResult, error) {
+	state, err := s.consumeState(ctx, rawState, binding)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := s.provider.Exchange(ctx, code, state.Verifier)
+	if err != nil {
+		logger.Warn(ctx, "OIDC code exchange failed", logger.Err(err))
+		return nil, errors.Wrap(err, "oidc login failed")
+	}
+	token, err := s.provider.VerifyIDToken(ctx, rawIDToken, state.Nonce)
+	if err != nil {
+		logger.Warn(ctx, "OIDC id_token rejected", logger.Err(err))
+		return nil, errors.Wrap(err, "oidc login failed")
+	}
+
+	user, created, err := s.resolveUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	ctx = pkgrepo.WithTenant(ctx, user.TenantID)
+	if created || s.opts.SyncRoles {
+		if err := s.syncRoles(ctx, user.ID, token); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.auth.authenticated(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	result.Type = "oidc"
+	return result, nil
+}
+
+// consumeState 解开 Cookie 里的授权上下文，核对它与回调带回的 state 属于同一次授权，
+// 并把 state 标记为已用：不论随后换码成功与否，同一个 state 都不能再用第二次
+func (s *OIDCService) consumeState(ctx context.Context, rawState, binding string) (*oidcState, error) {
+	state, err := s.openState(binding)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(rawState), []byte(state.ID)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+	first, err := s.tokenStore.ConsumeRefresh(ctx, oidcStateKeyPrefix+state.ID, time.Unix(state.ExpiresAt, 0))
+	if err != nil {
+		logger.Error(ctx, "Failed to consume oidc state", logger.Err(err))
+		return nil, errors.Wrap(err, "failed to consume oidc state")
+	}
+	if !first {
+		logger.Warn(ctx, "OIDC state replayed")
+		return nil, ErrInvalidOIDCState
+	}
+	return state, nil
+}
+
+// resolveUser 按 (iss, sub) 找绑定的本地用户；首次登录时按配置关联同名用户或自动开户。
+// created 表示本次新开了账号
+func (s *OIDCService) resolveUser(ctx context.Context, token *oidc.IDToken) (*usermodel.User, bool, error) {
+	identity, err := s.identityRepo.Find(ctx, &model.UserIdentity{Issuer: token.Issuer, Subject: token.Subject})
+	if err == nil {
+		// 外部身份的绑定不分租户，已绑定的用户不论在哪个租户都能找到
+		user, err := s.userFinder.FindByID(pkgrepo.WithAllTenants(ctx), identity.UserID)
+		if err != nil {
+			logger.Error(ctx, "Failed to retrieve user for identity", logger.Err(err), logger.Uint64("user_id", identity.UserID))
+			return nil, false, errors.Wrap(err, "failed to retrieve user")
+		}
+		return user, false, nil
+	}
+	if !errors.Is(err, pkgrepo.ErrNotFound) {
+		logger.Error(ctx, "Failed to retrieve user identity", logger.Err(err), logger.String("subject", token.Subject))
+		return nil, false, errors.Wrap(err, "failed to retrieve user identity")
+	}
+
+	username := token.ClaimString(s.opts.UsernameClaim)
+	if username == "" || len(username) > maxUsernameLength {
+		logger.Warn(ctx, "OIDC identity has no usable username", logger.String("claim", s.opts.UsernameClaim), logger.String("subject", token.Subject))
+		return nil, false, ErrOIDCUserNotFound
+	}
+
+	existing, err := s.userFinder.FindByUsername(ctx, username)
+	switch {
+	case err == nil && s.opts.LinkExistingUsers:
+		if err := s.link(ctx, existing.ID, token); err != nil {
+			return nil, false, err
+		}
+		logger.Info(ctx, "Linked oidc identity to existing user", logger.String("username", username))
+		return existing, false, nil
+	case err == nil:
+		return nil, false, ErrOIDCUsernameTaken
+	case !errors.Is(err, pkgrepo.ErrNotFound):
+		return nil, false, errors.Wrap(err, "failed to retrieve user")
+	case !s.opts.AutoProvision:
+		return nil, false, ErrOIDCUserNotFound
+	}
+
+	user, err := s.provision(ctx, username, token)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// provision 自动开户并绑定外部身份，两步在同一个事务里，避免留下没有绑定的孤儿账号
+func (s *OIDCService) provision(ctx context.Context, username string, token *oidc.IDToken) (*usermodel.User, error) {
+	var user *usermodel.User
+	err := s.identityRepo.Transaction(ctx, func(txCtx context.Context) error {
+		created, err := s.userCreator.CreateExternal(txCtx, &userdto.CreateUserRequest{
+			Username: username,
+			Email:    token.ClaimString(s.opts.EmailClaim),
+		})
+		if err != nil {
+			return err
+		}
+		user = created
+		return s.link(txCtx, created.ID, token)
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to provision oidc user", logger.Err(err), logger.String("username", username))
+		return nil, errors.Wrap(err, "failed to provision user")
+	}
+	logger.Info(ctx, "Provisioned user from oidc identity", logger.String("username", username), logger.Uint64("user_id", user.ID))
+	return user, nil
+}
+
+func (s *OIDCService) link(ctx context.Context, userID uint64, token *oidc.IDToken) error {
+	identity := &model.UserIdentity{UserID: userID, Issuer: token.Issuer, Subject: token.Subject}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		logger.Error(ctx, "Failed to link user identity", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to link user identity")
+	}
+	return nil
+}
+
+// syncRoles 按映射规则计算角色并覆盖用户当前角色，随后清掉权限缓存
+func (s *OIDCService) syncRoles(ctx context.Context, userID uint64, token *oidc.IDToken) error {
+	codes := s.mappedRoleCodes(token)
+	roleIDs := []uint64{}
+	if len(codes) > 0 {
+		roles, err := s.roleRepo.QueryBuilder().In("code", codes).Find(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve mapped roles")
+		}
+		if len(roles) != len(codes) {
+			// 配置里写错的角色 code 不阻断登录，只记录下来
+			logger.Warn(ctx, "Some mapped oidc roles do not exist", logger.Strings("roles", codes))
+		}
+		roleIDs = lo.Map(roles, func(r model.Role, _ int) uint64 { return r.ID })
+	}
+
+	if err := s.userRoleSvc.assignUserRoles(ctx, userID, roleIDs); err != nil {
+		logger.Error(ctx, "Failed to sync oidc roles", logger.Err(err), logger.Uint64("user_id", userID))
+		return err
+	}
+	s.checker.InvalidateUser(userID)
+	return nil
+}
+
+// mappedRoleCodes 命中规则的角色 code（去重）；一条都没命中时取默认角色
+func (s *OIDCService) mappedRoleCodes(token *oidc.IDToken) []string {
+	var codes []string
+	for _, mapping := range s.opts.RoleMappings {
+		if lo.Contains(token.ClaimStrings(mapping.Claim), mapping.Value) {
+			codes = append(codes, mapping.Role)
+		}
+	}
+	if len(codes) == 0 {
+		codes = s.opts.DefaultRoles
+	}
+	return lo.Uniq(codes)
+}
+
+func (s *OIDCService) sealState(state oidcState) (string, error) {
+	plain, err := json.Marshal(state)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode oidc state")
+	}
+	ciphertext, err := s.cipher.Encrypt(plain)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encrypt oidc state")
+	}
+	return base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// openState 解密并校验授权上下文：AES-GCM 保证它确实由本服务签发且未被篡改
+func (s *OIDCService) openState(raw string) (*oidcState, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	plain, err := s.cipher.Decrypt(ciphertext)
+	if err != nil {
+		return nil, ErrInvalidOIDCState
+	}
+	var state oidcState
+	if err := json.Unmarshal(plain, &state); err != nil || time.Now().Unix() > state.ExpiresAt {
+		return nil, ErrInvalidOIDCState
+	}
+	return &state, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	"github.com/ayxworxfr/go_admin/pkg/oidc"
+	"github.com/ayxworxfr/go_admin/pkg/oidc/oidctest"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOIDCService(t *testing.T, idp *oidctest.Server) *OIDCService {
+	t.Helper()
+	// state 校验失败时不会走到用户与登录流程，这里不需要它们
+	return NewOIDCService(repotest.NewDB(t), nil, nil, nil, nil, nil, tokenstore.NewInMemoryTokenStore(), OIDCOptions{
+		Provider: oidc.Config{
+			Issuer:      idp.URL,
+			ClientID:    idp.ClientID,
+			RedirectURL: "http://localhost:8000/login/callback",
+		},
+		StateKey: "test-state-key",
+		StateTTL: time.Minute,
+	})
+}
+
+// authorize 发起一次授权并模拟 IdP 回调，返回 code、state 与写进 Cookie 的 binding
+func authorize(t *testing.T, idp *oidctest.Server, svc *OIDCService) (code, state, binding string) {
+	t.Helper()
+	result, binding, err := svc.AuthorizeURL(context.Background())
+	require.NoError(t, err)
+	code, state, err = idp.Authorize(result.URL)
+	require.NoError(t, err)
+	require.Equal(t, result.State, state)
+	return code, state, binding
+}
+
+func TestOIDCCallback_RequiresStateCookie(t *testing.T) {
+	idp := oidctest.NewServer("go-admin")
+	defer idp.Close()
+	svc := newTestOIDCService(t, idp)
+	ctx := context.Background()
+
+	code, state, _ := authorize(t, idp, svc)
+	_, _, otherBinding := authorize(t, idp, svc)
+
+	// 没有 Cookie：截获回调地址的人无法完成登录
+	_, err := svc.Callback(ctx, code, state, "", dto.ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+	// 另一次授权的 Cookie：不能把自己的 code 塞给别人的浏览器
+	_, err = svc.Callback(ctx, code, state, otherBinding, dto.ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+}
+
+func TestOIDCCallback_StateIsSingleUse(t *testing.T) {
+	idp := oidctest.NewServer("go-admin")
+	defer idp.Close()
+	svc := newTestOIDCService(t, idp)
+	ctx := context.Background()
+
+	code, state, binding := authorize(t, idp, svc)
+
+	// 第一次回调换码失败，state 同样作废
+	_, err := svc.Callback(ctx, "bogus-code", state, binding, dto.ClientInfo{})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidOIDCState)
+
+	_, err = svc.Callback(ctx, code, state, binding, dto.ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+}
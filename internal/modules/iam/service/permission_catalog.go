@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/pkg/errors"
+)
+
+const (
+	// catalogModuleCodePrefix 同步时为每个模块建的分组菜单的 code 前缀，如 API_USER
+	catalogModuleCodePrefix = "API_"
+	// catalogCodePrefix 同步新建的接口权限 code 前缀，后接 method+path 的哈希，保证重复同步幂等
+	catalogCodePrefix = "api:"
+	// permissionNameMaxLen 与 permission.name 列宽一致
+	permissionNameMaxLen = 50
+)
+
+// CatalogSyncOptions 权限目录同步参数
+type CatalogSyncOptions struct {
+	// GrantRoles 新建的接口权限与模块菜单自动授予这些角色（角色 code），
+	// 通常是管理员角色，否则新接口上线后谁都访问不了。
+	// 同步不挂租户时各租户里 code 相同的角色都会被授予
+	GrantRoles []string
+	// DryRun 只计算差异，不写库
+	DryRun bool
+}
+
+// SyncCatalog 按路由表同步接口权限（type=3）：
+//   - 没有 method+path 完全相同的权限的路由，在所属模块的分组菜单下新建一条；
+//   - 已有接口权限的规则匹配不到任何路由时标记 orphaned，重新匹配上时清除标记。
+//
+// 只增不删：通配写法的权限（如 /api/protected/user/*）和管理员改过名称的权限原样保留，
+// orphaned 的权限也只标记不删除，是否删除由管理员决定。
+// 已删除（在回收站里）的权限不会重新创建，也不参与 orphaned 标记。
+func (s *PermissionService) SyncCatalog(ctx context.Context, routes []dto.CatalogRoute, opts CatalogSyncOptions) (*dto.CatalogSyncResult, error) {
+	// 回收站里的权限也算已存在：管理员删掉的接口权限不会被同步重新建出来，要用就从回收站恢复
+	existing, err := s.permissionRepo.FindAll(pkgrepo.WithDeleted(ctx), &model.Permission{})
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve permissions", logger.Err(err))
+		return nil, errors.Wrap(err, "failed to retrieve permissions")
+	}
+
+	plan := planCatalog(existing, routes)
+	result := plan.result()
+	if opts.DryRun || plan.empty() {
+		return result, nil
+	}
+
+	err = s.permissionRepo.Transaction(ctx, func(txCtx context.Context) error {
+		created, err := s.applyCatalog(txCtx, plan)
+		if err != nil {
+			return err
+		}
+		return s.grantCatalog(txCtx, created, opts.GrantRoles)
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to sync permission catalog", logger.Err(err))
+		return nil, err
+	}
+	return result, nil
+}
+
+// catalogPlan 同步要做的全部变更，先算完再落库，DryRun 直接返回它
+type catalogPlan struct {
+	modules  map[string]*model.Permission // module -> 分组菜单，ID 为 0 表示待新建
+	newPerms map[string][]model.Permission
+	orphaned []model.Permission
+	restored []model.Permission
+}
+
+func planCatalog(existing []model.Permission, routes []dto.CatalogRoute) *catalogPlan {
+	plan := &catalogPlan{modules: make(map[string]*model.Permission), newPerms: make(map[string][]model.Permission)}
+
+	exact := make(map[string]bool)
+	byCode := make(map[string]*model.Permission)
+	for i := range existing {
+		p := &existing[i]
+		byCode[p.Code] = p
+		if p.Type == model.PermissionTypeAPI {
+			exact[p.Method+":"+p.Path] = true
+		}
+	}
+
+	for _, rt := range routes {
+		key := rt.Method + ":" + rt.Path
+		if exact[key] {
+			continue
+		}
+		exact[key] = true
+
+		if _, ok := plan.modules[rt.Module]; !ok {
+			code := catalogModuleCodePrefix + strings.ToUpper(strings.ReplaceAll(rt.Module, "-", "_"))
+			if parent, ok := byCode[code]; ok {
+				plan.modules[rt.Module] = parent
+			} else {
+				plan.modules[rt.Module] = &model.Permission{
+					Name:        truncate(rt.Module+" 接口", permissionNameMaxLen),
+					Code:        code,
+					Description: "权限目录同步自动创建的 " + rt.Module + " 模块接口分组",
+					Type:        model.PermissionTypeMenu,
+					Status:      1,
+				}
+			}
+		}
+		plan.newPerms[rt.Module] = append(plan.newPerms[rt.Module], model.Permission{
+			Name:        truncate(rt.Module+"."+rt.Name, permissionNameMaxLen),
+			Code:        catalogCode(key),
+			Description: rt.Method + " " + rt.Path,
+			Type:        model.PermissionTypeAPI,
+			Path:        rt.Path,
+			Method:      rt.Method,
+			Effect:      model.PermissionEffectAllow,
+			Status:      1,
+		})
+	}
+
+	for _, p := range existing {
+		if p.Type != model.PermissionTypeAPI || p.Method == "" || p.Path == "" || p.Deleted() {
+			continue
+		}
+		live := routesMatch(routes, p.Method+":"+p.Path)
+		switch {
+		case !live && !p.Orphaned:
+			plan.orphaned = append(plan.orphaned, p)
+		case live && p.Orphaned:
+			plan.restored = append(plan.restored, p)
+		}
+	}
+	return plan
+}
+
+// routesMatch 权限规则是否至少匹配一条路由。路由路径里的 :id 按字面量参与匹配，
+// 规则一侧的 :name 能匹配上它
+func routesMatch(routes []dto.CatalogRoute, rule string) bool {
+	matcher, err := pathmatch.Compile([]string{rule})
+	if err != nil {
+		return false
+	}
+	for _, rt := range routes {
+		if matcher.Match(rt.Method, rt.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *catalogPlan) empty() bool {
+	return len(p.newPerms) == 0 && len(p.orphaned) == 0 && len(p.restored) == 0
+}
+
+func (p *catalogPlan) result() *dto.CatalogSyncResult {
+	result := &dto.CatalogSyncResult{Created: []string{}, Orphaned: []string{}, Restored: []string{}, Modules: []string{}}
+	for module, perms := range p.newPerms {
+		if p.modules[module].ID == 0 {
+			result.Modules = append(result.Modules, p.modules[module].Code)
+		}
+		for _, perm := range perms {
+			result.Created = append(result.Created, perm.Method+":"+perm.Path)
+		}
+	}
+	for _, perm := range p.orphaned {
+		result.Orphaned = append(result.Orphaned, perm.Method+":"+perm.Path)
+	}
+	for _, perm := range p.restored {
+		result.Restored = append(result.Restored, perm.Method+":"+perm.Path)
+	}
+	sort.Strings(result.Created)
+	sort.Strings(result.Orphaned)
+	sort.Strings(result.Restored)
+	sort.Strings(result.Modules)
+	return result
+}
+
+// applyCatalog 落库并返回新建的权限（含模块菜单）ID
+func (s *PermissionService) applyCatalog(ctx context.Context, plan *catalogPlan) ([]uint64, error) {
+	var created []uint64
+	for module, perms := range plan.newPerms {
+		parent := plan.modules[module]
+		if parent.ID == 0 {
+			if err := s.permissionRepo.Create(ctx, parent); err != nil {
+				return nil, errors.Wrapf(err, "failed to create module menu %s", parent.Code)
+			}
+			created = append(created, parent.ID)
+		}
+		for i := range perms {
+			perms[i].ParentID = parent.ID
+			if err := s.permissionRepo.Create(ctx, &perms[i]); err != nil {
+				return nil, errors.Wrapf(err, "failed to create permission %s", perms[i].Description)
+			}
+			created = append(created, perms[i].ID)
+		}
+	}
+
+	for _, p := range plan.orphaned {
+		if err := s.permissionRepo.Update(ctx, &model.Permission{ID: p.ID, Orphaned: true}, "orphaned"); err != nil {
+			return nil, errors.Wrapf(err, "failed to flag permission %d", p.ID)
+		}
+	}
+	for _, p := range plan.restored {
+		if err := s.permissionRepo.Update(ctx, &model.Permission{ID: p.ID, Orphaned: false}, "orphaned"); err != nil {
+			return nil, errors.Wrapf(err, "failed to unflag permission %d", p.ID)
+		}
+	}
+	return created, nil
+}
+
+func (s *PermissionService) grantCatalog(ctx context.Context, permissionIDs []uint64, roleCodes []string) error {
+	if len(permissionIDs) == 0 || len(roleCodes) == 0 {
+		return nil
+	}
+	roles, err := s.roleRepo.QueryBuilder().In("code", roleCodes).Find(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve roles")
+	}
+	if len(roles) < len(roleCodes) {
+		logger.Warn(ctx, "Some roles to grant synced permissions do not exist", logger.Strings("role_codes", roleCodes))
+	}
+
+	grants := make([]model.RolePermission, 0, len(roles)*len(permissionIDs))
+	for _, role := range roles {
+		for _, id := range permissionIDs {
+			grants = append(grants, model.RolePermission{RoleID: role.ID, PermissionID: id})
+		}
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	if err := s.rolePermissionRepo.BatchCreate(ctx, grants); err != nil {
+		return errors.Wrap(err, "failed to grant synced permissions")
+	}
+	return nil
+}
+
+func catalogCode(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return catalogCodePrefix + hex.EncodeToString(sum[:8])
+}
+
+// truncate 按字符截断，避免把多字节字符截成半个
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -3,10 +3,14 @@ package service
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/cache"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
+	"github.com/jinzhu/copier"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
 )
@@ -33,40 +37,160 @@ func NewPermissionChecker(userRoleSvc *UserRoleService, roleSvc *RoleService, pe
 	}
 }
 
-// HasPermission 检查用户是否拥有指定方法+路径的权限（带缓存）
-func (c *PermissionChecker) HasPermission(ctx context.Context, userID uint64, method, path string) (bool, error) {
-	if permissionMap, ok := c.cache.Get(userID); ok {
-		return matchPath(permissionMap, method, path), nil
+// HasPermission 检查用户是否拥有指定方法+路径的权限。用户的全部权限规则编译成一棵
+// 前缀树后缓存，命中缓存时匹配耗时与权限条数无关。
+// 允许与拒绝同时匹配时按 pkg/pathmatch 的优先级裁决：更具体的规则胜出，同样具体时拒绝优先。
+// role 是令牌里用户切换到的角色，非空时只按该角色（及其祖先）计算，为空时按全部角色合并
+func (c *PermissionChecker) HasPermission(ctx context.Context, userID uint64, role, method, path string) (bool, error) {
+	matcher, err := c.userMatcher(ctx, userID, role)
+	if err != nil {
+		return false, err
+	}
+	return matcher.Match(method, path), nil
+}
+
+// HasScopedPermission 在 HasPermission 的基础上再用凭证自带的 scope 收窄：请求必须同时
+// 被用户权限和 scope 允许。scope 与权限记录使用同一套 pathmatch 规则语法，
+// 因此令牌的能力永远不会超出其所属用户
+func (c *PermissionChecker) HasScopedPermission(ctx context.Context, userID uint64, role, method, path string, scopes []string) (bool, error) {
+	allowed, err := c.HasPermission(ctx, userID, role, method, path)
+	if err != nil || !allowed {
+		return false, err
+	}
+
+	// scope 在签发时已校验过语法，条数也少，每次请求现编译即可
+	scopeMatcher, err := pathmatch.Compile(scopes)
+	if err != nil {
+		logger.Warn(ctx, "Invalid token scope", logger.Err(err), logger.Uint64("user_id", userID))
+		return false, nil
+	}
+	return scopeMatcher.Match(method, path), nil
+}
+
+// userMatcher 取用户的权限匹配器，未命中缓存时从数据库加载并编译。
+// 个别规则语法有误（如早于语法校验写入的脏数据）只跳过该条并告警，不影响其余权限。
+// 用户有临时角色时，缓存在下一次角色生效/到期的时刻失效，不必等清理任务
+func (c *PermissionChecker) userMatcher(ctx context.Context, userID uint64, role string) (*pathmatch.Matcher, error) {
+	key := cache.Key{UserID: userID, Role: role}
+	if matcher, ok := c.cache.Get(key); ok {
+		return matcher, nil
 	}
+	// 回源期间角色被改动时，带着旧版本的写回会被缓存丢弃
+	version := c.cache.Version(userID)
 
-	permissions, err := c.getUserAllPermissions(ctx, userID)
+	permissions, err := c.getUserAllPermissions(ctx, userID, role)
 	if err != nil {
 		logger.Error(ctx, "Failed to retrieve user permissions", logger.Err(err), logger.Uint64("user_id", userID))
-		return false, errors.Wrap(err, "failed to retrieve user permissions")
+		return nil, errors.Wrap(err, "failed to retrieve user permissions")
+	}
+
+	next, err := c.userRoleSvc.nextRoleChange(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user role windows", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to retrieve user role windows")
 	}
 
-	permissionMap := make(map[string]bool, len(permissions))
+	matcher, _ := compileRules(ctx, permissions)
+	if next.IsZero() {
+		c.cache.Set(key, version, matcher)
+	} else {
+		c.cache.SetUntil(key, version, matcher, next)
+	}
+	return matcher, nil
+}
+
+// compileRules 把权限编译成匹配器，拒绝类权限带 "!" 前缀。返回的切片与 matcher.Rules() 一一对应，
+// 用来从 Lookup 的结论找回权限记录
+func compileRules(ctx context.Context, permissions []model.Permission) (*pathmatch.Matcher, []model.Permission) {
+	matcher := pathmatch.New()
+	compiled := make([]model.Permission, 0, len(permissions))
 	for _, perm := range permissions {
-		if perm.Method != "" && perm.Path != "" {
-			permissionMap[perm.Method+":"+perm.Path] = true
+		if perm.Method == "" || perm.Path == "" {
+			continue
 		}
+		rule := perm.Method + ":" + perm.Path
+		if perm.Effect == model.PermissionEffectDeny {
+			rule = "!" + rule
+		}
+		if err := matcher.Add(rule); err != nil {
+			logger.Warn(ctx, "Skipping invalid permission rule", logger.Err(err), logger.Uint64("permission_id", perm.ID))
+			continue
+		}
+		compiled = append(compiled, perm)
+	}
+	return matcher, compiled
+}
+
+// Explain 说明用户访问 method + path 的鉴权结论：哪条权限起了决定作用，用户又是经由哪些角色拿到它的。
+// 规则与优先级和 HasPermission 相同，但不走缓存，反映的是数据库里的当前配置
+func (c *PermissionChecker) Explain(ctx context.Context, userID uint64, method, path string) (*dto.ExplainPermissionResponse, error) {
+	permissions, err := c.getUserAllPermissions(ctx, userID, "")
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user permissions", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to retrieve user permissions")
+	}
+
+	matcher, compiled := compileRules(ctx, permissions)
+	decision := matcher.Lookup(strings.ToUpper(method), path)
+	result := &dto.ExplainPermissionResponse{Allowed: decision.Allowed(), Decision: "no_match", Roles: []string{}}
+	if !decision.Matched {
+		return result, nil
+	}
+
+	perm := compiled[decision.Index]
+	result.Decision = decision.Effect.String()
+	result.Rule = decision.Rule
+	result.Permission = &dto.PermissionResponse{}
+	if err := copier.Copy(result.Permission, &perm); err != nil {
+		return nil, errors.Wrap(err, "failed to copy permission to response")
+	}
+	if result.Roles, err = c.rolesGranting(ctx, userID, perm.ID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rolesGranting 用户的哪些角色（含祖先角色）直接或经由父权限持有 permissionID
+func (c *PermissionChecker) rolesGranting(ctx context.Context, userID, permissionID uint64) ([]string, error) {
+	roles, err := c.userRoleSvc.RetrieveRolesByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve user roles")
+	}
+	roles, err = c.roleSvc.expandRolesWithCTE(ctx, lo.Map(roles, func(r model.Role, _ int) uint64 { return r.ID }))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to expand role hierarchy")
 	}
-	c.cache.Set(userID, permissionMap)
 
-	return matchPath(permissionMap, method, path), nil
+	codes := []string{}
+	for _, role := range roles {
+		direct, err := c.roleSvc.RetrievePermissionByRoleID(ctx, role.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve role permissions")
+		}
+		// 递归 CTE 的结果包含起点本身，直接分配与经由父权限展开的一并覆盖
+		expanded, err := c.roleSvc.getChildPermissionsWithCTE(ctx, lo.Map(direct, func(p model.Permission, _ int) uint64 { return p.ID }))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve child permissions")
+		}
+		if lo.ContainsBy(expanded, func(p model.Permission) bool { return p.ID == permissionID }) {
+			codes = append(codes, role.Code)
+		}
+	}
+	return codes, nil
 }
 
 // GetUserPermissionPaths 获取用户有权限访问的所有 "method:path"，
-// 供 user 模块渲染前端路由/菜单用（不走缓存，调用频率远低于 HasPermission）
-func (c *PermissionChecker) GetUserPermissionPaths(ctx context.Context, userID uint64) ([]string, error) {
-	permissions, err := c.getUserAllPermissions(ctx, userID)
+// 供 user 模块渲染前端路由/菜单用（不走缓存，调用频率远低于 HasPermission）。
+// 拒绝类权限不是可访问的入口，不列出。role 的含义同 HasPermission
+func (c *PermissionChecker) GetUserPermissionPaths(ctx context.Context, userID uint64, role string) ([]string, error) {
+	permissions, err := c.getUserAllPermissions(ctx, userID, role)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve user permissions")
 	}
 
 	paths := make([]string, 0, len(permissions))
 	for _, perm := range permissions {
-		if perm.Method != "" && perm.Path != "" {
+		if perm.Method != "" && perm.Path != "" && perm.Effect != model.PermissionEffectDeny {
 			paths = append(paths, perm.Method+":"+perm.Path)
 		}
 	}
@@ -75,7 +199,7 @@ func (c *PermissionChecker) GetUserPermissionPaths(ctx context.Context, userID u
 
 // GetUserPermissions 获取用户的权限详情列表（供管理台展示，非鉴权路径，不走缓存）
 func (c *PermissionChecker) GetUserPermissions(ctx context.Context, userID uint64) ([]model.Permission, error) {
-	return c.getUserAllPermissions(ctx, userID)
+	return c.getUserAllPermissions(ctx, userID, "")
 }
 
 // InvalidateUser 供角色/权限分配变更后清除单个用户的缓存
@@ -83,6 +207,19 @@ func (c *PermissionChecker) InvalidateUser(userID uint64) {
 	c.cache.InvalidateUser(userID)
 }
 
+// SweepExpiredRoles 删除已到期的临时角色分配并清除相关用户的缓存，由定时任务调用
+func (c *PermissionChecker) SweepExpiredRoles(ctx context.Context) (int, error) {
+	userIDs, err := c.userRoleSvc.deleteExpiredAssignments(ctx, time.Now())
+	if err != nil {
+		logger.Error(ctx, "Failed to delete expired user roles", logger.Err(err))
+		return 0, errors.Wrap(err, "failed to delete expired user roles")
+	}
+	for _, userID := range userIDs {
+		c.cache.InvalidateUser(userID)
+	}
+	return len(userIDs), nil
+}
+
 // InvalidateAll 供角色-权限关系整体变更后清空缓存。
 // 缓存清理统一收口在这里，其它组件（RoleService/UserRoleService）不直接
 // 持有缓存引用，避免"拆分后清理逻辑散落在多处、漏掉某个新组件"。
@@ -90,23 +227,28 @@ func (c *PermissionChecker) InvalidateAll() {
 	c.cache.InvalidateAll()
 }
 
-// getUserAllPermissions 获取用户的所有权限，包含通过父子关系递归展开的子权限
-func (c *PermissionChecker) getUserAllPermissions(ctx context.Context, userID uint64) ([]model.Permission, error) {
+// getUserAllPermissions 获取用户的所有权限：先沿角色继承链展开祖先角色，
+// 再包含通过权限父子关系递归展开的子权限。role 非空时只从用户当前仍持有的该角色出发，
+// 角色已被收回则没有任何权限
+func (c *PermissionChecker) getUserAllPermissions(ctx context.Context, userID uint64, role string) ([]model.Permission, error) {
 	roles, err := c.userRoleSvc.RetrieveRolesByUserID(ctx, userID)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve user roles")
 	}
+	if role != "" {
+		roles = lo.Filter(roles, func(r model.Role, _ int) bool { return r.Code == role })
+	}
 	if len(roles) == 0 {
 		return []model.Permission{}, nil
 	}
 
-	var permissions []model.Permission
-	for _, role := range roles {
-		perms, err := c.roleSvc.RetrievePermissionByRoleID(ctx, role.ID)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to retrieve role permissions")
-		}
-		permissions = append(permissions, perms...)
+	roles, err = c.roleSvc.expandRolesWithCTE(ctx, lo.Map(roles, func(r model.Role, _ int) uint64 { return r.ID }))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to expand role hierarchy")
+	}
+	permissions, err := c.roleSvc.retrievePermissionsByRoleIDs(ctx, lo.Map(roles, func(r model.Role, _ int) uint64 { return r.ID }))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve role permissions")
 	}
 	if len(permissions) == 0 {
 		return []model.Permission{}, nil
@@ -121,30 +263,3 @@ func (c *PermissionChecker) getUserAllPermissions(ctx context.Context, userID ui
 
 	return lo.UniqBy(permissions, func(p model.Permission) uint64 { return p.ID }), nil
 }
-
-// matchPath 检查权限映射中是否有匹配的 method+path，支持方法通配符 "*"
-// 与路径尾部通配符 "/*"
-func matchPath(permissionMap map[string]bool, method, path string) bool {
-	if permissionMap[method+":"+path] {
-		return true
-	}
-
-	for permKey := range permissionMap {
-		parts := strings.SplitN(permKey, ":", 2)
-		if len(parts) != 2 {
-			continue
-		}
-		permMethod, permPath := parts[0], parts[1]
-
-		if permMethod != method && permMethod != "*" {
-			continue
-		}
-		if strings.HasSuffix(permPath, "/*") {
-			prefix := strings.TrimSuffix(permPath, "/*")
-			if strings.HasPrefix(path, prefix) {
-				return true
-			}
-		}
-	}
-	return false
-}
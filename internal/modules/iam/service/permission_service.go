@@ -2,34 +2,62 @@ package service
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	tenantsvc "github.com/ayxworxfr/go_admin/internal/modules/tenant/service"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
 	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/hashicorp/go-multierror"
 	"github.com/jinzhu/copier"
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 )
 
+// ErrInvalidPermissionRule 权限的 method/path 不符合 pkg/pathmatch 的规则语法，或 effect 取值不对
+var ErrInvalidPermissionRule = errors.New("invalid permission method or path")
+
 // PermissionService 权限元数据管理服务：只做 Permission 的 CRUD，
 // 不再兼管"用户是否有权限"的判断——那是鉴权热路径，交给 PermissionChecker。
 // 两者虽然都叫"权限"，但一个是管理台配置操作，一个是每次请求都要走的判断逻辑，
 // 变更频率和性能要求完全不同，这正是拆分的依据。
+//
+// 权限目录不分租户，全部租户共用一份：增删改只对平台租户开放，
+// 租户管理员只能在自己的角色上挑选已有权限。
 type PermissionService struct {
-	permissionRepo *pkgrepo.Repository[model.Permission]
+	permissionRepo     *pkgrepo.Repository[model.Permission]
+	roleRepo           *pkgrepo.Repository[model.Role]
+	rolePermissionRepo *pkgrepo.Repository[model.RolePermission]
 }
 
 // NewPermissionService 创建权限元数据服务
 func NewPermissionService(db *pkgrepo.DB) *PermissionService {
-	return &PermissionService{permissionRepo: newRepositories(db).permission}
+	repos := newRepositories(db)
+	return &PermissionService{
+		permissionRepo:     repos.permission,
+		roleRepo:           repos.role,
+		rolePermissionRepo: repos.rolePermission,
+	}
 }
 
 // CreatePermission 创建权限
 func (s *PermissionService) CreatePermission(ctx context.Context, req *dto.CreatePermissionRequest) (*dto.PermissionResponse, error) {
+	if err := requirePlatform(ctx); err != nil {
+		return nil, err
+	}
 	var permission model.Permission
 	if err := copier.Copy(&permission, req); err != nil {
 		return nil, errors.Wrap(err, "failed to copy request to permission")
 	}
+	if err := validateRule(permission.Method, permission.Path, permission.Effect); err != nil {
+		return nil, err
+	}
+	if permission.Effect == "" {
+		permission.Effect = model.PermissionEffectAllow
+	}
 
 	if err := s.permissionRepo.Create(ctx, &permission); err != nil {
 		logger.Error(ctx, "Failed to create permission", logger.Err(err))
@@ -45,10 +73,21 @@ func (s *PermissionService) CreatePermission(ctx context.Context, req *dto.Creat
 
 // CreatePermissions 批量创建权限
 func (s *PermissionService) CreatePermissions(ctx context.Context, req *dto.CreatePermissionsRequest) error {
+	if err := requirePlatform(ctx); err != nil {
+		return err
+	}
 	permissions := make([]model.Permission, 0, len(req.Permissions))
 	if err := copier.Copy(&permissions, &req.Permissions); err != nil {
 		return errors.Wrap(err, "failed to copy requests to permissions")
 	}
+	for i := range permissions {
+		if err := validateRule(permissions[i].Method, permissions[i].Path, permissions[i].Effect); err != nil {
+			return err
+		}
+		if permissions[i].Effect == "" {
+			permissions[i].Effect = model.PermissionEffectAllow
+		}
+	}
 
 	if err := s.permissionRepo.BatchCreate(ctx, permissions); err != nil {
 		logger.Error(ctx, "Failed to create permissions", logger.Err(err))
@@ -59,15 +98,25 @@ func (s *PermissionService) CreatePermissions(ctx context.Context, req *dto.Crea
 
 // UpdatePermission 更新权限
 func (s *PermissionService) UpdatePermission(ctx context.Context, req *dto.UpdatePermissionRequest) (*dto.PermissionResponse, error) {
+	if err := requirePlatform(ctx); err != nil {
+		return nil, err
+	}
 	permission, err := s.permissionRepo.FindByID(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "Failed to retrieve permission", logger.Err(err), logger.Uint64("permission_id", req.ID))
 		return nil, errors.Wrap(err, "failed to retrieve permission")
 	}
 
+	effect := permission.Effect
 	if err := copier.Copy(permission, req); err != nil {
 		return nil, errors.Wrap(err, "failed to copy request to permission")
 	}
+	if req.Effect == "" {
+		permission.Effect = effect
+	}
+	if err := validateRule(permission.Method, permission.Path, permission.Effect); err != nil {
+		return nil, err
+	}
 
 	if err := s.permissionRepo.Update(ctx, permission); err != nil {
 		logger.Error(ctx, "Failed to update permission", logger.Err(err), logger.Uint64("permission_id", req.ID))
@@ -81,8 +130,11 @@ func (s *PermissionService) UpdatePermission(ctx context.Context, req *dto.Updat
 	return &result, nil
 }
 
-// DeletePermissionBatch 批量删除权限
+// DeletePermissionBatch 批量（软）删除权限，角色上的分配保留，恢复后原样生效
 func (s *PermissionService) DeletePermissionBatch(ctx context.Context, ids []uint64) error {
+	if err := requirePlatform(ctx); err != nil {
+		return err
+	}
 	if len(ids) == 0 {
 		return nil
 	}
@@ -94,6 +146,48 @@ func (s *PermissionService) DeletePermissionBatch(ctx context.Context, ids []uin
 	return nil
 }
 
+// RestorePermissionBatch 批量恢复回收站里的权限，逐个恢复并收集错误
+func (s *PermissionService) RestorePermissionBatch(ctx context.Context, ids []uint64) error {
+	if err := requirePlatform(ctx); err != nil {
+		return err
+	}
+	var result *multierror.Error
+	for _, id := range ids {
+		if err := s.permissionRepo.Restore(ctx, id); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to restore permission %d", id))
+		}
+	}
+	if err := result.ErrorOrNil(); err != nil {
+		logger.Error(ctx, "Failed to restore permissions", logger.Err(err), logger.Uint64s("permission_ids", ids))
+		return err
+	}
+	return nil
+}
+
+// PurgeDeleted 物理删除 before 之前软删除的权限及其在各角色上的分配，返回清理的权限数
+func (s *PermissionService) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	permissions, err := s.permissionRepo.QueryBuilder().Lt("delete_time", before).Find(pkgrepo.OnlyDeleted(ctx))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve deleted permissions")
+	}
+	if len(permissions) == 0 {
+		return 0, nil
+	}
+	ids := lo.Map(permissions, func(p model.Permission, _ int) uint64 { return p.ID })
+
+	err = s.permissionRepo.Transaction(ctx, func(txCtx context.Context) error {
+		if err := s.rolePermissionRepo.QueryBuilder().In("permission_id", ids).Delete(txCtx); err != nil {
+			return errors.Wrap(err, "failed to delete role permissions")
+		}
+		return s.permissionRepo.Purge(txCtx, ids)
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to purge deleted permissions", logger.Err(err), logger.Uint64s("permission_ids", ids))
+		return 0, errors.Wrap(err, "failed to purge deleted permissions")
+	}
+	return len(ids), nil
+}
+
 // GetPermission 获取单个权限
 func (s *PermissionService) GetPermission(ctx context.Context, id uint64) (*dto.PermissionResponse, error) {
 	permission, err := s.permissionRepo.FindByID(ctx, id)
@@ -109,8 +203,11 @@ func (s *PermissionService) GetPermission(ctx context.Context, id uint64) (*dto.
 	return &result, nil
 }
 
-// GetPermissionList 获取权限列表
+// GetPermissionList 获取权限列表；req.Deleted 为 true 时查回收站
 func (s *PermissionService) GetPermissionList(ctx context.Context, req *dto.GetPermissionListRequest) ([]*dto.PermissionResponse, int64, error) {
+	if req.Deleted {
+		ctx = pkgrepo.OnlyDeleted(ctx)
+	}
 	permissions, total, err := s.permissionRepo.FindPage(ctx, req, req.Limit, req.Offset)
 	if err != nil {
 		logger.Error(ctx, "Failed to retrieve permissions", logger.Err(err))
@@ -123,3 +220,30 @@ func (s *PermissionService) GetPermissionList(ctx context.Context, req *dto.GetP
 	}
 	return result, total, nil
 }
+
+// requirePlatform 权限目录的写操作只允许平台租户进行
+func requirePlatform(ctx context.Context) error {
+	if !tenantsvc.IsPlatformContext(ctx) {
+		return errors.WithStack(tenantsvc.ErrPlatformOnly)
+	}
+	return nil
+}
+
+// validateRule 写入前校验 method + path 能否编译成匹配规则，以及 effect 取值。
+// method 和 path 都为空的是菜单分组一类不参与鉴权的节点，不校验规则。
+// 拒绝只能通过 effect 表达，method 里的 "!" 前缀会被规则语法当成拒绝，这里不接受
+func validateRule(method, path, effect string) error {
+	if effect != "" && effect != model.PermissionEffectAllow && effect != model.PermissionEffectDeny {
+		return errors.Wrapf(ErrInvalidPermissionRule, "unknown effect %q", effect)
+	}
+	if method == "" && path == "" {
+		return nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(method), "!") {
+		return errors.Wrap(ErrInvalidPermissionRule, "use effect=deny instead of a ! prefix")
+	}
+	if err := pathmatch.Validate(method + ":" + path); err != nil {
+		return errors.Wrap(ErrInvalidPermissionRule, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/constant"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/pathmatch"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+const (
+	personalTokenBytes      = 32
+	personalTokenHintLength = 4
+	// personalTokenTouchInterval 最近使用时间的落库间隔：机器客户端调用频繁，
+	// 每个请求都写一次库不划算，分钟级精度足够判断令牌是否还在用
+	personalTokenTouchInterval = time.Minute
+)
+
+// 个人访问令牌的业务错误
+var (
+	ErrPersonalTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidPersonalToken  = errors.New("invalid or expired personal access token")
+	ErrInvalidTokenScope     = errors.New("invalid token scope, expected METHOD[|METHOD]:/path")
+)
+
+// scopeMethods scope 允许的 HTTP 方法，"*" 表示任意方法
+var scopeMethods = map[string]bool{
+	http.MethodGet: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, "*": true,
+}
+
+// PersonalTokenService 个人访问令牌：签发、列出、撤销，以及供 JWT 中间件调用的校验。
+// 令牌只代表"以某个用户的身份、在某个范围内"调用接口，权限判断仍交给 PermissionChecker，
+// 这里只负责把令牌还原成与 JWT 相同形态的 Claims。
+type PersonalTokenService struct {
+	repo       *pkgrepo.Repository[model.PersonalToken]
+	userFinder usersvc.UserFinder
+}
+
+// NewPersonalTokenService 创建个人访问令牌服务
+func NewPersonalTokenService(db *pkgrepo.DB, userFinder usersvc.UserFinder) *PersonalTokenService {
+	return &PersonalTokenService{
+		repo:       pkgrepo.NewRepository[model.PersonalToken](db),
+		userFinder: userFinder,
+	}
+}
+
+// Create 为用户签发一个新令牌，返回值里的明文只此一次，之后无法再查到
+func (s *PersonalTokenService) Create(ctx context.Context, userID uint64, req *dto.CreatePersonalTokenRequest) (*dto.PersonalTokenCreatedResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	encodedScopes, err := json.Marshal(scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode token scopes")
+	}
+
+	buf := make([]byte, personalTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, errors.Wrap(err, "failed to generate personal access token")
+	}
+	raw := constant.PersonalTokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	token := &model.PersonalToken{
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: hashPersonalToken(raw),
+		Hint:      raw[len(raw)-personalTokenHintLength:],
+		Scopes:    string(encodedScopes),
+		ExpiresAt: time.Now().AddDate(0, 0, req.ExpiresInDays),
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		logger.Error(ctx, "Failed to create personal access token", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to create personal access token")
+	}
+
+	resp := &dto.PersonalTokenCreatedResponse{Token: raw}
+	resp.PersonalTokenResponse = *toPersonalTokenResponse(token, scopes)
+	return resp, nil
+}
+
+// List 列出用户的全部令牌（含已过期的，便于用户自行清理）
+func (s *PersonalTokenService) List(ctx context.Context, userID uint64) ([]*dto.PersonalTokenResponse, error) {
+	tokens, err := s.repo.QueryBuilder().Eq("user_id", userID).OrderBy("id DESC").Find(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to list personal access tokens", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to list personal access tokens")
+	}
+
+	result := make([]*dto.PersonalTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		scopes, err := decodeScopes(tokens[i].Scopes)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, toPersonalTokenResponse(&tokens[i], scopes))
+	}
+	return result, nil
+}
+
+// Revoke 撤销用户的某个令牌，立即生效；令牌不属于该用户时返回 ErrPersonalTokenNotFound
+func (s *PersonalTokenService) Revoke(ctx context.Context, userID, id uint64) error {
+	token, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, pkgrepo.ErrNotFound) || (err == nil && token.UserID != userID) {
+		return ErrPersonalTokenNotFound
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve personal access token", logger.Err(err), logger.Uint64("token_id", id))
+		return errors.Wrap(err, "failed to retrieve personal access token")
+	}
+
+	if err := s.repo.DeleteByID(ctx, id); err != nil {
+		logger.Error(ctx, "Failed to delete personal access token", logger.Err(err), logger.Uint64("token_id", id))
+		return errors.Wrap(err, "failed to delete personal access token")
+	}
+	return nil
+}
+
+// Authenticate 校验令牌明文并还原为 Claims，由 JWT 中间件在识别到令牌前缀时调用。
+// 每次都回表确认账号状态：PAT 的有效期以月计，不能像 JWT 那样只靠用户级撤销名单兜底
+// （撤销记录只保留到 refresh token 过期为止）。
+func (s *PersonalTokenService) Authenticate(ctx context.Context, raw string) (*jwtauth.Claims, error) {
+	token, err := s.repo.Find(ctx, &model.PersonalToken{TokenHash: hashPersonalToken(raw)})
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, ErrInvalidPersonalToken
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve personal access token", logger.Err(err))
+		return nil, errors.Wrap(err, "failed to retrieve personal access token")
+	}
+	now := time.Now()
+	if !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	user, err := s.userFinder.FindByID(ctx, token.UserID)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, ErrInvalidPersonalToken
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user for personal access token", logger.Err(err), logger.Uint64("user_id", token.UserID))
+		return nil, errors.Wrap(err, "failed to retrieve user")
+	}
+	if !user.CanLogin() {
+		return nil, ErrAccountDisabled
+	}
+
+	scopes, err := decodeScopes(token.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if now.Sub(token.LastUsedAt) >= personalTokenTouchInterval {
+		s.touch(ctx, token.ID, now)
+	}
+
+	return &jwtauth.Claims{
+		Identity: strconv.FormatUint(user.ID, 10),
+		Nice:     user.Username,
+		Type:     jwtauth.PersonalTokenType,
+		Scopes:   scopes,
+		TenantID: user.TenantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(token.CreateTime),
+			ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+		},
+	}, nil
+}
+
+// touch 记录最近使用时间；失败只记日志，不影响本次请求
+func (s *PersonalTokenService) touch(ctx context.Context, id uint64, now time.Time) {
+	if err := s.repo.Update(ctx, &model.PersonalToken{ID: id, LastUsedAt: now}); err != nil {
+		logger.Warn(ctx, "Failed to update personal access token last used time", logger.Err(err), logger.Uint64("token_id", id))
+	}
+}
+
+func hashPersonalToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes 校验并规范化 scope：语法同权限规则（见 pkg/pathmatch），方法转大写、去重
+func normalizeScopes(scopes []string) ([]string, error) {
+	result := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		normalized, err := pathmatch.Normalize(scope)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidTokenScope, scope)
+		}
+		method, _, _ := strings.Cut(normalized, ":")
+		for _, m := range strings.Split(method, "|") {
+			if !scopeMethods[m] {
+				return nil, errors.Wrap(ErrInvalidTokenScope, scope)
+			}
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			result = append(result, normalized)
+		}
+	}
+	return result, nil
+}
+
+func decodeScopes(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var scopes []string
+	if err := json.Unmarshal([]byte(raw), &scopes); err != nil {
+		return nil, errors.Wrap(err, "failed to decode token scopes")
+	}
+	return scopes, nil
+}
+
+func toPersonalTokenResponse(token *model.PersonalToken, scopes []string) *dto.PersonalTokenResponse {
+	resp := &dto.PersonalTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Hint:       token.Hint,
+		Scopes:     scopes,
+		ExpiresAt:  token.ExpiresAt,
+		CreateTime: token.CreateTime,
+	}
+	if !token.LastUsedAt.IsZero() {
+		lastUsed := token.LastUsedAt
+		resp.LastUsedAt = &lastUsed
+	}
+	return resp
+}
@@ -5,7 +5,7 @@ import (
 	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
 )
 
-// repositories 打包 iam 模块内部用到的四个仓储。
+// repositories 打包 iam 模块内部用到的几个核心仓储。
 //
 // 之前这段代码单独放在 service/internal/repository 子包下，靠 Go 的
 // internal 可见性规则挡住 handler 的越权 import——但这四个仓储全是对
@@ -21,14 +21,18 @@ type repositories struct {
 	permission     *pkgrepo.Repository[model.Permission]
 	userRole       *pkgrepo.Repository[model.UserRole]
 	rolePermission *pkgrepo.Repository[model.RolePermission]
+	roleParent     *pkgrepo.Repository[model.RoleParent]
+	roleDept       *pkgrepo.Repository[model.RoleDept]
 }
 
-// newRepositories 基于同一个 *DB 构造四个仓储实例。
+// newRepositories 基于同一个 *DB 构造各仓储实例。
 func newRepositories(db *pkgrepo.DB) *repositories {
 	return &repositories{
 		role:           pkgrepo.NewRepository[model.Role](db),
 		permission:     pkgrepo.NewRepository[model.Permission](db),
 		userRole:       pkgrepo.NewRepository[model.UserRole](db),
 		rolePermission: pkgrepo.NewRepository[model.RolePermission](db),
+		roleParent:     pkgrepo.NewRepository[model.RoleParent](db),
+		roleDept:       pkgrepo.NewRepository[model.RoleDept](db),
 	}
 }
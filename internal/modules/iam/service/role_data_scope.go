@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// AssignRoleDepts 设置自定义数据范围的部门（覆盖式），只在 data_scope 为 DataScopeCustom 时生效。
+// 换成其它数据范围时保留这份配置，切回自定义时不必重新勾选
+func (s *RoleService) AssignRoleDepts(ctx context.Context, roleID uint64, deptIDs []uint64) error {
+	deptIDs = lo.Uniq(deptIDs)
+	current, err := s.roleDeptRepo.FindAll(ctx, &model.RoleDept{RoleID: roleID})
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve role depts")
+	}
+
+	existingIDs := lo.Map(current, func(rd model.RoleDept, _ int) uint64 { return rd.DeptID })
+	toRemoveIDs := lo.Filter(existingIDs, func(id uint64, _ int) bool { return !lo.Contains(deptIDs, id) })
+	toAddIDs := lo.Filter(deptIDs, func(id uint64, _ int) bool { return !lo.Contains(existingIDs, id) })
+
+	return s.roleDeptRepo.Transaction(ctx, func(txCtx context.Context) error {
+		if len(toRemoveIDs) > 0 {
+			if err := s.roleDeptRepo.QueryBuilder().Eq("role_id", roleID).In("dept_id", toRemoveIDs).Delete(txCtx); err != nil {
+				return errors.Wrap(err, "failed to delete role depts")
+			}
+		}
+		if len(toAddIDs) > 0 {
+			depts := lo.Map(toAddIDs, func(deptID uint64, _ int) model.RoleDept {
+				return model.RoleDept{RoleID: roleID, DeptID: deptID}
+			})
+			if err := s.roleDeptRepo.BatchCreate(txCtx, depts); err != nil {
+				return errors.Wrap(err, "failed to create role depts")
+			}
+		}
+		return nil
+	})
+}
+
+// retrieveDeptIDs 批量查询角色的自定义数据范围部门，key 为角色 ID
+func (s *RoleService) retrieveDeptIDs(ctx context.Context, roleIDs []uint64) (map[uint64][]uint64, error) {
+	result := make(map[uint64][]uint64, len(roleIDs))
+	if len(roleIDs) == 0 {
+		return result, nil
+	}
+	depts, err := s.roleDeptRepo.QueryBuilder().In("role_id", roleIDs).OrderBy("id").Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "query RoleDept failed")
+	}
+	for _, rd := range depts {
+		result[rd.RoleID] = append(result[rd.RoleID], rd.DeptID)
+	}
+	return result, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+var (
+	// ErrInvalidRoleParent 父角色不存在
+	ErrInvalidRoleParent = errors.New("invalid parent role")
+	// ErrRoleCycle 父角色设置会让继承关系成环（包括继承自己）
+	ErrRoleCycle = errors.New("role inheritance cycle")
+)
+
+// AssignRoleParents 设置角色的父角色（覆盖式）：对比新旧集合只做增量增删。
+// 写入前检查父角色存在且不会成环——只要父角色及其祖先里没有 roleID 本身，
+// 加上这些边之后就不会出现环。检查与写入在同一事务里，经过的角色都加了行锁
+func (s *RoleService) AssignRoleParents(ctx context.Context, roleID uint64, parentIDs []uint64) error {
+	parentIDs = lo.Uniq(parentIDs)
+	return s.roleParentRepo.Transaction(ctx, func(txCtx context.Context) error {
+		// 锁住角色本身：同一角色的并发设置排队执行，下面的增量对比不会基于过时的旧集合
+		if _, err := s.roleRepo.QueryBuilder().Eq("id", roleID).ForUpdate().First(txCtx); err != nil {
+			return errors.Wrap(err, "failed to lock role")
+		}
+		if len(parentIDs) > 0 {
+			count, err := s.roleRepo.QueryBuilder().In("id", parentIDs).Count(txCtx)
+			if err != nil {
+				return errors.Wrap(err, "failed to retrieve parent roles")
+			}
+			if count != int64(len(parentIDs)) {
+				return errors.Wrap(ErrInvalidRoleParent, "parent role does not exist")
+			}
+		}
+		if err := s.checkRoleParents(txCtx, roleID, parentIDs); err != nil {
+			return err
+		}
+
+		current, err := s.roleParentRepo.FindAll(txCtx, &model.RoleParent{RoleID: roleID})
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve role parents")
+		}
+		existingIDs := lo.Map(current, func(rp model.RoleParent, _ int) uint64 { return rp.ParentID })
+		toRemoveIDs := lo.Filter(existingIDs, func(id uint64, _ int) bool { return !lo.Contains(parentIDs, id) })
+		toAddIDs := lo.Filter(parentIDs, func(id uint64, _ int) bool { return !lo.Contains(existingIDs, id) })
+
+		if len(toRemoveIDs) > 0 {
+			if err := s.roleParentRepo.QueryBuilder().Eq("role_id", roleID).In("parent_id", toRemoveIDs).Delete(txCtx); err != nil {
+				return errors.Wrap(err, "failed to delete role parents")
+			}
+		}
+		if len(toAddIDs) > 0 {
+			parents := lo.Map(toAddIDs, func(parentID uint64, _ int) model.RoleParent {
+				return model.RoleParent{RoleID: roleID, ParentID: parentID}
+			})
+			if err := s.roleParentRepo.BatchCreate(txCtx, parents); err != nil {
+				return errors.Wrap(err, "failed to create role parents")
+			}
+		}
+		return nil
+	})
+}
+
+// checkRoleParents 沿继承关系从 parentIDs 逐层向上找，祖先里出现 roleID 本身就会成环。
+// 已删除的角色不参与继承，与 expandRolesWithCTE 一致；恢复角色时须再检查一次。
+//
+// 须在事务内调用：逐层读取时给经过的角色与它们的继承关系加行锁（SELECT ... FOR UPDATE），
+// 而不是用 CTE 做一次快照读。并发的两次变更如果合起来会成环，后一个必然要等前一个持有的某把锁，
+// 等到时锁定读看到的已是提交后的继承关系
+func (s *RoleService) checkRoleParents(ctx context.Context, roleID uint64, parentIDs []uint64) error {
+	if lo.Contains(parentIDs, roleID) {
+		return errors.Wrapf(ErrRoleCycle, "role %d cannot inherit from itself", roleID)
+	}
+
+	visited := make(map[uint64]struct{})
+	frontier := parentIDs
+	for len(frontier) > 0 {
+		roles, err := s.roleRepo.QueryBuilder().In("id", frontier).OrderBy("id").ForUpdate().Find(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to lock ancestor roles")
+		}
+		ids := lo.Map(roles, func(r model.Role, _ int) uint64 { return r.ID })
+		if lo.Contains(ids, roleID) {
+			return errors.Wrapf(ErrRoleCycle, "role %d is already an ancestor of the given parents", roleID)
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			visited[id] = struct{}{}
+		}
+
+		edges, err := s.roleParentRepo.QueryBuilder().In("role_id", ids).ForUpdate().Find(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to lock role parents")
+		}
+		frontier = lo.Uniq(lo.FilterMap(edges, func(rp model.RoleParent, _ int) (uint64, bool) {
+			_, seen := visited[rp.ParentID]
+			return rp.ParentID, !seen
+		}))
+	}
+	return nil
+}
+
+// retrieveParentIDs 批量查询角色的直接父角色 ID，key 为子角色 ID
+func (s *RoleService) retrieveParentIDs(ctx context.Context, roleIDs []uint64) (map[uint64][]uint64, error) {
+	result := make(map[uint64][]uint64, len(roleIDs))
+	if len(roleIDs) == 0 {
+		return result, nil
+	}
+	parents, err := s.roleParentRepo.QueryBuilder().In("role_id", roleIDs).OrderBy("id").Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "query RoleParent failed")
+	}
+	for _, rp := range parents {
+		result[rp.RoleID] = append(result[rp.RoleID], rp.ParentID)
+	}
+	return result, nil
+}
+
+// retrieveInheritedPermissions 角色从祖先角色继承来的权限，不含已直接分配给该角色的
+func (s *RoleService) retrieveInheritedPermissions(ctx context.Context, roleID uint64, direct []model.Permission) ([]model.Permission, error) {
+	roles, err := s.expandRolesWithCTE(ctx, []uint64{roleID})
+	if err != nil {
+		return nil, err
+	}
+	ancestorIDs := lo.FilterMap(roles, func(r model.Role, _ int) (uint64, bool) { return r.ID, r.ID != roleID })
+	permissions, err := s.retrievePermissionsByRoleIDs(ctx, ancestorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	directIDs := lo.SliceToMap(direct, func(p model.Permission) (uint64, struct{}) { return p.ID, struct{}{} })
+	return lo.Filter(permissions, func(p model.Permission, _ int) bool {
+		_, ok := directIDs[p.ID]
+		return !ok
+	}), nil
+}
+
+// expandRolesWithCTE 使用递归 CTE 沿继承关系向上展开，返回 roleIDs 自身及其全部祖先角色。
+// 用 UNION 而不是 UNION ALL：即使库里被绕过校验写入了环，去重也能让递归终止。
+// 已删除的角色不参与展开，也就不再把它的祖先带进来
+func (s *RoleService) expandRolesWithCTE(ctx context.Context, roleIDs []uint64) ([]model.Role, error) {
+	if len(roleIDs) == 0 {
+		return []model.Role{}, nil
+	}
+
+	query := `
+	WITH RECURSIVE role_tree AS (
+		SELECT id
+		FROM role
+		WHERE id IN (` + placeholders(len(roleIDs)) + `) AND ` + notDeleted("role") + `
+		UNION
+		SELECT rp.parent_id
+		FROM role_parent rp
+		JOIN role_tree rt ON rp.role_id = rt.id
+		JOIN role p ON p.id = rp.parent_id
+		WHERE ` + notDeleted("p") + `
+	)
+	SELECT r.* FROM role r
+	JOIN role_tree rt ON r.id = rt.id
+	`
+
+	roles, err := s.roleRepo.Query(ctx, query, lo.ToAnySlice(roleIDs)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to expand role hierarchy with CTE")
+	}
+	return roles, nil
+}
+
+// placeholders 生成 IN 子句的 n 个 "?" 占位符
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// notDeleted 手写 SQL 里排除软删除记录的条件。Query 不经过 xorm 的软删除处理，
+// 判断方式与 xorm 一致：删除时间为 NULL 或零值都算未删除
+func notDeleted(table string) string {
+	col := table + ".delete_time"
+	return "(" + col + " IS NULL OR " + col + " = '0001-01-01 00:00:00')"
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRoleService(t *testing.T) (*RoleService, context.Context) {
+	t.Helper()
+	db := repotest.NewDB(t, new(model.Role), new(model.RoleParent))
+	return NewRoleService(db), pkgrepo.WithTenant(context.Background(), 1)
+}
+
+func createTestRoles(t *testing.T, ctx context.Context, s *RoleService, codes ...string) []uint64 {
+	t.Helper()
+	ids := make([]uint64, 0, len(codes))
+	for _, code := range codes {
+		role := &model.Role{Name: code, Code: code, Status: 1}
+		require.NoError(t, s.roleRepo.Create(ctx, role))
+		ids = append(ids, role.ID)
+	}
+	return ids
+}
+
+func TestAssignRoleParents_RejectsCycles(t *testing.T) {
+	s, ctx := newTestRoleService(t)
+	ids := createTestRoles(t, ctx, s, "A", "B", "C")
+	a, b, c := ids[0], ids[1], ids[2]
+
+	require.NoError(t, s.AssignRoleParents(ctx, a, []uint64{b}))
+	require.NoError(t, s.AssignRoleParents(ctx, b, []uint64{c}))
+
+	assert.ErrorIs(t, s.AssignRoleParents(ctx, c, []uint64{a}), ErrRoleCycle)
+	assert.ErrorIs(t, s.AssignRoleParents(ctx, c, []uint64{c}), ErrRoleCycle)
+	assert.ErrorIs(t, s.AssignRoleParents(ctx, c, []uint64{999}), ErrInvalidRoleParent)
+
+	parents, err := s.retrieveParentIDs(ctx, []uint64{c})
+	require.NoError(t, err)
+	assert.Empty(t, parents[c], "被拒绝的设置不留下任何边")
+}
+
+// TestRestoreRole_RejectsCycle 角色删除期间继承关系绕回到它，恢复会让环重新生效
+func TestRestoreRole_RejectsCycle(t *testing.T) {
+	s, ctx := newTestRoleService(t)
+	ids := createTestRoles(t, ctx, s, "A", "B", "C")
+	a, b, c := ids[0], ids[1], ids[2]
+
+	require.NoError(t, s.AssignRoleParents(ctx, a, []uint64{b}))
+	require.NoError(t, s.AssignRoleParents(ctx, b, []uint64{c}))
+	require.NoError(t, s.DeleteRole(ctx, b))
+	// B 已删除，A 与 C 之间不再连通
+	require.NoError(t, s.AssignRoleParents(ctx, c, []uint64{a}))
+
+	restored, err := s.RestoreRoleBatch(ctx, []uint64{b})
+	assert.ErrorIs(t, err, ErrRoleCycle)
+	assert.Zero(t, restored)
+	_, err = s.roleRepo.FindByID(ctx, b)
+	assert.ErrorIs(t, err, pkgrepo.ErrNotFound, "恢复失败时角色仍在回收站")
+
+	require.NoError(t, s.AssignRoleParents(ctx, c, nil))
+	restored, err = s.RestoreRoleBatch(ctx, []uint64{b})
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+}
@@ -2,7 +2,7 @@ package service
 
 import (
 	"context"
-	"strings"
+	"time"
 
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
@@ -22,6 +22,9 @@ type RoleService struct {
 	roleRepo       *pkgrepo.Repository[model.Role]
 	permissionRepo *pkgrepo.Repository[model.Permission]
 	rolePermRepo   *pkgrepo.Repository[model.RolePermission]
+	roleParentRepo *pkgrepo.Repository[model.RoleParent]
+	roleDeptRepo   *pkgrepo.Repository[model.RoleDept]
+	userRoleRepo   *pkgrepo.Repository[model.UserRole]
 }
 
 // NewRoleService 创建角色服务。之所以在这里而不是在 bootstrap 里调用
@@ -34,6 +37,9 @@ func NewRoleService(db *pkgrepo.DB) *RoleService {
 		roleRepo:       repos.role,
 		permissionRepo: repos.permission,
 		rolePermRepo:   repos.rolePermission,
+		roleParentRepo: repos.roleParent,
+		roleDeptRepo:   repos.roleDept,
+		userRoleRepo:   repos.userRole,
 	}
 }
 
@@ -43,6 +49,9 @@ func (s *RoleService) CreateRole(ctx context.Context, req *dto.CreateRoleRequest
 	if err := copier.Copy(&role, req); err != nil {
 		return nil, errors.Wrap(err, "failed to copy request to role")
 	}
+	if role.DataScope == 0 {
+		role.DataScope = model.DataScopeAll
+	}
 
 	var result dto.RoleResponse
 	var permissionResponses []*dto.PermissionResponse
@@ -58,6 +67,18 @@ func (s *RoleService) CreateRole(ctx context.Context, req *dto.CreateRoleRequest
 				return errors.Wrap(err, "failed to assign permissions to role")
 			}
 		}
+		if len(req.ParentIDs) > 0 {
+			if err := s.AssignRoleParents(txCtx, role.ID, req.ParentIDs); err != nil {
+				logger.Error(txCtx, "Failed to assign parents to role", logger.Err(err), logger.Uint64("role_id", role.ID))
+				return errors.Wrap(err, "failed to assign parents to role")
+			}
+		}
+		if len(req.DeptIDs) > 0 {
+			if err := s.AssignRoleDepts(txCtx, role.ID, req.DeptIDs); err != nil {
+				logger.Error(txCtx, "Failed to assign depts to role", logger.Err(err), logger.Uint64("role_id", role.ID))
+				return errors.Wrap(err, "failed to assign depts to role")
+			}
+		}
 
 		if err := copier.Copy(&result, &role); err != nil {
 			return errors.Wrap(err, "failed to copy role to result")
@@ -71,7 +92,7 @@ func (s *RoleService) CreateRole(ctx context.Context, req *dto.CreateRoleRequest
 		if err := copier.Copy(&permissionResponses, &permissions); err != nil {
 			return errors.Wrap(err, "failed to copy permissions to response")
 		}
-		return nil
+		return s.fillRoleDetail(txCtx, &result, permissions)
 	})
 	if err != nil {
 		return nil, err
@@ -91,8 +112,21 @@ func (s *RoleService) UpdateRole(ctx context.Context, req *dto.UpdateRoleRequest
 	if err := copier.Copy(role, req); err != nil {
 		return nil, errors.Wrap(err, "failed to copy request to role")
 	}
+	var mustCols []string
+	if req.RequireMFA != nil {
+		role.RequireMFA = *req.RequireMFA
+		mustCols = append(mustCols, "require_mfa")
+	}
+
+	// 继承关系最可能因成环被拒绝，先于其它字段写入，失败时角色保持原样
+	if req.ParentIDs != nil {
+		if err := s.AssignRoleParents(ctx, role.ID, *req.ParentIDs); err != nil {
+			logger.Error(ctx, "Failed to assign parents to role", logger.Err(err), logger.Uint64("role_id", req.ID))
+			return nil, errors.Wrap(err, "failed to assign parents to role")
+		}
+	}
 
-	if err := s.roleRepo.Update(ctx, role); err != nil {
+	if err := s.roleRepo.Update(ctx, role, mustCols...); err != nil {
 		logger.Error(ctx, "Failed to update role", logger.Err(err), logger.Uint64("role_id", req.ID))
 		return nil, errors.Wrap(err, "failed to update role")
 	}
@@ -103,6 +137,12 @@ func (s *RoleService) UpdateRole(ctx context.Context, req *dto.UpdateRoleRequest
 			return nil, errors.Wrap(err, "failed to assign permissions to role")
 		}
 	}
+	if req.DeptIDs != nil {
+		if err := s.AssignRoleDepts(ctx, role.ID, *req.DeptIDs); err != nil {
+			logger.Error(ctx, "Failed to assign depts to role", logger.Err(err), logger.Uint64("role_id", req.ID))
+			return nil, errors.Wrap(err, "failed to assign depts to role")
+		}
+	}
 
 	var result dto.RoleResponse
 	if err := copier.Copy(&result, role); err != nil {
@@ -117,40 +157,113 @@ func (s *RoleService) UpdateRole(ctx context.Context, req *dto.UpdateRoleRequest
 	if err := copier.Copy(&result.Permissions, &permissions); err != nil {
 		return nil, errors.Wrap(err, "failed to copy permissions to response")
 	}
+	if err := s.fillRoleDetail(ctx, &result, permissions); err != nil {
+		logger.Error(ctx, "Failed to retrieve role detail", logger.Err(err), logger.Uint64("role_id", role.ID))
+		return nil, err
+	}
 
 	return &result, nil
 }
 
-// DeleteRoleBatch 批量删除角色，逐个删除并收集错误
-func (s *RoleService) DeleteRoleBatch(ctx context.Context, ids []uint64) error {
+// DeleteRoleBatch 批量删除角色，逐个删除并收集错误，返回删除成功的角色数
+func (s *RoleService) DeleteRoleBatch(ctx context.Context, ids []uint64) (int, error) {
 	var result *multierror.Error
+	deleted := 0
 	for _, id := range ids {
 		if err := s.DeleteRole(ctx, id); err != nil {
 			result = multierror.Append(result, err)
+			continue
 		}
+		deleted++
 	}
-	return result.ErrorOrNil()
+	return deleted, result.ErrorOrNil()
 }
 
-// DeleteRole 删除角色（事务内先删角色-权限关联，再删角色本身）
+// DeleteRole 软删除角色。关联数据原样保留以便恢复：已删除的角色查不出来，
+// 持有它的用户不再获得它的权限，继承链上的 CTE 也会跳过它；关联数据在 PurgeDeleted 时一并清理
 func (s *RoleService) DeleteRole(ctx context.Context, id uint64) error {
 	if _, err := s.roleRepo.FindByID(ctx, id); err != nil {
 		logger.Error(ctx, "Failed to retrieve role", logger.Err(err), logger.Uint64("role_id", id))
 		return errors.Wrap(err, "failed to retrieve role")
 	}
+	if err := s.roleRepo.DeleteByID(ctx, id); err != nil {
+		logger.Error(ctx, "Failed to delete role", logger.Err(err), logger.Uint64("role_id", id))
+		return errors.Wrap(err, "failed to delete role")
+	}
+	return nil
+}
+
+// RestoreRoleBatch 批量恢复回收站里的角色，逐个恢复并收集错误，返回恢复成功的角色数
+func (s *RoleService) RestoreRoleBatch(ctx context.Context, ids []uint64) (int, error) {
+	var result *multierror.Error
+	restored := 0
+	for _, id := range ids {
+		if err := s.restoreRole(ctx, id); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to restore role %d", id))
+			continue
+		}
+		restored++
+	}
+	if err := result.ErrorOrNil(); err != nil {
+		logger.Error(ctx, "Failed to restore roles", logger.Err(err), logger.Uint64s("role_ids", ids))
+		return restored, err
+	}
+	return restored, nil
+}
 
+// restoreRole 恢复单个角色。删除期间其它角色的继承关系可能已经绕回到它，
+// 它原有的父角色一旦重新生效就会成环，这种情况拒绝恢复
+func (s *RoleService) restoreRole(ctx context.Context, id uint64) error {
 	return s.roleRepo.Transaction(ctx, func(txCtx context.Context) error {
-		if err := s.rolePermRepo.QueryBuilder().Eq("role_id", id).Delete(txCtx); err != nil {
+		if err := s.roleRepo.Restore(txCtx, id); err != nil {
+			return err
+		}
+		parents, err := s.roleParentRepo.FindAll(txCtx, &model.RoleParent{RoleID: id})
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve role parents")
+		}
+		return s.checkRoleParents(txCtx, id, lo.Map(parents, func(rp model.RoleParent, _ int) uint64 { return rp.ParentID }))
+	})
+}
+
+// PurgeDeleted 物理删除 before 之前软删除的角色，连同权限分配、继承关系、数据范围与用户分配，返回清理的角色数
+func (s *RoleService) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	roles, err := s.roleRepo.QueryBuilder().Lt("delete_time", before).Find(pkgrepo.OnlyDeleted(ctx))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve deleted roles")
+	}
+	if len(roles) == 0 {
+		return 0, nil
+	}
+	ids := lo.Map(roles, func(r model.Role, _ int) uint64 { return r.ID })
+
+	err = s.roleRepo.Transaction(ctx, func(txCtx context.Context) error {
+		if err := s.rolePermRepo.QueryBuilder().In("role_id", ids).Delete(txCtx); err != nil {
 			return errors.Wrap(err, "failed to delete role permissions")
 		}
-		if err := s.roleRepo.DeleteByID(txCtx, id); err != nil {
-			return errors.Wrap(err, "failed to delete role")
+		// 作为子角色和作为父角色的继承关系都要删掉
+		if err := s.roleParentRepo.QueryBuilder().In("role_id", ids).Delete(txCtx); err != nil {
+			return errors.Wrap(err, "failed to delete role parents")
 		}
-		return nil
+		if err := s.roleParentRepo.QueryBuilder().In("parent_id", ids).Delete(txCtx); err != nil {
+			return errors.Wrap(err, "failed to delete role children")
+		}
+		if err := s.roleDeptRepo.QueryBuilder().In("role_id", ids).Delete(txCtx); err != nil {
+			return errors.Wrap(err, "failed to delete role depts")
+		}
+		if err := s.userRoleRepo.QueryBuilder().In("role_id", ids).Delete(txCtx); err != nil {
+			return errors.Wrap(err, "failed to delete user roles")
+		}
+		return s.roleRepo.Purge(txCtx, ids)
 	})
+	if err != nil {
+		logger.Error(ctx, "Failed to purge deleted roles", logger.Err(err), logger.Uint64s("role_ids", ids))
+		return 0, errors.Wrap(err, "failed to purge deleted roles")
+	}
+	return len(ids), nil
 }
 
-// GetRole 获取单个角色（附带权限列表）
+// GetRole 获取单个角色（附带直接分配与继承来的权限）
 func (s *RoleService) GetRole(ctx context.Context, id uint64) (*dto.RoleResponse, error) {
 	role, err := s.roleRepo.FindByID(ctx, id)
 	if err != nil {
@@ -171,13 +284,21 @@ func (s *RoleService) GetRole(ctx context.Context, id uint64) (*dto.RoleResponse
 	if err := copier.Copy(&result.Permissions, &permissions); err != nil {
 		return nil, errors.Wrap(err, "failed to copy permissions to response")
 	}
+	if err := s.fillRoleDetail(ctx, &result, permissions); err != nil {
+		logger.Error(ctx, "Failed to retrieve role detail", logger.Err(err), logger.Uint64("role_id", role.ID))
+		return nil, err
+	}
 
 	return &result, nil
 }
 
-// GetRoleList 获取角色列表，Flags 控制是否展开每个角色的权限
+// GetRoleList 获取角色列表，Flags 控制是否展开每个角色的权限；req.Deleted 为 true 时查回收站
 func (s *RoleService) GetRoleList(ctx context.Context, req *dto.GetRoleListRequest) ([]*dto.RoleResponse, int64, error) {
-	roles, total, err := s.roleRepo.FindPage(ctx, req, req.Limit, req.Offset)
+	listCtx := ctx
+	if req.Deleted {
+		listCtx = pkgrepo.OnlyDeleted(ctx)
+	}
+	roles, total, err := s.roleRepo.FindPage(listCtx, req, req.Limit, req.Offset)
 	if err != nil {
 		logger.Error(ctx, "Failed to retrieve roles", logger.Err(err))
 		return nil, 0, errors.Wrap(err, "failed to retrieve roles")
@@ -188,6 +309,22 @@ func (s *RoleService) GetRoleList(ctx context.Context, req *dto.GetRoleListReque
 		return nil, 0, errors.Wrap(err, "failed to copy roles to result")
 	}
 
+	roleIDs := lo.Map(roles, func(r model.Role, _ int) uint64 { return r.ID })
+	parentIDs, err := s.retrieveParentIDs(ctx, roleIDs)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve role parents", logger.Err(err))
+		return nil, 0, errors.Wrap(err, "failed to retrieve role parents")
+	}
+	deptIDs, err := s.retrieveDeptIDs(ctx, roleIDs)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve role depts", logger.Err(err))
+		return nil, 0, errors.Wrap(err, "failed to retrieve role depts")
+	}
+	for _, item := range result {
+		item.ParentIDs = nonNilIDs(parentIDs[item.ID])
+		item.DeptIDs = deptIDs[item.ID]
+	}
+
 	if !dto.NewResponseFlags(req.Flags).Has(dto.INCLUDE_PERMISSION) {
 		return result, total, nil
 	}
@@ -201,6 +338,14 @@ func (s *RoleService) GetRoleList(ctx context.Context, req *dto.GetRoleListReque
 		if err := copier.Copy(&result[i].Permissions, &permissions); err != nil {
 			return nil, 0, errors.Wrap(err, "failed to copy permissions to response")
 		}
+		inherited, err := s.retrieveInheritedPermissions(ctx, role.ID, permissions)
+		if err != nil {
+			logger.Error(ctx, "Failed to retrieve inherited permissions", logger.Err(err), logger.Uint64("role_id", role.ID))
+			return nil, 0, err
+		}
+		if err := copier.Copy(&result[i].InheritedPermissions, &inherited); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to copy permissions to response")
+		}
 	}
 
 	return result, total, nil
@@ -253,8 +398,17 @@ func (s *RoleService) AssignRolePermissions(ctx context.Context, roleID uint64,
 // RetrievePermissionByRoleID 通过角色 ID 查询关联权限，是 RoleService 对外
 // 暴露的读接口——iam 内的 UserRoleService/PermissionChecker 都组合本服务
 // 来复用这段查询逻辑，而不是各自重新连表查询。
+// 只含直接分配给该角色的权限，继承来的见 retrieveInheritedPermissions。
 func (s *RoleService) RetrievePermissionByRoleID(ctx context.Context, roleID uint64) ([]model.Permission, error) {
-	rolePermissions, err := s.rolePermRepo.QueryBuilder().Eq("role_id", roleID).Find(ctx)
+	return s.retrievePermissionsByRoleIDs(ctx, []uint64{roleID})
+}
+
+// retrievePermissionsByRoleIDs 批量查询多个角色直接分配的权限（已去重）
+func (s *RoleService) retrievePermissionsByRoleIDs(ctx context.Context, roleIDs []uint64) ([]model.Permission, error) {
+	if len(roleIDs) == 0 {
+		return []model.Permission{}, nil
+	}
+	rolePermissions, err := s.rolePermRepo.QueryBuilder().In("role_id", roleIDs).Find(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "query RolePermission failed")
 	}
@@ -262,7 +416,7 @@ func (s *RoleService) RetrievePermissionByRoleID(ctx context.Context, roleID uin
 		return []model.Permission{}, nil
 	}
 
-	permissionIDs := lo.Map(rolePermissions, func(rp model.RolePermission, _ int) uint64 { return rp.PermissionID })
+	permissionIDs := lo.Uniq(lo.Map(rolePermissions, func(rp model.RolePermission, _ int) uint64 { return rp.PermissionID }))
 	permissions, err := s.permissionRepo.QueryBuilder().In("id", permissionIDs).Find(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "query Permission failed")
@@ -270,26 +424,23 @@ func (s *RoleService) RetrievePermissionByRoleID(ctx context.Context, roleID uin
 	return permissions, nil
 }
 
-// getChildPermissionsWithCTE 使用递归 CTE 查询所有子权限（供 PermissionChecker 复用）
+// getChildPermissionsWithCTE 使用递归 CTE 查询所有子权限（供 PermissionChecker 复用）。
+// 已删除的权限连同它下面的子树一起跳过
 func (s *RoleService) getChildPermissionsWithCTE(ctx context.Context, parentIDs []uint64) ([]model.Permission, error) {
 	if len(parentIDs) == 0 {
 		return []model.Permission{}, nil
 	}
 
-	placeholders := make([]string, len(parentIDs))
-	for i := range placeholders {
-		placeholders[i] = "?"
-	}
-
 	query := `
 	WITH RECURSIVE permission_tree AS (
 		SELECT id, parent_id
 		FROM permission
-		WHERE id IN (` + strings.Join(placeholders, ", ") + `)
+		WHERE id IN (` + placeholders(len(parentIDs)) + `) AND ` + notDeleted("permission") + `
 		UNION ALL
 		SELECT p.id, p.parent_id
 		FROM permission p
 		JOIN permission_tree pt ON p.parent_id = pt.id
+		WHERE ` + notDeleted("p") + `
 	)
 	SELECT p.* FROM permission p
 	JOIN permission_tree pt ON p.id = pt.id
@@ -301,3 +452,35 @@ func (s *RoleService) getChildPermissionsWithCTE(ctx context.Context, parentIDs
 	}
 	return childPermissions, nil
 }
+
+// fillRoleDetail 填充角色详情里的父角色、继承来的权限与自定义数据范围，direct 为已查出的直接权限
+func (s *RoleService) fillRoleDetail(ctx context.Context, result *dto.RoleResponse, direct []model.Permission) error {
+	parentIDs, err := s.retrieveParentIDs(ctx, []uint64{result.ID})
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve role parents")
+	}
+	result.ParentIDs = nonNilIDs(parentIDs[result.ID])
+
+	deptIDs, err := s.retrieveDeptIDs(ctx, []uint64{result.ID})
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve role depts")
+	}
+	result.DeptIDs = deptIDs[result.ID]
+
+	inherited, err := s.retrieveInheritedPermissions(ctx, result.ID, direct)
+	if err != nil {
+		return err
+	}
+	if err := copier.Copy(&result.InheritedPermissions, &inherited); err != nil {
+		return errors.Wrap(err, "failed to copy permissions to response")
+	}
+	return nil
+}
+
+// nonNilIDs 没有父角色时返回空数组，JSON 里输出 [] 而不是 null
+func nonNilIDs(ids []uint64) []uint64 {
+	if ids == nil {
+		return []uint64{}
+	}
+	return ids
+}
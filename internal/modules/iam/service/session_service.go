@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/session"
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/tokenstore"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/jinzhu/copier"
+	"github.com/pkg/errors"
+)
+
+// SessionService 把"会话登记"（session.Store）与"令牌失效"（tokenstore 的家族撤销）
+// 串在一起：会话 ID 就是令牌家族 ID，结束会话 = 撤销家族 + 删除登记记录。
+// AuthService 在登录/刷新时调用它记账，SessionHandler 用它做列表与踢下线。
+type SessionService struct {
+	store      session.Store
+	tokenStore tokenstore.TokenStore
+	jwt        *jwtauth.JWT
+}
+
+// NewSessionService 创建会话服务
+func NewSessionService(store session.Store, tokenStore tokenstore.TokenStore, jwt *jwtauth.JWT) *SessionService {
+	return &SessionService{store: store, tokenStore: tokenStore, jwt: jwt}
+}
+
+// Start 登记一次登录产生的新会话
+func (s *SessionService) Start(ctx context.Context, userID uint64, familyID string, client dto.ClientInfo) error {
+	now := time.Now()
+	sess := &session.Session{
+		ID:          familyID,
+		UserID:      userID,
+		Device:      client.Device,
+		IP:          client.IP,
+		UserAgent:   client.UserAgent,
+		IssuedAt:    now,
+		LastRefresh: now,
+		ExpiresAt:   now.Add(s.jwt.RefreshTokenExpiration()),
+	}
+	if err := s.store.Save(ctx, sess); err != nil {
+		logger.Error(ctx, "Failed to save session", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to save session")
+	}
+	return nil
+}
+
+// Touch 刷新令牌后更新会话的最后刷新时间与过期时间。找不到登记记录（升级前签发的
+// token 轮换出的新家族）时补登一条，保证此后它也能在列表里被看见、被撤销。
+func (s *SessionService) Touch(ctx context.Context, userID uint64, familyID string, client dto.ClientInfo) error {
+	sess, err := s.store.Get(ctx, userID, familyID)
+	if errors.Is(err, session.ErrNotFound) {
+		return s.Start(ctx, userID, familyID, client)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to get session", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to get session")
+	}
+
+	now := time.Now()
+	sess.LastRefresh = now
+	sess.ExpiresAt = now.Add(s.jwt.RefreshTokenExpiration())
+	sess.IP = client.IP
+	sess.UserAgent = client.UserAgent
+	if err := s.store.Save(ctx, sess); err != nil {
+		logger.Error(ctx, "Failed to save session", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to save session")
+	}
+	return nil
+}
+
+// List 列出用户的活跃会话，currentID 为发起请求的会话 ID，用于标记"当前设备"
+func (s *SessionService) List(ctx context.Context, userID uint64, currentID string) ([]*dto.SessionResponse, error) {
+	sessions, err := s.store.List(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to list sessions", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to list sessions")
+	}
+
+	result := make([]*dto.SessionResponse, 0, len(sessions))
+	if err := copier.Copy(&result, &sessions); err != nil {
+		return nil, errors.Wrap(err, "failed to copy sessions")
+	}
+	for _, item := range result {
+		item.Current = currentID != "" && item.ID == currentID
+	}
+	return result, nil
+}
+
+// Revoke 撤销用户的某个会话；会话不属于该用户或已结束时返回 session.ErrNotFound
+func (s *SessionService) Revoke(ctx context.Context, userID uint64, id string) error {
+	if _, err := s.store.Get(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.End(ctx, userID, id)
+}
+
+// RevokeAll 撤销用户的全部会话（"退出所有设备" / 管理员强制下线），返回撤销数量
+func (s *SessionService) RevokeAll(ctx context.Context, userID uint64) (int, error) {
+	sessions, err := s.store.List(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to list sessions", logger.Err(err), logger.Uint64("user_id", userID))
+		return 0, errors.Wrap(err, "failed to list sessions")
+	}
+	for _, sess := range sessions {
+		if err := s.End(ctx, userID, sess.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(sessions), nil
+}
+
+// RevokeOtherSessions 实现 user.TokenRevoker：修改密码后让其它设备下线，发起修改的当前会话保留。
+// 与 RevokeUserTokens 不同，这里逐个撤销家族而不写用户级撤销——后者按签发时间一刀切，
+// 会把当前会话也一起踢掉
+func (s *SessionService) RevokeOtherSessions(ctx context.Context, userID uint64, keepID string) error {
+	sessions, err := s.store.List(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to list sessions", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to list sessions")
+	}
+	for _, sess := range sessions {
+		if sess.ID == keepID {
+			continue
+		}
+		if err := s.End(ctx, userID, sess.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeUserTokens 实现 user.TokenRevoker：账号停用/删除时让该用户此前签发的全部 token
+// 立即失效。用户级撤销覆盖了没有登记会话的旧 token，家族撤销做不到这一点；
+// 会话记录随后清掉，列表里不再显示这些已失效的设备。
+func (s *SessionService) RevokeUserTokens(ctx context.Context, userID uint64) error {
+	now := time.Now()
+	if err := s.tokenStore.RevokeUser(ctx, strconv.FormatUint(userID, 10), now, now.Add(s.jwt.RefreshTokenExpiration())); err != nil {
+		logger.Error(ctx, "Failed to revoke user tokens", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to revoke user tokens")
+	}
+
+	sessions, err := s.store.List(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to list sessions", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to list sessions")
+	}
+	for _, sess := range sessions {
+		if err := s.store.Delete(ctx, userID, sess.ID); err != nil {
+			logger.Error(ctx, "Failed to delete session", logger.Err(err), logger.Uint64("user_id", userID))
+			return errors.Wrap(err, "failed to delete session")
+		}
+	}
+	return nil
+}
+
+// End 结束一个会话：先撤销令牌家族再删登记记录，顺序反过来的话中途失败会留下
+// "列表里看不见、token 却还能用"的会话。不校验会话是否存在，供登出与重放检测复用。
+func (s *SessionService) End(ctx context.Context, userID uint64, id string) error {
+	if id == "" {
+		return nil
+	}
+	if err := s.tokenStore.RevokeFamily(ctx, id, time.Now().Add(s.jwt.RefreshTokenExpiration())); err != nil {
+		logger.Error(ctx, "Failed to revoke token family", logger.Err(err), logger.String("family_id", id))
+		return errors.Wrap(err, "failed to revoke token family")
+	}
+	if err := s.store.Delete(ctx, userID, id); err != nil {
+		logger.Error(ctx, "Failed to delete session", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to delete session")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/pkg/errors"
+)
+
+// UserPurger 实现 user.RelatedDataPurger：用户被物理删除时一并删除其外部身份绑定、角色分配、
+// 两步验证与个人访问令牌。只依赖 *DB，先于 user.Service 构造，装配时不会绕成环。
+// 扮演记录属于审计数据，不随用户删除
+type UserPurger struct {
+	identity *pkgrepo.Repository[model.UserIdentity]
+	userRole *pkgrepo.Repository[model.UserRole]
+	mfa      *pkgrepo.Repository[model.UserMFA]
+	token    *pkgrepo.Repository[model.PersonalToken]
+}
+
+// NewUserPurger 创建用户关联数据清理器
+func NewUserPurger(db *pkgrepo.DB) *UserPurger {
+	return &UserPurger{
+		identity: pkgrepo.NewRepository[model.UserIdentity](db),
+		userRole: pkgrepo.NewRepository[model.UserRole](db),
+		mfa:      pkgrepo.NewRepository[model.UserMFA](db),
+		token:    pkgrepo.NewRepository[model.PersonalToken](db),
+	}
+}
+
+// PurgeUsers 实现 user.RelatedDataPurger
+func (p *UserPurger) PurgeUsers(ctx context.Context, userIDs []uint64) error {
+	if err := p.identity.QueryBuilder().In("user_id", userIDs).Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete user identities")
+	}
+	if err := p.userRole.QueryBuilder().In("user_id", userIDs).Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete user roles")
+	}
+	if err := p.mfa.QueryBuilder().In("user_id", userIDs).Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete user mfa")
+	}
+	if err := p.token.QueryBuilder().In("user_id", userIDs).Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to delete personal tokens")
+	}
+	return nil
+}
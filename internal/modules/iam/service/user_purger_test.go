@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
+	usermodel "github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeDeletedUsers_RemovesIdentities(t *testing.T) {
+	db := repotest.NewDB(t, new(usermodel.User), new(usermodel.PasswordHistory),
+		new(model.UserIdentity), new(model.UserRole), new(model.UserMFA), new(model.PersonalToken))
+	users := pkgrepo.NewRepository[usermodel.User](db)
+	identities := pkgrepo.NewRepository[model.UserIdentity](db)
+	userRoles := pkgrepo.NewRepository[model.UserRole](db)
+	ctx := context.Background()
+
+	for _, u := range []usermodel.User{{ID: 1, Username: "gone", Email: "gone@example.com"}, {ID: 2, Username: "kept", Email: "kept@example.com"}} {
+		require.NoError(t, users.Create(ctx, &u))
+		require.NoError(t, identities.Create(ctx, &model.UserIdentity{UserID: u.ID, Issuer: "https://idp.example.com", Subject: u.Username}))
+		require.NoError(t, userRoles.Create(ctx, &model.UserRole{UserID: u.ID, RoleID: 1}))
+	}
+	require.NoError(t, users.DeleteByID(ctx, uint64(1)))
+
+	svc := usersvc.NewService(db, nil, nil, nil, NewUserPurger(db))
+	purged, err := svc.PurgeDeleted(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	// 同一个 IdP 账号的绑定已随用户删除，不会再把登录指向不存在的用户
+	left, err := identities.QueryBuilder().Find(ctx)
+	require.NoError(t, err)
+	require.Len(t, left, 1)
+	assert.Equal(t, uint64(2), left[0].UserID)
+
+	roles, err := userRoles.QueryBuilder().Find(ctx)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, uint64(2), roles[0].UserID)
+}
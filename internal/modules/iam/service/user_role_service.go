@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/iam/model"
@@ -13,6 +14,13 @@ import (
 	"github.com/samber/lo"
 )
 
+var (
+	// ErrInvalidRoleWindow 临时角色的有效期不合法（结束不晚于开始，或已经过期）
+	ErrInvalidRoleWindow = errors.New("invalid role validity window")
+	// ErrRoleNotFound 要分配的角色不存在，或属于别的租户
+	ErrRoleNotFound = errors.New("role not found")
+)
+
 // UserRoleService 负责"用户拥有哪些角色"这一件事：分配、查询、以及为登录/
 // 鉴权场景提供角色数据。之所以从旧版 PermissionService 里单独拆出来，是因为
 // 它的变更方向（哪个用户属于哪个角色）与 RoleService（角色本身长什么样）
@@ -43,8 +51,21 @@ func (s *UserRoleService) AssignRoles(ctx context.Context, userID uint64, roleID
 	return s.assignUserRoles(ctx, userID, roleIDs)
 }
 
-// assignUserRoles 对比新旧角色集合，只做增量的删除与新增
+// assignUserRoles 对比新旧角色集合，只做增量的删除与新增。
+// 保留下来的分配沿用原有效期，新增的分配长期有效
 func (s *UserRoleService) assignUserRoles(ctx context.Context, userID uint64, roleIDs []uint64) error {
+	// 角色按租户隔离，计数时只数得到本租户的角色，别的租户的角色 ID 传进来会少一个
+	roleIDs = lo.Uniq(roleIDs)
+	if len(roleIDs) > 0 {
+		count, err := s.roleSvc.roleRepo.QueryBuilder().In("id", roleIDs).Count(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to check roles")
+		}
+		if count != int64(len(roleIDs)) {
+			return errors.WithStack(ErrRoleNotFound)
+		}
+	}
+
 	current, err := s.userRoleRepo.FindAll(ctx, &model.UserRole{UserID: userID})
 	if err != nil {
 		return errors.Wrap(err, "failed to retrieve user roles")
@@ -72,13 +93,56 @@ func (s *UserRoleService) assignUserRoles(ctx context.Context, userID uint64, ro
 	})
 }
 
-// RetrieveRolesByUserID 通过用户 ID 查询关联角色（不含权限展开），
+// GrantTemporaryRole 为用户设置一个带有效期的角色：已有该角色时只改有效期，否则新增。
+// validFrom 为空表示立即生效，validUntil 为空表示长期有效
+func (s *UserRoleService) GrantTemporaryRole(ctx context.Context, userID, roleID uint64, validFrom, validUntil *time.Time) error {
+	if validUntil != nil && (!validUntil.After(time.Now()) || (validFrom != nil && !validUntil.After(*validFrom))) {
+		return errors.Wrap(ErrInvalidRoleWindow, "valid_until must be in the future and after valid_from")
+	}
+	if _, err := s.userFinder.FindByID(ctx, userID); err != nil {
+		logger.Error(ctx, "Failed to retrieve user", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to retrieve user")
+	}
+	if _, err := s.roleSvc.roleRepo.FindByID(ctx, roleID); err != nil {
+		if errors.Is(err, pkgrepo.ErrNotFound) {
+			return errors.WithStack(ErrRoleNotFound)
+		}
+		logger.Error(ctx, "Failed to retrieve role", logger.Err(err), logger.Uint64("role_id", roleID))
+		return errors.Wrap(err, "failed to retrieve role")
+	}
+
+	current, err := s.userRoleRepo.Find(ctx, &model.UserRole{UserID: userID, RoleID: roleID})
+	if err != nil && !errors.Is(err, pkgrepo.ErrNotFound) {
+		return errors.Wrap(err, "failed to retrieve user role")
+	}
+	if current == nil {
+		userRole := &model.UserRole{UserID: userID, RoleID: roleID, ValidFrom: validFrom, ValidUntil: validUntil}
+		if err := s.userRoleRepo.Create(ctx, userRole); err != nil {
+			logger.Error(ctx, "Failed to create user role", logger.Err(err), logger.Uint64("user_id", userID))
+			return errors.Wrap(err, "failed to create user role")
+		}
+		return nil
+	}
+
+	// 两列都可能被改回 NULL，必须显式列出
+	current.ValidFrom, current.ValidUntil = validFrom, validUntil
+	if err := s.userRoleRepo.Update(ctx, current, "valid_from", "valid_until"); err != nil {
+		logger.Error(ctx, "Failed to update user role", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to update user role")
+	}
+	return nil
+}
+
+// RetrieveRolesByUserID 通过用户 ID 查询当前生效的关联角色（不含权限展开），
 // 是本服务对外暴露的读接口，PermissionChecker 组合本方法计算用户权限集合。
+// 未到生效时间或已过期的临时分配不计入
 func (s *UserRoleService) RetrieveRolesByUserID(ctx context.Context, userID uint64) ([]model.Role, error) {
 	userRoles, err := s.userRoleRepo.QueryBuilder().Eq("user_id", userID).Find(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "query UserRole failed")
 	}
+	now := time.Now()
+	userRoles = lo.Filter(userRoles, func(ur model.UserRole, _ int) bool { return ur.ActiveAt(now) })
 	if len(userRoles) == 0 {
 		return []model.Role{}, nil
 	}
@@ -91,6 +155,103 @@ func (s *UserRoleService) RetrieveRolesByUserID(ctx context.Context, userID uint
 	return roles, nil
 }
 
+// RoleIDsByCodes 实现 user.RoleDirectory：按 code 查本租户的角色 ID
+func (s *UserRoleService) RoleIDsByCodes(ctx context.Context, codes []string) (map[string]uint64, error) {
+	ids := make(map[string]uint64, len(codes))
+	if len(codes) == 0 {
+		return ids, nil
+	}
+	roles, err := s.roleSvc.roleRepo.QueryBuilder().In("code", lo.Uniq(codes)).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "query Role failed")
+	}
+	for _, r := range roles {
+		ids[r.Code] = r.ID
+	}
+	return ids, nil
+}
+
+// RoleCodesByUsers 实现 user.RoleDirectory：一批用户当前生效的角色 code，
+// 导出时按页调用，每页两次查询，不逐个用户查
+func (s *UserRoleService) RoleCodesByUsers(ctx context.Context, userIDs []uint64) (map[uint64][]string, error) {
+	codes := make(map[uint64][]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return codes, nil
+	}
+	userRoles, err := s.userRoleRepo.QueryBuilder().In("user_id", userIDs).OrderBy("id").Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "query UserRole failed")
+	}
+	now := time.Now()
+	userRoles = lo.Filter(userRoles, func(ur model.UserRole, _ int) bool { return ur.ActiveAt(now) })
+	if len(userRoles) == 0 {
+		return codes, nil
+	}
+
+	roleIDs := lo.Uniq(lo.Map(userRoles, func(ur model.UserRole, _ int) uint64 { return ur.RoleID }))
+	roles, err := s.roleSvc.roleRepo.QueryBuilder().In("id", roleIDs).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "query Role failed")
+	}
+	codeByID := lo.SliceToMap(roles, func(r model.Role) (uint64, string) { return r.ID, r.Code })
+	for _, ur := range userRoles {
+		if code, ok := codeByID[ur.RoleID]; ok {
+			codes[ur.UserID] = append(codes[ur.UserID], code)
+		}
+	}
+	return codes, nil
+}
+
+// nextRoleChange 用户的角色集合下一次因有效期发生变化的时刻，没有临时分配时返回零值
+func (s *UserRoleService) nextRoleChange(ctx context.Context, userID uint64) (time.Time, error) {
+	userRoles, err := s.userRoleRepo.QueryBuilder().Eq("user_id", userID).Find(ctx)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "query UserRole failed")
+	}
+	now := time.Now()
+	var next time.Time
+	for _, ur := range userRoles {
+		if t := ur.NextChange(now); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next, nil
+}
+
+// deleteExpiredAssignments 删除在 now 之前到期的分配，返回受影响的用户 ID
+func (s *UserRoleService) deleteExpiredAssignments(ctx context.Context, now time.Time) ([]uint64, error) {
+	expired, err := s.userRoleRepo.QueryBuilder().Lte("valid_until", now).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "query expired UserRole failed")
+	}
+	if len(expired) == 0 {
+		return []uint64{}, nil
+	}
+
+	ids := lo.Map(expired, func(ur model.UserRole, _ int) uint64 { return ur.ID })
+	if err := s.userRoleRepo.QueryBuilder().In("id", ids).Delete(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to delete expired user roles")
+	}
+	return lo.Uniq(lo.Map(expired, func(ur model.UserRole, _ int) uint64 { return ur.UserID })), nil
+}
+
+// retrieveRoleWindows 用户带有效期的分配（含尚未生效的），供管理端查看
+func (s *UserRoleService) retrieveRoleWindows(ctx context.Context, userID uint64) ([]*dto.UserRoleWindow, error) {
+	userRoles, err := s.userRoleRepo.QueryBuilder().Eq("user_id", userID).OrderBy("id").Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "query UserRole failed")
+	}
+	now := time.Now()
+	return lo.FilterMap(userRoles, func(ur model.UserRole, _ int) (*dto.UserRoleWindow, bool) {
+		return &dto.UserRoleWindow{
+			RoleID:     ur.RoleID,
+			ValidFrom:  ur.ValidFrom,
+			ValidUntil: ur.ValidUntil,
+			Active:     ur.ActiveAt(now),
+		}, ur.ValidFrom != nil || ur.ValidUntil != nil
+	}), nil
+}
+
 // RetrieveRoleResponsesByUserID 查询用户角色并按 flags 决定是否展开权限
 func (s *UserRoleService) RetrieveRoleResponsesByUserID(ctx context.Context, userID uint64, flags int) ([]*dto.RoleResponse, error) {
 	roles, err := s.RetrieveRolesByUserID(ctx, userID)
@@ -135,11 +296,18 @@ func (s *UserRoleService) GetUserRoles(ctx context.Context, userID uint64, flags
 		return nil, errors.Wrap(err, "failed to retrieve user roles")
 	}
 
+	windows, err := s.retrieveRoleWindows(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user role windows", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to retrieve user role windows")
+	}
+
 	return &dto.UserRolesResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Roles:    roles,
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Roles:     roles,
+		Temporary: windows,
 	}, nil
 }
 
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/pkg/store"
+)
+
+// InMemoryStore 进程内会话登记表。以用户为粒度存一张 会话ID→Session 的表，
+// 整张表存活到其中最晚过期的会话为止；单条过期会话在读取时过滤。
+// 与 InMemoryTokenStore 一样不支持多实例。
+type InMemoryStore struct {
+	mu    sync.Mutex // 保护对单个用户会话表的读-改-写
+	users *store.Memory[uint64, map[string]Session]
+}
+
+// NewInMemoryStore 创建进程内会话登记表
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{users: store.NewMemory[uint64, map[string]Session](0)}
+}
+
+// Save 新增或覆盖会话
+func (s *InMemoryStore) Save(_ context.Context, sess *Session) error {
+	if sess == nil || sess.ID == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sessions := make(map[string]Session)
+	old, _ := s.users.Get(sess.UserID)
+	for id, item := range old {
+		if item.ExpiresAt.After(now) {
+			sessions[id] = item
+		}
+	}
+	sessions[sess.ID] = *sess
+	s.users.SetUntil(sess.UserID, sessions, latestExpiry(sessions))
+	return nil
+}
+
+// Get 读取单条会话
+func (s *InMemoryStore) Get(_ context.Context, userID uint64, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, _ := s.users.Get(userID)
+	item, ok := sessions[id]
+	if !ok || !item.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &item, nil
+}
+
+// List 列出用户未过期的会话
+func (s *InMemoryStore) List(_ context.Context, userID uint64) ([]Session, error) {
+	s.mu.Lock()
+	sessions, _ := s.users.Get(userID)
+	now := time.Now()
+	result := make([]Session, 0, len(sessions))
+	for _, item := range sessions {
+		if item.ExpiresAt.After(now) {
+			result = append(result, item)
+		}
+	}
+	s.mu.Unlock()
+
+	sortByIssuedAtDesc(result)
+	return result, nil
+}
+
+// Delete 删除一条会话
+func (s *InMemoryStore) Delete(_ context.Context, userID uint64, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.users.Get(userID)
+	if !ok {
+		return nil
+	}
+	if _, exists := old[id]; !exists {
+		return nil
+	}
+	// 存进 store 的 map 不原地修改，复制后整体替换，避免与并发读取共享底层 map
+	sessions := make(map[string]Session, len(old))
+	for k, v := range old {
+		if k != id {
+			sessions[k] = v
+		}
+	}
+	if len(sessions) == 0 {
+		s.users.Delete(userID)
+		return nil
+	}
+	s.users.SetUntil(userID, sessions, latestExpiry(sessions))
+	return nil
+}
+
+func latestExpiry(sessions map[string]Session) time.Time {
+	var latest time.Time
+	for _, item := range sessions {
+		if item.ExpiresAt.After(latest) {
+			latest = item.ExpiresAt
+		}
+	}
+	return latest
+}
+
+func sortByIssuedAtDesc(sessions []Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].IssuedAt.After(sessions[j].IssuedAt)
+	})
+}
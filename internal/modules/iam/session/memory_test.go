@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSession(userID uint64, id string, issuedAt time.Time) *Session {
+	return &Session{
+		ID:          id,
+		UserID:      userID,
+		Device:      "web",
+		IP:          "127.0.0.1",
+		UserAgent:   "test-agent",
+		IssuedAt:    issuedAt,
+		LastRefresh: issuedAt,
+		ExpiresAt:   issuedAt.Add(time.Hour),
+	}
+}
+
+func TestInMemoryStore_SaveListDelete(t *testing.T) {
+	s := NewInMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, s.Save(ctx, newTestSession(1, "old", now.Add(-time.Minute))))
+	require.NoError(t, s.Save(ctx, newTestSession(1, "new", now)))
+	require.NoError(t, s.Save(ctx, newTestSession(2, "other", now)))
+
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "new", list[0].ID, "按签发时间倒序")
+
+	got, err := s.Get(ctx, 1, "old")
+	require.NoError(t, err)
+	require.Equal(t, "test-agent", got.UserAgent)
+
+	// 其他用户的会话 ID 不能跨用户读取
+	_, err = s.Get(ctx, 1, "other")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Delete(ctx, 1, "old"))
+	require.NoError(t, s.Delete(ctx, 1, "missing"))
+	list, err = s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+}
+
+func TestInMemoryStore_ExpiredSessionHidden(t *testing.T) {
+	s := NewInMemoryStore()
+	ctx := context.Background()
+
+	expired := newTestSession(1, "expired", time.Now().Add(-2*time.Hour))
+	require.NoError(t, s.Save(ctx, expired))
+	require.NoError(t, s.Save(ctx, newTestSession(1, "alive", time.Now())))
+
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "alive", list[0].ID)
+
+	_, err = s.Get(ctx, 1, "expired")
+	require.ErrorIs(t, err, ErrNotFound)
+}
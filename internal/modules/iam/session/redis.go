@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+)
+
+const defaultKeyPrefix = "go_admin:session:"
+
+// RedisStore 基于 Redis 的会话登记表：每个用户一个 Hash，field 为会话 ID，value 为
+// Session 的 JSON。Hash 的 TTL 随每次写入顺延到最新会话的过期时间——refresh 有效期
+// 是全局配置，最新写入的会话总是最晚过期，因此不会把更早写入、更晚过期的会话提前清掉。
+type RedisStore struct {
+	client    *pkgredis.Client
+	keyPrefix string
+}
+
+// NewRedisStore 创建 Redis 会话登记表。client 必须非 nil；prefix 为空时用默认前缀。
+func NewRedisStore(client *pkgredis.Client, keyPrefix string) *RedisStore {
+	if keyPrefix == "" {
+		keyPrefix = defaultKeyPrefix
+	}
+	if !strings.HasSuffix(keyPrefix, ":") {
+		keyPrefix += ":"
+	}
+	return &RedisStore{client: client, keyPrefix: keyPrefix}
+}
+
+func (s *RedisStore) key(userID uint64) string {
+	return s.keyPrefix + strconv.FormatUint(userID, 10)
+}
+
+// Save 写入会话并顺延整张表的 TTL
+func (s *RedisStore) Save(ctx context.Context, sess *Session) error {
+	if sess == nil || sess.ID == "" {
+		return nil
+	}
+	ttl := time.Until(sess.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+	key := s.key(sess.UserID)
+	if err := s.client.HSet(ctx, key, sess.ID, string(data)); err != nil {
+		return err
+	}
+	return s.client.Expire(ctx, key, ttl)
+}
+
+// Get 读取单条会话
+func (s *RedisStore) Get(ctx context.Context, userID uint64, id string) (*Session, error) {
+	raw, ok, err := s.client.HGet(ctx, s.key(userID), id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var sess Session
+	if err := json.Unmarshal([]byte(raw), &sess); err != nil {
+		return nil, fmt.Errorf("unmarshal session %q: %w", id, err)
+	}
+	if !sess.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &sess, nil
+}
+
+// List 列出未过期的会话，顺手删除已过期或无法解析的字段
+func (s *RedisStore) List(ctx context.Context, userID uint64) ([]Session, error) {
+	key := s.key(userID)
+	all, err := s.client.HGetAll(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]Session, 0, len(all))
+	var stale []string
+	for id, raw := range all {
+		var sess Session
+		if err := json.Unmarshal([]byte(raw), &sess); err != nil || !sess.ExpiresAt.After(now) {
+			stale = append(stale, id)
+			continue
+		}
+		result = append(result, sess)
+	}
+	if err := s.client.HDel(ctx, key, stale...); err != nil {
+		return nil, err
+	}
+
+	sortByIssuedAtDesc(result)
+	return result, nil
+}
+
+// Delete 删除一条会话
+func (s *RedisStore) Delete(ctx context.Context, userID uint64, id string) error {
+	return s.client.HDel(ctx, s.key(userID), id)
+}
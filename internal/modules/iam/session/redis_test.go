@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	pkgredis "github.com/ayxworxfr/go_admin/pkg/redis"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisClient(t *testing.T) (*pkgredis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client, err := pkgredis.New(pkgredis.Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client, mr
+}
+
+func TestRedisStore_SaveListDelete(t *testing.T) {
+	client, mr := newTestRedisClient(t)
+	s := NewRedisStore(client, "test:session")
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, s.Save(ctx, newTestSession(1, "old", now.Add(-time.Minute))))
+	require.NoError(t, s.Save(ctx, newTestSession(1, "new", now)))
+	require.True(t, mr.Exists("test:session:1"))
+	require.Greater(t, mr.TTL("test:session:1"), time.Duration(0))
+
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "new", list[0].ID)
+
+	got, err := s.Get(ctx, 1, "old")
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", got.IP)
+
+	require.NoError(t, s.Delete(ctx, 1, "old"))
+	_, err = s.Get(ctx, 1, "old")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRedisStore_ListDropsExpired(t *testing.T) {
+	client, mr := newTestRedisClient(t)
+	s := NewRedisStore(client, "")
+	ctx := context.Background()
+
+	require.NoError(t, s.Save(ctx, newTestSession(1, "alive", time.Now())))
+	// 直接写入一条已过期的记录，模拟 Hash 整体 TTL 尚未到期但单条已失效
+	mr.HSet(defaultKeyPrefix+"1", "expired", `{"id":"expired","user_id":1,"expires_at":"2000-01-01T00:00:00Z"}`)
+
+	list, err := s.List(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "alive", list[0].ID)
+	require.Empty(t, mr.HGet(defaultKeyPrefix+"1", "expired"))
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(Options{})
+	require.NoError(t, err)
+	require.IsType(t, &InMemoryStore{}, store)
+
+	_, err = New(Options{Driver: "redis"})
+	require.Error(t, err)
+
+	client, _ := newTestRedisClient(t)
+	store, err = New(Options{Driver: "redis", Redis: client})
+	require.NoError(t, err)
+	require.IsType(t, &RedisStore{}, store)
+
+	_, err = New(Options{Driver: "mongo"})
+	require.Error(t, err)
+}
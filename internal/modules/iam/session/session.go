@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound 会话不存在（已过期、已撤销或从未登记）
+var ErrNotFound = errors.New("session not found")
+
+// Session 一次登录产生的会话。ID 即该次登录的令牌家族 ID（jwtauth.Claims.FamilyID），
+// 撤销会话 = 撤销整个家族，access/refresh token 一并失效。
+type Session struct {
+	ID          string    `json:"id"`
+	UserID      uint64    `json:"user_id"`
+	Device      string    `json:"device"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	IssuedAt    time.Time `json:"issued_at"`
+	LastRefresh time.Time `json:"last_refresh"`
+	// ExpiresAt 当前 refresh token 的过期时间，过了这个点会话自然结束
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store 按用户登记会话的策略接口，实现与 tokenstore 对称：
+//   - InMemoryStore：单机进程内，默认本地开发使用
+//   - RedisStore：多实例共享，与 RedisTokenStore 搭配使用
+//
+// Store 只负责"记账"，真正让 token 失效的是 tokenstore.RevokeFamily，由 SessionService 串起来。
+type Store interface {
+	// Save 新增或覆盖一条会话（以 UserID + ID 定位）
+	Save(ctx context.Context, s *Session) error
+	// Get 读取单条会话；不存在或已过期返回 ErrNotFound
+	Get(ctx context.Context, userID uint64, id string) (*Session, error)
+	// List 列出用户未过期的会话，按签发时间倒序
+	List(ctx context.Context, userID uint64) ([]Session, error)
+	// Delete 删除一条会话；不存在时不报错
+	Delete(ctx context.Context, userID uint64, id string) error
+}
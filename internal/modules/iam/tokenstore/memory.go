@@ -11,14 +11,22 @@ import (
 // （查询/写入时顺手清理，不引入额外的定时器 goroutine）。
 // 不支持多实例：实例 A 登出后，实例 B 看不到撤销记录。
 //
-// 底层复用 pkg/store.Memory：键为 jti，值仅为占位；存活到原 token 过期时刻。
+// 底层复用 pkg/store.Memory：键为 jti / 家族 ID，值仅为占位；存活到原 token 过期时刻。
 type InMemoryTokenStore struct {
-	store *store.Memory[string, struct{}]
+	store    *store.Memory[string, struct{}]
+	used     *store.Memory[string, struct{}] // 已消费的 refresh token jti
+	families *store.Memory[string, struct{}] // 已撤销的令牌家族
+	users    *store.Memory[string, int64]    // 用户 ID -> 撤销截止时刻（Unix 秒）
 }
 
 // NewInMemoryTokenStore 创建进程内撤销名单
 func NewInMemoryTokenStore() *InMemoryTokenStore {
-	return &InMemoryTokenStore{store: store.NewMemory[string, struct{}](0)}
+	return &InMemoryTokenStore{
+		store:    store.NewMemory[string, struct{}](0),
+		used:     store.NewMemory[string, struct{}](0),
+		families: store.NewMemory[string, struct{}](0),
+		users:    store.NewMemory[string, int64](0),
+	}
 }
 
 // Revoke 记录一次撤销
@@ -39,3 +47,49 @@ func (s *InMemoryTokenStore) IsRevoked(_ context.Context, jti string) (bool, err
 	}
 	return s.store.Has(jti), nil
 }
+
+// ConsumeRefresh 原子地标记 refresh jti 已使用；已过期的 token 不会走到这里（解析阶段即被拒绝）
+func (s *InMemoryTokenStore) ConsumeRefresh(_ context.Context, jti string, exp time.Time) (bool, error) {
+	if jti == "" {
+		return true, nil
+	}
+	return s.used.SetIfAbsentUntil(jti, struct{}{}, exp), nil
+}
+
+// RevokeFamily 记录家族撤销
+func (s *InMemoryTokenStore) RevokeFamily(_ context.Context, familyID string, exp time.Time) error {
+	if familyID == "" {
+		return nil
+	}
+	s.families.SetUntil(familyID, struct{}{}, exp)
+	return nil
+}
+
+// IsFamilyRevoked 查询家族是否已撤销
+func (s *InMemoryTokenStore) IsFamilyRevoked(_ context.Context, familyID string) (bool, error) {
+	if familyID == "" {
+		return false, nil
+	}
+	return s.families.Has(familyID), nil
+}
+
+// RevokeUser 记录用户级撤销截止时刻；重复撤销直接覆盖，后一次的截止时刻总是更晚
+func (s *InMemoryTokenStore) RevokeUser(_ context.Context, userID string, before, exp time.Time) error {
+	if userID == "" {
+		return nil
+	}
+	s.users.SetUntil(userID, before.Unix(), exp)
+	return nil
+}
+
+// IsUserRevoked 签发时刻不晚于截止时刻的 token 视为已撤销
+func (s *InMemoryTokenStore) IsUserRevoked(_ context.Context, userID string, issuedAt time.Time) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	before, ok := s.users.Get(userID)
+	if !ok {
+		return false, nil
+	}
+	return issuedAt.IsZero() || issuedAt.Unix() <= before, nil
+}
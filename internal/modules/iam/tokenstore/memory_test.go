@@ -41,3 +41,63 @@ func TestInMemoryTokenStore_EmptyJTI(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, revoked)
 }
+
+func TestInMemoryTokenStore_ConsumeRefreshOnce(t *testing.T) {
+	s := NewInMemoryTokenStore()
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour)
+
+	first, err := s.ConsumeRefresh(ctx, "rt-1", exp)
+	require.NoError(t, err)
+	require.True(t, first)
+
+	again, err := s.ConsumeRefresh(ctx, "rt-1", exp)
+	require.NoError(t, err)
+	require.False(t, again, "第二次使用同一 refresh jti 应判为重放")
+}
+
+func TestInMemoryTokenStore_RevokeFamily(t *testing.T) {
+	s := NewInMemoryTokenStore()
+	ctx := context.Background()
+
+	revoked, err := s.IsFamilyRevoked(ctx, "fam-1")
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, s.RevokeFamily(ctx, "fam-1", time.Now().Add(time.Hour)))
+	revoked, err = s.IsFamilyRevoked(ctx, "fam-1")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// 家族与单个 jti 的撤销互不干扰
+	revoked, err = s.IsRevoked(ctx, "fam-1")
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestInMemoryTokenStore_RevokeUser(t *testing.T) {
+	s := NewInMemoryTokenStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	revoked, err := s.IsUserRevoked(ctx, "1", now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, s.RevokeUser(ctx, "1", now, now.Add(time.Hour)))
+
+	// 截止时刻之前（含同一秒）签发的 token 失效
+	for _, iat := range []time.Time{now.Add(-time.Minute), now, {}} {
+		revoked, err = s.IsUserRevoked(ctx, "1", iat)
+		require.NoError(t, err)
+		require.True(t, revoked, "iat=%v", iat)
+	}
+
+	// 之后重新签发的 token 不受影响，其他用户也不受影响
+	revoked, err = s.IsUserRevoked(ctx, "1", now.Add(2*time.Second))
+	require.NoError(t, err)
+	require.False(t, revoked)
+	revoked, err = s.IsUserRevoked(ctx, "2", now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
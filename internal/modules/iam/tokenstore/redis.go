@@ -2,6 +2,8 @@ package tokenstore
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,6 +34,19 @@ func (s *RedisTokenStore) key(jti string) string {
 	return s.keyPrefix + jti
 }
 
+// usedKey / familyKey 与撤销键共用前缀，靠子命名空间区分；jti 与家族 ID 都是 uuid，不会互相冲突
+func (s *RedisTokenStore) usedKey(jti string) string {
+	return s.keyPrefix + "used:" + jti
+}
+
+func (s *RedisTokenStore) familyKey(familyID string) string {
+	return s.keyPrefix + "family:" + familyID
+}
+
+func (s *RedisTokenStore) userKey(userID string) string {
+	return s.keyPrefix + "user:" + userID
+}
+
 // Revoke 将 jti 写入 Redis，TTL = 直到原 token 过期的剩余时间。
 // 已过期或空 jti 直接跳过，避免无意义写入。
 func (s *RedisTokenStore) Revoke(ctx context.Context, jti string, exp time.Time) error {
@@ -52,3 +67,64 @@ func (s *RedisTokenStore) IsRevoked(ctx context.Context, jti string) (bool, erro
 	}
 	return s.client.Exists(ctx, s.key(jti))
 }
+
+// ConsumeRefresh 用 SET NX 标记 refresh jti 已使用：并发刷新时只有一个请求能拿到 true，
+// 其余都视为重放。TTL 同样对齐 token 剩余有效期。
+func (s *RedisTokenStore) ConsumeRefresh(ctx context.Context, jti string, exp time.Time) (bool, error) {
+	if jti == "" {
+		return true, nil
+	}
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return false, nil
+	}
+	return s.client.SetNX(ctx, s.usedKey(jti), "1", ttl)
+}
+
+// RevokeFamily 写入家族撤销键
+func (s *RedisTokenStore) RevokeFamily(ctx context.Context, familyID string, exp time.Time) error {
+	if familyID == "" {
+		return nil
+	}
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.familyKey(familyID), "1", ttl)
+}
+
+// IsFamilyRevoked 查询家族撤销键是否存在
+func (s *RedisTokenStore) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	if familyID == "" {
+		return false, nil
+	}
+	return s.client.Exists(ctx, s.familyKey(familyID))
+}
+
+// RevokeUser 写入用户级撤销键，值为截止时刻的 Unix 秒
+func (s *RedisTokenStore) RevokeUser(ctx context.Context, userID string, before, exp time.Time) error {
+	if userID == "" {
+		return nil
+	}
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.userKey(userID), strconv.FormatInt(before.Unix(), 10), ttl)
+}
+
+// IsUserRevoked 读取用户级撤销键并与 token 的签发时刻比较
+func (s *RedisTokenStore) IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	v, ok, err := s.client.Get(ctx, s.userKey(userID))
+	if err != nil || !ok {
+		return false, err
+	}
+	before, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid user revocation value %q: %w", v, err)
+	}
+	return issuedAt.IsZero() || issuedAt.Unix() <= before, nil
+}
@@ -59,3 +59,54 @@ func TestRedisTokenStore_TTLAligned(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, revoked)
 }
+
+func TestRedisTokenStore_ConsumeRefreshOnce(t *testing.T) {
+	s, mr := newTestRedisStore(t)
+	ctx := context.Background()
+	exp := time.Now().Add(time.Hour)
+
+	first, err := s.ConsumeRefresh(ctx, "rt-1", exp)
+	require.NoError(t, err)
+	require.True(t, first)
+	require.True(t, mr.Exists("test:revoked:used:rt-1"))
+
+	again, err := s.ConsumeRefresh(ctx, "rt-1", exp)
+	require.NoError(t, err)
+	require.False(t, again)
+}
+
+func TestRedisTokenStore_RevokeFamily(t *testing.T) {
+	s, mr := newTestRedisStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.RevokeFamily(ctx, "fam-1", time.Now().Add(2*time.Second)))
+	revoked, err := s.IsFamilyRevoked(ctx, "fam-1")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	mr.FastForward(3 * time.Second)
+	revoked, err = s.IsFamilyRevoked(ctx, "fam-1")
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestRedisTokenStore_RevokeUser(t *testing.T) {
+	s, mr := newTestRedisStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, s.RevokeUser(ctx, "1", now, now.Add(2*time.Second)))
+	require.True(t, mr.Exists("test:revoked:user:1"))
+
+	revoked, err := s.IsUserRevoked(ctx, "1", now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.True(t, revoked)
+	revoked, err = s.IsUserRevoked(ctx, "1", now.Add(2*time.Second))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	mr.FastForward(3 * time.Second)
+	revoked, err = s.IsUserRevoked(ctx, "1", now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
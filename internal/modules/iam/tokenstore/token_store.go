@@ -20,4 +20,21 @@ type TokenStore interface {
 	// IsRevoked 查询 jti 是否已被撤销。约定：jti 为空时由调用方自行判断是否跳过检查
 	// （中间件层会对空 jti 直接放行，兼容签发时未带 jti 的旧 token）
 	IsRevoked(ctx context.Context, jti string) (bool, error)
+
+	// ConsumeRefresh 将 refresh token 的 jti 标记为已使用，exp 为该 token 的过期时间。
+	// 首次使用返回 true；返回 false 说明该 jti 此前已被消费过，即发生了重放。
+	// OIDC 登录的 state 同样一次性，也借它标记（ID 带前缀，与 jti 不会冲突）
+	ConsumeRefresh(ctx context.Context, jti string, exp time.Time) (bool, error)
+	// RevokeFamily 撤销整个令牌家族（同一次登录轮换出的全部 token），exp 为记录保留到的时刻
+	RevokeFamily(ctx context.Context, familyID string, exp time.Time) error
+	// IsFamilyRevoked 查询令牌家族是否已被撤销；familyID 为空视为未撤销
+	IsFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+
+	// RevokeUser 撤销用户在 before 及之前签发的全部 token（账号停用/删除时使用），
+	// exp 为记录保留到的时刻，应不早于该用户最晚一个 token 的过期时间。
+	// 之后重新签发的 token 不受影响，因此账号恢复后无需清除该记录
+	RevokeUser(ctx context.Context, userID string, before, exp time.Time) error
+	// IsUserRevoked 查询签发于 issuedAt 的 token 是否已被用户级撤销；比较精度为秒，
+	// 与 JWT iat 的精度一致。未带 iat 的旧 token（issuedAt 为零值）只要存在撤销记录即视为已撤销
+	IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) (bool, error)
 }
@@ -0,0 +1,67 @@
+package dto
+
+import "time"
+
+// CreateDeptRequest 创建部门请求，新部门排在同级部门的最后
+type CreateDeptRequest struct {
+	ParentID uint64 `json:"parent_id"` // 0 表示顶级部门
+	Name     string `json:"name" vd:"len($)>0&&len($)<50"`
+	LeaderID uint64 `json:"leader_id"` // 0 表示不设负责人
+}
+
+// UpdateDeptRequest 更新部门名称与负责人。调整位置走 move / reorder 接口
+type UpdateDeptRequest struct {
+	ID       uint64  `json:"id" vd:"$>0"`
+	Name     string  `json:"name" vd:"len($)<50"` // 空串表示不修改
+	LeaderID *uint64 `json:"leader_id"`           // 指针区分"未设置"和"清除负责人"（传 0）
+}
+
+// MoveDeptRequest 把部门连同下级部门移到另一个父部门下，排在新同级部门的最后
+type MoveDeptRequest struct {
+	ID       uint64 `json:"id" vd:"$>0"`
+	ParentID uint64 `json:"parent_id"` // 0 表示移为顶级部门
+}
+
+// ReorderDeptRequest 调整同一父部门下各部门的顺序：IDs 按新顺序列出该父部门的全部直接下级
+type ReorderDeptRequest struct {
+	ParentID uint64   `json:"parent_id"`
+	IDs      []uint64 `json:"ids" vd:"len($)>0"`
+}
+
+// DeleteDeptRequest 删除部门请求，只能删除没有下级部门也没有成员的部门
+type DeleteDeptRequest struct {
+	ID uint64 `json:"id" vd:"$>0"`
+}
+
+// GetDeptRequest 获取单个部门请求
+type GetDeptRequest struct {
+	ID uint64 `query:"id" vd:"$>0"`
+}
+
+// GetDeptTreeRequest 获取部门树请求
+type GetDeptTreeRequest struct {
+	RootID uint64 `query:"root_id"` // 只返回该部门及其下级；0 表示整棵树
+}
+
+// DeptMembersRequest 调入或移出部门成员。用户只属于一个部门，调入会把用户从原部门移出
+type DeptMembersRequest struct {
+	DeptID  uint64   `json:"dept_id" vd:"$>0"`
+	UserIDs []uint64 `json:"user_ids" vd:"len($)>0"`
+}
+
+// DeptResponse 部门视图对象
+type DeptResponse struct {
+	ID         uint64    `json:"id"`
+	ParentID   uint64    `json:"parent_id"`
+	Name       string    `json:"name"`
+	Sort       int       `json:"sort"`
+	LeaderID   uint64    `json:"leader_id"`
+	CreateTime time.Time `json:"create_time"`
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// DeptNode 部门树节点，Children 已按顺序排好
+type DeptNode struct {
+	DeptResponse
+	Children []*DeptNode `json:"children"`
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/org/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/org/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// Handler 组织架构接口：部门树的增删改、移动与排序，以及部门成员调整
+type Handler struct {
+	svc *service.Service
+}
+
+// NewHandler 创建组织架构处理器
+func NewHandler(svc *service.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// deptError 部门不存在按 404，重名与非空按冲突，树结构不合法属于参数错误，其余按数据库错误处理
+func deptError(err error) *api.Response {
+	switch {
+	case errors.Is(err, service.ErrDeptNotFound):
+		return api.NotFound(err)
+	case errors.Is(err, service.ErrDeptNameExists), errors.Is(err, service.ErrDeptNotEmpty):
+		return api.Conflict(err)
+	case errors.Is(err, service.ErrDeptCycle), errors.Is(err, service.ErrInvalidDeptOrder), errors.Is(err, service.ErrInvalidLeader):
+		return api.ParamError(err)
+	default:
+		return api.DatabaseError(err)
+	}
+}
+
+// @route Post /dept
+// CreateDept 创建部门
+func (h *Handler) CreateDept(c *api.Context, req *dto.CreateDeptRequest) *api.Response {
+	result, err := h.svc.Create(c.Context(), req)
+	if err != nil {
+		return deptError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Put /dept
+// UpdateDept 修改部门名称与负责人
+func (h *Handler) UpdateDept(c *api.Context, req *dto.UpdateDeptRequest) *api.Response {
+	result, err := h.svc.Update(c.Context(), req)
+	if err != nil {
+		return deptError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Put /dept/move
+// MoveDept 把部门连同下级部门移到另一个父部门下
+func (h *Handler) MoveDept(c *api.Context, req *dto.MoveDeptRequest) *api.Response {
+	result, err := h.svc.Move(c.Context(), req)
+	if err != nil {
+		return deptError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Put /dept/reorder
+// ReorderDept 调整同级部门的顺序
+func (h *Handler) ReorderDept(c *api.Context, req *dto.ReorderDeptRequest) *api.Response {
+	if err := h.svc.Reorder(c.Context(), req); err != nil {
+		return deptError(err)
+	}
+	return api.NoContent()
+}
+
+// @route Delete /dept
+// DeleteDept 删除没有下级部门和成员的部门
+func (h *Handler) DeleteDept(c *api.Context, req *dto.DeleteDeptRequest) *api.Response {
+	if err := h.svc.Delete(c.Context(), req.ID); err != nil {
+		return deptError(err)
+	}
+	return api.NoContent()
+}
+
+// @route Get /dept
+// GetDept 获取单个部门
+func (h *Handler) GetDept(c *api.Context, req *dto.GetDeptRequest) *api.Response {
+	result, err := h.svc.Get(c.Context(), req.ID)
+	if err != nil {
+		return deptError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Get /dept/tree
+// GetDeptTree 获取部门树，root_id 不为 0 时只返回该部门的子树
+func (h *Handler) GetDeptTree(c *api.Context, req *dto.GetDeptTreeRequest) *api.Response {
+	result, err := h.svc.Tree(c.Context(), req.RootID)
+	if err != nil {
+		return deptError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Post /dept/member
+// AddDeptMembers 把用户调入部门。成员列表走 GET /user/list?dept_id=
+func (h *Handler) AddDeptMembers(c *api.Context, req *dto.DeptMembersRequest) *api.Response {
+	if err := h.svc.MoveMembers(c.Context(), req.DeptID, req.UserIDs); err != nil {
+		return deptError(err)
+	}
+	return api.NoContent()
+}
+
+// @route Delete /dept/member
+// RemoveDeptMembers 把用户移出部门
+func (h *Handler) RemoveDeptMembers(c *api.Context, req *dto.DeptMembersRequest) *api.Response {
+	if err := h.svc.RemoveMembers(c.Context(), req.DeptID, req.UserIDs); err != nil {
+		return deptError(err)
+	}
+	return api.NoContent()
+}
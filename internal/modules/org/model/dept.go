@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Dept 部门：组织架构树上的一个节点，按租户隔离。ParentID 为 0 的是顶级部门，
+// 同一父部门下按 Sort 升序排列、Sort 相同再按 ID。
+//
+// 用户属于哪个部门记在用户自己的 dept_id 上（一个用户只属于一个部门），由 user 模块维护，
+// 数据范围"本部门/本部门及下级部门"也以它为准
+type Dept struct {
+	ID         uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	TenantID   uint64    `xorm:"bigint unsigned notnull default 1 index 'tenant_id'" json:"tenant_id" tenant:"id"`
+	ParentID   uint64    `xorm:"bigint unsigned notnull default 0 index 'parent_id'" json:"parent_id"`
+	Name       string    `xorm:"varchar(50) notnull 'name'" json:"name"` // 同一父部门下唯一
+	Sort       int       `xorm:"int notnull default 0 'sort'" json:"sort"`
+	LeaderID   uint64    `xorm:"bigint unsigned notnull default 0 'leader_id'" json:"leader_id"` // 负责人用户 ID，0 表示未设置
+	CreateTime time.Time `xorm:"created" json:"create_time"`
+	UpdateTime time.Time `xorm:"updated" json:"update_time"`
+}
@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/org/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/org/model"
+	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/jinzhu/copier"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+var (
+	// ErrDeptNotFound 部门不存在（或属于别的租户）
+	ErrDeptNotFound = errors.New("dept not found")
+	// ErrDeptNameExists 同一父部门下已有同名部门
+	ErrDeptNameExists = errors.New("dept name already exists under the parent")
+	// ErrDeptCycle 移动会让部门成为自己的下级
+	ErrDeptCycle = errors.New("dept cannot be moved under itself or its descendants")
+	// ErrDeptNotEmpty 部门还有下级部门或成员，不能删除
+	ErrDeptNotEmpty = errors.New("dept still has sub-depts or members")
+	// ErrInvalidDeptOrder 重排时给出的部门与父部门的直接下级不一致
+	ErrInvalidDeptOrder = errors.New("ids must list every direct sub-dept of the parent exactly once")
+	// ErrInvalidLeader 负责人用户不存在
+	ErrInvalidLeader = errors.New("leader user does not exist")
+)
+
+// Service 组织架构服务：维护按租户隔离的部门树，成员关系经 user.DeptMembers 写在用户上。
+// 下级部门用递归 CTE 一次查出，DescendantIDs 同时供 iam 的数据范围和用户列表的按部门筛选使用。
+type Service struct {
+	repo       *pkgrepo.Repository[model.Dept]
+	userFinder usersvc.UserFinder
+	members    usersvc.DeptMembers
+}
+
+// NewService 创建组织架构服务
+func NewService(db *pkgrepo.DB, userFinder usersvc.UserFinder, members usersvc.DeptMembers) *Service {
+	return &Service{
+		repo:       pkgrepo.NewRepository[model.Dept](db),
+		userFinder: userFinder,
+		members:    members,
+	}
+}
+
+// Create 创建部门，排在同级部门的最后
+func (s *Service) Create(ctx context.Context, req *dto.CreateDeptRequest) (*dto.DeptResponse, error) {
+	if err := s.checkParent(ctx, req.ParentID); err != nil {
+		return nil, err
+	}
+	if err := s.checkName(ctx, req.ParentID, req.Name, 0); err != nil {
+		return nil, err
+	}
+	if err := s.checkLeader(ctx, req.LeaderID); err != nil {
+		return nil, err
+	}
+	next, err := s.nextSort(ctx, req.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	dept := &model.Dept{ParentID: req.ParentID, Name: req.Name, Sort: next, LeaderID: req.LeaderID}
+	if err := s.repo.Create(ctx, dept); err != nil {
+		logger.Error(ctx, "Failed to create dept", logger.Err(err), logger.String("name", req.Name))
+		return nil, errors.Wrap(err, "failed to create dept")
+	}
+	return toResponse(dept)
+}
+
+// Update 修改部门名称与负责人
+func (s *Service) Update(ctx context.Context, req *dto.UpdateDeptRequest) (*dto.DeptResponse, error) {
+	dept, err := s.find(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != "" && req.Name != dept.Name {
+		if err := s.checkName(ctx, dept.ParentID, req.Name, dept.ID); err != nil {
+			return nil, err
+		}
+		dept.Name = req.Name
+	}
+	if req.LeaderID != nil {
+		if err := s.checkLeader(ctx, *req.LeaderID); err != nil {
+			return nil, err
+		}
+		dept.LeaderID = *req.LeaderID
+	}
+	if err := s.repo.Update(ctx, dept, "leader_id"); err != nil {
+		logger.Error(ctx, "Failed to update dept", logger.Err(err), logger.Uint64("dept_id", req.ID))
+		return nil, errors.Wrap(err, "failed to update dept")
+	}
+	return toResponse(dept)
+}
+
+// Move 把部门连同整棵子树挂到新的父部门下，排在新同级部门的最后。
+// 新父部门不能是部门自己或它的下级，否则树会断成一个环
+func (s *Service) Move(ctx context.Context, req *dto.MoveDeptRequest) (*dto.DeptResponse, error) {
+	var dept *model.Dept
+	moved := false
+	err := s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		// 先锁住被移动的部门和新父部门，再在同一事务里做环检测和更新：
+		// 两个部门互相移到对方下面时会在这里排队，后到的一方能看到先到的一方提交后的树
+		locked, err := s.lockDepts(txCtx, req.ID, req.ParentID)
+		if err != nil {
+			return err
+		}
+		dept = locked[req.ID]
+		if req.ParentID == dept.ParentID {
+			return nil
+		}
+		if req.ParentID != 0 {
+			subtree, err := s.DescendantIDs(txCtx, dept.ID)
+			if err != nil {
+				return err
+			}
+			if lo.Contains(subtree, req.ParentID) {
+				return errors.Wrapf(ErrDeptCycle, "dept %d", dept.ID)
+			}
+		}
+		if err := s.checkName(txCtx, req.ParentID, dept.Name, dept.ID); err != nil {
+			return err
+		}
+		next, err := s.nextSort(txCtx, req.ParentID)
+		if err != nil {
+			return err
+		}
+
+		dept.ParentID, dept.Sort = req.ParentID, next
+		if err := s.repo.Update(txCtx, dept, "parent_id", "sort"); err != nil {
+			logger.Error(ctx, "Failed to move dept", logger.Err(err), logger.Uint64("dept_id", dept.ID), logger.Uint64("parent_id", req.ParentID))
+			return errors.Wrap(err, "failed to move dept")
+		}
+		moved = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if moved {
+		logger.Info(ctx, "Dept moved", logger.Uint64("dept_id", dept.ID), logger.Uint64("parent_id", req.ParentID))
+	}
+	return toResponse(dept)
+}
+
+// Reorder 按 IDs 的顺序重写同级部门的 Sort。IDs 必须恰好是父部门的全部直接下级，
+// 漏掉或多出的部门说明调用方看到的树已经过时，整批拒绝而不是只排一部分
+func (s *Service) Reorder(ctx context.Context, req *dto.ReorderDeptRequest) error {
+	siblings, err := s.repo.QueryBuilder().Eq("parent_id", req.ParentID).Find(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve depts", logger.Err(err), logger.Uint64("parent_id", req.ParentID))
+		return errors.Wrap(err, "failed to retrieve depts")
+	}
+	ids := lo.Uniq(req.IDs)
+	current := lo.Map(siblings, func(d model.Dept, _ int) uint64 { return d.ID })
+	if len(ids) != len(req.IDs) || len(ids) != len(current) || len(lo.Without(ids, current...)) > 0 {
+		return errors.WithStack(ErrInvalidDeptOrder)
+	}
+
+	err = s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		for i, id := range ids {
+			if err := s.repo.Update(txCtx, &model.Dept{ID: id, Sort: i + 1}, "sort"); err != nil {
+				return errors.Wrapf(err, "failed to reorder dept %d", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to reorder depts", logger.Err(err), logger.Uint64("parent_id", req.ParentID))
+		return err
+	}
+	return nil
+}
+
+// Delete 删除部门。还有下级部门或成员时拒绝，先把它们移走
+func (s *Service) Delete(ctx context.Context, id uint64) error {
+	if _, err := s.find(ctx, id); err != nil {
+		return err
+	}
+	children, err := s.repo.QueryBuilder().Eq("parent_id", id).Count(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to count sub-depts")
+	}
+	members, err := s.members.CountDeptMembers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 || members > 0 {
+		return errors.Wrapf(ErrDeptNotEmpty, "dept %d has %d sub-depts and %d members", id, children, members)
+	}
+	if err := s.repo.DeleteByID(ctx, id); err != nil {
+		logger.Error(ctx, "Failed to delete dept", logger.Err(err), logger.Uint64("dept_id", id))
+		return errors.Wrap(err, "failed to delete dept")
+	}
+	return nil
+}
+
+// Get 获取单个部门
+func (s *Service) Get(ctx context.Context, id uint64) (*dto.DeptResponse, error) {
+	dept, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(dept)
+}
+
+// Tree 部门树。rootID 为 0 时返回本租户的全部顶级部门及其下级，否则返回以它为根的子树
+func (s *Service) Tree(ctx context.Context, rootID uint64) ([]*dto.DeptNode, error) {
+	var (
+		depts []model.Dept
+		err   error
+	)
+	if rootID == 0 {
+		depts, err = s.repo.FindAll(ctx, &model.Dept{})
+	} else {
+		if _, err := s.find(ctx, rootID); err != nil {
+			return nil, err
+		}
+		depts, err = s.subtree(ctx, rootID)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve depts", logger.Err(err), logger.Uint64("root_id", rootID))
+		return nil, errors.Wrap(err, "failed to retrieve depts")
+	}
+	return buildTree(depts, rootID)
+}
+
+// MoveMembers 把用户调入部门
+func (s *Service) MoveMembers(ctx context.Context, deptID uint64, userIDs []uint64) error {
+	if _, err := s.find(ctx, deptID); err != nil {
+		return err
+	}
+	return s.members.MoveToDept(ctx, userIDs, deptID)
+}
+
+// RemoveMembers 把用户移出部门，移出后不属于任何部门
+func (s *Service) RemoveMembers(ctx context.Context, deptID uint64, userIDs []uint64) error {
+	if _, err := s.find(ctx, deptID); err != nil {
+		return err
+	}
+	return s.members.RemoveFromDept(ctx, deptID, userIDs)
+}
+
+// DescendantIDs 实现 iam.DeptTree：deptID 及其全部下级部门的 ID。部门不存在时返回空
+func (s *Service) DescendantIDs(ctx context.Context, deptID uint64) ([]uint64, error) {
+	depts, err := s.subtree(ctx, deptID)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(depts, func(d model.Dept, _ int) uint64 { return d.ID }), nil
+}
+
+// subtree 使用递归 CTE 沿 parent_id 向下展开，返回 rootID 自身及其全部下级部门。
+// 与角色继承的展开一样用 UNION 去重，库里即使被绕过校验写出了环，递归也能终止。
+// Query 不自动过滤租户，起点自己带上 tenant_id；下级部门由创建和移动保证与上级同租户
+func (s *Service) subtree(ctx context.Context, rootID uint64) ([]model.Dept, error) {
+	tenant := ""
+	args := []any{rootID}
+	if tenantID, scoped := pkgrepo.TenantOf(ctx); scoped {
+		tenant = " AND tenant_id = ?"
+		args = append(args, tenantID)
+	}
+	query := `
+	WITH RECURSIVE dept_tree AS (
+		SELECT id
+		FROM dept
+		WHERE id = ?` + tenant + `
+		UNION
+		SELECT d.id
+		FROM dept d
+		JOIN dept_tree dt ON d.parent_id = dt.id
+	)
+	SELECT d.* FROM dept d
+	JOIN dept_tree dt ON d.id = dt.id
+	`
+
+	depts, err := s.repo.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve sub-depts with CTE")
+	}
+	return depts, nil
+}
+
+// buildTree 把部门列表组装成以 rootID 为根的树；rootID 为 0 时以全部顶级部门为根
+func buildTree(depts []model.Dept, rootID uint64) ([]*dto.DeptNode, error) {
+	sort.Slice(depts, func(i, j int) bool {
+		if depts[i].Sort != depts[j].Sort {
+			return depts[i].Sort < depts[j].Sort
+		}
+		return depts[i].ID < depts[j].ID
+	})
+
+	nodes := make(map[uint64]*dto.DeptNode, len(depts))
+	for i := range depts {
+		resp, err := toResponse(&depts[i])
+		if err != nil {
+			return nil, err
+		}
+		nodes[resp.ID] = &dto.DeptNode{DeptResponse: *resp, Children: []*dto.DeptNode{}}
+	}
+	roots := []*dto.DeptNode{}
+	for i := range depts {
+		node := nodes[depts[i].ID]
+		if rootID == 0 && node.ParentID == 0 || node.ID == rootID {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return roots, nil
+}
+
+func (s *Service) find(ctx context.Context, id uint64) (*model.Dept, error) {
+	dept, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, errors.Wrapf(ErrDeptNotFound, "dept %d", id)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve dept", logger.Err(err), logger.Uint64("dept_id", id))
+		return nil, errors.Wrap(err, "failed to retrieve dept")
+	}
+	return dept, nil
+}
+
+// lockDepts 在事务内按 ID 升序锁住给出的部门（0 表示顶级，跳过），任何一个不存在都返回 ErrDeptNotFound。
+// 固定加锁顺序，避免两个事务各持一把锁互相等待
+func (s *Service) lockDepts(ctx context.Context, ids ...uint64) (map[uint64]*model.Dept, error) {
+	ids = lo.Without(lo.Uniq(ids), 0)
+	depts, err := s.repo.QueryBuilder().In("id", ids).OrderBy("id").ForUpdate().Find(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to lock depts", logger.Err(err), logger.Uint64s("dept_ids", ids))
+		return nil, errors.Wrap(err, "failed to lock depts")
+	}
+	locked := make(map[uint64]*model.Dept, len(depts))
+	for i := range depts {
+		locked[depts[i].ID] = &depts[i]
+	}
+	for _, id := range ids {
+		if _, ok := locked[id]; !ok {
+			return nil, errors.Wrapf(ErrDeptNotFound, "dept %d", id)
+		}
+	}
+	return locked, nil
+}
+
+// checkParent 父部门为 0（顶级）或本租户里存在的部门
+func (s *Service) checkParent(ctx context.Context, parentID uint64) error {
+	if parentID == 0 {
+		return nil
+	}
+	_, err := s.find(ctx, parentID)
+	return err
+}
+
+// checkName 同一父部门下名称唯一，大小写与首尾空白不算区别；exceptID 是正在修改的部门自己
+func (s *Service) checkName(ctx context.Context, parentID uint64, name string, exceptID uint64) error {
+	siblings, err := s.repo.QueryBuilder().Eq("parent_id", parentID).Find(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve depts")
+	}
+	for _, d := range siblings {
+		if d.ID != exceptID && strings.EqualFold(strings.TrimSpace(d.Name), strings.TrimSpace(name)) {
+			return errors.Wrapf(ErrDeptNameExists, "name %q", name)
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkLeader(ctx context.Context, leaderID uint64) error {
+	if leaderID == 0 {
+		return nil
+	}
+	// 负责人不受当前操作者的数据范围限制，只要是本租户的用户即可
+	_, err := s.userFinder.FindByID(pkgrepo.WithoutDataScope(ctx), leaderID)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return errors.Wrapf(ErrInvalidLeader, "user %d", leaderID)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve leader")
+	}
+	return nil
+}
+
+// nextSort 新加入 parentID 下的部门使用的 Sort，排在现有同级部门之后
+func (s *Service) nextSort(ctx context.Context, parentID uint64) (int, error) {
+	last, err := s.repo.QueryBuilder().Eq("parent_id", parentID).OrderBy("sort DESC").First(ctx)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve depts")
+	}
+	return last.Sort + 1, nil
+}
+
+func toResponse(dept *model.Dept) (*dto.DeptResponse, error) {
+	var resp dto.DeptResponse
+	if err := copier.Copy(&resp, dept); err != nil {
+		return nil, errors.Wrap(err, "failed to convert dept to response")
+	}
+	return &resp, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/org/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/org/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDeptFixture 部门树：1 -> 2 -> 3，4 为另一个顶级部门
+func newDeptFixture(t *testing.T) (*Service, *pkgrepo.DB, context.Context) {
+	t.Helper()
+	db := repotest.NewDB(t, new(model.Dept))
+	svc := NewService(db, nil, nil)
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	for _, d := range []model.Dept{
+		{ID: 1, Name: "总部", Sort: 1},
+		{ID: 2, ParentID: 1, Name: "研发部", Sort: 1},
+		{ID: 3, ParentID: 2, Name: "后端组", Sort: 1},
+		{ID: 4, Name: "分公司", Sort: 2},
+	} {
+		require.NoError(t, svc.repo.Create(ctx, &d))
+	}
+	return svc, db, ctx
+}
+
+func TestMove_RejectsDescendantParent(t *testing.T) {
+	svc, _, ctx := newDeptFixture(t)
+
+	_, err := svc.Move(ctx, &dto.MoveDeptRequest{ID: 1, ParentID: 3})
+	assert.ErrorIs(t, err, ErrDeptCycle)
+	_, err = svc.Move(ctx, &dto.MoveDeptRequest{ID: 2, ParentID: 2})
+	assert.ErrorIs(t, err, ErrDeptCycle)
+
+	dept, err := svc.find(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, dept.ParentID)
+}
+
+func TestMove_UnknownParent(t *testing.T) {
+	svc, _, ctx := newDeptFixture(t)
+
+	_, err := svc.Move(ctx, &dto.MoveDeptRequest{ID: 2, ParentID: 99})
+	assert.ErrorIs(t, err, ErrDeptNotFound)
+}
+
+func TestMove_AppendsUnderNewParent(t *testing.T) {
+	svc, _, ctx := newDeptFixture(t)
+
+	resp, err := svc.Move(ctx, &dto.MoveDeptRequest{ID: 3, ParentID: 4})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), resp.ParentID)
+	assert.Equal(t, 1, resp.Sort)
+
+	ids, err := svc.DescendantIDs(ctx, 1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{1, 2}, ids)
+}
+
+func TestUserPurger_ClearsLeader(t *testing.T) {
+	svc, db, ctx := newDeptFixture(t)
+	require.NoError(t, svc.repo.Update(ctx, &model.Dept{ID: 2, LeaderID: 7}))
+
+	require.NoError(t, NewUserPurger(db).PurgeUsers(ctx, []uint64{7}))
+
+	dept, err := svc.find(ctx, 2)
+	require.NoError(t, err)
+	assert.Zero(t, dept.LeaderID)
+	assert.Equal(t, "研发部", dept.Name)
+}
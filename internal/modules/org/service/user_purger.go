@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/org/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/pkg/errors"
+)
+
+// UserPurger 实现 user.RelatedDataPurger：用户被物理删除时，清空以其为负责人的部门的 leader_id。
+// 部门成员关系记在用户行上，随用户一起删除，这里不用处理。只依赖 *DB，先于 user.Service 构造
+type UserPurger struct {
+	repo *pkgrepo.Repository[model.Dept]
+}
+
+// NewUserPurger 创建部门负责人清理器
+func NewUserPurger(db *pkgrepo.DB) *UserPurger {
+	return &UserPurger{repo: pkgrepo.NewRepository[model.Dept](db)}
+}
+
+// PurgeUsers 实现 user.RelatedDataPurger
+func (p *UserPurger) PurgeUsers(ctx context.Context, userIDs []uint64) error {
+	depts, err := p.repo.QueryBuilder().In("leader_id", userIDs).Find(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve depts led by purged users")
+	}
+	for _, d := range depts {
+		if err := p.repo.Update(ctx, &model.Dept{ID: d.ID}, "leader_id"); err != nil {
+			return errors.Wrapf(err, "failed to clear leader of dept %d", d.ID)
+		}
+	}
+	return nil
+}
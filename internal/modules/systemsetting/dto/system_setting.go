@@ -30,6 +30,17 @@ type DeleteSystemSettingRequest struct {
 	IDs []uint64 `json:"ids" vd:"len($)>0"`
 }
 
+// SetSystemSettingOverrideRequest 设置当前租户的配置覆盖值请求
+type SetSystemSettingOverrideRequest struct {
+	Key   string `json:"key" vd:"len($)>0&&len($)<50"`
+	Value string `json:"value"`
+}
+
+// DeleteSystemSettingOverrideRequest 删除当前租户的配置覆盖值请求
+type DeleteSystemSettingOverrideRequest struct {
+	Key string `json:"key" vd:"len($)>0&&len($)<50"`
+}
+
 // GetSystemSettingRequest 获取系统配置请求
 type GetSystemSettingRequest struct {
 	ID uint64 `query:"id" vd:"$>0"`
@@ -61,7 +72,8 @@ type SystemSettingResponse struct {
 	ID          uint64           `json:"id"`
 	Category    string           `json:"category"`
 	Key         string           `json:"key"`
-	Value       string           `json:"value"`
+	Value       string           `json:"value"`      // 当前租户的生效值
+	Overridden  bool             `json:"overridden"` // Value 是否来自当前租户的覆盖值
 	Type        uint8            `json:"type"`
 	TypeDisplay string           `json:"type_display"`
 	Description string           `json:"description"`
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/ayxworxfr/go_admin/internal/modules/systemsetting/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/systemsetting/service"
+	tenantsvc "github.com/ayxworxfr/go_admin/internal/modules/tenant/service"
 	"github.com/ayxworxfr/go_admin/pkg/api"
 )
 
@@ -16,6 +19,20 @@ func NewHandler(svc *service.Service) *Handler {
 	return &Handler{svc: svc}
 }
 
+// settingError 非平台租户改默认值属于越权，其余沿用业务错误
+func settingError(err error) *api.Response {
+	switch {
+	case errors.Is(err, tenantsvc.ErrPlatformOnly):
+		return api.Forbidden(err)
+	case errors.Is(err, service.ErrSettingNotFound):
+		return api.NotFound(err)
+	case errors.Is(err, service.ErrOverrideNeedsTenant):
+		return api.ParamError(err)
+	default:
+		return api.BusinessError(err)
+	}
+}
+
 // @route Post /system-setting
 func (h *Handler) CreateSystemSetting(c *api.Context, req *dto.CreateSystemSettingRequest) *api.Response {
 	userID, err := c.UserID()
@@ -24,7 +41,7 @@ func (h *Handler) CreateSystemSetting(c *api.Context, req *dto.CreateSystemSetti
 	}
 	result, err := h.svc.Create(c.Context(), req, userID)
 	if err != nil {
-		return api.BusinessError(err)
+		return settingError(err)
 	}
 	return api.Success(result)
 }
@@ -51,7 +68,7 @@ func (h *Handler) GetSystemSettingList(c *api.Context, req *dto.GetSystemSetting
 func (h *Handler) UpdateSystemSetting(c *api.Context, req *dto.UpdateSystemSettingRequest) *api.Response {
 	result, err := h.svc.Update(c.Context(), req)
 	if err != nil {
-		return api.BusinessError(err)
+		return settingError(err)
 	}
 	return api.Success(result)
 }
@@ -59,7 +76,7 @@ func (h *Handler) UpdateSystemSetting(c *api.Context, req *dto.UpdateSystemSetti
 // @route Delete /system-setting
 func (h *Handler) DeleteSystemSetting(c *api.Context, req *dto.DeleteSystemSettingRequest) *api.Response {
 	if err := h.svc.DeleteBatch(c.Context(), req.IDs); err != nil {
-		return api.BusinessError(err)
+		return settingError(err)
 	}
 	return api.Success(nil)
 }
@@ -72,3 +89,26 @@ func (h *Handler) GetSystemSettingByCategory(c *api.Context, req *dto.GetSystemS
 	}
 	return api.Success(result)
 }
+
+// @route Put /system-setting/override
+// SetSystemSettingOverride 为当前租户设置配置覆盖值
+func (h *Handler) SetSystemSettingOverride(c *api.Context, req *dto.SetSystemSettingOverrideRequest) *api.Response {
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized(err)
+	}
+	result, err := h.svc.SetOverride(c.Context(), req.Key, req.Value, userID)
+	if err != nil {
+		return settingError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Delete /system-setting/override
+// DeleteSystemSettingOverride 删除当前租户的配置覆盖值，恢复默认值
+func (h *Handler) DeleteSystemSettingOverride(c *api.Context, req *dto.DeleteSystemSettingOverrideRequest) *api.Response {
+	if err := h.svc.DeleteOverride(c.Context(), req.Key); err != nil {
+		return settingError(err)
+	}
+	return api.Success(nil)
+}
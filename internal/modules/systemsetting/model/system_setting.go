@@ -14,3 +14,15 @@ type SystemSetting struct {
 	CreateTime  time.Time `xorm:"created 'create_time'" json:"create_time"`
 	UpdateTime  time.Time `xorm:"updated 'update_time'" json:"update_time"`
 }
+
+// SystemSettingOverride 租户对某项系统配置的覆盖值。SystemSetting 是全部租户共用的默认值，
+// 租户内读取配置时有覆盖值就用覆盖值；类型、分类与说明沿用默认值那一条
+type SystemSettingOverride struct {
+	ID         uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	TenantID   uint64    `xorm:"bigint unsigned notnull unique(uk_tenant_key) 'tenant_id'" json:"tenant_id" tenant:"id"`
+	Key        string    `xorm:"varchar(50) notnull unique(uk_tenant_key) 'key'" json:"key"`
+	Value      string    `xorm:"text 'value'" json:"value"`
+	UpdateBy   uint64    `xorm:"bigint unsigned notnull 'update_by'" json:"update_by"`
+	CreateTime time.Time `xorm:"created 'create_time'" json:"create_time"`
+	UpdateTime time.Time `xorm:"updated 'update_time'" json:"update_time"`
+}
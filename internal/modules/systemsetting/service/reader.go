@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/systemsetting/model"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/pkg/errors"
+)
+
+// ErrSettingNotFound 配置项不存在
+var ErrSettingNotFound = errors.New("system setting not found")
+
+// Reader 只读访问系统配置。与 Service 拆开是因为 Service 依赖 user.UserFinder
+// 展示创建人，而 user 模块自己也要读运行时配置（如密码策略）——读配置的一方
+// 拿 Reader，装配时就不会绕成环。
+//
+// ctx 挂了租户时读到的是该租户的生效值：有覆盖值用覆盖值，否则用默认值。
+type Reader struct {
+	repo      *pkgrepo.Repository[model.SystemSetting]
+	overrides *pkgrepo.Repository[model.SystemSettingOverride]
+}
+
+// NewReader 创建系统配置只读访问器
+func NewReader(db *pkgrepo.DB) *Reader {
+	return &Reader{
+		repo:      pkgrepo.NewRepository[model.SystemSetting](db),
+		overrides: pkgrepo.NewRepository[model.SystemSettingOverride](db),
+	}
+}
+
+// GetValue 获取系统配置值并按配置类型转换，取不到或转换失败时返回 defaultValue
+func (r *Reader) GetValue(ctx context.Context, key string, defaultValue any) any {
+	setting, err := r.getByKey(ctx, key)
+	if err != nil {
+		return defaultValue
+	}
+
+	switch setting.Type {
+	case TypeText:
+		return setting.Value
+	case TypeNumber:
+		if intVal, err := strconv.Atoi(setting.Value); err == nil {
+			return intVal
+		}
+		if val, err := strconv.ParseFloat(setting.Value, 64); err == nil {
+			return val
+		}
+	case TypeBool:
+		if val, err := strconv.ParseBool(setting.Value); err == nil {
+			return val
+		}
+	case TypeJSON:
+		var result any
+		if err := json.Unmarshal([]byte(setting.Value), &result); err == nil {
+			return result
+		}
+	}
+	return defaultValue
+}
+
+// GetJSON 把 JSON 类型的配置解码进 dst，found 表示配置是否存在。
+// dst 里预先填好的字段在配置缺省对应键时保持不变，调用方可以先放默认值
+func (r *Reader) GetJSON(ctx context.Context, key string, dst any) (bool, error) {
+	setting, err := r.getByKey(ctx, key)
+	if errors.Is(err, ErrSettingNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if setting.Type != TypeJSON {
+		return true, errors.Errorf("system setting %q is not JSON", key)
+	}
+	if err := json.Unmarshal([]byte(setting.Value), dst); err != nil {
+		return true, errors.Wrapf(err, "failed to decode system setting %q", key)
+	}
+	return true, nil
+}
+
+func (r *Reader) getByKey(ctx context.Context, key string) (*model.SystemSetting, error) {
+	settings, err := r.repo.QueryBuilder().Eq("key", key).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query system setting")
+	}
+	// 之前这里用 errors.Wrap(err, ...) 同时处理"查询出错"和"没有记录"，后者 err 为 nil，
+	// Wrap 也返回 nil，调用方拿到 (nil, nil) 后解引用直接 panic
+	if len(settings) == 0 {
+		return nil, ErrSettingNotFound
+	}
+	if _, err := r.applyOverrides(ctx, settings); err != nil {
+		return nil, err
+	}
+	return &settings[0], nil
+}
+
+// applyOverrides 把 ctx 所在租户的覆盖值写进 settings，返回被覆盖的 key。
+// 没挂租户时不查询，settings 保持默认值
+func (r *Reader) applyOverrides(ctx context.Context, settings []model.SystemSetting) (map[string]bool, error) {
+	if _, scoped := pkgrepo.TenantOf(ctx); !scoped || len(settings) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(settings))
+	for i := range settings {
+		keys = append(keys, settings[i].Key)
+	}
+	overrides, err := r.overrides.QueryBuilder().In("key", keys).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query system setting overrides")
+	}
+
+	values := make(map[string]string, len(overrides))
+	for _, o := range overrides {
+		values[o.Key] = o.Value
+	}
+	overridden := make(map[string]bool, len(values))
+	for i := range settings {
+		if v, ok := values[settings[i].Key]; ok {
+			settings[i].Value = v
+			overridden[settings[i].Key] = true
+		}
+	}
+	return overridden, nil
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/ayxworxfr/go_admin/internal/modules/systemsetting/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/systemsetting/model"
+	tenantsvc "github.com/ayxworxfr/go_admin/internal/modules/tenant/service"
 	usersvc "github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/constant"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
 	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
 	"github.com/hashicorp/go-multierror"
@@ -23,7 +25,10 @@ const (
 	TypeJSON   uint8 = 4
 )
 
-// coreSettingKeys 核心配置不允许被删除
+// ErrOverrideNeedsTenant 覆盖值只能在平台之外的某个租户里设置，平台租户直接改默认值
+var ErrOverrideNeedsTenant = errors.New("setting overrides require a tenant other than the platform")
+
+// coreSettingKeys 核心配置不允许被删除，也不允许租户覆盖
 var coreSettingKeys = map[string]struct{}{
 	"system.name":         {},
 	"system.version":      {},
@@ -34,7 +39,10 @@ var coreSettingKeys = map[string]struct{}{
 // Service 系统配置服务：与 iam/user 之间无业务耦合，是一个独立的限界上下文，
 // 唯一的跨模块依赖是展示 create_by 时需要查一下用户名，通过 user.UserFinder
 // 这个最小接口完成，不直连 user 的仓储。
+//
+// 配置项本身（默认值）只有平台租户能增删改，其他租户只能为已有配置项设置覆盖值。
 type Service struct {
+	*Reader
 	repo       *pkgrepo.Repository[model.SystemSetting]
 	userFinder usersvc.UserFinder
 }
@@ -43,6 +51,7 @@ type Service struct {
 // 生成，不再单独包一层 internal/repository 子包，理由见 user 模块 NewService 的注释。
 func NewService(db *pkgrepo.DB, userFinder usersvc.UserFinder) *Service {
 	return &Service{
+		Reader:     NewReader(db),
 		repo:       pkgrepo.NewRepository[model.SystemSetting](db),
 		userFinder: userFinder,
 	}
@@ -50,6 +59,9 @@ func NewService(db *pkgrepo.DB, userFinder usersvc.UserFinder) *Service {
 
 // Create 创建系统配置
 func (s *Service) Create(ctx context.Context, req *dto.CreateSystemSettingRequest, createBy uint64) (*dto.SystemSettingResponse, error) {
+	if !tenantsvc.IsPlatformContext(ctx) {
+		return nil, errors.WithStack(tenantsvc.ErrPlatformOnly)
+	}
 	if err := s.checkKeyUnique(ctx, req.Key, 0); err != nil {
 		return nil, err
 	}
@@ -77,6 +89,9 @@ func (s *Service) Create(ctx context.Context, req *dto.CreateSystemSettingReques
 
 // Update 更新系统配置
 func (s *Service) Update(ctx context.Context, req *dto.UpdateSystemSettingRequest) (*dto.SystemSettingResponse, error) {
+	if !tenantsvc.IsPlatformContext(ctx) {
+		return nil, errors.WithStack(tenantsvc.ErrPlatformOnly)
+	}
 	setting, err := s.repo.FindByID(ctx, req.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "system setting not found")
@@ -106,6 +121,9 @@ func (s *Service) Update(ctx context.Context, req *dto.UpdateSystemSettingReques
 
 // DeleteBatch 批量删除系统配置（核心配置不允许删除）
 func (s *Service) DeleteBatch(ctx context.Context, ids []uint64) error {
+	if !tenantsvc.IsPlatformContext(ctx) {
+		return errors.WithStack(tenantsvc.ErrPlatformOnly)
+	}
 	var result *multierror.Error
 	for _, id := range ids {
 		if err := s.delete(ctx, id); err != nil {
@@ -161,36 +179,6 @@ func (s *Service) GetByCategory(ctx context.Context, category string) ([]*dto.Sy
 	return s.toResponseList(ctx, settings)
 }
 
-// GetValue 获取系统配置值并按配置类型转换，取不到或转换失败时返回 defaultValue
-func (s *Service) GetValue(ctx context.Context, key string, defaultValue any) any {
-	setting, err := s.getByKey(ctx, key)
-	if err != nil {
-		return defaultValue
-	}
-
-	switch setting.Type {
-	case TypeText:
-		return setting.Value
-	case TypeNumber:
-		if intVal, err := strconv.Atoi(setting.Value); err == nil {
-			return intVal
-		}
-		if val, err := strconv.ParseFloat(setting.Value, 64); err == nil {
-			return val
-		}
-	case TypeBool:
-		if val, err := strconv.ParseBool(setting.Value); err == nil {
-			return val
-		}
-	case TypeJSON:
-		var result any
-		if err := json.Unmarshal([]byte(setting.Value), &result); err == nil {
-			return result
-		}
-	}
-	return defaultValue
-}
-
 // Set 设置系统配置：存在则更新，不存在则创建
 func (s *Service) Set(ctx context.Context, category, key, value, description string, settingType uint8, createBy uint64) error {
 	existing, err := s.getByKey(ctx, key)
@@ -207,12 +195,64 @@ func (s *Service) Set(ctx context.Context, category, key, value, description str
 	return err
 }
 
-func (s *Service) getByKey(ctx context.Context, key string) (*model.SystemSetting, error) {
+// SetOverride 为 ctx 所在租户设置配置项的覆盖值，已有覆盖值时改写。值按默认值那一条的类型校验
+func (s *Service) SetOverride(ctx context.Context, key, value string, updateBy uint64) (*dto.SystemSettingResponse, error) {
+	if err := requireTenant(ctx); err != nil {
+		return nil, err
+	}
 	settings, err := s.repo.QueryBuilder().Eq("key", key).Find(ctx)
-	if err != nil || len(settings) == 0 {
-		return nil, errors.Wrap(err, "system setting not found")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query system setting")
+	}
+	if len(settings) == 0 {
+		return nil, errors.Wrapf(ErrSettingNotFound, "key %q", key)
+	}
+	setting := &settings[0]
+	if _, isCore := coreSettingKeys[key]; isCore {
+		return nil, errors.Errorf("core setting '%s' cannot be overridden", key)
+	}
+	if err := validateValue(setting.Type, value); err != nil {
+		return nil, err
 	}
-	return &settings[0], nil
+
+	existing, err := s.overrides.QueryBuilder().Eq("key", key).Find(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query system setting override")
+	}
+	if len(existing) > 0 {
+		override := &existing[0]
+		override.Value, override.UpdateBy = value, updateBy
+		// Value 可能是空串，按非零字段更新会把它跳过
+		err = s.overrides.Update(ctx, override, "value")
+	} else {
+		err = s.overrides.Create(ctx, &model.SystemSettingOverride{Key: key, Value: value, UpdateBy: updateBy})
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to save system setting override", logger.Err(err), logger.String("key", key))
+		return nil, errors.Wrap(err, "failed to save system setting override")
+	}
+
+	logger.Info(ctx, "System setting overridden", logger.String("key", key), logger.Uint64("update_by", updateBy))
+	return s.toResponse(ctx, setting)
+}
+
+// DeleteOverride 删除 ctx 所在租户对配置项的覆盖值，恢复使用默认值
+func (s *Service) DeleteOverride(ctx context.Context, key string) error {
+	if err := requireTenant(ctx); err != nil {
+		return err
+	}
+	if err := s.overrides.QueryBuilder().Eq("key", key).Delete(ctx); err != nil {
+		logger.Error(ctx, "Failed to delete system setting override", logger.Err(err), logger.String("key", key))
+		return errors.Wrap(err, "failed to delete system setting override")
+	}
+	return nil
+}
+
+func requireTenant(ctx context.Context) error {
+	if tenantID, scoped := pkgrepo.TenantOf(ctx); !scoped || tenantID == constant.PlatformTenantID {
+		return errors.WithStack(ErrOverrideNeedsTenant)
+	}
+	return nil
 }
 
 func (s *Service) checkKeyUnique(ctx context.Context, key string, excludeID uint64) error {
@@ -268,13 +308,23 @@ func typeDisplay(settingType uint8) string {
 	}
 }
 
-// toResponse 转换为响应对象，并按 create_by 附加创建人信息
+// toResponse 转换为响应对象，值为 ctx 所在租户的生效值
 func (s *Service) toResponse(ctx context.Context, setting *model.SystemSetting) (*dto.SystemSettingResponse, error) {
+	result, err := s.toResponseList(ctx, []model.SystemSetting{*setting})
+	if err != nil {
+		return nil, err
+	}
+	return result[0], nil
+}
+
+// buildResponse 转换为响应对象，并按 create_by 附加创建人信息
+func (s *Service) buildResponse(ctx context.Context, setting *model.SystemSetting, overridden bool) (*dto.SystemSettingResponse, error) {
 	var resp dto.SystemSettingResponse
 	if err := copier.Copy(&resp, setting); err != nil {
 		return nil, errors.Wrap(err, "failed to convert system setting to response")
 	}
 	resp.TypeDisplay = typeDisplay(setting.Type)
+	resp.Overridden = overridden
 
 	if creator, err := s.userFinder.FindByID(ctx, setting.CreateBy); err == nil {
 		resp.CreateBy = &dto.CreatorResponse{ID: creator.ID, Username: creator.Username}
@@ -283,9 +333,13 @@ func (s *Service) toResponse(ctx context.Context, setting *model.SystemSetting)
 }
 
 func (s *Service) toResponseList(ctx context.Context, settings []model.SystemSetting) ([]*dto.SystemSettingResponse, error) {
+	overridden, err := s.applyOverrides(ctx, settings)
+	if err != nil {
+		return nil, err
+	}
 	result := make([]*dto.SystemSettingResponse, 0, len(settings))
 	for i := range settings {
-		resp, err := s.toResponse(ctx, &settings[i])
+		resp, err := s.buildResponse(ctx, &settings[i], overridden[settings[i].Key])
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/ayxworxfr/go_admin/pkg/apiparam"
+)
+
+// CreateTenantRequest 创建租户请求
+type CreateTenantRequest struct {
+	Code        string `json:"code" vd:"len($)>0&&len($)<50"` // 小写字母、数字、- 与 _，见 service.ErrInvalidTenantCode
+	Name        string `json:"name" vd:"len($)>0&&len($)<100"`
+	Description string `json:"description" vd:"len($)<255"`
+}
+
+// UpdateTenantRequest 更新租户请求，code 创建后不可修改（已下发给客户端做登录标识）
+type UpdateTenantRequest struct {
+	ID          uint64 `json:"id" vd:"$>0"`
+	Name        string `json:"name" vd:"len($)<100"`
+	Description string `json:"description" vd:"len($)<255"`
+	Status      int    `json:"status" vd:"$>=0&&$<=2"` // 0 表示不修改
+}
+
+// GetTenantRequest 获取租户请求
+type GetTenantRequest struct {
+	ID uint64 `query:"id" vd:"$>0"`
+}
+
+// GetTenantListRequest 获取租户列表请求
+type GetTenantListRequest struct {
+	apiparam.Page
+	Code   string `query:"code" vd:"len($)>=0&&len($)<50" xorm:"code op=like"`
+	Name   string `query:"name" vd:"len($)>=0&&len($)<100" xorm:"name op=like"`
+	Status int    `query:"status" xorm:"status op=eq"`
+}
+
+// TenantResponse 租户视图对象
+type TenantResponse struct {
+	ID          uint64    `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Status      int       `json:"status"`
+	Platform    bool      `json:"platform"` // 是否为平台租户
+	CreateTime  time.Time `json:"create_time"`
+	UpdateTime  time.Time `json:"update_time"`
+}
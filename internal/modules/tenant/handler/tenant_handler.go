@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/tenant/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/tenant/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// Handler 租户管理接口，只对平台租户开放
+type Handler struct {
+	svc *service.Service
+}
+
+// NewHandler 创建租户处理器
+func NewHandler(svc *service.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// tenantError 非平台租户调用属于越权，code 冲突或不合法属于参数错误，其余按数据库错误处理
+func tenantError(err error) *api.Response {
+	switch {
+	case errors.Is(err, service.ErrPlatformOnly):
+		return api.Forbidden(err)
+	case errors.Is(err, service.ErrTenantNotFound):
+		return api.NotFound(err)
+	case errors.Is(err, service.ErrTenantCodeExists):
+		return api.Conflict(err)
+	case errors.Is(err, service.ErrInvalidTenantCode):
+		return api.ParamError(err)
+	default:
+		return api.DatabaseError(err)
+	}
+}
+
+// @route Post /tenant
+// CreateTenant 创建租户
+func (h *Handler) CreateTenant(c *api.Context, req *dto.CreateTenantRequest) *api.Response {
+	result, err := h.svc.Create(c.Context(), req)
+	if err != nil {
+		return tenantError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Put /tenant
+// UpdateTenant 更新租户，停用后该租户的用户不能再登录
+func (h *Handler) UpdateTenant(c *api.Context, req *dto.UpdateTenantRequest) *api.Response {
+	result, err := h.svc.Update(c.Context(), req)
+	if err != nil {
+		return tenantError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Get /tenant
+// GetTenant 获取单个租户
+func (h *Handler) GetTenant(c *api.Context, req *dto.GetTenantRequest) *api.Response {
+	result, err := h.svc.Get(c.Context(), req.ID)
+	if err != nil {
+		return tenantError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Get /tenant/list
+// GetTenantList 分页查询租户列表
+func (h *Handler) GetTenantList(c *api.Context, req *dto.GetTenantListRequest) *api.Response {
+	result, total, err := h.svc.List(c.Context(), req)
+	if err != nil {
+		return tenantError(err)
+	}
+	return api.PageSuccess(result, total)
+}
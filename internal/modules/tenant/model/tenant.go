@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// 租户状态，取值与用户状态的约定一致：0 不作为合法值
+const (
+	StatusActive   = 1 // 启用
+	StatusDisabled = 2 // 停用：拒绝该租户的新登录，已签发的令牌到期后失效
+)
+
+// Tenant 租户：一个部署里托管的一家客户组织。用户、角色、用户角色分配与系统配置的覆盖值
+// 按租户隔离（模型上标注 `tenant:"id"`，由 pkg/repository 自动过滤与写入），
+// 权限目录与系统配置的默认值是全部租户共用的。
+//
+// ID 为 constant.PlatformTenantID 的是平台租户，引入多租户前的存量数据都归它。
+// 租户表本身不分租户。
+type Tenant struct {
+	ID          uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	Code        string    `xorm:"varchar(50) notnull unique 'code'" json:"code"` // 登录时在 X-Tenant 请求头里传的标识
+	Name        string    `xorm:"varchar(100) notnull 'name'" json:"name"`
+	Description string    `xorm:"varchar(255) 'description'" json:"description"`
+	Status      int       `xorm:"int 'status'" json:"status"` // 取值见 StatusXxx 常量
+	CreateTime  time.Time `xorm:"created" json:"create_time"`
+	UpdateTime  time.Time `xorm:"updated" json:"update_time"`
+}
+
+// Active 租户是否启用
+func (t *Tenant) Active() bool {
+	return t.Status != StatusDisabled
+}
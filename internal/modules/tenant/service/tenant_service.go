@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/tenant/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/tenant/model"
+	"github.com/ayxworxfr/go_admin/pkg/constant"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/jinzhu/copier"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrTenantNotFound 租户不存在
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrTenantDisabled 租户已停用
+	ErrTenantDisabled = errors.New("tenant is disabled")
+	// ErrTenantCodeExists 租户 code 已被占用
+	ErrTenantCodeExists = errors.New("tenant code already exists")
+	// ErrInvalidTenantCode 租户 code 只能由小写字母、数字、- 与 _ 组成，且以字母或数字开头
+	ErrInvalidTenantCode = errors.New("invalid tenant code")
+	// ErrPlatformOnly 操作只允许在平台租户内（或跨租户时）进行
+	ErrPlatformOnly = errors.New("operation is restricted to the platform tenant")
+)
+
+var tenantCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,49}$`)
+
+// IsPlatformContext ctx 是否处在平台租户里：挂的是平台租户、平台管理员跨租户，
+// 或者根本没挂租户（定时任务、命令行工具）。管理租户、改动全部租户共用的数据
+// （权限目录、系统配置默认值）前用它把关，别的租户即使被授予了对应接口权限也做不了
+func IsPlatformContext(ctx context.Context) bool {
+	tenantID, scoped := pkgrepo.TenantOf(ctx)
+	return !scoped || tenantID == constant.PlatformTenantID
+}
+
+// Service 租户管理服务。租户表本身不分租户，管理接口只对平台租户开放；
+// ResolveTenant 供租户中间件在登录等未认证请求上把 X-Tenant 请求头解析为租户 ID。
+type Service struct {
+	repo *pkgrepo.Repository[model.Tenant]
+}
+
+// NewService 创建租户服务
+func NewService(db *pkgrepo.DB) *Service {
+	return &Service{repo: pkgrepo.NewRepository[model.Tenant](db)}
+}
+
+// ResolveTenant 按 code 查找启用中的租户。实现 middleware.TenantResolver
+func (s *Service) ResolveTenant(ctx context.Context, code string) (uint64, error) {
+	tenant, err := s.repo.Find(ctx, &model.Tenant{Code: code})
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return 0, errors.Wrapf(ErrTenantNotFound, "tenant %q", code)
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve tenant")
+	}
+	if !tenant.Active() {
+		return 0, errors.Wrapf(ErrTenantDisabled, "tenant %q", code)
+	}
+	return tenant.ID, nil
+}
+
+// Create 创建租户。新租户没有任何用户和角色，由平台管理员带着 X-Tenant 请求头进去初始化
+func (s *Service) Create(ctx context.Context, req *dto.CreateTenantRequest) (*dto.TenantResponse, error) {
+	if !IsPlatformContext(ctx) {
+		return nil, errors.WithStack(ErrPlatformOnly)
+	}
+	if !tenantCodePattern.MatchString(req.Code) {
+		return nil, errors.Wrapf(ErrInvalidTenantCode, "code %q", req.Code)
+	}
+	count, err := s.repo.QueryBuilder().Eq("code", req.Code).Count(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check tenant code")
+	}
+	if count > 0 {
+		return nil, errors.Wrapf(ErrTenantCodeExists, "code %q", req.Code)
+	}
+
+	tenant := &model.Tenant{Code: req.Code, Name: req.Name, Description: req.Description, Status: model.StatusActive}
+	if err := s.repo.Create(ctx, tenant); err != nil {
+		logger.Error(ctx, "Failed to create tenant", logger.Err(err), logger.String("code", req.Code))
+		return nil, errors.Wrap(err, "failed to create tenant")
+	}
+	logger.Info(ctx, "Tenant created", logger.Uint64("tenant_id", tenant.ID), logger.String("code", tenant.Code))
+	return toResponse(tenant)
+}
+
+// Update 更新租户名称、描述与状态。平台租户不能停用，否则平台管理员自己也登录不了
+func (s *Service) Update(ctx context.Context, req *dto.UpdateTenantRequest) (*dto.TenantResponse, error) {
+	if !IsPlatformContext(ctx) {
+		return nil, errors.WithStack(ErrPlatformOnly)
+	}
+	tenant, err := s.find(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ID == constant.PlatformTenantID && req.Status == model.StatusDisabled {
+		return nil, errors.New("platform tenant cannot be disabled")
+	}
+
+	if req.Name != "" {
+		tenant.Name = req.Name
+	}
+	if req.Description != "" {
+		tenant.Description = req.Description
+	}
+	if req.Status != 0 {
+		tenant.Status = req.Status
+	}
+	if err := s.repo.Update(ctx, tenant); err != nil {
+		logger.Error(ctx, "Failed to update tenant", logger.Err(err), logger.Uint64("tenant_id", req.ID))
+		return nil, errors.Wrap(err, "failed to update tenant")
+	}
+	logger.Info(ctx, "Tenant updated", logger.Uint64("tenant_id", tenant.ID), logger.Int("status", tenant.Status))
+	return toResponse(tenant)
+}
+
+// Get 获取单个租户
+func (s *Service) Get(ctx context.Context, id uint64) (*dto.TenantResponse, error) {
+	if !IsPlatformContext(ctx) {
+		return nil, errors.WithStack(ErrPlatformOnly)
+	}
+	tenant, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(tenant)
+}
+
+// List 分页查询租户列表
+func (s *Service) List(ctx context.Context, req *dto.GetTenantListRequest) ([]*dto.TenantResponse, int64, error) {
+	if !IsPlatformContext(ctx) {
+		return nil, 0, errors.WithStack(ErrPlatformOnly)
+	}
+	tenants, total, err := s.repo.FindPage(ctx, req, req.Limit, req.Offset)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve tenants", logger.Err(err))
+		return nil, 0, errors.Wrap(err, "failed to retrieve tenants")
+	}
+	result := make([]*dto.TenantResponse, 0, len(tenants))
+	for i := range tenants {
+		resp, err := toResponse(&tenants[i])
+		if err != nil {
+			return nil, 0, err
+		}
+		result = append(result, resp)
+	}
+	return result, total, nil
+}
+
+func (s *Service) find(ctx context.Context, id uint64) (*model.Tenant, error) {
+	tenant, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		return nil, errors.Wrapf(ErrTenantNotFound, "tenant %d", id)
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve tenant", logger.Err(err), logger.Uint64("tenant_id", id))
+		return nil, errors.Wrap(err, "failed to retrieve tenant")
+	}
+	return tenant, nil
+}
+
+func toResponse(tenant *model.Tenant) (*dto.TenantResponse, error) {
+	var resp dto.TenantResponse
+	if err := copier.Copy(&resp, tenant); err != nil {
+		return nil, errors.Wrap(err, "failed to convert tenant to response")
+	}
+	resp.Platform = tenant.ID == constant.PlatformTenantID
+	return &resp, nil
+}
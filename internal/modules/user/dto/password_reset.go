@@ -0,0 +1,12 @@
+package dto
+
+// ForgotPasswordRequest 申请找回密码
+type ForgotPasswordRequest struct {
+	Email string `json:"email" vd:"len($)>0&&len($)<100"`
+}
+
+// ResetPasswordRequest 凭邮件里的令牌设置新密码
+type ResetPasswordRequest struct {
+	Token       string `json:"token" vd:"len($)>0&&len($)<=128"`
+	NewPassword string `json:"new_password" vd:"len($)>0&&len($)<=128"`
+}
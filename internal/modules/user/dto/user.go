@@ -9,24 +9,26 @@ import (
 // CreateUserRequest 创建用户请求
 type CreateUserRequest struct {
 	Username  string   `json:"username" vd:"len($)>0&&len($)<50"`
-	Password  string   `json:"password" vd:"len($)>=6&&len($)<20"` // 明文；Service 落库前哈希为 PasswordHash
+	Password  string   `json:"password" vd:"len($)>0&&len($)<=128"` // 明文；强度由系统设置里的密码策略校验，Service 落库前哈希为 PasswordHash
 	Email     string   `json:"email" vd:"len($)>0&&len($)<100"`
 	Phone     string   `json:"phone" vd:"len($)<20"`
 	AvatarURL string   `json:"avatar_url" vd:"len($)<255"`
 	RoleIDs   []uint64 `json:"role_ids" vd:"len($)>0" msg:"role_ids is required and must be non-empty"` // 至少关联一个角色，创建后由 handler 编排调用 iam 分配
-	Status    int      `json:"status"`
+	Status    int      `json:"status" vd:"$>=0&&$<=3"`                                                  // 不传默认活跃
+	DeptID    uint64   `json:"dept_id"`                                                                 // 所属部门，0 表示未分配
 }
 
 // UpdateUserRequest 更新用户请求
 type UpdateUserRequest struct {
 	ID        uint64    `json:"id" vd:"$>0"`
 	Username  string    `json:"username" vd:"len($)>=0&&len($)<50"`
-	Password  string    `json:"password" vd:"len($)>=0||(len($)>=6&&len($)<20)"` // 明文；空串表示不修改 PasswordHash
+	Password  string    `json:"password" vd:"len($)<=128"` // 明文；空串表示不修改 PasswordHash，否则须满足密码策略
 	Email     string    `json:"email" vd:"len($)>=0&&len($)<100"`
 	Phone     string    `json:"phone" vd:"len($)<20"`
 	AvatarURL string    `json:"avatar_url" vd:"len($)<255"`
-	RoleIDs   *[]uint64 `json:"role_ids"` // 指针区分"未设置"和"清空角色"
-	Status    int       `json:"status"`
+	RoleIDs   *[]uint64 `json:"role_ids"`               // 指针区分"未设置"和"清空角色"
+	Status    int       `json:"status" vd:"$>=0&&$<=3"` // 0 表示不修改；改为禁用/锁定时立即吊销该用户已签发的令牌
+	DeptID    uint64    `json:"dept_id"`                // 0 表示不修改
 }
 
 // DeleteUserRequest 删除用户请求
@@ -34,6 +36,11 @@ type DeleteUserRequest struct {
 	IDs []uint64 `json:"ids" vd:"len($)>0"`
 }
 
+// RestoreUserRequest 从回收站恢复用户请求
+type RestoreUserRequest struct {
+	IDs []uint64 `json:"ids" vd:"len($)>0"`
+}
+
 // GetUserRequest 获取单个用户：id 与 username 二选一（都传时优先 id）
 type GetUserRequest struct {
 	ID       uint64 `query:"id"`
@@ -43,10 +50,20 @@ type GetUserRequest struct {
 // GetUserListRequest 获取用户列表请求
 type GetUserListRequest struct {
 	apiparam.Page
+	UserFilter
+}
+
+// UserFilter 用户列表与导出共用的筛选条件，xorm 标签供仓储按非零字段生成查询
+type UserFilter struct {
 	Username string `query:"username" vd:"len($)>=0&&len($)<50" xorm:"username op=like"`
 	Email    string `query:"email" vd:"len($)>=0&&len($)<100" xorm:"email op=like"`
 	Phone    string `query:"phone" vd:"len($)>=0&&len($)<20" xorm:"phone op=like"`
 	Status   int    `query:"status" xorm:"status op=eq"`
+	DeptID   uint64 `query:"dept_id" xorm:"dept_id op=eq"`
+	SubDepts bool   `query:"sub_depts"` // 为 true 时 dept_id 连同其下级部门一起筛选
+	Deleted  bool   `query:"deleted"`   // 为 true 时只查已删除的用户（回收站）
+	// DeptIDs 由 handler 按 dept_id 与 sub_depts 展开，不从请求绑定
+	DeptIDs []uint64 `query:"-" json:"-" xorm:"dept_id op=in"`
 }
 
 // UserResponse 用户视图对象
@@ -57,9 +74,14 @@ type UserResponse struct {
 	Phone         string    `json:"phone"`
 	AvatarURL     string    `json:"avatar_url"`
 	Status        int       `json:"status"`
+	DeptID        uint64    `json:"dept_id"`
 	CreateTime    time.Time `json:"create_time"`
 	UpdateTime    time.Time `json:"update_time"`
 	LastLoginTime time.Time `json:"last_login_time"`
+	// PasswordChangedAt 最近一次设置密码的时间，零值表示本地不托管密码
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	// DeleteTime 删除时间，只有回收站里的用户非零
+	DeleteTime time.Time `json:"delete_time"`
 }
 
 // UserRoutes 登录用户可访问的前端路由/菜单权限
@@ -68,3 +90,16 @@ type UserRoutes struct {
 	Role     string   `json:"role"`
 	Routes   []string `json:"routes"`
 }
+
+// UpdateProfileRequest 当前用户修改自己的资料，用户名、状态、角色只能由管理员修改
+type UpdateProfileRequest struct {
+	Email     string `json:"email" vd:"len($)>0&&len($)<100"`
+	Phone     string `json:"phone" vd:"len($)<20"`
+	AvatarURL string `json:"avatar_url" vd:"len($)<255"`
+}
+
+// ChangePasswordRequest 当前用户修改自己的密码，必须提供原密码
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" vd:"len($)>0&&len($)<=128"`
+	NewPassword string `json:"new_password" vd:"len($)>0&&len($)<=128"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"mime/multipart"
+
+	"github.com/cloudwego/hertz/pkg/app/server/binding"
+)
+
+// Validate 按 vd 标签校验，规则与 POST /user 绑定请求时相同，批量导入逐行复用
+func (r *CreateUserRequest) Validate() error {
+	return binding.Validate(r)
+}
+
+// ImportUsersRequest 批量导入用户：上传 CSV 或 XLSX，格式按文件扩展名判断。
+// 第一行为表头，识别 username、email、phone、roles、password 几列，列的顺序不限，其余列忽略
+type ImportUsersRequest struct {
+	File   *multipart.FileHeader `form:"file"`
+	DryRun bool                  `form:"dry_run" query:"dry_run"` // 只校验并返回逐行预览，不落库
+}
+
+// ImportUserRow 导入文件中一行的解析与校验结果
+type ImportUserRow struct {
+	Row      int      `json:"row"` // 在文件中的行号，表头是第 1 行
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Phone    string   `json:"phone"`
+	Roles    []string `json:"roles"`
+	Errors   []string `json:"errors,omitempty"`
+	UserID   uint64   `json:"user_id,omitempty"` // 导入成功后的用户 ID
+}
+
+// ImportUsersResponse 批量导入结果。只要有一行校验不通过整批都不导入，Imported 为 false
+type ImportUsersResponse struct {
+	DryRun   bool             `json:"dry_run"`
+	Imported bool             `json:"imported"`
+	Total    int              `json:"total"`
+	Invalid  int              `json:"invalid"`
+	Rows     []*ImportUserRow `json:"rows"`
+}
+
+// ExportUsersRequest 导出用户，筛选条件与用户列表相同，不分页
+type ExportUsersRequest struct {
+	UserFilter
+	Format string `query:"format"` // csv（默认）或 xlsx
+}
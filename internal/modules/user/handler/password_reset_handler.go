@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+)
+
+// PasswordResetHandler 找回密码，挂在公开路由组。未启用时 resetSvc 为 nil，两个接口都返回 404
+type PasswordResetHandler struct {
+	resetSvc *service.PasswordResetService
+}
+
+// NewPasswordResetHandler 创建找回密码处理器
+func NewPasswordResetHandler(resetSvc *service.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{resetSvc: resetSvc}
+}
+
+// @route Post /password/forgot
+// ForgotPassword 不论邮箱是否已注册都返回成功，邮件在后台发送
+func (h *PasswordResetHandler) ForgotPassword(c *api.Context, req *dto.ForgotPasswordRequest) *api.Response {
+	if h.resetSvc == nil {
+		return api.NotFound("password reset is not enabled")
+	}
+	h.resetSvc.RequestReset(c.Context(), req.Email)
+	return api.NoContent()
+}
+
+// @route Post /password/reset
+// ResetPassword 用邮件里的令牌设置新密码
+func (h *PasswordResetHandler) ResetPassword(c *api.Context, req *dto.ResetPasswordRequest) *api.Response {
+	if h.resetSvc == nil {
+		return api.NotFound("password reset is not enabled")
+	}
+	err := h.resetSvc.ConfirmReset(c.Context(), req.Token, req.NewPassword)
+	if errors.Is(err, service.ErrInvalidResetToken) {
+		return api.ParamError(service.ErrInvalidResetToken)
+	}
+	if err != nil {
+		return userError(err)
+	}
+	return api.NoContent()
+}
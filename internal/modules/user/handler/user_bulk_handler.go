@@ -0,0 +1,85 @@
+package handler
+
+import (
+	stdctx "context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/user/service"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/tabular"
+)
+
+// BulkHandler 批量导入导出用户
+type BulkHandler struct {
+	bulkSvc  *service.BulkService
+	deptTree DeptTree
+}
+
+// NewBulkHandler 创建批量导入导出处理器
+func NewBulkHandler(bulkSvc *service.BulkService, deptTree DeptTree) *BulkHandler {
+	return &BulkHandler{bulkSvc: bulkSvc, deptTree: deptTree}
+}
+
+// @route Post /user/import
+// ImportUsers 上传 CSV 或 XLSX（multipart 字段 file）。有行校验不通过时整批不导入，
+// 照常返回逐行结果；dry_run=true 时只校验
+func (h *BulkHandler) ImportUsers(c *api.Context, req *dto.ImportUsersRequest) *api.Response {
+	if req.File == nil {
+		return api.ParamError("file is required")
+	}
+	format, err := tabular.FormatOf(req.File.Filename)
+	if err != nil {
+		return api.ParamError(err)
+	}
+	f, err := req.File.Open()
+	if err != nil {
+		return api.ParamError(err)
+	}
+	defer f.Close()
+
+	result, err := h.bulkSvc.Import(c.Context(), format, f, req.DryRun)
+	switch {
+	case errors.Is(err, service.ErrInvalidImportFile), errors.Is(err, tabular.ErrMalformed):
+		return api.ParamError(err)
+	case err != nil:
+		return userError(err)
+	}
+	return api.Success(result)
+}
+
+// @route Get /user/export
+// ExportUsers 按用户列表的筛选条件导出，format 为 csv（默认）或 xlsx。
+// 文件边查边写、以分块编码下发；中途出错时连接被中断，下载方拿到的是不完整的文件而不是错误的文件
+func (h *BulkHandler) ExportUsers(c *api.Context, req *dto.ExportUsersRequest) *api.Response {
+	format, err := tabular.ParseFormat(req.Format)
+	if err != nil {
+		return api.ParamError(err)
+	}
+	if err := expandDeptFilter(c.Context(), h.deptTree, &req.UserFilter); err != nil {
+		return api.DatabaseError(err)
+	}
+
+	// 响应体在 handler 返回之后才开始读取，导出不能随请求 ctx 一起取消；
+	// 下载方断开时框架关闭管道，写入失败后导出随之结束
+	ctx := stdctx.WithoutCancel(c.Context())
+	pr, pw := io.Pipe()
+	go func() {
+		err := h.bulkSvc.Export(ctx, pw, format, &req.UserFilter)
+		if err != nil {
+			logger.Error(ctx, "Failed to export users", logger.Err(err))
+		}
+		pw.CloseWithError(err)
+	}()
+
+	filename := fmt.Sprintf("users_%s.%s", time.Now().Format("20060102150405"), format)
+	rc := c.Request()
+	rc.Response.Header.SetContentType(format.ContentType())
+	rc.Response.Header.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	rc.SetBodyStream(pr, -1)
+	return nil
+}
@@ -2,11 +2,14 @@ package handler
 
 import (
 	stdctx "context"
+	"errors"
 
 	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
 	"github.com/ayxworxfr/go_admin/internal/modules/user/model"
 	"github.com/ayxworxfr/go_admin/internal/modules/user/service"
 	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/ayxworxfr/go_admin/pkg/passwordpolicy"
 	"github.com/jinzhu/copier"
 )
 
@@ -18,9 +21,15 @@ type RoleAssigner interface {
 }
 
 // PermissionPathResolver 是渲染用户可访问路由所需的最小接口，由 iam 模块的
-// PermissionChecker 实现。
+// PermissionChecker 实现。role 为令牌里切换到的角色，为空表示全部角色。
 type PermissionPathResolver interface {
-	GetUserPermissionPaths(ctx stdctx.Context, userID uint64) ([]string, error)
+	GetUserPermissionPaths(ctx stdctx.Context, userID uint64, role string) ([]string, error)
+}
+
+// DeptTree 是按部门筛选用户时展开下级部门、以及校验 dept_id 所需的最小接口，由 org 模块的 Service 实现。
+// ctx 带着请求租户，别的租户的部门和不存在的部门一样返回空
+type DeptTree interface {
+	DescendantIDs(ctx stdctx.Context, deptID uint64) ([]uint64, error)
 }
 
 // Handler 用户管理接口。当前用户信息只从中间件注入的 JWT 载荷读取，
@@ -29,11 +38,12 @@ type Handler struct {
 	svc          *service.Service
 	roleAssigner RoleAssigner
 	permResolver PermissionPathResolver
+	deptTree     DeptTree
 }
 
 // NewHandler 创建用户处理器，依赖均由 Container 装配注入。
-func NewHandler(svc *service.Service, roleAssigner RoleAssigner, permResolver PermissionPathResolver) *Handler {
-	return &Handler{svc: svc, roleAssigner: roleAssigner, permResolver: permResolver}
+func NewHandler(svc *service.Service, roleAssigner RoleAssigner, permResolver PermissionPathResolver, deptTree DeptTree) *Handler {
+	return &Handler{svc: svc, roleAssigner: roleAssigner, permResolver: permResolver, deptTree: deptTree}
 }
 
 func toUserResponse(u *model.User) (*dto.UserResponse, error) {
@@ -44,6 +54,46 @@ func toUserResponse(u *model.User) (*dto.UserResponse, error) {
 	return &resp, nil
 }
 
+// userError 密码不满足策略是请求参数问题，其余按数据库错误返回
+func userError(err error) *api.Response {
+	var policyErr *passwordpolicy.Error
+	if errors.As(err, &policyErr) {
+		return api.ParamError(policyErr)
+	}
+	return api.DatabaseError(err)
+}
+
+// expandDeptFilter sub_depts 为 true 时把 dept_id 展开为该部门及其全部下级部门。
+// 部门不存在时保留原来的 dept_id 条件（查不到任何用户），而不是退化成不按部门筛选
+func expandDeptFilter(ctx stdctx.Context, tree DeptTree, f *dto.UserFilter) error {
+	if !f.SubDepts || f.DeptID == 0 {
+		return nil
+	}
+	ids, err := tree.DescendantIDs(ctx, f.DeptID)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		f.DeptID, f.DeptIDs = 0, ids
+	}
+	return nil
+}
+
+// checkDept 写入用户的 dept_id 必须是请求租户下存在的部门，0 表示未分配（更新时表示不修改）
+func checkDept(ctx stdctx.Context, tree DeptTree, deptID uint64) *api.Response {
+	if deptID == 0 {
+		return nil
+	}
+	ids, err := tree.DescendantIDs(ctx, deptID)
+	if err != nil {
+		return api.DatabaseError(err)
+	}
+	if len(ids) == 0 {
+		return api.ParamError("dept not found")
+	}
+	return nil
+}
+
 // @route Get /user
 func (h *Handler) GetUser(c *api.Context, req *dto.GetUserRequest) *api.Response {
 	var (
@@ -71,9 +121,12 @@ func (h *Handler) GetUser(c *api.Context, req *dto.GetUserRequest) *api.Response
 
 // @route Post /user
 func (h *Handler) CreateUser(c *api.Context, req *dto.CreateUserRequest) *api.Response {
+	if resp := checkDept(c.Context(), h.deptTree, req.DeptID); resp != nil {
+		return resp
+	}
 	u, err := h.svc.Create(c.Context(), req)
 	if err != nil {
-		return api.DatabaseError(err)
+		return userError(err)
 	}
 
 	if err := h.roleAssigner.AssignRoles(c.Context(), u.ID, req.RoleIDs); err != nil {
@@ -89,9 +142,21 @@ func (h *Handler) CreateUser(c *api.Context, req *dto.CreateUserRequest) *api.Re
 
 // @route Put /user
 func (h *Handler) UpdateUser(c *api.Context, req *dto.UpdateUserRequest) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	// 扮演令牌不能改账号的密码、角色或状态：否则扮演者能借被扮演用户的管理权限接管别的账号、
+	// 给自己授权，扮演结束后仍然有效
+	if claims.Impersonating() && (req.Password != "" || req.RoleIDs != nil || req.Status != 0) {
+		return api.Forbidden("impersonation tokens cannot change passwords, roles or account status")
+	}
+	if resp := checkDept(c.Context(), h.deptTree, req.DeptID); resp != nil {
+		return resp
+	}
 	u, err := h.svc.Update(c.Context(), req)
 	if err != nil {
-		return api.DatabaseError(err)
+		return userError(err)
 	}
 
 	if req.RoleIDs != nil {
@@ -109,6 +174,9 @@ func (h *Handler) UpdateUser(c *api.Context, req *dto.UpdateUserRequest) *api.Re
 
 // @route Get /user/list
 func (h *Handler) GetUserList(c *api.Context, req *dto.GetUserListRequest) *api.Response {
+	if err := expandDeptFilter(c.Context(), h.deptTree, &req.UserFilter); err != nil {
+		return api.DatabaseError(err)
+	}
 	data, total, err := h.svc.List(c.Context(), req)
 	if err != nil {
 		return api.DatabaseError(err)
@@ -133,6 +201,14 @@ func (h *Handler) DeleteUser(c *api.Context, req *dto.DeleteUserRequest) *api.Re
 	return api.NoContent()
 }
 
+// @route Put /user/restore
+func (h *Handler) RestoreUser(c *api.Context, req *dto.RestoreUserRequest) *api.Response {
+	if err := h.svc.RestoreUsers(c.Context(), req.IDs); err != nil {
+		return api.DatabaseError(err)
+	}
+	return api.NoContent()
+}
+
 // @route Get /user/routes
 func (h *Handler) GetUserRoutes(c *api.Context) *api.Response {
 	claims, err := c.Claims()
@@ -144,7 +220,7 @@ func (h *Handler) GetUserRoutes(c *api.Context) *api.Response {
 		return api.Unauthorized("Invalid token")
 	}
 
-	permissionPaths, err := h.permResolver.GetUserPermissionPaths(c.Context(), userID)
+	permissionPaths, err := h.permResolver.GetUserPermissionPaths(c.Context(), userID, claims.ActingRole)
 	if err != nil {
 		return api.InternalError(err)
 	}
@@ -169,6 +245,83 @@ func (h *Handler) GetUserCurrent(c *api.Context) *api.Response {
 		"userid": claims.Identity,
 		"email":  "antdesign@alipay.com",
 		"access": claims.RoleKey,
+		"roles":  claims.Roles,
 	}
 	return api.Success(result)
 }
+
+// 以下 /user/current/* 接口只作用于调用方自己，身份取自 JWT，请求体里没有用户 ID。
+// 它们在 JWT 中间件的 ExcludePaths 里免于 RBAC 路径校验：任何登录用户都能管理自己的资料，
+// 不需要为此授予能修改任意用户的 PUT /user 权限
+
+// @route Get /user/current/profile
+func (h *Handler) GetCurrentProfile(c *api.Context) *api.Response {
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	u, err := h.svc.FindByID(c.Context(), userID)
+	if err != nil {
+		return api.DatabaseError(err)
+	}
+
+	resp, err := toUserResponse(u)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(resp)
+}
+
+// @route Put /user/current/profile
+func (h *Handler) UpdateCurrentProfile(c *api.Context, req *dto.UpdateProfileRequest) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	// 扮演者改掉邮箱后就能经找回密码接管账号
+	if claims.Impersonating() {
+		return api.Forbidden("impersonation tokens cannot change the profile")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	u, err := h.svc.UpdateProfile(c.Context(), userID, req)
+	if err != nil {
+		return api.DatabaseError(err)
+	}
+
+	resp, err := toUserResponse(u)
+	if err != nil {
+		return api.InternalError(err)
+	}
+	return api.Success(resp)
+}
+
+// @route Put /user/current/password
+func (h *Handler) ChangeCurrentPassword(c *api.Context, req *dto.ChangePasswordRequest) *api.Response {
+	claims, err := c.Claims()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+	// 个人访问令牌是给脚本用的，拿到它不应该就能改掉账号密码
+	if claims.Type == jwtauth.PersonalTokenType {
+		return api.Forbidden("personal access tokens cannot change the password")
+	}
+	if claims.Impersonating() {
+		return api.Forbidden("impersonation tokens cannot change the password")
+	}
+	userID, err := c.UserID()
+	if err != nil {
+		return api.Unauthorized("Invalid token")
+	}
+
+	err = h.svc.ChangePassword(c.Context(), userID, claims.FamilyID, req)
+	if errors.Is(err, service.ErrIncorrectPassword) {
+		return api.ParamError("old password is incorrect")
+	}
+	if err != nil {
+		return userError(err)
+	}
+	return api.NoContent()
+}
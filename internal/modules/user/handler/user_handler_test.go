@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCurrentUserRejectsImpersonation 扮演者既不能改被扮演用户的邮箱（改完就能找回密码），
+// 也不能改密码；被拒绝时还没有走到服务层
+func TestCurrentUserRejectsImpersonation(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil)
+	newContext := func() *api.Context {
+		rc := app.NewContext(0)
+		rc.Set(jwtauth.ClaimsKey, &jwtauth.Claims{Identity: "2", Actor: &jwtauth.Actor{Identity: "1", Nice: "admin"}})
+		return api.New(context.Background(), rc)
+	}
+
+	resp := h.UpdateCurrentProfile(newContext(), &dto.UpdateProfileRequest{Email: "attacker@example.com"})
+	require.NotNil(t, resp)
+	assert.Equal(t, 403, resp.HTTPStatus())
+
+	resp = h.ChangeCurrentPassword(newContext(), &dto.ChangePasswordRequest{OldPassword: "x", NewPassword: "y"})
+	require.NotNil(t, resp)
+	assert.Equal(t, 403, resp.HTTPStatus())
+
+	// 管理接口同样不能借扮演令牌改别人的密码、角色和状态
+	roleIDs := []uint64{1}
+	for name, req := range map[string]*dto.UpdateUserRequest{
+		"password": {ID: 3, Password: "N3w-password"},
+		"roles":    {ID: 3, RoleIDs: &roleIDs},
+		"status":   {ID: 3, Status: 1},
+	} {
+		resp = h.UpdateUser(newContext(), req)
+		require.NotNil(t, resp, name)
+		assert.Equal(t, 403, resp.HTTPStatus(), name)
+	}
+}
+
+// deptsByTenant 按租户记录存在的部门
+type deptsByTenant map[uint64][]uint64
+
+func (d deptsByTenant) DescendantIDs(ctx context.Context, deptID uint64) ([]uint64, error) {
+	tenantID, _ := pkgrepo.TenantOf(ctx)
+	if lo.Contains(d[tenantID], deptID) {
+		return []uint64{deptID}, nil
+	}
+	return nil, nil
+}
+
+// TestUserRejectsUnknownDept 不存在的部门和别的租户的部门都不能写进用户资料，被拒绝时还没有走到服务层
+func TestUserRejectsUnknownDept(t *testing.T) {
+	h := NewHandler(nil, nil, nil, deptsByTenant{1: {10}, 2: {20}})
+	newContext := func() *api.Context {
+		rc := app.NewContext(0)
+		rc.Set(jwtauth.ClaimsKey, &jwtauth.Claims{Identity: "1"})
+		return api.New(pkgrepo.WithTenant(context.Background(), 1), rc)
+	}
+
+	for _, deptID := range []uint64{20, 99} {
+		resp := h.CreateUser(newContext(), &dto.CreateUserRequest{Username: "bob", DeptID: deptID})
+		require.NotNil(t, resp)
+		assert.Equal(t, 400, resp.HTTPStatus(), deptID)
+
+		resp = h.UpdateUser(newContext(), &dto.UpdateUserRequest{ID: 3, DeptID: deptID})
+		require.NotNil(t, resp)
+		assert.Equal(t, 400, resp.HTTPStatus(), deptID)
+	}
+}
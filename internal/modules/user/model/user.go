@@ -2,20 +2,53 @@ package model
 
 import "time"
 
+// 用户状态。0 不作为合法状态值：xorm 按非零字段更新，0 无法表达"改成某状态"，
+// 历史数据里未填写的 0 按活跃处理（见 CanLogin）。
+const (
+	StatusActive   = 1 // 活跃
+	StatusDisabled = 2 // 禁用：管理员停用账号
+	StatusLocked   = 3 // 锁定：管理员冻结账号，与登录失败触发的临时锁定无关
+)
+
 // User 用户模型。不再持有密码哈希/校验方法——加密算法是可替换的策略，
 // 由 Service 层持有 crypter.PasswordHasher 依赖，模型只保留纯数据结构，
 // 避免"数据结构与具体加密实现耦合"导致以后换算法要改模型定义。
+//
+// datascope 标签让仓储按数据范围自动过滤用户：ID 即归属人（"仅本人"看到自己），DeptID 为所属部门。
+// 用户属于一个租户，用户名与邮箱只在租户内唯一。删除是软删除，唯一键里不含删除时间，
+// 已删除用户的用户名与邮箱在被清理（PurgeDeleted）之前仍然占用
 type User struct {
-	ID       uint64 `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
-	Username string `xorm:"varchar(50) notnull unique 'username'" json:"username"`
+	ID       uint64 `xorm:"pk autoincr bigint unsigned 'id'" json:"id" datascope:"owner"`
+	TenantID uint64 `xorm:"bigint unsigned notnull default 1 unique(uk_tenant_username) unique(uk_tenant_email) 'tenant_id'" json:"tenant_id" tenant:"id"`
+	Username string `xorm:"varchar(50) notnull unique(uk_tenant_username) 'username'" json:"username"`
 	// PasswordHash 持久化的 Argon2id 自描述哈希串，不是明文密码。
 	// json:"-"：即使误把 model 直接序列化进响应，也不会泄露凭证材料。
 	PasswordHash  string    `xorm:"varchar(255) notnull 'password_hash'" json:"-"`
-	Email         string    `xorm:"varchar(100) notnull unique 'email'" json:"email"`
+	Email         string    `xorm:"varchar(100) notnull unique(uk_tenant_email) 'email'" json:"email"`
 	Phone         string    `xorm:"varchar(20) 'phone'" json:"phone"`
 	AvatarURL     string    `xorm:"varchar(255) 'avatar_url'" json:"avatar_url"`
-	Status        int       `xorm:"int 'status'" json:"status"`
+	Status        int       `xorm:"int 'status'" json:"status"` // 取值见 StatusXxx 常量
+	DeptID        uint64    `xorm:"bigint unsigned index 'dept_id'" json:"dept_id" datascope:"dept"`
 	CreateTime    time.Time `xorm:"created" json:"create_time"`
 	UpdateTime    time.Time `xorm:"updated" json:"update_time"`
 	LastLoginTime time.Time `xorm:"datetime 'last_login_time'" json:"last_login_time"`
+	// PasswordChangedAt 最近一次设置密码的时间，用于密码过期判断；
+	// 为空表示本地不托管密码（外部身份开户），或是引入该字段前就存在、还没改过密码的账号
+	PasswordChangedAt time.Time `xorm:"datetime 'password_changed_at'" json:"password_changed_at"`
+	// DeleteTime 软删除时间，为空表示未删除；仓储默认不返回已删除的用户，见 pkg/repository 的 WithDeleted
+	DeleteTime time.Time `xorm:"deleted index 'delete_time'" json:"delete_time"`
+}
+
+// CanLogin 账号是否允许登录和持有令牌：禁用、锁定之外的状态都放行
+func (u *User) CanLogin() bool {
+	return u.Status != StatusDisabled && u.Status != StatusLocked
+}
+
+// PasswordHistory 用户用过的密码哈希，只为"最近 N 次密码不能复用"这一条规则保留，
+// 每个用户至多保留策略允许的上限条数
+type PasswordHistory struct {
+	ID           uint64    `xorm:"pk autoincr bigint unsigned 'id'" json:"id"`
+	UserID       uint64    `xorm:"bigint unsigned notnull index 'user_id'" json:"user_id"`
+	PasswordHash string    `xorm:"varchar(255) notnull 'password_hash'" json:"-"`
+	CreateTime   time.Time `xorm:"created" json:"create_time"`
 }
@@ -0,0 +1,12 @@
+package service
+
+import "context"
+
+// AvatarSetter 是 user 模块对外暴露的头像维护能力（消费方视角）。
+// 头像文件由 file 模块保存和关联，这里只负责把用户资料上的 avatar_url 指向它。
+//
+// 依赖方向：file -> user.AvatarSetter，user 不反向依赖 file。
+type AvatarSetter interface {
+	// SetAvatarURL 改写用户的头像地址，url 为空表示清除
+	SetAvatarURL(ctx context.Context, userID uint64, url string) error
+}
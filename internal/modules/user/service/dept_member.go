@@ -0,0 +1,17 @@
+package service
+
+import "context"
+
+// DeptMembers 是 user 模块对外暴露的部门成员维护能力（消费方视角）。
+// 用户所属部门记在 user.dept_id 上，org 模块调整成员、删除部门前检查成员都经由它，
+// 不直接读写用户表。
+//
+// 依赖方向：org -> user.DeptMembers，user 不反向依赖 org。
+type DeptMembers interface {
+	// MoveToDept 把一批用户调入部门（从原部门移出）；有用户不存在时整批不改
+	MoveToDept(ctx context.Context, userIDs []uint64, deptID uint64) error
+	// RemoveFromDept 把一批用户移出部门，不在该部门的用户忽略
+	RemoveFromDept(ctx context.Context, deptID uint64, userIDs []uint64) error
+	// CountDeptMembers 部门的直接成员数，含回收站里的用户
+	CountDeptMembers(ctx context.Context, deptID uint64) (int64, error)
+}
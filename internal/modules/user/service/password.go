@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/passwordpolicy"
+	"github.com/pkg/errors"
+)
+
+// passwordPolicyKey 系统设置里密码策略的键，值为 passwordpolicy.Policy 的 JSON
+const passwordPolicyKey = "password_policy"
+
+// PasswordPolicy 读取当前生效的密码策略。每次都从系统设置读取，管理员修改后立即生效；
+// 配置缺失或读取失败时退回默认策略，而不是放弃检查
+func (s *Service) PasswordPolicy(ctx context.Context) passwordpolicy.Policy {
+	policy := passwordpolicy.Default()
+	if _, err := s.settings.GetJSON(ctx, passwordPolicyKey, &policy); err != nil {
+		logger.Error(ctx, "Failed to load password policy, using default", logger.Err(err))
+		return passwordpolicy.Default()
+	}
+	return policy.Normalize()
+}
+
+// PasswordExpired 实现 PasswordAging：按当前策略的 max_age_days 判断
+func (s *Service) PasswordExpired(ctx context.Context, u *model.User) bool {
+	return s.PasswordPolicy(ctx).Expired(u.PasswordChangedAt, time.Now())
+}
+
+// checkNewPassword 校验新密码：先过策略规则，再与当前密码及最近 history_count 次历史比对。
+// 复用同样以 *passwordpolicy.Error 返回，调用方统一当作参数错误处理
+func (s *Service) checkNewPassword(ctx context.Context, policy passwordpolicy.Policy, u *model.User, password string) error {
+	if err := policy.Validate(password, u.Username); err != nil {
+		return err
+	}
+	if policy.HistoryCount == 0 || u.ID == 0 {
+		return nil
+	}
+
+	reused := errors.WithStack(&passwordpolicy.Error{
+		Violations: []string{"must not reuse any of the last " + strconv.Itoa(policy.HistoryCount) + " passwords"},
+	})
+	if u.PasswordHash != "" && s.hasher.Verify(password, u.PasswordHash) {
+		return reused
+	}
+	history, err := s.historyRepo.QueryBuilder().Eq("user_id", u.ID).OrderBy("id DESC").Limit(policy.HistoryCount).Find(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve password history", logger.Err(err), logger.Uint64("user_id", u.ID))
+		return errors.Wrap(err, "failed to retrieve password history")
+	}
+	for _, h := range history {
+		// 当前密码已经比过，历史里最新一条通常就是它，不再重复做一次慢哈希
+		if h.PasswordHash != u.PasswordHash && s.hasher.Verify(password, h.PasswordHash) {
+			return reused
+		}
+	}
+	return nil
+}
+
+// replacePassword 校验新密码后替换 u 的密码并记入历史，调用方负责开启事务
+func (s *Service) replacePassword(ctx context.Context, u *model.User, password string) error {
+	if err := s.checkNewPassword(ctx, s.PasswordPolicy(ctx), u, password); err != nil {
+		return err
+	}
+	hashed, err := s.hasher.Hash(password)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash password")
+	}
+	changed := &model.User{ID: u.ID, PasswordHash: hashed, PasswordChangedAt: time.Now()}
+	if err := s.repo.Update(ctx, changed); err != nil {
+		logger.Error(ctx, "Failed to change password", logger.Err(err), logger.Uint64("user_id", u.ID))
+		return errors.Wrap(err, "failed to change password")
+	}
+	return s.recordPassword(ctx, u.ID, hashed)
+}
+
+// recordPassword 记入历史并清理超出上限的旧记录。上限固定取 MaxHistoryCount 而不是当前的
+// history_count，这样管理员之后调大该值时，历史数据已经在那里了
+func (s *Service) recordPassword(ctx context.Context, userID uint64, hash string) error {
+	if err := s.historyRepo.Create(ctx, &model.PasswordHistory{UserID: userID, PasswordHash: hash}); err != nil {
+		return errors.Wrap(err, "failed to record password history")
+	}
+	stale, err := s.historyRepo.QueryBuilder().Eq("user_id", userID).OrderBy("id DESC").
+		Offset(passwordpolicy.MaxHistoryCount).Limit(passwordpolicy.MaxHistoryCount).Find(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve password history")
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	ids := make([]uint64, 0, len(stale))
+	for _, h := range stale {
+		ids = append(ids, h.ID)
+	}
+	if err := s.historyRepo.QueryBuilder().In("id", ids).Delete(ctx); err != nil {
+		return errors.Wrap(err, "failed to prune password history")
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/model"
+)
+
+// PasswordAging 是 user 模块对外暴露的密码过期判断（消费方视角）。
+// iam 登录成功后据此在结果里标记"必须修改密码"，过期天数等规则仍由 user 模块按密码策略维护。
+//
+// 依赖方向：iam -> user.PasswordAging，user 不反向依赖 iam。
+type PasswordAging interface {
+	PasswordExpired(ctx context.Context, user *model.User) bool
+}
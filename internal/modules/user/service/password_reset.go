@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/notifier"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/resettoken"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidResetToken 重置令牌格式错误、签名不对、已过期或已被使用。
+// 几种情况对外一律返回这一个错误，不让调用方借此探测账号
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// PasswordResetOptions 找回密码参数，由组合根从配置映射而来
+type PasswordResetOptions struct {
+	// Secret 签名重置令牌的口令
+	Secret   string
+	TokenTTL time.Duration
+	// ResetURL 前端重置密码页，令牌以 token 查询参数附在后面
+	ResetURL string
+}
+
+// PasswordResetService 找回密码：按邮箱发送重置链接，凭链接里的令牌设置新密码。
+//
+// 令牌不落库：签名覆盖用户当前的密码哈希，密码一旦重置，之前发出的链接全部失效，
+// 天然一次性。两个接口对"账号是否存在"给出完全相同的响应，发信也放到后台，
+// 避免从响应内容或耗时上枚举注册邮箱。
+type PasswordResetService struct {
+	users    *Service
+	notifier notifier.Notifier
+	signer   *resettoken.Signer
+	opts     PasswordResetOptions
+}
+
+// NewPasswordResetService 创建找回密码服务
+func NewPasswordResetService(users *Service, n notifier.Notifier, opts PasswordResetOptions) *PasswordResetService {
+	return &PasswordResetService{
+		users:    users,
+		notifier: n,
+		signer:   resettoken.NewSigner(opts.Secret),
+		opts:     opts,
+	}
+}
+
+// RequestReset 受理找回密码请求后立即返回，查找账号和发信都在后台进行，
+// 结果只写日志。ctx 取消不会中断后台发信
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) {
+	go s.sendResetMail(context.WithoutCancel(ctx), email)
+}
+
+func (s *PasswordResetService) sendResetMail(ctx context.Context, email string) {
+	u, err := s.users.repo.Find(ctx, &model.User{Email: email})
+	if errors.Is(err, pkgrepo.ErrNotFound) {
+		logger.Info(ctx, "Password reset requested for unknown email")
+		return
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user for password reset", logger.Err(err))
+		return
+	}
+	if !u.CanLogin() {
+		logger.Info(ctx, "Password reset requested for inactive user", logger.Uint64("user_id", u.ID))
+		return
+	}
+
+	token := s.signer.Issue(u.ID, u.PasswordHash, time.Now().Add(s.opts.TokenTTL))
+	link, err := resetLink(s.opts.ResetURL, token)
+	if err != nil {
+		logger.Error(ctx, "Failed to build password reset link", logger.Err(err))
+		return
+	}
+	msg := notifier.Message{
+		To:      []string{u.Email},
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，您好：\n\n我们收到了重置您账号密码的请求。请在 %d 分钟内打开以下链接设置新密码：\n\n%s\n\n"+
+			"如果这不是您本人的操作，请忽略本邮件，您的密码不会改变。\n",
+			u.Username, int(s.opts.TokenTTL.Minutes()), link),
+	}
+	if err := s.notifier.Notify(ctx, msg); err != nil {
+		logger.Error(ctx, "Failed to send password reset mail", logger.Err(err), logger.Uint64("user_id", u.ID))
+		return
+	}
+	logger.Info(ctx, "Password reset mail sent", logger.Uint64("user_id", u.ID))
+}
+
+// ConfirmReset 校验令牌并设置新密码，成功后吊销该用户的全部令牌。
+// 令牌在行锁内按当前密码哈希校验，同一个链接并发提交两次只有一次能成功。
+// 令牌绑定了用户 ID，重置页的请求不带租户，按全部租户查找用户
+func (s *PasswordResetService) ConfirmReset(ctx context.Context, token, password string) error {
+	userID, err := resettoken.UserID(token)
+	if err != nil {
+		return errors.WithStack(ErrInvalidResetToken)
+	}
+	ctx = pkgrepo.WithAllTenants(ctx)
+
+	err = s.users.repo.Transaction(ctx, func(txCtx context.Context) error {
+		u, err := s.users.repo.QueryBuilder().Eq("id", userID).ForUpdate().First(txCtx)
+		if errors.Is(err, pkgrepo.ErrNotFound) {
+			return errors.WithStack(ErrInvalidResetToken)
+		}
+		if err != nil {
+			logger.Error(ctx, "Failed to retrieve user", logger.Err(err), logger.Uint64("user_id", userID))
+			return errors.Wrap(err, "failed to retrieve user")
+		}
+		if !u.CanLogin() {
+			return errors.WithStack(ErrInvalidResetToken)
+		}
+		if err := s.signer.Verify(token, u.PasswordHash, time.Now()); err != nil {
+			logger.Info(ctx, "Rejected password reset token", logger.Err(err), logger.Uint64("user_id", userID))
+			return errors.WithStack(ErrInvalidResetToken)
+		}
+		return s.users.replacePassword(txCtx, u, password)
+	})
+	if err != nil {
+		return err
+	}
+	return s.users.revokeTokens(ctx, userID)
+}
+
+func resetLink(base, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid password reset url")
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,14 @@
+package service
+
+import "context"
+
+// RelatedDataPurger 是物理删除用户时清理其它模块关联数据的能力（消费方视角），由 iam（外部身份、
+// 角色分配等）、file（头像等文件关联）、org（部门负责人）各自实现，PurgeDeleted 在删除用户的同一事务里
+// 依次调用：ctx 携带事务，实现方用同一个 *DB 上的仓储即可加入，任何一个失败整批回滚。
+// 否则外部身份绑定会留着指向已不存在的用户，同一个 IdP 账号再也登不进来。
+//
+// 依赖方向：iam/file/org -> user.RelatedDataPurger，user 不 import 它们。
+type RelatedDataPurger interface {
+	// PurgeUsers 删除或解除与这批用户的关联
+	PurgeUsers(ctx context.Context, userIDs []uint64) error
+}
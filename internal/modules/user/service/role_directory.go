@@ -0,0 +1,15 @@
+package service
+
+import "context"
+
+// RoleDirectory 是批量导入导出用户所需的角色能力（消费方视角），由 iam 模块的 UserRoleService 实现。
+// 导入导出的文件里角色一律写 code：ID 在不同环境之间对不上，code 才是管理员认得的名字。
+//
+// 依赖方向：user -> RoleDirectory，user 不 import iam（后者反过来依赖 user.UserFinder）。
+type RoleDirectory interface {
+	// RoleIDsByCodes 按 code 查本租户的角色 ID，查不到的 code 不出现在结果里
+	RoleIDsByCodes(ctx context.Context, codes []string) (map[string]uint64, error)
+	// RoleCodesByUsers 一批用户当前生效的角色 code
+	RoleCodesByUsers(ctx context.Context, userIDs []uint64) (map[uint64][]string, error)
+	AssignRoles(ctx context.Context, userID uint64, roleIDs []uint64) error
+}
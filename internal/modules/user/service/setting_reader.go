@@ -0,0 +1,11 @@
+package service
+
+import "context"
+
+// SettingReader 是 user 模块读取运行时配置所需的最小接口，由 systemsetting 模块的
+// Reader 隐式实现。found=false 表示配置项不存在，调用方回退到默认值。
+//
+// 依赖方向：user -> SettingReader，user 不 import systemsetting（后者反过来依赖 user.UserFinder）。
+type SettingReader interface {
+	GetJSON(ctx context.Context, key string, dst any) (found bool, err error)
+}
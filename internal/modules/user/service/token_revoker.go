@@ -0,0 +1,15 @@
+package service
+
+import "context"
+
+// TokenRevoker 是 user 模块停用账号、修改密码时所需的令牌吊销能力（消费方视角），由 iam 模块的
+// SessionService 实现。user 只需要表达"让这个用户已签发的令牌全部失效"或"只保留当前会话"，
+// 至于撤销名单存在哪、会话登记怎么清理，都是 iam 的内部细节。
+//
+// 依赖方向与 UserFinder 相反：user -> TokenRevoker <- iam，实现由 Container 注入，
+// user 包本身仍然不 import iam。
+type TokenRevoker interface {
+	RevokeUserTokens(ctx context.Context, userID uint64) error
+	// RevokeOtherSessions 结束用户除 keepSessionID 之外的全部会话
+	RevokeOtherSessions(ctx context.Context, userID uint64, keepSessionID string) error
+}
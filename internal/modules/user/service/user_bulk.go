@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/tabular"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+const (
+	// maxImportRows 单次导入的行数上限。整批在一个事务里落库、每行一次慢哈希，更多的用户应拆成几个文件
+	maxImportRows = 1000
+	// exportBatchSize 导出时每批从数据库读取的用户数
+	exportBatchSize  = 500
+	exportTimeLayout = "2006-01-02 15:04:05"
+)
+
+// ErrInvalidImportFile 导入文件整体不可用：缺少必需的列、没有数据行或超过行数上限。
+// 单行的问题不走这个错误，记在该行的 Errors 里
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+// importColumns 导入文件识别的列，requiredImportColumns 是其中必须出现的
+var (
+	importColumns         = []string{"username", "email", "phone", "roles", "password"}
+	requiredImportColumns = []string{"username", "email", "roles"}
+)
+
+// exportHeader 导出文件的表头。username、email、phone、roles 与导入文件同名，导出的文件删掉多余的列即可再导入
+var exportHeader = []string{"id", "username", "email", "phone", "roles", "status", "dept_id", "create_time", "last_login_time"}
+
+// BulkService 批量导入导出用户。
+//
+// 导入分两步：先逐行解析，按 CreateUserRequest 的规则和密码策略校验，并检查用户名、邮箱在文件内
+// 和租户内是否重复；全部通过后在一个事务里逐行建号、记密码历史、分配角色。任何一行有错整批不落库，
+// 返回逐行结果供修正后重传，DryRun 只做第一步。
+// 导出按 ID 游标分批读取、边读边写，不把全部用户装进内存。
+type BulkService struct {
+	users *Service
+	roles RoleDirectory
+}
+
+// NewBulkService 创建批量导入导出服务
+func NewBulkService(users *Service, roles RoleDirectory) *BulkService {
+	return &BulkService{users: users, roles: roles}
+}
+
+// importRow 导入文件中的一个数据行：view 是返回给调用方的逐行结果，req 是据此构造的建号请求
+type importRow struct {
+	view *dto.ImportUserRow
+	req  dto.CreateUserRequest
+}
+
+func (r *importRow) fail(format string, args ...any) {
+	r.view.Errors = append(r.view.Errors, fmt.Sprintf(format, args...))
+}
+
+// Import 从表格文件导入用户。文件本身不可用时返回 ErrInvalidImportFile 或 tabular 的格式错误；
+// 有行校验不通过时不返回错误，结果里 Imported 为 false。
+// 没填密码的行由 CreateExternal 生成随机密码，用户需经找回密码设置自己的密码
+func (s *BulkService) Import(ctx context.Context, format tabular.Format, r io.Reader, dryRun bool) (*dto.ImportUsersResponse, error) {
+	// 表头占一行
+	table, err := tabular.ReadAll(r, format, maxImportRows+1)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parseImportRows(table)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateImport(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	result := &dto.ImportUsersResponse{DryRun: dryRun, Total: len(rows), Rows: make([]*dto.ImportUserRow, 0, len(rows))}
+	for _, row := range rows {
+		result.Rows = append(result.Rows, row.view)
+		if len(row.view.Errors) > 0 {
+			result.Invalid++
+		}
+	}
+	if dryRun || result.Invalid > 0 {
+		return result, nil
+	}
+
+	err = s.users.repo.Transaction(ctx, func(txCtx context.Context) error {
+		for _, row := range rows {
+			create := s.users.Create
+			if row.req.Password == "" {
+				create = s.users.CreateExternal
+			}
+			u, err := create(txCtx, &row.req)
+			if err != nil {
+				return errors.Wrapf(err, "failed to import row %d", row.view.Row)
+			}
+			if err := s.roles.AssignRoles(txCtx, u.ID, row.req.RoleIDs); err != nil {
+				return errors.Wrapf(err, "failed to assign roles of row %d", row.view.Row)
+			}
+			row.view.UserID = u.ID
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to import users", logger.Err(err), logger.Int("rows", len(rows)))
+		return nil, err
+	}
+	result.Imported = true
+	return result, nil
+}
+
+// parseImportRows 按表头定位各列，跳过空行，返回数据行
+func parseImportRows(table [][]string) ([]*importRow, error) {
+	if len(table) == 0 {
+		return nil, errors.Wrap(ErrInvalidImportFile, "header row is missing")
+	}
+	cols := make(map[string]int)
+	for i, name := range table[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, dup := cols[name]; !dup && lo.Contains(importColumns, name) {
+			cols[name] = i
+		}
+	}
+	for _, name := range requiredImportColumns {
+		if _, ok := cols[name]; !ok {
+			return nil, errors.Wrapf(ErrInvalidImportFile, "column %s is missing", name)
+		}
+	}
+	cell := func(record []string, name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []*importRow
+	for i, record := range table[1:] {
+		if lo.EveryBy(record, func(c string) bool { return strings.TrimSpace(c) == "" }) {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, errors.Wrapf(ErrInvalidImportFile, "more than %d rows", maxImportRows)
+		}
+		row := &importRow{view: &dto.ImportUserRow{
+			Row:      i + 2,
+			Username: cell(record, "username"),
+			Email:    cell(record, "email"),
+			Phone:    cell(record, "phone"),
+			Roles:    splitRoleCodes(cell(record, "roles")),
+		}}
+		row.req = dto.CreateUserRequest{
+			Username: row.view.Username,
+			Email:    row.view.Email,
+			Phone:    row.view.Phone,
+			Password: cell(record, "password"),
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.Wrap(ErrInvalidImportFile, "no data rows")
+	}
+	return rows, nil
+}
+
+// splitRoleCodes 一个单元格里的多个角色 code 可以用逗号、分号、竖线或空白分隔
+func splitRoleCodes(s string) []string {
+	codes := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '，' || r == ';' || r == '|' || unicode.IsSpace(r)
+	})
+	return lo.Uniq(codes)
+}
+
+// validateImport 逐行校验，问题记在各行的 Errors 里，只有查询失败才返回错误
+func (s *BulkService) validateImport(ctx context.Context, rows []*importRow) error {
+	codes := lo.FlatMap(rows, func(row *importRow, _ int) []string { return row.view.Roles })
+	roleIDs, err := s.roles.RoleIDsByCodes(ctx, codes)
+	if err != nil {
+		logger.Error(ctx, "Failed to resolve role codes", logger.Err(err))
+		return errors.Wrap(err, "failed to resolve role codes")
+	}
+	takenNames, takenEmails, err := s.takenAccounts(ctx, rows)
+	if err != nil {
+		return err
+	}
+
+	policy := s.users.PasswordPolicy(ctx)
+	firstByName := make(map[string]int, len(rows))
+	firstByEmail := make(map[string]int, len(rows))
+	for _, row := range rows {
+		for _, code := range row.view.Roles {
+			if id, ok := roleIDs[code]; ok {
+				row.req.RoleIDs = append(row.req.RoleIDs, id)
+			} else {
+				row.fail("role %s does not exist", code)
+			}
+		}
+
+		// 没填密码的行不托管本地密码，按 CreateUserRequest 校验其余字段时用占位值跳过密码
+		check := row.req
+		if check.Password == "" {
+			check.Password = "-"
+		} else if err := policy.Validate(row.req.Password, row.req.Username); err != nil {
+			row.fail("%s", err.Error())
+		}
+		if err := check.Validate(); err != nil {
+			row.fail("%s", err.Error())
+		}
+
+		// MySQL 默认排序规则下唯一键不区分大小写，文件内查重也照此处理
+		name, email := strings.ToLower(row.req.Username), strings.ToLower(row.req.Email)
+		if first, ok := firstByName[name]; ok && name != "" {
+			row.fail("username %s duplicates row %d", row.req.Username, first)
+		} else if takenNames[name] {
+			row.fail("username %s already exists", row.req.Username)
+		} else {
+			firstByName[name] = row.view.Row
+		}
+		if first, ok := firstByEmail[email]; ok && email != "" {
+			row.fail("email %s duplicates row %d", row.req.Email, first)
+		} else if takenEmails[email] {
+			row.fail("email %s already exists", row.req.Email)
+		} else {
+			firstByEmail[email] = row.view.Row
+		}
+	}
+	return nil
+}
+
+// takenAccounts 文件里已被本租户占用的用户名与邮箱（小写）。
+// 唯一键按租户生效而不是按数据范围，回收站里的用户也仍然占用，所以查询时两者都不过滤
+func (s *BulkService) takenAccounts(ctx context.Context, rows []*importRow) (names, emails map[string]bool, err error) {
+	ctx = pkgrepo.WithDeleted(pkgrepo.WithoutDataScope(ctx))
+	names, emails = make(map[string]bool), make(map[string]bool)
+
+	usernames := lo.Uniq(lo.Map(rows, func(row *importRow, _ int) string { return row.req.Username }))
+	byName, err := s.users.repo.QueryBuilder().In("username", usernames).Find(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve users", logger.Err(err))
+		return nil, nil, errors.Wrap(err, "failed to retrieve users")
+	}
+	for _, u := range byName {
+		names[strings.ToLower(u.Username)] = true
+	}
+
+	addresses := lo.Uniq(lo.Map(rows, func(row *importRow, _ int) string { return row.req.Email }))
+	byEmail, err := s.users.repo.QueryBuilder().In("email", addresses).Find(ctx)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve users", logger.Err(err))
+		return nil, nil, errors.Wrap(err, "failed to retrieve users")
+	}
+	for _, u := range byEmail {
+		emails[strings.ToLower(u.Email)] = true
+	}
+	return names, emails, nil
+}
+
+// Export 按与用户列表相同的筛选条件导出用户，逐批写入 w。
+// 中途出错时 w 里只有部分内容，调用方负责让下载方知道文件不完整
+func (s *BulkService) Export(ctx context.Context, w io.Writer, format tabular.Format, filter *dto.UserFilter) error {
+	if filter.Deleted {
+		ctx = pkgrepo.OnlyDeleted(ctx)
+	}
+	tw, err := tabular.NewWriter(w, format)
+	if err != nil {
+		return err
+	}
+	if err := tw.Write(exportHeader); err != nil {
+		return errors.Wrap(err, "failed to write export header")
+	}
+
+	var lastID uint64
+	for {
+		users, err := s.users.repo.QueryBuilder().Filter(filter).Gt("id", lastID).OrderBy("id").Limit(exportBatchSize).Find(ctx)
+		if err != nil {
+			logger.Error(ctx, "Failed to retrieve users", logger.Err(err), logger.Uint64("after_id", lastID))
+			return errors.Wrap(err, "failed to retrieve users")
+		}
+		if len(users) == 0 {
+			break
+		}
+		ids := lo.Map(users, func(u model.User, _ int) uint64 { return u.ID })
+		roles, err := s.roles.RoleCodesByUsers(ctx, ids)
+		if err != nil {
+			logger.Error(ctx, "Failed to retrieve user roles", logger.Err(err))
+			return errors.Wrap(err, "failed to retrieve user roles")
+		}
+		for i := range users {
+			if err := tw.Write(exportRecord(&users[i], roles[users[i].ID])); err != nil {
+				return errors.Wrap(err, "failed to write export row")
+			}
+		}
+		if len(users) < exportBatchSize {
+			break
+		}
+		lastID = users[len(users)-1].ID
+	}
+	return tw.Close()
+}
+
+func exportRecord(u *model.User, roles []string) []string {
+	return []string{
+		strconv.FormatUint(u.ID, 10),
+		u.Username,
+		u.Email,
+		u.Phone,
+		strings.Join(roles, ","),
+		strconv.Itoa(u.Status),
+		strconv.FormatUint(u.DeptID, 10),
+		formatExportTime(u.CreateTime),
+		formatExportTime(u.LastLoginTime),
+	}
+}
+
+// formatExportTime 零值（从未登录等）输出空串
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(exportTimeLayout)
+}
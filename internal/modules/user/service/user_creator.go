@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/user/model"
+)
+
+// UserCreator 是 user 模块对外暴露的建号能力（消费方视角）。
+// iam 的 OIDC 登录在外部身份首次出现时按需开户：这类账号由 IdP 认证，本地不托管密码，
+// 因此不走密码策略，也不参与密码过期；状态默认值等其余规则与管理员建号共用一份。
+//
+// 依赖方向：iam -> user.UserCreator，user 不反向依赖 iam。
+type UserCreator interface {
+	CreateExternal(ctx context.Context, req *dto.CreateUserRequest) (*model.User, error)
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"time"
 
 	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
@@ -12,27 +14,39 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/jinzhu/copier"
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 )
 
+// ErrIncorrectPassword 自助修改密码时原密码不正确
+var ErrIncorrectPassword = errors.New("incorrect password")
+
 // Service 用户服务：负责用户 CRUD 与密码校验。
 // 密码哈希算法通过 crypter.PasswordHasher 接口注入（策略模式），
 // 替换旧版写死调用全局 crypter.Instance 的方式，换算法只需换一个实现，
 // Service 本身不用改。
 type Service struct {
-	repo   *pkgrepo.Repository[model.User]
-	hasher crypter.PasswordHasher
+	repo        *pkgrepo.Repository[model.User]
+	historyRepo *pkgrepo.Repository[model.PasswordHistory]
+	hasher      crypter.PasswordHasher
+	revoker     TokenRevoker
+	settings    SettingReader
+	purgers     []RelatedDataPurger
 }
 
-// NewService 创建用户服务。db 用于构造内部仓储，hasher 由 Container 统一装配。
+// NewService 创建用户服务。db 用于构造内部仓储，hasher/revoker/settings/purgers 由 Container 统一装配。
 //
 // repo 字段直接调用 pkg/repository 的泛型构造函数生成，不再单独包一层
 // internal/repository 子包——这里没有任何自定义查询，repo 字段本身是
 // unexported，handler 拿不到 *Service 的内部字段，多一层子包只是重复
 // Go 已经免费提供的封装，不需要为一个单行包装函数多开一个包。
-func NewService(db *pkgrepo.DB, hasher crypter.PasswordHasher) *Service {
+func NewService(db *pkgrepo.DB, hasher crypter.PasswordHasher, revoker TokenRevoker, settings SettingReader, purgers ...RelatedDataPurger) *Service {
 	return &Service{
-		repo:   pkgrepo.NewRepository[model.User](db),
-		hasher: hasher,
+		repo:        pkgrepo.NewRepository[model.User](db),
+		historyRepo: pkgrepo.NewRepository[model.PasswordHistory](db),
+		hasher:      hasher,
+		revoker:     revoker,
+		settings:    settings,
+		purgers:     purgers,
 	}
 }
 
@@ -65,34 +79,88 @@ func (s *Service) UpdateLastLoginTime(ctx context.Context, userID uint64) error
 	return nil
 }
 
-// Create 创建用户（明文密码只存在于 DTO；落库前在此哈希为 PasswordHash）
+// Create 创建用户（明文密码只存在于 DTO；落库前在此哈希为 PasswordHash）。
+// 密码不满足策略时返回 *passwordpolicy.Error
 func (s *Service) Create(ctx context.Context, req *dto.CreateUserRequest) (*model.User, error) {
 	var u model.User
 	// 字段名刻意不同：req.Password（明文）不会被 copier 拷进 u.PasswordHash（哈希）
 	if err := copier.Copy(&u, req); err != nil {
 		return nil, errors.Wrap(err, "failed to copy request to user")
 	}
+	if err := s.PasswordPolicy(ctx).Validate(req.Password, u.Username); err != nil {
+		return nil, err
+	}
 
 	hashed, err := s.hasher.Hash(req.Password)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to hash password")
 	}
 	u.PasswordHash = hashed
+	u.PasswordChangedAt = time.Now()
 
-	if err := s.repo.Create(ctx, &u); err != nil {
-		logger.Error(ctx, "Failed to create user", logger.Err(err))
-		return nil, errors.Wrap(err, "failed to create user")
+	err = s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		if err := s.create(txCtx, &u); err != nil {
+			return err
+		}
+		return s.recordPassword(txCtx, u.ID, hashed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CreateExternal 实现 UserCreator：为外部身份开户。密码是随机生成后即丢弃的，
+// 账号只能经 IdP 登录，除非管理员之后为其设置密码
+func (s *Service) CreateExternal(ctx context.Context, req *dto.CreateUserRequest) (*model.User, error) {
+	var u model.User
+	if err := copier.Copy(&u, req); err != nil {
+		return nil, errors.Wrap(err, "failed to copy request to user")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Wrap(err, "failed to generate password")
+	}
+	hashed, err := s.hasher.Hash(base64.RawStdEncoding.EncodeToString(secret))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash password")
+	}
+	u.PasswordHash = hashed
+
+	if err := s.create(ctx, &u); err != nil {
+		return nil, err
 	}
 	return &u, nil
 }
 
-// Update 更新用户。密码为空表示不修改，保留原 PasswordHash。
+func (s *Service) create(ctx context.Context, u *model.User) error {
+	if u.Status == 0 {
+		u.Status = model.StatusActive
+	}
+	if err := s.repo.Create(ctx, u); err != nil {
+		logger.Error(ctx, "Failed to create user", logger.Err(err))
+		return errors.Wrap(err, "failed to create user")
+	}
+	return nil
+}
+
+// Update 更新用户。密码为空表示不修改，保留原 PasswordHash；新密码须满足密码策略且不能与最近几次重复。
+// 账号由可登录变为禁用/锁定时，落库后立即吊销该用户已签发的全部令牌。
 func (s *Service) Update(ctx context.Context, req *dto.UpdateUserRequest) (*model.User, error) {
 	u, err := s.repo.FindByID(ctx, req.ID)
 	if err != nil {
 		logger.Error(ctx, "Failed to retrieve user", logger.Err(err), logger.Uint64("user_id", req.ID))
 		return nil, errors.Wrap(err, "failed to retrieve user")
 	}
+	wasActive := u.CanLogin()
+
+	// 密码策略要对照库里的用户名：下面的 copier 会把请求里留空的 username 也拷过来
+	if req.Password != "" {
+		if err := s.checkNewPassword(ctx, s.PasswordPolicy(ctx), u, req.Password); err != nil {
+			return nil, err
+		}
+	}
 
 	// copier 只按同名字段拷贝；Password ≠ PasswordHash，原哈希天然不会被明文覆盖
 	if err := copier.Copy(u, req); err != nil {
@@ -105,27 +173,99 @@ func (s *Service) Update(ctx context.Context, req *dto.UpdateUserRequest) (*mode
 			return nil, errors.Wrap(err, "failed to hash password")
 		}
 		u.PasswordHash = hashed
+		u.PasswordChangedAt = time.Now()
 	}
 
-	if err := s.repo.Update(ctx, u); err != nil {
-		logger.Error(ctx, "Failed to update user", logger.Err(err), logger.Uint64("user_id", req.ID))
-		return nil, errors.Wrap(err, "failed to update user")
+	err = s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		if err := s.repo.Update(txCtx, u); err != nil {
+			logger.Error(ctx, "Failed to update user", logger.Err(err), logger.Uint64("user_id", req.ID))
+			return errors.Wrap(err, "failed to update user")
+		}
+		if req.Password == "" {
+			return nil
+		}
+		return s.recordPassword(txCtx, u.ID, u.PasswordHash)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if wasActive && !u.CanLogin() {
+		if err := s.revokeTokens(ctx, u.ID); err != nil {
+			return nil, err
+		}
 	}
 	return u, nil
 }
 
-// DeleteUsers 按 ID 批量删除用户。
+// UpdateProfile 用户修改自己的资料，只写邮箱、手机号、头像三列。
+// phone/avatar_url 列入 mustCols，允许用户把它们清空
+func (s *Service) UpdateProfile(ctx context.Context, userID uint64, req *dto.UpdateProfileRequest) (*model.User, error) {
+	profile := &model.User{ID: userID, Email: req.Email, Phone: req.Phone, AvatarURL: req.AvatarURL}
+	if err := s.repo.Update(ctx, profile, "phone", "avatar_url"); err != nil {
+		logger.Error(ctx, "Failed to update profile", logger.Err(err), logger.Uint64("user_id", userID))
+		return nil, errors.Wrap(err, "failed to update profile")
+	}
+	return s.repo.FindByID(ctx, userID)
+}
+
+// SetAvatarURL 实现 AvatarSetter
+func (s *Service) SetAvatarURL(ctx context.Context, userID uint64, url string) error {
+	if err := s.repo.Update(ctx, &model.User{ID: userID, AvatarURL: url}, "avatar_url"); err != nil {
+		logger.Error(ctx, "Failed to update avatar", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to update avatar")
+	}
+	return nil
+}
+
+// ChangePassword 用户修改自己的密码：必须提供原密码，新密码走与管理员改密相同的策略和历史检查。
+// 成功后结束该用户除 sessionID 以外的全部会话——改密往往是怀疑密码泄露，
+// 别处登录的会话不能继续有效，而发起修改的这台设备没必要被踢下线
+func (s *Service) ChangePassword(ctx context.Context, userID uint64, sessionID string, req *dto.ChangePasswordRequest) error {
+	u, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		logger.Error(ctx, "Failed to retrieve user", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to retrieve user")
+	}
+	if !s.hasher.Verify(req.OldPassword, u.PasswordHash) {
+		return errors.WithStack(ErrIncorrectPassword)
+	}
+	err = s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		return s.replacePassword(txCtx, u, req.NewPassword)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revoker.RevokeOtherSessions(ctx, userID, sessionID); err != nil {
+		logger.Error(ctx, "Failed to revoke other sessions", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrap(err, "failed to revoke other sessions")
+	}
+	return nil
+}
+
+// DeleteUsers 按 ID 批量（软）删除用户，删除的用户进回收站，可以用 RestoreUsers 恢复。
 //
 // 旧版实现把 DeleteUserRequest{IDs} 直接 copier.Copy 进 model.User 再整体
 // Delete：由于两者字段名不匹配，拷贝后的 model.User 是零值，Delete 会按
 // "ID=0" 这个（几乎必然不存在的）条件删除，实际上什么都没删掉——请求方
 // 以为删除成功，数据库里记录原样还在。这里改成逐个按 ID 删除并收集错误，
 // 才是"删除请求里的每一个 ID"这句需求本身该有的实现。
+// 删除成功的用户同时吊销其已签发的令牌，否则被删账号手里的 token 仍能通过鉴权。
 func (s *Service) DeleteUsers(ctx context.Context, ids []uint64) error {
 	var result *multierror.Error
 	for _, id := range ids {
+		// 按主键删除不受数据范围约束，先按范围查一次：范围外的用户与不存在同样处理
+		if _, err := s.repo.FindByID(ctx, id); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to retrieve user %d", id))
+			continue
+		}
 		if err := s.repo.DeleteByID(ctx, id); err != nil {
 			result = multierror.Append(result, errors.Wrapf(err, "failed to delete user %d", id))
+			continue
+		}
+		if err := s.revokeTokens(ctx, id); err != nil {
+			result = multierror.Append(result, err)
 		}
 	}
 	if err := result.ErrorOrNil(); err != nil {
@@ -135,7 +275,123 @@ func (s *Service) DeleteUsers(ctx context.Context, ids []uint64) error {
 	return nil
 }
 
-// List 分页查询用户列表
+// RestoreUsers 恢复回收站里的用户，与删除一样逐个处理并收集错误。
+// 删除时吊销的令牌不会回来，恢复后的用户需要重新登录
+func (s *Service) RestoreUsers(ctx context.Context, ids []uint64) error {
+	var result *multierror.Error
+	deleted := pkgrepo.OnlyDeleted(ctx)
+	for _, id := range ids {
+		// 与删除同理，先在数据范围内确认该用户确实已被删除
+		if _, err := s.repo.FindByID(deleted, id); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to retrieve deleted user %d", id))
+			continue
+		}
+		if err := s.repo.Restore(ctx, id); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to restore user %d", id))
+		}
+	}
+	if err := result.ErrorOrNil(); err != nil {
+		logger.Error(ctx, "Failed to restore users", logger.Err(err), logger.Uint64s("user_ids", ids))
+		return err
+	}
+	return nil
+}
+
+// MoveToDept 实现 DeptMembers：数据范围外的用户与不存在同样处理，整批不改
+func (s *Service) MoveToDept(ctx context.Context, userIDs []uint64, deptID uint64) error {
+	userIDs = lo.Uniq(userIDs)
+	count, err := s.repo.QueryBuilder().In("id", userIDs).Count(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to check users")
+	}
+	if count != int64(len(userIDs)) {
+		return errors.Wrap(pkgrepo.ErrNotFound, "some users do not exist")
+	}
+	return s.setDept(ctx, userIDs, deptID)
+}
+
+// RemoveFromDept 实现 DeptMembers
+func (s *Service) RemoveFromDept(ctx context.Context, deptID uint64, userIDs []uint64) error {
+	members, err := s.repo.QueryBuilder().Eq("dept_id", deptID).In("id", lo.Uniq(userIDs)).Find(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve dept members")
+	}
+	return s.setDept(ctx, lo.Map(members, func(u model.User, _ int) uint64 { return u.ID }), 0)
+}
+
+// CountDeptMembers 实现 DeptMembers。部门删除前据此确认没有成员，
+// 数据范围外的成员和回收站里的用户也要算上，否则恢复出来的用户会挂在不存在的部门下
+func (s *Service) CountDeptMembers(ctx context.Context, deptID uint64) (int64, error) {
+	ctx = pkgrepo.WithDeleted(pkgrepo.WithoutDataScope(ctx))
+	count, err := s.repo.QueryBuilder().Eq("dept_id", deptID).Count(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count dept members")
+	}
+	return count, nil
+}
+
+func (s *Service) setDept(ctx context.Context, userIDs []uint64, deptID uint64) error {
+	err := s.repo.Transaction(ctx, func(txCtx context.Context) error {
+		for _, id := range userIDs {
+			if err := s.repo.Update(txCtx, &model.User{ID: id, DeptID: deptID}, "dept_id"); err != nil {
+				return errors.Wrapf(err, "failed to update dept of user %d", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to update user dept", logger.Err(err), logger.Uint64s("user_ids", userIDs), logger.Uint64("dept_id", deptID))
+		return err
+	}
+	return nil
+}
+
+// PurgeDeleted 物理删除 before 之前软删除的用户及其历史密码，连同其它模块经 RelatedDataPurger 登记的
+// 关联数据，返回清理的用户数，供定时任务调用。所属部门记在用户行上，随用户一起删除
+func (s *Service) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	users, err := s.repo.QueryBuilder().Lt("delete_time", before).Find(pkgrepo.OnlyDeleted(ctx))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve deleted users")
+	}
+	if len(users) == 0 {
+		return 0, nil
+	}
+	ids := make([]uint64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	err = s.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.historyRepo.QueryBuilder().In("user_id", ids).Delete(ctx); err != nil {
+			return errors.Wrap(err, "failed to delete password history")
+		}
+		for _, purger := range s.purgers {
+			if err := purger.PurgeUsers(ctx, ids); err != nil {
+				return err
+			}
+		}
+		return s.repo.Purge(ctx, ids)
+	})
+	if err != nil {
+		logger.Error(ctx, "Failed to purge deleted users", logger.Err(err), logger.Uint64s("user_ids", ids))
+		return 0, errors.Wrap(err, "failed to purge deleted users")
+	}
+	return len(ids), nil
+}
+
+// revokeTokens 吊销用户的全部令牌。账号状态已经落库，吊销失败时返回错误让调用方重试，
+// 而不是吞掉——否则管理员以为账号已停用，对方手里的 token 却还能继续用到自然过期。
+func (s *Service) revokeTokens(ctx context.Context, userID uint64) error {
+	if err := s.revoker.RevokeUserTokens(ctx, userID); err != nil {
+		logger.Error(ctx, "Failed to revoke user tokens", logger.Err(err), logger.Uint64("user_id", userID))
+		return errors.Wrapf(err, "failed to revoke tokens of user %d", userID)
+	}
+	return nil
+}
+
+// List 分页查询用户列表；req.Deleted 为 true 时查回收站
 func (s *Service) List(ctx context.Context, req *dto.GetUserListRequest) ([]model.User, int64, error) {
-	return s.repo.FindPage(ctx, req, req.Limit, req.Offset)
+	if req.Deleted {
+		ctx = pkgrepo.OnlyDeleted(ctx)
+	}
+	return s.repo.FindPage(ctx, &req.UserFilter, req.Limit, req.Offset)
 }
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/internal/modules/user/dto"
+	"github.com/ayxworxfr/go_admin/internal/modules/user/model"
+	"github.com/ayxworxfr/go_admin/pkg/crypter"
+	"github.com/ayxworxfr/go_admin/pkg/passwordpolicy"
+	pkgrepo "github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/ayxworxfr/go_admin/pkg/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noSettings 没有保存过任何设置，密码策略取默认值
+type noSettings struct{}
+
+func (noSettings) GetJSON(context.Context, string, any) (bool, error) { return false, nil }
+
+// TestUpdate_PasswordChecksStoredUsername 请求里不带 username 时，新密码仍不能包含库里的用户名
+func TestUpdate_PasswordChecksStoredUsername(t *testing.T) {
+	db := repotest.NewDB(t, new(model.User), new(model.PasswordHistory))
+	svc := NewService(db, crypter.NewArgon2Hasher(), nil, noSettings{})
+	ctx := pkgrepo.WithTenant(context.Background(), 1)
+	require.NoError(t, svc.repo.Create(ctx, &model.User{ID: 1, Username: "alice", Email: "alice@example.com", Status: 1}))
+
+	_, err := svc.Update(ctx, &dto.UpdateUserRequest{ID: 1, Password: "Alice-Harbor-42"})
+	var policyErr *passwordpolicy.Error
+	require.ErrorAs(t, err, &policyErr)
+	assert.Contains(t, policyErr.Violations, "must not contain the username")
+
+	u, err := svc.Update(ctx, &dto.UpdateUserRequest{ID: 1, Password: "Quiet-Harbor-42"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, u.PasswordHash)
+}
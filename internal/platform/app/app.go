@@ -27,6 +27,7 @@ func NewApp(cfg *config.Config) *App {
 	h := server.Default(tracer,
 		server.WithHostPorts(fmt.Sprintf(":%d", cfg.Server.Port)),
 		server.WithCustomBinder(validator.NewDecimalBinder()),
+		server.WithMaxRequestBodySize(maxRequestBodySize(cfg)),
 	)
 	h.Use(hertztracing.ServerMiddleware(tcfg))
 	return &App{
@@ -35,6 +36,14 @@ func NewApp(cfg *config.Config) *App {
 	}
 }
 
+// defaultMaxRequestBodySize 与 Hertz 的默认值相同
+const defaultMaxRequestBodySize = 4 << 20
+
+// maxRequestBodySize 请求体上限要容得下最大的上传文件，再给 multipart 的分隔与表头留出 1MB 余量
+func maxRequestBodySize(cfg *config.Config) int {
+	return max(defaultMaxRequestBodySize, int(cfg.File.MaxSizeMB<<20)+1<<20)
+}
+
 func (a *App) Run() error {
 	ctx := context.Background()
 	logger.Info(ctx, "Starting application...")
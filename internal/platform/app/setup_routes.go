@@ -5,11 +5,15 @@ import (
 	"github.com/ayxworxfr/go_admin/internal/platform/router"
 )
 
-// SetupRoutes 挂载全部路由。authHandler 负责登录/刷新令牌等公开接口，
+// ProtectedPrefix 受 JWT 保护的业务路由前缀，权限记录里的 path 都以它开头
+const ProtectedPrefix = "/api/protected"
+
+// SetupRoutes 挂载全部路由。wellKnownHandler 挂在根路径下（如 /.well-known/jwks.json），
+// publicHandlers 负责登录（密码 / OIDC）、刷新令牌等无需鉴权的接口，
 // businessHandlers 是需要 JWT 鉴权保护、按方法名自动注册的业务 Handler
 // （user/iam/systemsetting 各模块的 Handler 实例），全部由 bootstrap.Container
 // 组装后传入，本函数只做路由挂载，不关心每个 Handler 依赖了什么 Service。
-func (a *App) SetupRoutes(authHandler any, jwtMiddleware *middleware.JWTAuthMiddleware, businessHandlers ...any) {
+func (a *App) SetupRoutes(wellKnownHandler any, publicHandlers []any, jwtMiddleware *middleware.JWTAuthMiddleware, businessHandlers ...any) {
 	root := a.Group("/")
 	root.GET("/health", HealthHandler)
 	root.GET("/metrics", MetricsHandler())
@@ -17,13 +21,22 @@ func (a *App) SetupRoutes(authHandler any, jwtMiddleware *middleware.JWTAuthMidd
 	api := a.Group("/api")
 	reg := router.NewRegister()
 
-	// 公开路由：登录 / 刷新令牌
-	reg.RegisterStruct(api, authHandler)
+	// 公开路由：标准路径约定的元数据（JWKS）
+	reg.RegisterStruct(root, wellKnownHandler)
+
+	// 公开路由：登录（密码 / OIDC）/ 刷新令牌
+	reg.RegisterStruct(api, publicHandlers...)
 
 	// 使用JWT中间件保护的路由
-	protected := api.Group("/protected")
+	protected := a.Group(ProtectedPrefix)
 	protected.Use(jwtMiddleware.Handle())
 
 	// 各业务模块路由
 	reg.RegisterStruct(protected, businessHandlers...)
 }
+
+// ProtectedRoutes 列出 businessHandlers 挂载后的完整路由，与 SetupRoutes 使用同一套解析规则，
+// 供权限目录同步在不启动服务的情况下取得路由表
+func ProtectedRoutes(businessHandlers ...any) []router.RouteInfo {
+	return router.NewRegister().Describe(ProtectedPrefix, businessHandlers...)
+}
@@ -11,13 +11,23 @@ import (
 
 // Config 结构体用于存储所有配置
 type Config struct {
-	Server        ServerConfig        `yaml:"server"`
-	Database      DatabaseConfig      `yaml:"database"`
-	Redis         RedisConfig         `yaml:"redis"`
-	JWT           JWTConfig           `yaml:"jwt"`
-	Logger        LoggerConfig        `yaml:"logger"`
-	OpenTelemetry OpenTelemetryConfig `yaml:"opentelemetry"`
-	Tasks         []cron.TaskConfig   `yaml:"tasks"`
+	Server          ServerConfig          `yaml:"server"`
+	Database        DatabaseConfig        `yaml:"database"`
+	Redis           RedisConfig           `yaml:"redis"`
+	JWT             JWTConfig             `yaml:"jwt"`
+	LoginGuard      LoginGuardConfig      `yaml:"login_guard"`
+	MFA             MFAConfig             `yaml:"mfa"`
+	Impersonation   ImpersonationConfig   `yaml:"impersonation"`
+	OIDC            OIDCConfig            `yaml:"oidc"`
+	PasswordReset   PasswordResetConfig   `yaml:"password_reset"`
+	Mail            MailConfig            `yaml:"mail"`
+	PermissionSync  PermissionSyncConfig  `yaml:"permission_sync"`
+	PermissionCache PermissionCacheConfig `yaml:"permission_cache"`
+	SoftDelete      SoftDeleteConfig      `yaml:"soft_delete"`
+	File            FileConfig            `yaml:"file"`
+	Logger          LoggerConfig          `yaml:"logger"`
+	OpenTelemetry   OpenTelemetryConfig   `yaml:"opentelemetry"`
+	Tasks           []cron.TaskConfig     `yaml:"tasks"`
 }
 
 // ServerConfig 存储服务器相关配置
@@ -90,12 +100,284 @@ func NewTokenStoreConfig() TokenStoreConfig {
 	}
 }
 
+// SessionStoreConfig 会话登记表存储策略配置（挂在 jwt 下），驱动取值同 token_store
+type SessionStoreConfig struct {
+	Driver    string `yaml:"driver"`
+	KeyPrefix string `yaml:"key_prefix"`
+}
+
+// NewSessionStoreConfig 默认使用进程内 memory 驱动
+func NewSessionStoreConfig() SessionStoreConfig {
+	return SessionStoreConfig{
+		Driver:    "memory",
+		KeyPrefix: "go_admin:session:",
+	}
+}
+
 // JWTConfig 存储 JWT 相关配置（签发参数 + 登出撤销策略同属会话生命周期）
 type JWTConfig struct {
-	Secret          string           `yaml:"secret"`
-	AccessTokenExp  string           `yaml:"access_token_exp"`
-	RefreshTokenExp string           `yaml:"refresh_token_exp"`
-	TokenStore      TokenStoreConfig `yaml:"token_store"`
+	// Algorithm 签名算法：HS256（默认，使用 secret）| RS256 | EdDSA（使用 keys_dir 下的私钥）。
+	// 非对称算法下 secret 若仍非空，则作为只验签的旧密钥，切换前签发的 token 过期前仍可用
+	Algorithm string `yaml:"algorithm"`
+	Secret    string `yaml:"secret"`
+	// KeysDir 非对称私钥目录，每个 <kid>.pem 一把，由 cmd/jwtkey 生成
+	KeysDir string `yaml:"keys_dir"`
+	// ActiveKID 用于签发的密钥；为空时取最新生成的一把，其余只用于验签
+	ActiveKID       string             `yaml:"active_kid"`
+	AccessTokenExp  string             `yaml:"access_token_exp"`
+	RefreshTokenExp string             `yaml:"refresh_token_exp"`
+	TokenStore      TokenStoreConfig   `yaml:"token_store"`
+	SessionStore    SessionStoreConfig `yaml:"session_store"`
+}
+
+// NewJWTConfig 默认沿用 HS256 共享密钥，撤销名单与会话登记表走 memory
+func NewJWTConfig() JWTConfig {
+	return JWTConfig{
+		Algorithm:    "HS256",
+		KeysDir:      "conf/jwt_keys",
+		TokenStore:   NewTokenStoreConfig(),
+		SessionStore: NewSessionStoreConfig(),
+	}
+}
+
+// LoginGuardConfig 登录防爆破配置：Window 内同一 用户名+IP 连续失败 MaxAttempts 次即锁定，
+// 锁定时长从 LockoutDuration 起按次数翻倍，封顶 MaxLockoutDuration。时长按 time.ParseDuration 解析。
+type LoginGuardConfig struct {
+	Enable             bool   `yaml:"enable"`
+	MaxAttempts        int    `yaml:"max_attempts"`
+	Window             string `yaml:"window"`
+	LockoutDuration    string `yaml:"lockout_duration"`
+	MaxLockoutDuration string `yaml:"max_lockout_duration"`
+	// Driver: memory | redis，取值同 jwt.token_store.driver
+	Driver    string `yaml:"driver"`
+	KeyPrefix string `yaml:"key_prefix"`
+}
+
+// NewLoginGuardConfig 默认开启：15 分钟内失败 5 次锁 5 分钟，最长锁 24 小时
+func NewLoginGuardConfig() LoginGuardConfig {
+	return LoginGuardConfig{
+		Enable:             true,
+		MaxAttempts:        5,
+		Window:             "15m",
+		LockoutDuration:    "5m",
+		MaxLockoutDuration: "24h",
+		Driver:             "memory",
+		KeyPrefix:          "go_admin:login_guard:",
+	}
+}
+
+// MFAConfig 两步验证（TOTP）配置
+type MFAConfig struct {
+	// Issuer 显示在验证器 App 里的签发方名称
+	Issuer string `yaml:"issuer"`
+	// SecretKey 加密落库 TOTP 密钥用的口令，经 SHA-256 派生为 AES-256 密钥；
+	// 为空时退回使用 jwt.secret。一旦有用户绑定就不能再改，否则已绑定的密钥无法解密
+	SecretKey string `yaml:"secret_key"`
+	// ChallengeTTL 登录第一步通过后下发的挑战令牌有效期，按 time.ParseDuration 解析
+	ChallengeTTL string `yaml:"challenge_ttl"`
+	// Skew 允许的时钟偏差（时间步数，每步 30 秒）
+	Skew int `yaml:"skew"`
+}
+
+// NewMFAConfig 默认挑战令牌 5 分钟有效，容忍前后各一个时间步的时钟偏差
+func NewMFAConfig() MFAConfig {
+	return MFAConfig{
+		Issuer:       "Go Admin",
+		ChallengeTTL: "5m",
+		Skew:         1,
+	}
+}
+
+// ImpersonationConfig 扮演用户（以其他用户身份登录）配置
+type ImpersonationConfig struct {
+	// TTL 扮演令牌有效期，按 time.ParseDuration 解析。扮演令牌不能续期，到期须重新发起
+	TTL string `yaml:"ttl"`
+}
+
+// NewImpersonationConfig 默认扮演令牌 30 分钟有效，够排查一个问题又不至于长期挂着
+func NewImpersonationConfig() ImpersonationConfig {
+	return ImpersonationConfig{TTL: "30m"}
+}
+
+// OIDCConfig OpenID Connect 登录配置，enable=false 时 /api/oidc/* 返回 404
+type OIDCConfig struct {
+	Enable       bool   `yaml:"enable"`
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL 在 IdP 登记的回调地址，指向前端页面，由前端把 code/state 转交 /api/oidc/callback
+	RedirectURL string   `yaml:"redirect_url"`
+	Scopes      []string `yaml:"scopes"`
+	// UsernameClaim / EmailClaim 本地用户名、邮箱取自 id_token 的哪个声明
+	UsernameClaim string `yaml:"username_claim"`
+	EmailClaim    string `yaml:"email_claim"`
+	// AutoProvision 首次登录的外部身份自动开户
+	AutoProvision bool `yaml:"auto_provision"`
+	// LinkExistingUsers 首次登录时按用户名关联已有本地账号；仅在用户名声明不能由用户自行修改时开启
+	LinkExistingUsers bool `yaml:"link_existing_users"`
+	// RoleMappings 按声明授予角色（角色 code），都没命中时授予 DefaultRoles
+	RoleMappings []OIDCRoleMappingConfig `yaml:"role_mappings"`
+	DefaultRoles []string                `yaml:"default_roles"`
+	// SyncRoles 每次登录都按映射结果覆盖用户角色；关闭时只在开户时授予
+	SyncRoles bool `yaml:"sync_roles"`
+	// StateKey 加密授权 state 的口令，为空时退回使用 jwt.secret
+	StateKey string `yaml:"state_key"`
+	// StateTTL 从跳转 IdP 到回调完成的最长时间，按 time.ParseDuration 解析
+	StateTTL string `yaml:"state_ttl"`
+}
+
+// OIDCRoleMappingConfig 声明 Claim（字符串或数组）包含 Value 时授予角色 Role
+type OIDCRoleMappingConfig struct {
+	Claim string `yaml:"claim"`
+	Value string `yaml:"value"`
+	Role  string `yaml:"role"`
+}
+
+// NewOIDCConfig 默认关闭；用户名取 preferred_username，state 10 分钟内有效
+func NewOIDCConfig() OIDCConfig {
+	return OIDCConfig{
+		Scopes:        []string{"openid", "profile", "email"},
+		UsernameClaim: "preferred_username",
+		EmailClaim:    "email",
+		StateTTL:      "10m",
+	}
+}
+
+// PasswordResetConfig 找回密码配置，enable=false 时 /api/password/* 返回 404
+type PasswordResetConfig struct {
+	Enable bool `yaml:"enable"`
+	// SecretKey 签名重置令牌的口令，为空时退回使用 jwt.secret
+	SecretKey string `yaml:"secret_key"`
+	// TokenTTL 重置链接有效期，按 time.ParseDuration 解析
+	TokenTTL string `yaml:"token_ttl"`
+	// ResetURL 前端重置密码页，邮件里的链接为 ResetURL?token=...
+	ResetURL string `yaml:"reset_url"`
+}
+
+// NewPasswordResetConfig 默认关闭；重置链接 30 分钟内有效
+func NewPasswordResetConfig() PasswordResetConfig {
+	return PasswordResetConfig{TokenTTL: "30m"}
+}
+
+// MailConfig 邮件投递配置
+type MailConfig struct {
+	// Driver log=打印到日志；file=写成 dir 下的 .eml 文件；smtp=经 SMTP 服务器发送
+	Driver string     `yaml:"driver"`
+	From   string     `yaml:"from"`
+	Dir    string     `yaml:"dir"`
+	SMTP   SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig SMTP 服务器配置，username 为空时不认证
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// TLS starttls / implicit / none
+	TLS string `yaml:"tls"`
+	// Timeout 单封邮件从连接到发送完成的超时，按 time.ParseDuration 解析
+	Timeout string `yaml:"timeout"`
+}
+
+// NewMailConfig 默认只打印到日志，本地开发不需要邮件服务器
+func NewMailConfig() MailConfig {
+	return MailConfig{
+		Driver: "log",
+		From:   "Go Admin <noreply@localhost>",
+		Dir:    "./logs/mail",
+		SMTP:   SMTPConfig{TLS: "starttls", Timeout: "10s"},
+	}
+}
+
+// PermissionSyncConfig 接口权限目录同步配置，也可以用 cmd/permsync 手动执行
+type PermissionSyncConfig struct {
+	// OnStartup 启动时按路由表同步一次接口权限
+	OnStartup bool `yaml:"on_startup"`
+	// GrantRoles 新建的接口权限自动授予这些角色（角色 code）
+	GrantRoles []string `yaml:"grant_roles"`
+}
+
+// NewPermissionSyncConfig 默认不在启动时同步；同步出的新接口默认授予管理员
+func NewPermissionSyncConfig() PermissionSyncConfig {
+	return PermissionSyncConfig{GrantRoles: []string{"ADMIN"}}
+}
+
+// PermissionCacheConfig 用户权限缓存配置。多实例部署应选 redis：角色变更通过 pub/sub
+// 广播给所有实例，不必等缓存过期
+type PermissionCacheConfig struct {
+	// Driver: memory | redis，取值同 jwt.token_store.driver
+	Driver    string `yaml:"driver"`
+	KeyPrefix string `yaml:"key_prefix"`
+	// TTL 缓存存活时间，按 time.ParseDuration 解析
+	TTL string `yaml:"ttl"`
+	// NearTTL redis 驱动下进程内近端缓存的存活时间，是失效广播丢失时的兜底
+	NearTTL string `yaml:"near_ttl"`
+}
+
+// NewPermissionCacheConfig 默认进程内缓存一小时
+func NewPermissionCacheConfig() PermissionCacheConfig {
+	return PermissionCacheConfig{
+		Driver:    "memory",
+		KeyPrefix: "go_admin:perm:",
+		TTL:       "1h",
+		NearTTL:   "1m",
+	}
+}
+
+// SoftDeleteConfig 软删除配置：用户、角色、权限删除后先进回收站，保留期满由 soft_delete_purge_task 清理
+type SoftDeleteConfig struct {
+	// Retention 回收站保留期，按 time.ParseDuration 解析
+	Retention string `yaml:"retention"`
+}
+
+// NewSoftDeleteConfig 默认保留 30 天
+func NewSoftDeleteConfig() SoftDeleteConfig {
+	return SoftDeleteConfig{Retention: "720h"}
+}
+
+// FileConfig 文件上传配置
+type FileConfig struct {
+	// MaxSizeMB 单个文件的大小上限（MB），同时用来放宽 HTTP 请求体的大小上限
+	MaxSizeMB int64 `yaml:"max_size_mb"`
+	// AllowedTypes 允许上传的 MIME 类型，按文件内容嗅探而不是扩展名判断；"image/*" 形式匹配一整类
+	AllowedTypes []string          `yaml:"allowed_types"`
+	Storage      FileStorageConfig `yaml:"storage"`
+}
+
+// FileStorageConfig 文件内容的存储后端
+type FileStorageConfig struct {
+	// Driver local=本地磁盘；s3=S3 兼容对象存储（AWS S3、MinIO 等）
+	Driver string `yaml:"driver"`
+	// Root local 驱动的存储目录，相对路径按项目根目录解析
+	Root string       `yaml:"root"`
+	S3   FileS3Config `yaml:"s3"`
+}
+
+// FileS3Config S3 兼容对象存储连接参数
+type FileS3Config struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	// PathStyle 用 endpoint/bucket/key 寻址，MinIO 一般需要开启
+	PathStyle bool `yaml:"path_style"`
+	// Timeout 单次请求超时，按 time.ParseDuration 解析
+	Timeout string `yaml:"timeout"`
+}
+
+// NewFileConfig 默认存本地 ./uploads，单个文件不超过 10MB，只允许图片、PDF 和纯文本
+func NewFileConfig() FileConfig {
+	return FileConfig{
+		MaxSizeMB:    10,
+		AllowedTypes: []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"},
+		Storage: FileStorageConfig{
+			Driver: "local",
+			Root:   "./uploads",
+			S3:     FileS3Config{Region: "us-east-1", Timeout: "60s"},
+		},
+	}
 }
 
 // LoggerConfig 存储日志相关配置
@@ -119,10 +401,20 @@ func Load(filename string) (*Config, error) {
 	var err error
 	once.Do(func() {
 		config = &Config{
-			Database:      NewDatabaseConfig(), // 使用带有默认值的 DatabaseConfig
-			Redis:         NewRedisConfig(),
-			JWT:           JWTConfig{TokenStore: NewTokenStoreConfig()},
-			OpenTelemetry: NewOpenTelemetryConfig(),
+			Database:        NewDatabaseConfig(), // 使用带有默认值的 DatabaseConfig
+			Redis:           NewRedisConfig(),
+			JWT:             NewJWTConfig(),
+			LoginGuard:      NewLoginGuardConfig(),
+			MFA:             NewMFAConfig(),
+			Impersonation:   NewImpersonationConfig(),
+			OIDC:            NewOIDCConfig(),
+			PasswordReset:   NewPasswordResetConfig(),
+			Mail:            NewMailConfig(),
+			PermissionSync:  NewPermissionSyncConfig(),
+			PermissionCache: NewPermissionCacheConfig(),
+			SoftDelete:      NewSoftDeleteConfig(),
+			File:            NewFileConfig(),
+			OpenTelemetry:   NewOpenTelemetryConfig(),
 		}
 		err = loadFile(filename, config)
 		if err != nil {
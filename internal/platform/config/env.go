@@ -10,7 +10,8 @@ import (
 //
 // 密钥类（Docker / K8s 应以 .env 或 Secret 为唯一来源）：
 //
-//	DATABASE_PASSWORD、REDIS_PASSWORD、JWT_SECRET
+//	DATABASE_PASSWORD、REDIS_PASSWORD、JWT_SECRET、MFA_SECRET_KEY、OIDC_CLIENT_SECRET、
+//	PASSWORD_RESET_SECRET_KEY、SMTP_PASSWORD、FILE_S3_ACCESS_KEY、FILE_S3_SECRET_KEY
 //
 // 连接类（编排里改 host 不必改镜像内配置文件）：
 //
@@ -45,6 +46,20 @@ func applyEnvOverrides(cfg *Config) {
 
 	// JWT
 	overrideString(&cfg.JWT.Secret, "JWT_SECRET")
+
+	// 两步验证
+	overrideString(&cfg.MFA.SecretKey, "MFA_SECRET_KEY")
+
+	// OIDC
+	overrideString(&cfg.OIDC.ClientSecret, "OIDC_CLIENT_SECRET")
+
+	// 找回密码与邮件
+	overrideString(&cfg.PasswordReset.SecretKey, "PASSWORD_RESET_SECRET_KEY")
+	overrideString(&cfg.Mail.SMTP.Password, "SMTP_PASSWORD")
+
+	// 文件存储
+	overrideString(&cfg.File.Storage.S3.AccessKey, "FILE_S3_ACCESS_KEY")
+	overrideString(&cfg.File.Storage.S3.SecretKey, "FILE_S3_SECRET_KEY")
 }
 
 func overrideString(dst *string, key string) {
@@ -1,17 +1,27 @@
 package cron
 
 import (
+	"time"
+
 	"github.com/ayxworxfr/go_admin/internal/platform/config"
 	"github.com/ayxworxfr/go_admin/pkg/cron"
 )
 
-func InitCronTask() (*cron.TaskManager, error) {
+// InitCronTask 注册全部任务函数，按配置加载并启动。sweeper 为 nil 时不注册临时角色清理任务；
+// retention 为软删除记录在回收站里的保留期，到期由 purgers 物理删除
+func InitCronTask(sweeper ExpiredRoleSweeper, retention time.Duration, purgers ...DeletedPurger) (*cron.TaskManager, error) {
 	// 创建任务管理器
 	manager := cron.NewTaskManager(nil)
 
 	// 创建任务注册表并注册任务函数
 	registry := cron.NewTaskRegistry()
 	registry.Register("health_task", healthCheck)
+	if sweeper != nil {
+		registry.Register("role_expiry_task", roleExpiryTask(sweeper))
+	}
+	if len(purgers) > 0 {
+		registry.Register("soft_delete_purge_task", softDeletePurgeTask(retention, purgers...))
+	}
 
 	tasks := config.GetCronTasks()
 	if tasks == nil {
@@ -0,0 +1,29 @@
+package cron
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+)
+
+// ExpiredRoleSweeper 清理已到期的临时角色分配，由 iam 模块的 PermissionChecker 实现
+// （删分配的同时清掉受影响用户的权限缓存）。cron 只负责按时触发
+type ExpiredRoleSweeper interface {
+	SweepExpiredRoles(ctx context.Context) (int, error)
+}
+
+// roleExpiryTask 临时角色到期清理任务。到期那一刻的鉴权由权限缓存的失效时间保证，
+// 这里回收的是过期分配留下的数据
+func roleExpiryTask(sweeper ExpiredRoleSweeper) func() {
+	return func() {
+		ctx := context.Background()
+		affected, err := sweeper.SweepExpiredRoles(ctx)
+		if err != nil {
+			logger.Errorf(ctx, "[TASK] Role expiry sweep failed: %v", err)
+			return
+		}
+		if affected > 0 {
+			logger.Infof(ctx, "[TASK] Removed expired role assignments for %d users", affected)
+		}
+	}
+}
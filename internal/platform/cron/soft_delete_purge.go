@@ -0,0 +1,33 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+)
+
+// DeletedPurger 物理删除 before 之前软删除的记录，返回清理的条数。由 user 模块的 Service、
+// iam 模块的 RoleService 与 PermissionService 实现，各自负责清理自己的关联数据
+type DeletedPurger interface {
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+}
+
+// softDeletePurgeTask 回收站清理任务：删除超过保留期的软删除记录。
+// 一个 purger 失败不影响其余的，下一轮再试
+func softDeletePurgeTask(retention time.Duration, purgers ...DeletedPurger) func() {
+	return func() {
+		ctx := context.Background()
+		before := time.Now().Add(-retention)
+		for _, purger := range purgers {
+			purged, err := purger.PurgeDeleted(ctx, before)
+			if err != nil {
+				logger.Errorf(ctx, "[TASK] Soft delete purge failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				logger.Infof(ctx, "[TASK] Purged %d soft-deleted records (%T)", purged, purger)
+			}
+		}
+	}
+}
@@ -4,25 +4,46 @@ import (
 	"context"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ayxworxfr/go_admin/pkg/api"
 	"github.com/ayxworxfr/go_admin/pkg/constant"
 	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/repository"
 	"github.com/cloudwego/hertz/pkg/app"
 )
 
 // PermissionChecker 是 JWTMiddleware 鉴权所需的最小接口，由 iam 模块的
 // PermissionChecker 实现。中间件只依赖这一个方法，不感知权限数据的存储
 // 与缓存细节（策略模式：具体校验策略由调用方注入）。
+// role 取自令牌的 ActingRole，为空表示按用户全部角色鉴权。
 type PermissionChecker interface {
-	HasPermission(ctx context.Context, userID uint64, method, path string) (bool, error)
+	HasPermission(ctx context.Context, userID uint64, role, method, path string) (bool, error)
+	HasScopedPermission(ctx context.Context, userID uint64, role, method, path string, scopes []string) (bool, error)
+}
+
+// PersonalTokenAuthenticator 校验个人访问令牌并还原为 Claims，由 iam 模块的
+// PersonalTokenService 实现。带 constant.PersonalTokenPrefix 前缀的凭证交给它，
+// 其余按 JWT 解析。
+type PersonalTokenAuthenticator interface {
+	Authenticate(ctx context.Context, raw string) (*jwtauth.Claims, error)
 }
 
 // TokenStore 是令牌撤销状态查询的最小接口，由 iam 模块的 TokenStore 实现，
-// 用于支撑登出后的 token 失效。
+// 用于支撑登出后的 token 失效、refresh token 重放后整个家族的失效，
+// 以及账号停用/删除后该用户全部 token 的失效。
 type TokenStore interface {
 	IsRevoked(ctx context.Context, jti string) (bool, error)
+	IsFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+	IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) (bool, error)
+}
+
+// DataScopeResolver 计算用户的数据范围，由 iam 模块的 DataScopeService 实现。
+// 中间件只把它挂进请求 context，真正的计算推迟到第一次查询带数据范围的模型时。
+// role 与 PermissionChecker 一样取自令牌的 ActingRole
+type DataScopeResolver interface {
+	ResolveDataScope(ctx context.Context, userID uint64, role string) (*repository.DataScope, error)
 }
 
 // PermissionConfig 权限验证配置
@@ -35,26 +56,44 @@ type PermissionConfig struct {
 
 // 默认配置
 var defaultPermissionConfig = PermissionConfig{
-	ExcludePaths: []string{"/api/login", "/api/refresh"},
-	Enable:       true,
+	ExcludePaths: []string{
+		"/api/login",
+		"/api/refresh",
+		// 自助接口只作用于调用方自己，登录即可访问；其中改动联系方式与凭证的由 handler 拒绝扮演令牌
+		"GET:/api/protected/user/current/profile",
+		"PUT:/api/protected/user/current/profile",
+		"PUT:/api/protected/user/current/password",
+		"PUT:/api/protected/file/current/avatar",
+		// 头像对同一租户的所有登录用户可见
+		"GET:/api/protected/file/avatar",
+		// 结束扮演时手里只有扮演令牌，按被扮演用户的权限未必能访问它
+		"DELETE:/api/protected/impersonation",
+	},
+	Enable: true,
 }
 
 // JWTAuthMiddleware 承载 JWT 认证所需的依赖。JWT / PermissionChecker /
-// TokenStore 均由组合根构造注入，方便测试替换与后续更换实现（如 Redis TokenStore）。
+// TokenStore / PersonalTokenAuthenticator / DataScopeResolver 均由组合根构造注入，
+// 方便测试替换与后续更换实现（如 Redis TokenStore）。
 type JWTAuthMiddleware struct {
-	jwt        *jwtauth.JWT
-	checker    PermissionChecker
-	tokenStore TokenStore
-	config     PermissionConfig
+	jwt            *jwtauth.JWT
+	checker        PermissionChecker
+	tokenStore     TokenStore
+	personalTokens PersonalTokenAuthenticator
+	dataScopes     DataScopeResolver
+	config         PermissionConfig
 }
 
-// NewJWTMiddleware 创建 JWT 认证中间件，checker 与 tokenStore 由 Container 组装时注入。
-func NewJWTMiddleware(jwt *jwtauth.JWT, checker PermissionChecker, tokenStore TokenStore, config ...PermissionConfig) *JWTAuthMiddleware {
+// NewJWTMiddleware 创建 JWT 认证中间件，checker、tokenStore、personalTokens 与 dataScopes 由 Container 组装时注入。
+// dataScopes 为 nil 时不启用数据范围过滤。
+func NewJWTMiddleware(jwt *jwtauth.JWT, checker PermissionChecker, tokenStore TokenStore, personalTokens PersonalTokenAuthenticator,
+	dataScopes DataScopeResolver, config ...PermissionConfig,
+) *JWTAuthMiddleware {
 	cfg := defaultPermissionConfig
 	if len(config) > 0 {
 		cfg = config[0]
 	}
-	return &JWTAuthMiddleware{jwt: jwt, checker: checker, tokenStore: tokenStore, config: cfg}
+	return &JWTAuthMiddleware{jwt: jwt, checker: checker, tokenStore: tokenStore, personalTokens: personalTokens, dataScopes: dataScopes, config: cfg}
 }
 
 // Handle 返回 Hertz 处理函数
@@ -67,22 +106,20 @@ func (m *JWTAuthMiddleware) Handle() app.HandlerFunc {
 		}
 		tokenString := api.StripBearerPrefix(rawAuth)
 
-		claims, err := m.jwt.ParseToken(tokenString)
-		if err != nil {
-			api.Abort(c, api.Unauthorized("Invalid token: "+err.Error()))
-			return
-		}
-
-		// Token撤销检查：jti 为空（异常场景）时直接放行，避免误判下线
-		if claims.ID != "" {
-			revoked, err := m.tokenStore.IsRevoked(ctx, claims.ID)
+		// 个人访问令牌不是 JWT，校验（含账号状态）由 iam 查库完成，没有 jti/家族撤销这一层
+		var claims *jwtauth.Claims
+		if strings.HasPrefix(tokenString, constant.PersonalTokenPrefix) {
+			var err error
+			// 令牌还没验过，不知道属于哪个租户，按全部租户查
+			claims, err = m.personalTokens.Authenticate(repository.WithAllTenants(ctx), tokenString)
 			if err != nil {
-				logger.Error(ctx, "Failed to check token revocation", logger.Err(err))
-				api.Abort(c, api.Unauthorized("Token check error"))
+				api.Abort(c, api.Unauthorized("Invalid personal access token"))
 				return
 			}
-			if revoked {
-				api.Abort(c, api.Unauthorized("Token has been revoked"))
+		} else {
+			var resp *api.Response
+			if claims, resp = m.verifyJWT(ctx, tokenString); resp != nil {
+				api.Abort(c, resp)
 				return
 			}
 		}
@@ -93,18 +130,36 @@ func (m *JWTAuthMiddleware) Handle() app.HandlerFunc {
 			return
 		}
 		c.Set(jwtauth.ClaimsKey, claims)
+		// 扮演期间业务代码打出的每条日志都带上真实操作人
+		if claims.Impersonating() {
+			ctx = logger.WithContext(ctx, logger.String("impersonator_id", claims.Actor.Identity))
+		}
+		// 鉴权与数据范围按用户自己所在的租户计算，与平台管理员此次进入的是哪个租户无关
+		ownTenant := repository.WithTenant(ctx, claims.Tenant())
+		ctx = requestTenant(ctx, c, claims)
+		if m.dataScopes != nil {
+			ctx = repository.WithDataScope(ctx, func(context.Context) (*repository.DataScope, error) {
+				return m.dataScopes.ResolveDataScope(ownTenant, userID, claims.ActingRole)
+			})
+		}
 
 		if m.config.Enable {
 			requestMethod := string(c.Request.Method())
 			requestPath := string(c.Request.URI().Path())
 			methodPath := requestMethod + ":" + requestPath
 
-			if isExcludedPath(methodPath, m.config.ExcludePaths) {
+			// 带 scope 的令牌只能访问 scope 覆盖的接口，排除列表不为它们开口子
+			if len(claims.Scopes) == 0 && isExcludedPath(methodPath, m.config.ExcludePaths) {
 				c.Next(ctx)
 				return
 			}
 
-			hasPermission, err := m.checker.HasPermission(ctx, userID, requestMethod, requestPath)
+			var hasPermission bool
+			if len(claims.Scopes) > 0 {
+				hasPermission, err = m.checker.HasScopedPermission(ownTenant, userID, claims.ActingRole, requestMethod, requestPath, claims.Scopes)
+			} else {
+				hasPermission, err = m.checker.HasPermission(ownTenant, userID, claims.ActingRole, requestMethod, requestPath)
+			}
 			if err != nil {
 				logger.Error(ctx, "Failed to check permission", logger.Err(err),
 					logger.Uint64("user_id", userID), logger.String("method", requestMethod), logger.String("path", requestPath))
@@ -123,6 +178,63 @@ func (m *JWTAuthMiddleware) Handle() app.HandlerFunc {
 	}
 }
 
+// verifyJWT 校验 JWT 签名、令牌类型与各级撤销状态，失败时返回应答给客户端的响应
+func (m *JWTAuthMiddleware) verifyJWT(ctx context.Context, tokenString string) (*jwtauth.Claims, *api.Response) {
+	claims, err := m.jwt.ParseToken(tokenString)
+	if err != nil {
+		return nil, api.Unauthorized("Invalid token: " + err.Error())
+	}
+	// 只接受 access token：refresh token 与两步验证挑战令牌都不能直接访问业务接口。
+	// Type 为空的是早期签发的 token，保持兼容
+	if claims.Type != "" && claims.Type != jwtauth.AccessTokenType {
+		return nil, api.Unauthorized("Access token required")
+	}
+
+	// Token撤销检查：jti 为空（异常场景）时直接放行，避免误判下线
+	if claims.ID != "" {
+		revoked, err := m.tokenStore.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			logger.Error(ctx, "Failed to check token revocation", logger.Err(err))
+			return nil, api.Unauthorized("Token check error")
+		}
+		if revoked {
+			return nil, api.Unauthorized("Token has been revoked")
+		}
+	}
+	if claims.FamilyID != "" {
+		revoked, err := m.tokenStore.IsFamilyRevoked(ctx, claims.FamilyID)
+		if err != nil {
+			logger.Error(ctx, "Failed to check token family revocation", logger.Err(err))
+			return nil, api.Unauthorized("Token check error")
+		}
+		if revoked {
+			return nil, api.Unauthorized("Token has been revoked")
+		}
+	}
+	// 账号状态靠用户级撤销生效：停用/删除时写入撤销记录，这里只查撤销名单，
+	// 不为每个请求回表查询用户状态
+	revoked, err := m.tokenStore.IsUserRevoked(ctx, claims.Identity, claims.IssuedTime())
+	if err != nil {
+		logger.Error(ctx, "Failed to check user revocation", logger.Err(err))
+		return nil, api.Unauthorized("Token check error")
+	}
+	if revoked {
+		return nil, api.Unauthorized("Token has been revoked")
+	}
+	// 扮演者自己被停用后，他手里的扮演令牌一并失效
+	if claims.Impersonating() {
+		revoked, err = m.tokenStore.IsUserRevoked(ctx, claims.Actor.Identity, claims.IssuedTime())
+		if err != nil {
+			logger.Error(ctx, "Failed to check impersonator revocation", logger.Err(err))
+			return nil, api.Unauthorized("Token check error")
+		}
+		if revoked {
+			return nil, api.Unauthorized("Token has been revoked")
+		}
+	}
+	return claims, nil
+}
+
 // isExcludedPath 检查路径是否在排除列表中
 func isExcludedPath(methodPath string, excludePaths []string) bool {
 	for _, excludePath := range excludePaths {
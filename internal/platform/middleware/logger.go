@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
 	"github.com/ayxworxfr/go_admin/pkg/logger"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/protocol/consts"
@@ -121,6 +122,14 @@ func (l *LoggerMiddleware) Handle() app.HandlerFunc {
 			logger.Int("request_size", len(c.Request.Body())),
 			logger.Int("response_size", len(c.Response.Body())),
 		}
+		// 鉴权中间件在 Next 里才注入 claims，扮演期间的请求都标上扮演者
+		if claims, err := jwtauth.ClaimsFromContext(c); err == nil && claims.Impersonating() {
+			fields = append(fields,
+				logger.Bool("impersonating", true),
+				logger.String("user_id", claims.Identity),
+				logger.String("impersonator_id", claims.Actor.Identity),
+			)
+		}
 		if reqSnapshot != nil {
 			fields = append(fields, logger.Any("request", reqSnapshot))
 		}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/ayxworxfr/go_admin/pkg/api"
+	"github.com/ayxworxfr/go_admin/pkg/constant"
+	"github.com/ayxworxfr/go_admin/pkg/jwtauth"
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+	"github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// TenantResolver 把租户 code 解析为启用中的租户 ID，由 tenant 模块的 Service 实现
+type TenantResolver interface {
+	ResolveTenant(ctx context.Context, code string) (uint64, error)
+}
+
+// TenantMiddleware 按 X-Tenant 请求头为请求挂上租户，登录、刷新等未认证接口据此确定在哪个租户里查用户。
+// 不带请求头的请求归平台租户；"*" 只对平台管理员有意义，这里同样按平台租户处理，
+// 由 JWTAuthMiddleware 在确认身份后放开。
+//
+// 已认证的请求以令牌里的租户为准，JWTAuthMiddleware 会覆盖这里的结果，
+// 只有平台管理员才能借请求头进入别的租户。
+func TenantMiddleware(resolver TenantResolver) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		tenantID := constant.PlatformTenantID
+		if code := string(c.Request.Header.Peek(constant.HeaderTenant)); code != "" && code != constant.AllTenants {
+			id, err := resolver.ResolveTenant(ctx, code)
+			if err != nil {
+				logger.Warn(ctx, "Failed to resolve tenant", logger.Err(err), logger.String("tenant", code))
+				api.Abort(c, api.Forbidden("Unknown or disabled tenant"))
+				return
+			}
+			tenantID = id
+		}
+		c.Next(repository.WithTenant(ctx, tenantID))
+	}
+}
+
+// requestTenant 已认证请求的租户：普通用户固定在令牌里的租户；平台管理员可以用
+// X-Tenant 请求头进入任一租户（TenantMiddleware 已解析进 ctx），"*" 表示跨租户
+func requestTenant(ctx context.Context, c *app.RequestContext, claims *jwtauth.Claims) context.Context {
+	if claims.Tenant() != constant.PlatformTenantID || !claims.ActsAs(constant.PlatformAdminRoleCode) {
+		return repository.WithTenant(ctx, claims.Tenant())
+	}
+	if string(c.Request.Header.Peek(constant.HeaderTenant)) == constant.AllTenants {
+		return repository.WithAllTenants(ctx)
+	}
+	if _, scoped := repository.TenantOf(ctx); !scoped {
+		return repository.WithTenant(ctx, claims.Tenant())
+	}
+	return ctx
+}
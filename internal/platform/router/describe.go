@@ -0,0 +1,67 @@
+package router
+
+import (
+	"path"
+	"reflect"
+	"strings"
+)
+
+// RouteInfo 一条路由的元数据，不含处理函数，供权限目录同步等只关心"有哪些接口"的场景使用
+type RouteInfo struct {
+	Method Method
+	// Path 含分组前缀的完整路径，如 /api/protected/user/list
+	Path string
+	// Module 处理器所在模块，取自 internal/modules/<module>/handler 的包路径
+	Module string
+	// Handler 形如 Handler.GetUserList
+	Handler string
+}
+
+// Describe 按与 RegisterStruct 相同的规则解析 instances 的路由，但不挂载。
+// 只用到实例的类型，传零值的 Handler 即可，不需要装配好的依赖
+func (r *Register) Describe(prefix string, instances ...any) []RouteInfo {
+	var routes []RouteInfo
+	for _, instance := range instances {
+		t := reflect.TypeOf(instance)
+		if t == nil {
+			continue
+		}
+		if t.Kind() != reflect.Ptr {
+			t = reflect.PointerTo(t)
+		}
+		elem := t.Elem()
+		for i := 0; i < t.NumMethod(); i++ {
+			method := t.Method(i)
+			if !isHandlerMethod(method) {
+				continue
+			}
+			var m Method
+			var p string
+			if tag, ok := lookupMethodTag(method); ok {
+				m, p = tag.method, tag.path
+			} else {
+				m, p = inferFromName(method.Name, r.format)
+			}
+			routes = append(routes, RouteInfo{
+				Method:  m,
+				Path:    strings.TrimSuffix(prefix, "/") + p,
+				Module:  moduleOf(elem.PkgPath()),
+				Handler: elem.Name() + "." + method.Name,
+			})
+		}
+	}
+	return routes
+}
+
+// moduleOf 从 .../internal/modules/<module>/handler 取出模块名，不符合该布局时退回包名
+func moduleOf(pkgPath string) string {
+	const marker = "/internal/modules/"
+	if i := strings.Index(pkgPath, marker); i >= 0 {
+		rest := pkgPath[i+len(marker):]
+		if module, _, ok := strings.Cut(rest, "/"); ok {
+			return module
+		}
+		return rest
+	}
+	return path.Base(pkgPath)
+}
@@ -117,3 +117,22 @@ func TestCompiledRoutesFresh(t *testing.T) {
 		assert.Equal(t, r.Path, tag.path, r.Key())
 	}
 }
+
+func TestRegister_Describe(t *testing.T) {
+	routes := NewRegister().Describe("/api/protected/", (*MockHandler)(nil))
+	byHandler := make(map[string]RouteInfo, len(routes))
+	for _, rt := range routes {
+		byHandler[rt.Handler] = rt
+	}
+
+	require.Len(t, routes, 3)
+	assert.Equal(t, RouteInfo{Method: POST, Path: "/api/protected/login", Module: "router", Handler: "MockHandler.Login"}, byHandler["MockHandler.Login"])
+	assert.Equal(t, GET, byHandler["MockHandler.RefreshToken"].Method)
+	// 无编译表条目时与 RegisterStruct 一样按函数名推断
+	assert.Equal(t, "/api/protected/internal/method", byHandler["MockHandler.InternalMethod"].Path)
+}
+
+func TestModuleOf(t *testing.T) {
+	assert.Equal(t, "user", moduleOf("github.com/ayxworxfr/go_admin/internal/modules/user/handler"))
+	assert.Equal(t, "router", moduleOf(testRouterPkg))
+}
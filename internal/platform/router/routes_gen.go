@@ -3,36 +3,96 @@
 package router
 
 func init() {
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/file/handler", "Handler", "DeleteFile", DELETE, "/file")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/file/handler", "Handler", "DownloadFile", GET, "/file/download")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/file/handler", "Handler", "GetAvatar", GET, "/file/avatar")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/file/handler", "Handler", "GetFile", GET, "/file")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/file/handler", "Handler", "GetFileList", GET, "/file/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/file/handler", "Handler", "SetCurrentAvatar", PUT, "/file/current/avatar")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/file/handler", "Handler", "UploadFile", POST, "/file/upload")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "AuthHandler", "Login", POST, "/login")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "AuthHandler", "LoginMFA", POST, "/login/mfa")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "AuthHandler", "Logout", POST, "/logout")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "AuthHandler", "RefreshToken", POST, "/refresh/token")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "AuthHandler", "SetupLoginMFA", POST, "/login/mfa/setup")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "AuthHandler", "SwitchRole", POST, "/switch/role")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "ImpersonationHandler", "GetImpersonationList", GET, "/impersonation/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "ImpersonationHandler", "StartImpersonation", POST, "/impersonation")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "ImpersonationHandler", "StopImpersonation", DELETE, "/impersonation")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "JWKSHandler", "GetJWKS", GET, "/.well-known/jwks.json")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "LoginGuardHandler", "GetLoginLock", GET, "/user/lock")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "LoginGuardHandler", "UnlockUser", DELETE, "/user/lock")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "MFAHandler", "DisableMFA", DELETE, "/mfa")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "MFAHandler", "EnableMFA", POST, "/mfa/enable")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "MFAHandler", "GetMFAStatus", GET, "/mfa")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "MFAHandler", "RegenerateRecoveryCodes", POST, "/mfa/recovery-codes")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "MFAHandler", "SetupMFA", POST, "/mfa/setup")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "OIDCHandler", "Authorize", GET, "/oidc/authorize")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "OIDCHandler", "Callback", POST, "/oidc/callback")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "CreatePermission", POST, "/permission")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "CreatePermissionBatch", POST, "/permission/batch")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "DeletePermission", DELETE, "/permission")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "ExplainPermission", GET, "/permission/explain")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "GetPermission", GET, "/permission")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "GetPermissionList", GET, "/permission/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "RestorePermission", PUT, "/permission/restore")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PermissionHandler", "UpdatePermission", PUT, "/permission")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PersonalTokenHandler", "CreatePersonalToken", POST, "/personal-token")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PersonalTokenHandler", "GetUserPersonalTokens", GET, "/user/personal-token/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PersonalTokenHandler", "ListPersonalTokens", GET, "/personal-token/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PersonalTokenHandler", "RevokePersonalToken", DELETE, "/personal-token")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "PersonalTokenHandler", "RevokeUserPersonalToken", DELETE, "/user/personal-token")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "CreateRole", POST, "/role")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "CreateRoleBatch", POST, "/role/batch")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "DeleteRole", DELETE, "/role")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "GetRole", GET, "/role")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "GetRoleList", GET, "/role/list")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "GetRolePermissions", GET, "/role/permission/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "RestoreRole", PUT, "/role/restore")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "RoleHandler", "UpdateRole", PUT, "/role")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "SessionHandler", "ForceOffline", DELETE, "/user/session")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "SessionHandler", "GetUserSessions", GET, "/user/session/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "SessionHandler", "ListSessions", GET, "/session/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "SessionHandler", "RevokeAllSessions", DELETE, "/session/all")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "SessionHandler", "RevokeSession", DELETE, "/session")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "UserRoleHandler", "GetUserPermissions", GET, "/user/permissions")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "UserRoleHandler", "GetUserRoles", GET, "/user/roles")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "UserRoleHandler", "GrantTemporaryRole", POST, "/user/grant/role")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/iam/handler", "UserRoleHandler", "UserAssignRoles", POST, "/user/assign/roles")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "AddDeptMembers", POST, "/dept/member")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "CreateDept", POST, "/dept")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "DeleteDept", DELETE, "/dept")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "GetDept", GET, "/dept")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "GetDeptTree", GET, "/dept/tree")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "MoveDept", PUT, "/dept/move")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "RemoveDeptMembers", DELETE, "/dept/member")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "ReorderDept", PUT, "/dept/reorder")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/org/handler", "Handler", "UpdateDept", PUT, "/dept")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "CreateSystemSetting", POST, "/system-setting")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "DeleteSystemSetting", DELETE, "/system-setting")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "DeleteSystemSettingOverride", DELETE, "/system-setting/override")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "GetSystemSetting", GET, "/system-setting")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "GetSystemSettingByCategory", GET, "/system-setting/by-category")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "GetSystemSettingList", GET, "/system-setting/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "SetSystemSettingOverride", PUT, "/system-setting/override")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/systemsetting/handler", "Handler", "UpdateSystemSetting", PUT, "/system-setting")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/tenant/handler", "Handler", "CreateTenant", POST, "/tenant")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/tenant/handler", "Handler", "GetTenant", GET, "/tenant")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/tenant/handler", "Handler", "GetTenantList", GET, "/tenant/list")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/tenant/handler", "Handler", "UpdateTenant", PUT, "/tenant")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "BulkHandler", "ExportUsers", GET, "/user/export")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "BulkHandler", "ImportUsers", POST, "/user/import")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "ChangeCurrentPassword", PUT, "/user/current/password")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "CreateUser", POST, "/user")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "DeleteUser", DELETE, "/user")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "GetCurrentProfile", GET, "/user/current/profile")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "GetUser", GET, "/user")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "GetUserCurrent", GET, "/user/current")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "GetUserList", GET, "/user/list")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "GetUserRoutes", GET, "/user/routes")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "RestoreUser", PUT, "/user/restore")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "UpdateCurrentProfile", PUT, "/user/current/profile")
 	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "Handler", "UpdateUser", PUT, "/user")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "PasswordResetHandler", "ForgotPassword", POST, "/password/forgot")
+	registerCompiledRoute("github.com/ayxworxfr/go_admin/internal/modules/user/handler", "PasswordResetHandler", "ResetPassword", POST, "/password/reset")
 }
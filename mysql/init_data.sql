@@ -2,15 +2,21 @@
 -- 权限 path 必须与 @route 注册路径一致（最终为 /api/protected + 相对路径）。
 -- 鉴权只认 method+path 非空的记录（type=3 接口权限）；type=1 菜单 path 供前端树展示。
 
+-- 默认租户即平台租户（id=1，见 constant.PlatformTenantID），未指定租户的数据都归它
+INSERT INTO `tenant` (`id`, `code`, `name`, `description`, `status`) VALUES
+(1, 'default', '平台', '平台租户，平台管理员在此管理其他租户', 1);
+
 -- 插入默认用户 (密码: admin123，使用 Argon2id 加密，自描述格式见 pkg/crypter.Argon2Hasher)
 INSERT INTO `user` (`id`, `username`, `password_hash`, `email`, `phone`, `status`) VALUES
 (1, 'admin', '$argon2id$v=19$m=65536,t=3,p=2$l2/H7PBMkR7oLTQDFl/QYA$d8zUw8p/xnrrHTe2iFjpjVt+x0+Pzgq0GMNSHurHWuc', 'admin@example.com', '13800000000', 1),
 (2, 'demo', '$argon2id$v=19$m=65536,t=3,p=2$sRZMfGnjdrKH9nEW4Dmqsw$dRlNLutxEzDm4z0wKJvW2kzv2sB0ZyxdKwX/IAse/mw', 'demo@example.com', '13800000001', 1);
 
 -- 插入默认角色
-INSERT INTO `role` (`id`, `name`, `code`, `description`, `status`) VALUES
-(1, '系统管理员', 'ADMIN', '系统超级管理员，拥有所有权限', 1),
-(2, '普通用户', 'USER', '普通用户角色，基础权限', 1);
+-- 普通用户的数据范围为仅本人：文件等按归属人过滤的数据只看得到自己的
+INSERT INTO `role` (`id`, `name`, `code`, `description`, `status`, `data_scope`) VALUES
+(1, '系统管理员', 'ADMIN', '系统超级管理员，拥有所有权限', 1, 1),
+(2, '普通用户', 'USER', '普通用户角色，基础权限', 1, 4),
+(3, '平台管理员', 'PLATFORM_ADMIN', '可借 X-Tenant 请求头进入任一租户或跨租户操作，只在平台租户内生效', 1, 1);
 
 -- 插入基础权限
 INSERT INTO `permission` (`id`, `name`, `code`, `description`, `parent_id`, `type`, `path`, `method`, `status`) VALUES
@@ -20,6 +26,10 @@ INSERT INTO `permission` (`id`, `name`, `code`, `description`, `parent_id`, `typ
 (3, '角色管理', 'ROLE_MANAGE', '角色管理', 1, 1, '/api/protected/role', '', 1),
 (4, '权限管理', 'PERMISSION_MANAGE', '权限管理', 1, 1, '/api/protected/permission', '', 1),
 (5, '系统设置', 'SYSTEM_SETTING', '系统设置', 1, 1, '/api/protected/system-setting', '', 1),
+(6, '租户管理', 'TENANT_MANAGE', '租户管理（仅平台租户可用）', 1, 1, '/api/protected/tenant', '', 1),
+(7, '扮演用户', 'IMPERSONATION', '以其他用户身份登录排查问题', 1, 1, '/api/protected/impersonation', '', 1),
+(8, '组织架构', 'DEPT_MANAGE', '部门树与部门成员', 1, 1, '/api/protected/dept', '', 1),
+(9, '文件管理', 'FILE_MANAGE', '上传、下载与删除文件', 1, 1, '/api/protected/file', '', 1),
 -- 用户管理接口（type=3）↔ @route /user*
 (10, '查看用户', 'USER_VIEW', '查看用户列表', 2, 3, '/api/protected/user/*', 'GET', 1),
 (11, '创建用户', 'USER_CREATE', '创建新用户', 2, 3, '/api/protected/user/*', 'POST', 1),
@@ -40,14 +50,42 @@ INSERT INTO `permission` (`id`, `name`, `code`, `description`, `parent_id`, `typ
 (41, '创建设置', 'SETTING_CREATE', '创建系统设置', 5, 3, '/api/protected/system-setting/*', 'POST', 1),
 (42, '修改设置', 'SETTING_UPDATE', '修改系统设置', 5, 3, '/api/protected/system-setting/*', 'PUT', 1),
 (43, '删除设置', 'SETTING_DELETE', '删除系统设置', 5, 3, '/api/protected/system-setting/*', 'DELETE', 1),
+-- 租户管理接口 ↔ @route /tenant*（服务层另外要求调用方处在平台租户）
+(50, '查看租户', 'TENANT_VIEW', '查看租户列表', 6, 3, '/api/protected/tenant/*', 'GET', 1),
+(51, '创建租户', 'TENANT_CREATE', '创建新租户', 6, 3, '/api/protected/tenant/*', 'POST', 1),
+(52, '编辑租户', 'TENANT_UPDATE', '编辑、停用租户', 6, 3, '/api/protected/tenant/*', 'PUT', 1),
+-- 扮演用户接口 ↔ @route /impersonation*（结束扮演 DELETE 对所有登录用户放行，只对扮演令牌生效）
+(60, '发起扮演', 'IMPERSONATION_START', '以其他用户身份登录，持有该权限的用户不能被扮演', 7, 3, '/api/protected/impersonation', 'POST', 1),
+(61, '查看扮演记录', 'IMPERSONATION_VIEW', '查看扮演审计记录', 7, 3, '/api/protected/impersonation/*', 'GET', 1),
+-- 组织架构接口 ↔ @route /dept*（调整成员归部门权限，不需要用户管理权限）
+(70, '查看部门', 'DEPT_VIEW', '查看部门与部门树', 8, 3, '/api/protected/dept/*', 'GET', 1),
+(71, '创建部门', 'DEPT_CREATE', '创建部门、调入成员', 8, 3, '/api/protected/dept/*', 'POST', 1),
+(72, '编辑部门', 'DEPT_UPDATE', '编辑、移动部门与调整顺序', 8, 3, '/api/protected/dept/*', 'PUT', 1),
+(73, '删除部门', 'DEPT_DELETE', '删除部门、移出成员', 8, 3, '/api/protected/dept/*', 'DELETE', 1),
+-- 文件接口 ↔ @route /file*（设置自己的头像、查看头像对所有登录用户放行；"仅本人"数据范围下只能看到自己上传的文件）
+(80, '查看文件', 'FILE_VIEW', '查看与下载文件', 9, 3, '/api/protected/file/*', 'GET', 1),
+(81, '上传文件', 'FILE_UPLOAD', '上传文件', 9, 3, '/api/protected/file/*', 'POST', 1),
+(82, '删除文件', 'FILE_DELETE', '删除文件', 9, 3, '/api/protected/file/*', 'DELETE', 1),
 -- 个人信息（预留菜单；当前无独立 /profile 路由时不影响鉴权）
 (100, '个人信息', 'PROFILE', '查看和修改个人信息', 0, 1, '/api/protected/user/current', '', 1),
 (101, '查看个人信息', 'PROFILE_VIEW', '查看个人信息', 100, 3, '/api/protected/user/current', 'GET', 1),
-(102, '修改个人信息', 'PROFILE_UPDATE', '修改个人信息', 100, 3, '/api/protected/user/*', 'PUT', 1);
+(102, '修改个人信息', 'PROFILE_UPDATE', '修改个人信息', 100, 3, '/api/protected/user/*', 'PUT', 1),
+-- 我的会话 ↔ @route /session*（管理员查看/强制下线走 /user/session，归用户管理权限）
+(103, '查看我的会话', 'SESSION_VIEW', '查看自己的登录会话', 100, 3, '/api/protected/session/*', 'GET', 1),
+(104, '撤销我的会话', 'SESSION_REVOKE', '撤销自己的登录会话', 100, 3, '/api/protected/session/*', 'DELETE', 1),
+-- 两步验证 ↔ @route /mfa*（每个用户只能管理自己的绑定）
+(105, '查看两步验证', 'MFA_VIEW', '查看自己的两步验证状态', 100, 3, '/api/protected/mfa', 'GET', 1),
+(106, '设置两步验证', 'MFA_MANAGE', '绑定两步验证、重置恢复码', 100, 3, '/api/protected/mfa/*', 'POST', 1),
+(107, '停用两步验证', 'MFA_DISABLE', '停用自己的两步验证', 100, 3, '/api/protected/mfa', 'DELETE', 1),
+-- 个人访问令牌 ↔ @route /personal-token*（管理员查看/撤销走 /user/personal-token，归用户管理权限）
+(108, '查看访问令牌', 'PERSONAL_TOKEN_VIEW', '查看自己的个人访问令牌', 100, 3, '/api/protected/personal-token/*', 'GET', 1),
+(109, '创建访问令牌', 'PERSONAL_TOKEN_CREATE', '创建个人访问令牌', 100, 3, '/api/protected/personal-token', 'POST', 1),
+(110, '撤销访问令牌', 'PERSONAL_TOKEN_REVOKE', '撤销自己的个人访问令牌', 100, 3, '/api/protected/personal-token', 'DELETE', 1);
 
 -- 分配用户角色
 INSERT INTO `user_role` (`user_id`, `role_id`) VALUES
 (1, 1), -- admin用户分配系统管理员角色
+(1, 3), -- admin用户同时是平台管理员
 (2, 2); -- demo用户分配普通用户角色
 
 -- 分配角色权限
@@ -57,7 +95,8 @@ SELECT 1, `id` FROM `permission` WHERE `status` = 1;
 
 -- 普通用户只有基础权限
 INSERT INTO `role_permission` (`role_id`, `permission_id`) VALUES
-(2, 100), (2, 101), (2, 102); -- 个人信息相关权限
+(2, 100), (2, 101), (2, 102), (2, 103), (2, 104), (2, 105), (2, 106), (2, 107), (2, 108), (2, 109), (2, 110), -- 个人信息相关权限
+(2, 80), (2, 81), (2, 82); -- 上传头像等个人文件
 
 -- 插入默认系统设置
 INSERT INTO `system_setting` (`category`, `key`, `value`, `type`, `description`, `create_by`) VALUES
@@ -65,4 +104,4 @@ INSERT INTO `system_setting` (`category`, `key`, `value`, `type`, `description`,
 ('basic', 'system_version', '1.0.0', 1, '系统版本', 1),
 ('basic', 'page_size', '100', 2, '默认分页大小', 1),
 ('security', 'session_timeout', '7200', 2, '会话超时时间(秒)', 1),
-('security', 'password_policy', '{"min_length": 8, "require_special": true}', 4, '密码策略配置', 1);
+('security', 'password_policy', '{"min_length": 8, "require_upper": false, "require_lower": false, "require_digit": false, "require_special": true, "history_count": 5, "max_age_days": 0, "reject_common": true, "denylist": []}', 4, '密码策略配置', 1);
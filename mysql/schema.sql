@@ -1,23 +1,101 @@
 -- 脚手架数据库Schema
 -- 包含用户管理、权限管理、系统设置等基础功能
 
+-- 租户表
+CREATE TABLE IF NOT EXISTS `tenant` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '租户ID(1:平台租户)',
+    `code` VARCHAR(50) NOT NULL COMMENT '租户标识，登录时放在 X-Tenant 请求头',
+    `name` VARCHAR(100) NOT NULL COMMENT '租户名称',
+    `description` VARCHAR(255) COMMENT '租户描述',
+    `status` TINYINT DEFAULT 1 COMMENT '租户状态(1:启用,2:停用)',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_code` (`code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='租户表';
+
 -- 用户表
 CREATE TABLE IF NOT EXISTS `user` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '用户ID',
-    `username` VARCHAR(50) NOT NULL UNIQUE COMMENT '用户名',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID',
+    `username` VARCHAR(50) NOT NULL COMMENT '用户名（租户内唯一）',
     `password_hash` VARCHAR(255) NOT NULL COMMENT '密码哈希（Argon2id 自描述串）',
-    `email` VARCHAR(100) NOT NULL UNIQUE COMMENT '邮箱',
+    `email` VARCHAR(100) NOT NULL COMMENT '邮箱（租户内唯一）',
     `phone` VARCHAR(20) COMMENT '电话',
     `avatar_url` VARCHAR(255) COMMENT '头像URL',
-    `status` TINYINT DEFAULT 1 COMMENT '用户状态(1:活跃,0:禁用)',
+    `status` TINYINT DEFAULT 1 COMMENT '用户状态(1:活跃,2:禁用,3:锁定)',
+    `dept_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '所属部门ID(0:未分配)',
     `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `update_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     `last_login_time` TIMESTAMP COMMENT '最后登录时间',
+    `password_changed_at` DATETIME COMMENT '最近一次设置密码的时间（为空不参与过期）',
+    `delete_time` DATETIME NULL COMMENT '软删除时间（为空表示未删除）',
     PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tenant_username` (`tenant_id`, `username`),
+    UNIQUE KEY `uk_tenant_email` (`tenant_id`, `email`),
     KEY `idx_username` (`username`),
-    KEY `idx_email` (`email`)
+    KEY `idx_email` (`email`),
+    KEY `idx_dept_id` (`dept_id`),
+    KEY `idx_delete_time` (`delete_time`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户表';
 
+-- 部门表（组织架构树，用户所属部门见 user.dept_id）
+CREATE TABLE IF NOT EXISTS `dept` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '部门ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID',
+    `parent_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '上级部门ID(0:顶级部门)',
+    `name` VARCHAR(50) NOT NULL COMMENT '部门名称（同一上级部门下唯一）',
+    `sort` INT NOT NULL DEFAULT 0 COMMENT '同级部门中的顺序，升序',
+    `leader_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '负责人用户ID(0:未设置)',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='部门表';
+
+-- 文件表（上传文件的元数据，内容按 storage_key 存在本地磁盘或对象存储里）
+CREATE TABLE IF NOT EXISTS `file` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '文件ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID',
+    `name` VARCHAR(255) NOT NULL COMMENT '上传时的原始文件名',
+    `size` BIGINT NOT NULL COMMENT '字节数',
+    `mime_type` VARCHAR(100) NOT NULL COMMENT '按内容嗅探出的MIME类型',
+    `hash` CHAR(64) NOT NULL COMMENT '内容SHA-256',
+    `storage_key` VARCHAR(255) NOT NULL COMMENT '存储键，由哈希派生，内容相同的文件共用',
+    `uploader_id` BIGINT UNSIGNED NOT NULL COMMENT '上传人用户ID',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_hash` (`hash`),
+    KEY `idx_storage_key` (`storage_key`),
+    KEY `idx_uploader_id` (`uploader_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件表';
+
+-- 文件关联表（把文件挂到用户身上，如头像；每个用户每种用途一个文件）
+CREATE TABLE IF NOT EXISTS `file_link` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT 'ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID',
+    `file_id` BIGINT UNSIGNED NOT NULL COMMENT '文件ID',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `purpose` VARCHAR(32) NOT NULL COMMENT '用途(avatar:头像)',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_user_purpose` (`user_id`, `purpose`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_file_id` (`file_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='文件关联表';
+
+-- 历史密码表
+CREATE TABLE IF NOT EXISTS `password_history` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '记录ID',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `password_hash` VARCHAR(255) NOT NULL COMMENT '密码哈希',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '设置时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='历史密码表';
+
 -- 权限表
 CREATE TABLE IF NOT EXISTS `permission` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '权限ID',
@@ -28,39 +106,55 @@ CREATE TABLE IF NOT EXISTS `permission` (
     `type` INT NOT NULL COMMENT '权限类型(1:菜单,2:按钮,3:接口)',
     `path` VARCHAR(255) COMMENT '路径',
     `method` VARCHAR(50) COMMENT 'HTTP方法',
+    `effect` VARCHAR(10) NOT NULL DEFAULT 'allow' COMMENT '效果(allow:允许,deny:拒绝)',
     `status` TINYINT DEFAULT 1 COMMENT '权限状态(1:活跃,0:禁用)',
+    `orphaned` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '接口权限已匹配不到任何路由',
     `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `update_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `delete_time` DATETIME NULL COMMENT '软删除时间（为空表示未删除）',
     PRIMARY KEY (`id`),
     KEY `idx_parent_id` (`parent_id`),
     KEY `idx_type` (`type`),
-    KEY `idx_path_method` (`path`, `method`)
+    KEY `idx_path_method` (`path`, `method`),
+    KEY `idx_delete_time` (`delete_time`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='权限表';
 
 -- 角色表
 CREATE TABLE IF NOT EXISTS `role` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '角色ID',
-    `name` VARCHAR(50) NOT NULL UNIQUE COMMENT '角色名称',
-    `code` VARCHAR(50) NOT NULL UNIQUE COMMENT '角色代码',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID',
+    `name` VARCHAR(50) NOT NULL COMMENT '角色名称（租户内唯一）',
+    `code` VARCHAR(50) NOT NULL COMMENT '角色代码（租户内唯一）',
     `description` VARCHAR(255) COMMENT '角色描述',
     `status` TINYINT DEFAULT 1 COMMENT '角色状态(1:活跃,0:禁用)',
+    `require_mfa` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否要求持有者开启两步验证',
+    `data_scope` TINYINT NOT NULL DEFAULT 1 COMMENT '数据范围(1:全部,2:本部门及以下,3:本部门,4:仅本人,5:自定义部门)',
     `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     `update_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `delete_time` DATETIME NULL COMMENT '软删除时间（为空表示未删除）',
     PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tenant_role_name` (`tenant_id`, `name`),
+    UNIQUE KEY `uk_tenant_role_code` (`tenant_id`, `code`),
     KEY `idx_name` (`name`),
-    KEY `idx_code` (`code`)
+    KEY `idx_code` (`code`),
+    KEY `idx_delete_time` (`delete_time`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='角色表';
 
 -- 用户角色关联表
 CREATE TABLE IF NOT EXISTS `user_role` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT 'ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID，与用户、角色一致',
     `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
     `role_id` BIGINT UNSIGNED NOT NULL COMMENT '角色ID',
+    `valid_from` DATETIME NULL COMMENT '生效时间，为空表示立即生效',
+    `valid_until` DATETIME NULL COMMENT '失效时间，为空表示长期有效',
     `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     PRIMARY KEY (`id`),
     UNIQUE KEY `uk_user_role` (`user_id`, `role_id`),
     KEY `idx_user_id` (`user_id`),
-    KEY `idx_role_id` (`role_id`)
+    KEY `idx_role_id` (`role_id`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_valid_until` (`valid_until`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户角色关联表';
 
 -- 角色权限关联表
@@ -75,6 +169,91 @@ CREATE TABLE IF NOT EXISTS `role_permission` (
     KEY `idx_permission_id` (`permission_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='角色权限关联表';
 
+-- 角色继承表
+CREATE TABLE IF NOT EXISTS `role_parent` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT 'ID',
+    `role_id` BIGINT UNSIGNED NOT NULL COMMENT '子角色ID',
+    `parent_id` BIGINT UNSIGNED NOT NULL COMMENT '父角色ID',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_role_parent` (`role_id`, `parent_id`),
+    KEY `idx_parent_id` (`parent_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='角色继承表';
+
+-- 角色自定义数据范围表
+CREATE TABLE IF NOT EXISTS `role_dept` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT 'ID',
+    `role_id` BIGINT UNSIGNED NOT NULL COMMENT '角色ID',
+    `dept_id` BIGINT UNSIGNED NOT NULL COMMENT '部门ID',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_role_dept` (`role_id`, `dept_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='角色自定义数据范围表';
+
+-- 用户两步验证表
+CREATE TABLE IF NOT EXISTS `user_mfa` (
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    `secret` VARCHAR(255) NOT NULL COMMENT 'TOTP 密钥（AES-GCM 加密）',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已确认启用(0:待绑定,1:已启用)',
+    `recovery_codes` TEXT COMMENT '恢复码哈希（JSON 数组）',
+    `last_used_step` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次验证通过的 TOTP 时间步',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户两步验证表';
+
+-- 个人访问令牌表
+CREATE TABLE IF NOT EXISTS `personal_token` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '令牌ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '所属租户ID，与用户一致',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '所属用户ID',
+    `name` VARCHAR(50) NOT NULL COMMENT '令牌名称',
+    `token_hash` VARCHAR(64) NOT NULL COMMENT '令牌 SHA-256 摘要（十六进制）',
+    `hint` VARCHAR(16) COMMENT '令牌末尾几位，用于辨认',
+    `scopes` TEXT COMMENT '权限范围（JSON 数组，METHOD:path）',
+    `expires_at` DATETIME NOT NULL COMMENT '过期时间',
+    `last_used_at` DATETIME COMMENT '最近使用时间',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_token_hash` (`token_hash`),
+    KEY `idx_user_id` (`user_id`),
+    KEY `idx_tenant_id` (`tenant_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='个人访问令牌表';
+
+-- 外部身份绑定表
+CREATE TABLE IF NOT EXISTS `user_identity` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '绑定ID',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '本地用户ID',
+    `issuer` VARCHAR(255) NOT NULL COMMENT 'IdP 签发方（iss）',
+    `subject` VARCHAR(255) NOT NULL COMMENT 'IdP 用户标识（sub）',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_issuer_subject` (`issuer`, `subject`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='外部身份绑定表';
+
+-- 扮演记录表
+CREATE TABLE IF NOT EXISTS `impersonation_session` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '记录ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL DEFAULT 1 COMMENT '被扮演用户所在租户ID',
+    `actor_id` BIGINT UNSIGNED NOT NULL COMMENT '扮演者用户ID',
+    `actor_name` VARCHAR(50) NOT NULL COMMENT '扮演者用户名',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '被扮演用户ID',
+    `username` VARCHAR(50) NOT NULL COMMENT '被扮演用户名',
+    `reason` VARCHAR(255) NOT NULL COMMENT '扮演理由',
+    `family_id` VARCHAR(64) NOT NULL COMMENT '扮演令牌的令牌家族ID',
+    `ip` VARCHAR(64) COMMENT '发起扮演的客户端IP',
+    `user_agent` VARCHAR(255) COMMENT '发起扮演的客户端UA',
+    `expires_at` DATETIME NOT NULL COMMENT '扮演令牌过期时间',
+    `ended_at` DATETIME NULL COMMENT '主动结束时间',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_family_id` (`family_id`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_actor_id` (`actor_id`),
+    KEY `idx_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='扮演记录表';
+
 -- 系统设置表
 CREATE TABLE IF NOT EXISTS `system_setting` (
     `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT '配置ID',
@@ -91,3 +270,16 @@ CREATE TABLE IF NOT EXISTS `system_setting` (
     KEY `idx_category_key` (`category`, `key`),
     KEY `idx_type` (`type`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='系统配置表';
+
+-- 租户配置覆盖表
+CREATE TABLE IF NOT EXISTS `system_setting_override` (
+    `id` BIGINT UNSIGNED AUTO_INCREMENT COMMENT 'ID',
+    `tenant_id` BIGINT UNSIGNED NOT NULL COMMENT '租户ID',
+    `key` VARCHAR(50) NOT NULL COMMENT '配置键，对应 system_setting.key',
+    `value` TEXT COMMENT '该租户的配置值',
+    `update_by` BIGINT UNSIGNED NOT NULL COMMENT '最后修改人ID',
+    `create_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_time` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tenant_key` (`tenant_id`, `key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='租户配置覆盖表';
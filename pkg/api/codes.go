@@ -15,13 +15,14 @@ const (
 
 // 客户端错误类
 const (
-	CLIENT_PARAM_ERROR   = 200001 // 参数错误
-	CLIENT_NOT_FOUND     = 200002 // 资源不存在
-	CLIENT_UNAUTHORIZED  = 200003 // 未认证
-	CLIENT_FORBIDDEN     = 200004 // 禁止访问
-	CLIENT_CONFLICT      = 200005 // 资源冲突
-	CLIENT_INVALID_TOKEN = 200007 // 无效令牌
-	CLIENT_TOKEN_EXPIRED = 200008 // 令牌过期
+	CLIENT_PARAM_ERROR    = 200001 // 参数错误
+	CLIENT_NOT_FOUND      = 200002 // 资源不存在
+	CLIENT_UNAUTHORIZED   = 200003 // 未认证
+	CLIENT_FORBIDDEN      = 200004 // 禁止访问
+	CLIENT_CONFLICT       = 200005 // 资源冲突
+	CLIENT_INVALID_TOKEN  = 200007 // 无效令牌
+	CLIENT_TOKEN_EXPIRED  = 200008 // 令牌过期
+	CLIENT_ACCOUNT_LOCKED = 200009 // 账号临时锁定（连续登录失败）
 )
 
 // 服务端错误类
@@ -84,11 +84,17 @@ func StripBearerPrefix(raw string) string {
 	return raw
 }
 
-// UserID 从鉴权中间件注入的 JWT 载荷读取用户 ID；未登录返回 error，不再静默给 0
+// UserID 从鉴权中间件注入的 JWT 载荷读取用户 ID；未登录返回 error，不再静默给 0。
+// 扮演其他用户时返回的是被扮演的用户，业务按该用户的身份处理
 func (c *Context) UserID() (uint64, error) {
 	return jwtauth.UserIDUint64FromContext(c.rc)
 }
 
+// ActorID 读取真实操作人的用户 ID：扮演其他用户时为扮演者本人，否则与 UserID 相同
+func (c *Context) ActorID() (uint64, error) {
+	return jwtauth.ActorIDUint64FromContext(c.rc)
+}
+
 // Claims 从鉴权中间件注入的 JWT 载荷读取完整 Claims
 func (c *Context) Claims() (*jwtauth.Claims, error) {
 	return jwtauth.ClaimsFromContext(c.rc)
@@ -76,6 +76,7 @@ func TestResponse_HTTPStatus(t *testing.T) {
 	require.Equal(t, 403, Forbidden("x").HTTPStatus())
 	require.Equal(t, 404, NotFound("x").HTTPStatus())
 	require.Equal(t, 409, Conflict("x").HTTPStatus())
+	require.Equal(t, 423, AccountLocked("x").HTTPStatus())
 	require.Equal(t, 429, RateLimit("x").HTTPStatus())
 	require.Equal(t, 500, DatabaseError("x").HTTPStatus())
 }
@@ -27,6 +27,8 @@ func (rsp *Response) HTTPStatus() int {
 		return consts.StatusNotFound
 	case rsp.Code == CLIENT_CONFLICT:
 		return consts.StatusConflict
+	case rsp.Code == CLIENT_ACCOUNT_LOCKED:
+		return consts.StatusLocked
 	case rsp.Code == SERVER_RATE_LIMIT:
 		return consts.StatusTooManyRequests
 	case rsp.Code >= 200000 && rsp.Code < 300000:
@@ -93,6 +95,10 @@ func Conflict(message any) *Response {
 	return &Response{Code: CLIENT_CONFLICT, Message: formatMessage("Conflict", message)}
 }
 
+func AccountLocked(message any) *Response {
+	return &Response{Code: CLIENT_ACCOUNT_LOCKED, Message: formatMessage("Account locked", message)}
+}
+
 // --- 服务端 / 业务 ---
 
 func InternalError(message ...any) *Response {
@@ -4,10 +4,10 @@
 // 常量应该留在对应模块自己的包里（例如 jwtauth.ClaimsKey、jwtauth.AccessTokenType
 // 只服务于 jwtauth 自身的语义，搬到这里反而会制造一个所有模块都要认识的无意义公共依赖）。
 //
-// 当前收录的两个常量是从真实的重复代码里提炼出来的：Authorization 头名和 Bearer
-// 前缀曾经分别在 internal/platform/middleware（JWT 中间件）和 pkg/api
-// （Context.BearerToken）各写了一份，且大小写敏感策略不一致——中间件用大小写敏感的
-// 切片比较，api 用 strings.EqualFold，等价于同一个协议规则有两份可能分叉的实现。
+// Authorization 头名和 Bearer 前缀是从真实的重复代码里提炼出来的：它们曾经分别在
+// internal/platform/middleware（JWT 中间件）和 pkg/api（Context.BearerToken）各写了一份，
+// 且大小写敏感策略不一致——中间件用大小写敏感的切片比较，api 用 strings.EqualFold，
+// 等价于同一个协议规则有两份可能分叉的实现。
 package constant
 
 // HeaderAuthorization 是标准 HTTP Authorization 请求头名称。
@@ -18,3 +18,31 @@ const HeaderAuthorization = "Authorization"
 // 按 RFC 7235，认证方案名不区分大小写，比较该前缀时应配合 strings.EqualFold，
 // 不要直接做大小写敏感的字符串切片比较。
 const BearerPrefix = "Bearer "
+
+// PersonalTokenPrefix 是个人访问令牌（PAT）明文的固定前缀。
+//
+// iam 模块签发令牌时拼上它，JWT 中间件靠它区分 PAT 与 JWT、决定走哪条校验路径；
+// 两边必须一致，否则签发出去的令牌永远不会被识别。带固定前缀也方便代码扫描工具
+// 发现误提交到仓库里的令牌。
+const PersonalTokenPrefix = "gat_"
+
+// HeaderTenant 指定租户的请求头，值为租户 code。
+//
+// 登录等未认证接口靠它决定在哪个租户里找用户，缺省为平台租户；已认证的请求以令牌里的
+// 租户为准，只有平台管理员可以用它切到别的租户操作，取值 "*" 表示跨全部租户。
+// 租户中间件解析它、JWT 中间件决定是否采纳，两边必须认同一个头名。
+const HeaderTenant = "X-Tenant"
+
+// AllTenants 是 HeaderTenant 表示跨全部租户的取值。
+const AllTenants = "*"
+
+// PlatformTenantID 是平台租户的 ID。
+//
+// 引入多租户之前的存量数据全部归它，不带租户的旧令牌也按它处理；平台租户里的用户才可能
+// 是平台管理员。签发令牌的 iam、校验令牌的中间件与 tenant 模块都依赖这个约定。
+const PlatformTenantID uint64 = 1
+
+// PlatformAdminRoleCode 是平台管理员角色的 code。
+//
+// 只有平台租户里持有该角色的用户才能跨租户操作；其他租户建同名角色不会获得这项能力。
+const PlatformAdminRoleCode = "PLATFORM_ADMIN"
@@ -29,3 +29,13 @@ func UserIDUint64FromContext(c *app.RequestContext) (uint64, error) {
 	}
 	return strconv.ParseUint(claims.Identity, 10, 64)
 }
+
+// ActorIDUint64FromContext 从请求上下文获取真实操作人的用户 ID：扮演时为扮演者，
+// 否则与 UserIDUint64FromContext 相同。
+func ActorIDUint64FromContext(c *app.RequestContext) (uint64, error) {
+	claims, err := ClaimsFromContext(c)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(claims.ActorIdentity(), 10, 64)
+}
@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ayxworxfr/go_admin/pkg/constant"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -17,6 +19,11 @@ const (
 	AccessTokenType = "access"
 	// RefreshTokenType 表示 Refresh Token 类型
 	RefreshTokenType = "refresh"
+	// MFATokenType 表示两步验证挑战令牌：密码校验通过、尚未完成第二步时下发，只能用于换取正式令牌
+	MFATokenType = "mfa"
+	// PersonalTokenType 表示个人访问令牌。PAT 不是 JWT，校验通过后由 iam 模块构造同样的
+	// Claims 注入上下文，handler 读取当前用户的方式因此不必区分两种凭证
+	PersonalTokenType = "pat"
 	// ClaimsKey 表示 JWT 载荷的键名，由鉴权中间件写入 RequestContext
 	ClaimsKey = "jwt_claims"
 )
@@ -25,33 +32,97 @@ const (
 type Claims struct {
 	Identity string `json:"identity"` // 用户ID
 	Nice     string `json:"nice"`     // 用户名
-	RoleKey  string `json:"rolekey"`  // 角色标识
+	RoleKey  string `json:"rolekey"`  // 角色标识：扮演角色时为该角色，否则为优先级最高的角色
 	Type     string `json:"type"`     // token类型：access/refresh
+	// Roles 用户持有的全部角色 code，签发与刷新时按当前分配计算
+	Roles []string `json:"roles,omitempty"`
+	// ActingRole 用户切换到的角色，鉴权只按该角色计算；为空时按全部角色合并
+	ActingRole string `json:"arole,omitempty"`
+	// TenantID 用户所属租户，引入多租户前签发的令牌没有该字段，见 Tenant
+	TenantID uint64 `json:"tid,omitempty"`
+	// FamilyID 令牌家族：一次登录签发的 token 及其后续轮换出的 token 共享同一个 fid，
+	// 检测到 refresh token 重放时据此整体撤销
+	FamilyID string `json:"fid,omitempty"`
+	// Scopes 凭证的权限范围（"METHOD:path" 模式），目前只有个人访问令牌会带；
+	// 非空时请求必须同时落在用户权限与该范围之内
+	Scopes []string `json:"scp,omitempty"`
+	// Actor 扮演令牌里的真实操作人（RFC 8693 的 act 声明），Identity 是被扮演的用户
+	Actor *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Actor 扮演其他用户的真实操作人
+type Actor struct {
+	Identity string `json:"sub"`  // 用户ID
+	Nice     string `json:"nice"` // 用户名
+}
+
+// Impersonating 令牌是否是扮演令牌
+func (c *Claims) Impersonating() bool {
+	return c.Actor != nil
+}
+
+// ActorIdentity 真实操作人的用户 ID：扮演时为扮演者，否则就是令牌的主人
+func (c *Claims) ActorIdentity() string {
+	if c.Actor != nil {
+		return c.Actor.Identity
+	}
+	return c.Identity
+}
+
+// IssuedTime 返回签发时刻；升级前签发的 token 不带 iat，此时返回零值
+func (c *Claims) IssuedTime() time.Time {
+	if c.IssuedAt == nil {
+		return time.Time{}
+	}
+	return c.IssuedAt.Time
+}
+
+// Tenant 返回令牌所属租户，不带 tid 的旧令牌归平台租户
+func (c *Claims) Tenant() uint64 {
+	if c.TenantID == 0 {
+		return constant.PlatformTenantID
+	}
+	return c.TenantID
+}
+
+// ActsAs 令牌当前是否以 role 的身份行事：扮演了某个角色时只认该角色，否则看是否持有
+func (c *Claims) ActsAs(role string) bool {
+	if c.ActingRole != "" {
+		return c.ActingRole == role
+	}
+	return slices.Contains(c.Roles, role)
+}
+
 // TokenPair 包含 Access Token 和 Refresh Token
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresAt    int64  `json:"expires_at"`
+	// FamilyID 本对 token 所属的令牌家族，供服务端登记会话，不下发给客户端
+	FamilyID string `json:"-"`
 }
 
 // JWT 管理 Access/Refresh Token 的签发、解析与刷新。
 //
-// 签名密钥与过期时长只在 NewJWT 构造期设置，实例构造完成后不再变化，
+// 签名密钥与过期时长只在构造期设置，实例构造完成后不再变化，
 // 因此可以安全地在多个 goroutine 间共享同一个 *JWT（本项目就是这样用：
 // 由 Container 构造一次，注入给 AuthHandler/AuthService/JWTAuthMiddleware）。
 // 字段全部不导出：签名密钥属于敏感信息，不应该作为公开字段暴露给持有者随意读取。
 type JWT struct {
-	signingKey             []byte
+	keys                   *KeySet
 	tokenExpiration        time.Duration
 	refreshTokenExpiration time.Duration
 }
 
-// NewJWT 创建 JWT 管理器实例。tokenExp/refreshTokenExp 支持 s/m/h/d/w 单位
-// （如 "24h"、"30d"），解析失败会返回错误，不会构造出一个状态不完整的实例。
+// NewJWT 创建使用 HS256 共享密钥的 JWT 管理器实例。tokenExp/refreshTokenExp 支持
+// s/m/h/d/w 单位（如 "24h"、"30d"），解析失败会返回错误，不会构造出一个状态不完整的实例。
 func NewJWT(signingKey, tokenExp, refreshTokenExp string) (*JWT, error) {
+	return NewJWTWithKeys(NewHMACKeySet(signingKey), tokenExp, refreshTokenExp)
+}
+
+// NewJWTWithKeys 使用指定 KeySet 创建 JWT 管理器，用于 RS256/EdDSA 非对称签名与密钥轮换
+func NewJWTWithKeys(keys *KeySet, tokenExp, refreshTokenExp string) (*JWT, error) {
 	tokenExpDur, err := parseDuration(tokenExp)
 	if err != nil {
 		return nil, fmt.Errorf("invalid token expiration: %w", err)
@@ -63,12 +134,17 @@ func NewJWT(signingKey, tokenExp, refreshTokenExp string) (*JWT, error) {
 	}
 
 	return &JWT{
-		signingKey:             []byte(signingKey),
+		keys:                   keys,
 		tokenExpiration:        tokenExpDur,
 		refreshTokenExpiration: refreshTokenExpDur,
 	}, nil
 }
 
+// JWKS 返回验签公钥集合，供其它服务不持有密钥即可校验本服务签发的 token
+func (j *JWT) JWKS() JWKS {
+	return j.keys.JWKS()
+}
+
 // durationUnits 是 parseDuration 支持的单位，d/w 是对 time.ParseDuration 的补充
 // （标准库不认识"天"“周”，但配置文件里这样写最直观）。
 var durationUnits = map[string]time.Duration{
@@ -108,21 +184,69 @@ func parseDuration(s string) (time.Duration, error) {
 	return time.Duration(num * float64(unit)), nil
 }
 
-// GenerateToken 生成 JWT token 和 refresh token
-func (j *JWT) GenerateToken(userID, username, roleKey string) (*TokenPair, error) {
+// TokenOption 调整签发的令牌对载荷，对 access 与 refresh token 同时生效
+type TokenOption func(*Claims)
+
+// WithRoles 写入用户持有的全部角色
+func WithRoles(roles []string) TokenOption {
+	return func(c *Claims) { c.Roles = roles }
+}
+
+// WithActingRole 写入用户切换到的角色，空串表示不扮演任何单一角色
+func WithActingRole(role string) TokenOption {
+	return func(c *Claims) { c.ActingRole = role }
+}
+
+// WithTenant 写入用户所属租户
+func WithTenant(tenantID uint64) TokenOption {
+	return func(c *Claims) { c.TenantID = tenantID }
+}
+
+// WithActor 写入扮演者，签发的是以被扮演用户身份行事的扮演令牌
+func WithActor(userID, username string) TokenOption {
+	return func(c *Claims) { c.Actor = &Actor{Identity: userID, Nice: username} }
+}
+
+// GenerateToken 生成 JWT token 和 refresh token，并开启一个新的令牌家族（对应一次登录）
+func (j *JWT) GenerateToken(userID, username, roleKey string, opts ...TokenOption) (*TokenPair, error) {
+	return j.generatePair(uuid.NewString(), userID, username, roleKey, opts)
+}
+
+// RotateToken 在已有家族内签发新的令牌对，供 refresh 轮换使用。familyID 为空
+// （升级前签发的旧 token）时等同于 GenerateToken。
+func (j *JWT) RotateToken(familyID, userID, username, roleKey string, opts ...TokenOption) (*TokenPair, error) {
+	if familyID == "" {
+		familyID = uuid.NewString()
+	}
+	return j.generatePair(familyID, userID, username, roleKey, opts)
+}
+
+// RefreshTokenExpiration 返回 refresh token 有效期，撤销整个家族时用它估算记录的存活时长
+func (j *JWT) RefreshTokenExpiration() time.Duration {
+	return j.refreshTokenExpiration
+}
+
+func (j *JWT) generatePair(familyID, userID, username, roleKey string, opts []TokenOption) (*TokenPair, error) {
+	// iat 供用户级撤销比较"是否签发于账号停用之前"
+	now := time.Now()
+
 	// 生成 Access Token
 	accessClaims := Claims{
 		Identity: userID,
 		Nice:     username,
 		RoleKey:  roleKey,
 		Type:     AccessTokenType,
+		FamilyID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.NewString(), // jti，供 TokenStore 按需撤销
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.tokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.tokenExpiration)),
 		},
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenStr, err := accessToken.SignedString(j.signingKey)
+	for _, opt := range opts {
+		opt(&accessClaims)
+	}
+	accessTokenStr, err := j.keys.signClaims(accessClaims)
 	if err != nil {
 		return nil, fmt.Errorf("generate access token failed: %w", err)
 	}
@@ -133,13 +257,17 @@ func (j *JWT) GenerateToken(userID, username, roleKey string) (*TokenPair, error
 		Nice:     username,
 		RoleKey:  roleKey,
 		Type:     RefreshTokenType,
+		FamilyID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.NewString(),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.refreshTokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.refreshTokenExpiration)),
 		},
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenStr, err := refreshToken.SignedString(j.signingKey)
+	for _, opt := range opts {
+		opt(&refreshClaims)
+	}
+	refreshTokenStr, err := j.keys.signClaims(refreshClaims)
 	if err != nil {
 		return nil, fmt.Errorf("generate refresh token failed: %w", err)
 	}
@@ -147,18 +275,65 @@ func (j *JWT) GenerateToken(userID, username, roleKey string) (*TokenPair, error
 	return &TokenPair{
 		AccessToken:  accessTokenStr,
 		RefreshToken: refreshTokenStr,
-		ExpiresAt:    time.Now().Add(j.tokenExpiration).Unix(),
+		ExpiresAt:    now.Add(j.tokenExpiration).Unix(),
+		FamilyID:     familyID,
 	}, nil
 }
 
+// GenerateAccessToken 只签发一个 access token，不带 refresh token，过期后不能续期。
+// 有效期由调用方指定，开启一个新的令牌家族，撤销家族即可让它立即失效。
+// 返回的 TokenPair 里 RefreshToken 为空
+func (j *JWT) GenerateAccessToken(userID, username, roleKey string, ttl time.Duration, opts ...TokenOption) (*TokenPair, error) {
+	now := time.Now()
+	claims := Claims{
+		Identity: userID,
+		Nice:     username,
+		RoleKey:  roleKey,
+		Type:     AccessTokenType,
+		FamilyID: uuid.NewString(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	for _, opt := range opts {
+		opt(&claims)
+	}
+	token, err := j.keys.signClaims(claims)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token failed: %w", err)
+	}
+	return &TokenPair{AccessToken: token, ExpiresAt: now.Add(ttl).Unix(), FamilyID: claims.FamilyID}, nil
+}
+
+// GenerateMFAToken 签发两步验证挑战令牌。不属于任何令牌家族，也不带角色，
+// 有效期由调用方指定（通常只有几分钟）；opts 用于带上租户等换取正式令牌时需要的信息
+func (j *JWT) GenerateMFAToken(userID, username string, ttl time.Duration, opts ...TokenOption) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		Identity: userID,
+		Nice:     username,
+		Type:     MFATokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	for _, opt := range opts {
+		opt(&claims)
+	}
+	token, err := j.keys.signClaims(claims)
+	if err != nil {
+		return "", fmt.Errorf("generate mfa token failed: %w", err)
+	}
+	return token, nil
+}
+
 // ParseToken 解析 JWT token
 func (j *JWT) ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.signingKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.keys.keyFunc)
 
 	if err != nil {
 		return nil, fmt.Errorf("parse token failed: %w", err)
@@ -184,6 +359,7 @@ func (j *JWT) RefreshToken(refreshTokenStr string) (*TokenPair, error) {
 		return nil, errors.New("not a refresh token")
 	}
 
-	// 生成新的 Token 对
-	return j.GenerateToken(claims.Identity, claims.Nice, claims.RoleKey)
+	// 生成新的 Token 对（沿用原家族与角色信息）
+	return j.RotateToken(claims.FamilyID, claims.Identity, claims.Nice, claims.RoleKey,
+		WithRoles(claims.Roles), WithActingRole(claims.ActingRole), WithTenant(claims.TenantID))
 }
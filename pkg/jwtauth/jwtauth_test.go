@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ayxworxfr/go_admin/pkg/constant"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/stretchr/testify/assert"
 )
@@ -129,6 +130,8 @@ func TestJWT_ParseToken(t *testing.T) {
 	assert.Equal(t, username, claims.Nice)
 	assert.Equal(t, roleKey, claims.RoleKey)
 	assert.Equal(t, "access", claims.Type)
+	assert.WithinDuration(t, time.Now(), claims.IssuedTime(), 2*time.Second)
+	assert.True(t, (&Claims{}).IssuedTime().IsZero())
 }
 
 func TestJWT_RefreshToken(t *testing.T) {
@@ -246,3 +249,153 @@ func TestJWT_TokenExpiration(t *testing.T) {
 	assert.True(t, expiresAt > now)
 	assert.True(t, expiresAt < now+120) // 允许有少许误差
 }
+
+func TestJWT_RefreshKeepsFamily(t *testing.T) {
+	jwtManager, err := NewJWT("test-secret-key", "1m", "30d")
+	assert.NoError(t, err)
+
+	first, err := jwtManager.GenerateToken("123", "test-user", "admin")
+	assert.NoError(t, err)
+	firstClaims, err := jwtManager.ParseToken(first.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, firstClaims.FamilyID)
+
+	rotated, err := jwtManager.RefreshToken(first.RefreshToken)
+	assert.NoError(t, err)
+	rotatedClaims, err := jwtManager.ParseToken(rotated.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, firstClaims.FamilyID, rotatedClaims.FamilyID)
+	assert.NotEqual(t, firstClaims.ID, rotatedClaims.ID)
+
+	// 重新登录开启新家族
+	second, err := jwtManager.GenerateToken("123", "test-user", "admin")
+	assert.NoError(t, err)
+	secondClaims, err := jwtManager.ParseToken(second.AccessToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstClaims.FamilyID, secondClaims.FamilyID)
+}
+
+func TestJWT_RoleClaims(t *testing.T) {
+	jwtManager, err := NewJWT("test-secret-key", "1m", "30d")
+	assert.NoError(t, err)
+
+	pair, err := jwtManager.GenerateToken("123", "test-user", "auditor",
+		WithRoles([]string{"admin", "auditor"}), WithActingRole("auditor"))
+	assert.NoError(t, err)
+	for _, token := range []string{pair.AccessToken, pair.RefreshToken} {
+		claims, err := jwtManager.ParseToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"admin", "auditor"}, claims.Roles)
+		assert.Equal(t, "auditor", claims.ActingRole)
+	}
+
+	// 刷新沿用原有的角色信息
+	rotated, err := jwtManager.RefreshToken(pair.RefreshToken)
+	assert.NoError(t, err)
+	claims, err := jwtManager.ParseToken(rotated.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "auditor"}, claims.Roles)
+	assert.Equal(t, "auditor", claims.ActingRole)
+
+	plain, err := jwtManager.GenerateToken("123", "test-user", "admin")
+	assert.NoError(t, err)
+	claims, err = jwtManager.ParseToken(plain.AccessToken)
+	assert.NoError(t, err)
+	assert.Empty(t, claims.Roles)
+	assert.Empty(t, claims.ActingRole)
+}
+
+func TestJWT_TenantClaim(t *testing.T) {
+	jwtManager, err := NewJWT("test-secret-key", "1m", "30d")
+	assert.NoError(t, err)
+
+	pair, err := jwtManager.GenerateToken("123", "test-user", "admin", WithTenant(7))
+	assert.NoError(t, err)
+	claims, err := jwtManager.ParseToken(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), claims.Tenant())
+
+	rotated, err := jwtManager.RefreshToken(pair.RefreshToken)
+	assert.NoError(t, err)
+	claims, err = jwtManager.ParseToken(rotated.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), claims.Tenant())
+
+	mfaToken, err := jwtManager.GenerateMFAToken("123", "test-user", time.Minute, WithTenant(7))
+	assert.NoError(t, err)
+	claims, err = jwtManager.ParseToken(mfaToken)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), claims.TenantID)
+
+	// 不带 tid 的令牌归平台租户
+	legacy, err := jwtManager.GenerateToken("123", "test-user", "admin")
+	assert.NoError(t, err)
+	claims, err = jwtManager.ParseToken(legacy.AccessToken)
+	assert.NoError(t, err)
+	assert.Zero(t, claims.TenantID)
+	assert.Equal(t, constant.PlatformTenantID, claims.Tenant())
+}
+
+func TestClaims_ActsAs(t *testing.T) {
+	claims := &Claims{Roles: []string{"admin", "auditor"}}
+	assert.True(t, claims.ActsAs("admin"))
+	assert.False(t, claims.ActsAs("guest"))
+
+	claims.ActingRole = "auditor"
+	assert.True(t, claims.ActsAs("auditor"))
+	assert.False(t, claims.ActsAs("admin"), "扮演角色时只认该角色")
+}
+
+func TestJWT_GenerateMFAToken(t *testing.T) {
+	jwtManager, err := NewJWT("test-secret-key", "24h", "30d")
+	assert.NoError(t, err)
+
+	token, err := jwtManager.GenerateMFAToken("123", "test-user", 5*time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := jwtManager.ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, MFATokenType, claims.Type)
+	assert.Equal(t, "123", claims.Identity)
+	assert.Empty(t, claims.RoleKey)
+	assert.Empty(t, claims.FamilyID)
+	assert.NotEmpty(t, claims.ID)
+}
+
+func TestJWT_GenerateAccessToken(t *testing.T) {
+	jwtManager, err := NewJWT("test-secret-key", "24h", "30d")
+	assert.NoError(t, err)
+
+	pair, err := jwtManager.GenerateAccessToken("123", "test-user", "admin", 10*time.Minute,
+		WithTenant(7), WithActor("1", "support"))
+	assert.NoError(t, err)
+	assert.Empty(t, pair.RefreshToken)
+	assert.NotEmpty(t, pair.FamilyID)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), time.Unix(pair.ExpiresAt, 0), 2*time.Second)
+
+	claims, err := jwtManager.ParseToken(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, AccessTokenType, claims.Type)
+	assert.Equal(t, "123", claims.Identity)
+	assert.Equal(t, pair.FamilyID, claims.FamilyID)
+	assert.Equal(t, uint64(7), claims.TenantID)
+	assert.True(t, claims.Impersonating())
+	assert.Equal(t, &Actor{Identity: "1", Nice: "support"}, claims.Actor)
+	assert.Equal(t, "1", claims.ActorIdentity())
+}
+
+func TestActorIDUint64FromContext(t *testing.T) {
+	ctx := app.NewContext(1)
+	ctx.Set(ClaimsKey, &Claims{Identity: "123"})
+	actorID, err := ActorIDUint64FromContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(123), actorID, "不扮演时真实操作人就是令牌主人")
+
+	ctx.Set(ClaimsKey, &Claims{Identity: "123", Actor: &Actor{Identity: "1"}})
+	actorID, err = ActorIDUint64FromContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), actorID)
+	userID, err := UserIDUint64FromContext(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(123), userID)
+}
@@ -0,0 +1,248 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const (
+	keyFileExt    = ".pem"
+	minRSAKeyBits = 2048
+)
+
+// signingKey 一把签名密钥。HMAC 的签名与验签用同一个 []byte；
+// 非对称密钥签名用私钥、验签用公钥，公钥同时通过 JWKS 对外发布
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	sign   any
+	verify any
+}
+
+// KeySet 一组按 kid 区分的签名密钥：活动密钥负责签发，其余为已退役密钥，只用于验签，
+// 让轮换前签发、尚未过期的 token 继续可用。
+//
+// 构造完成后只读，可在多个 goroutine 间共享；轮换密钥需要重新构造（即重启进程），
+// 这与 JWT 实例"构造后不再变化"的约定一致。
+type KeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// NewHMACKeySet 创建只有一把 HS256 共享密钥的 KeySet，即引入非对称签名之前的行为。
+// 该密钥 kid 为空，签发的 token 不带 kid 头
+func NewHMACKeySet(secret string) *KeySet {
+	key := &signingKey{method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)}
+	return &KeySet{active: key, keys: map[string]*signingKey{"": key}}
+}
+
+// LoadKeySet 从 dir 加载全部 <kid>.pem 私钥（PKCS#8，RSA 或 Ed25519），以 activeKID
+// 为活动密钥；activeKID 为空时取 kid 最大的一把——GenerateKeyFile 生成的 kid 以时间开头，
+// 即最新生成的那把。alg 为期望的签名算法，活动密钥与之不符时报错，防止配置与密钥文件脱节
+func LoadKeySet(dir, alg, activeKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+keyFileExt))
+	if err != nil {
+		return nil, fmt.Errorf("list key files failed: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no %s key files found in %s", keyFileExt, dir)
+	}
+
+	ks := &KeySet{keys: make(map[string]*signingKey, len(paths))}
+	kids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		key, err := loadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[key.kid] = key
+		kids = append(kids, key.kid)
+	}
+
+	if activeKID == "" {
+		sort.Strings(kids)
+		activeKID = kids[len(kids)-1]
+	}
+	active, ok := ks.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found in %s", activeKID, dir)
+	}
+	if active.method.Alg() != alg {
+		return nil, fmt.Errorf("active key %q is %s, expected %s", activeKID, active.method.Alg(), alg)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+// WithLegacyHMAC 追加一把只用于验签的 HS256 共享密钥（kid 为空），供从 HS256 切换到
+// 非对称签名的过渡期使用：切换前签发的 token 在过期前仍然有效。secret 为空时原样返回
+func (ks *KeySet) WithLegacyHMAC(secret string) *KeySet {
+	if secret == "" {
+		return ks
+	}
+	if _, exists := ks.keys[""]; !exists {
+		ks.keys[""] = &signingKey{method: jwt.SigningMethodHS256, verify: []byte(secret)}
+	}
+	return ks
+}
+
+// ActiveKID 当前用于签发的密钥 ID
+func (ks *KeySet) ActiveKID() string {
+	return ks.active.kid
+}
+
+// signClaims 用活动密钥签名，非对称密钥在头部写入 kid
+func (ks *KeySet) signClaims(claims Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	if ks.active.kid != "" {
+		token.Header["kid"] = ks.active.kid
+	}
+	return token.SignedString(ks.active.sign)
+}
+
+// keyFunc 按 kid 头选择验签密钥。alg 必须与该密钥的算法完全一致，否则拒绝——
+// 防止攻击者把 alg 改成 HS256、拿公开的公钥当 HMAC 密钥伪造签名
+func (ks *KeySet) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verify, nil
+}
+
+// JWK 单把公钥的 JSON Web Key 表示（RFC 7517），只包含验签需要的字段
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519（OKP）
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS JSON Web Key Set，即 /.well-known/jwks.json 的响应体
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回全部非对称密钥（含已退役的）的公钥，按 kid 排序；HMAC 密钥不能公开，不在其中
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// GenerateKeyFile 生成一把新私钥并写入 dir/<kid>.pem（权限 0600），返回 kid。
+// kid 形如 20060102T150405-<随机串>，按字典序即按生成时间排序
+func GenerateKeyFile(dir, alg string) (string, error) {
+	var (
+		priv crypto.Signer
+		err  error
+	)
+	switch alg {
+	case AlgRS256:
+		priv, err = rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	case AlgEdDSA:
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return "", fmt.Errorf("unsupported key algorithm: %s", alg)
+	}
+	if err != nil {
+		return "", fmt.Errorf("generate %s key failed: %w", alg, err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", fmt.Errorf("marshal private key failed: %w", err)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("generate key id failed: %w", err)
+	}
+	kid := time.Now().UTC().Format("20060102T150405") + "-" + fmt.Sprintf("%x", suffix)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("create key directory failed: %w", err)
+	}
+	path := filepath.Join(dir, kid+keyFileExt)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("write key file failed: %w", err)
+	}
+	return kid, nil
+}
+
+// loadKeyFile 读取一个 PKCS#8 私钥文件，kid 取自文件名，算法由密钥类型决定
+func loadKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file failed: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: expected a PKCS#8 PRIVATE KEY block", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse private key failed: %w", path, err)
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(path), keyFileExt)
+	switch priv := parsed.(type) {
+	case *rsa.PrivateKey:
+		if priv.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("%s: RSA key must be at least %d bits", path, minRSAKeyBits)
+		}
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, sign: priv, verify: &priv.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, sign: priv, verify: priv.Public()}, nil
+	default:
+		return nil, errors.New(path + ": unsupported private key type")
+	}
+}
@@ -0,0 +1,124 @@
+package jwtauth
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet_AsymmetricSignAndParse(t *testing.T) {
+	for _, alg := range []string{AlgRS256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			dir := t.TempDir()
+			kid, err := GenerateKeyFile(dir, alg)
+			require.NoError(t, err)
+
+			keys, err := LoadKeySet(dir, alg, "")
+			require.NoError(t, err)
+			assert.Equal(t, kid, keys.ActiveKID())
+
+			j, err := NewJWTWithKeys(keys, "1h", "7d")
+			require.NoError(t, err)
+			pair, err := j.GenerateToken("1", "admin", "ADMIN")
+			require.NoError(t, err)
+
+			claims, err := j.ParseToken(pair.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, "1", claims.Identity)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(pair.AccessToken, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, kid, parsed.Header["kid"])
+			assert.Equal(t, alg, parsed.Header["alg"])
+		})
+	}
+}
+
+func TestKeySet_RotationKeepsOldTokensValid(t *testing.T) {
+	dir := t.TempDir()
+	oldKID, err := GenerateKeyFile(dir, AlgEdDSA)
+	require.NoError(t, err)
+	oldKeys, err := LoadKeySet(dir, AlgEdDSA, "")
+	require.NoError(t, err)
+	oldJWT, err := NewJWTWithKeys(oldKeys, "1h", "7d")
+	require.NoError(t, err)
+	pair, err := oldJWT.GenerateToken("1", "admin", "ADMIN")
+	require.NoError(t, err)
+
+	newKID, err := GenerateKeyFile(dir, AlgEdDSA)
+	require.NoError(t, err)
+	// kid 以秒级时间开头，同一秒内生成的两把靠随机后缀区分，这里显式指定活动密钥
+	newKeys, err := LoadKeySet(dir, AlgEdDSA, newKID)
+	require.NoError(t, err)
+	newJWT, err := NewJWTWithKeys(newKeys, "1h", "7d")
+	require.NoError(t, err)
+
+	_, err = newJWT.ParseToken(pair.AccessToken)
+	assert.NoError(t, err, "token signed by retired key should still verify")
+
+	kids := make([]string, 0, 2)
+	for _, key := range newJWT.JWKS().Keys {
+		assert.Equal(t, "OKP", key.Kty)
+		assert.NotEmpty(t, key.X)
+		kids = append(kids, key.Kid)
+	}
+	assert.ElementsMatch(t, []string{oldKID, newKID}, kids)
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	dir := t.TempDir()
+	_, err := GenerateKeyFile(dir, AlgRS256)
+	require.NoError(t, err)
+
+	_, err = LoadKeySet(dir, AlgEdDSA, "")
+	assert.Error(t, err)
+
+	_, err = LoadKeySet(dir, AlgRS256, "missing")
+	assert.Error(t, err)
+}
+
+func TestKeySet_LegacyHMAC(t *testing.T) {
+	legacy, err := NewJWT("legacy-secret", "1h", "7d")
+	require.NoError(t, err)
+	pair, err := legacy.GenerateToken("1", "admin", "ADMIN")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	_, err = GenerateKeyFile(dir, AlgRS256)
+	require.NoError(t, err)
+	keys, err := LoadKeySet(dir, AlgRS256, "")
+	require.NoError(t, err)
+
+	strict, err := NewJWTWithKeys(keys, "1h", "7d")
+	require.NoError(t, err)
+	_, err = strict.ParseToken(pair.AccessToken)
+	assert.Error(t, err, "HS256 token must be rejected without a legacy secret")
+
+	transitional, err := NewJWTWithKeys(keys.WithLegacyHMAC("legacy-secret"), "1h", "7d")
+	require.NoError(t, err)
+	_, err = transitional.ParseToken(pair.AccessToken)
+	assert.NoError(t, err)
+	for _, key := range transitional.JWKS().Keys {
+		assert.Equal(t, "RSA", key.Kty, "HMAC secret must never be published")
+	}
+}
+
+func TestKeySet_RejectsHMACForgedWithKid(t *testing.T) {
+	dir := t.TempDir()
+	kid, err := GenerateKeyFile(dir, AlgRS256)
+	require.NoError(t, err)
+	keys, err := LoadKeySet(dir, AlgRS256, "")
+	require.NoError(t, err)
+	j, err := NewJWTWithKeys(keys, "1h", "7d")
+	require.NoError(t, err)
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{Identity: "1", Type: AccessTokenType})
+	forged.Header["kid"] = kid
+	raw, err := forged.SignedString([]byte("guess"))
+	require.NoError(t, err)
+
+	_, err = j.ParseToken(raw)
+	assert.Error(t, err)
+}
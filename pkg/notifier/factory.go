@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 驱动名（与 mail.driver 配置值对齐）
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverSMTP = "smtp"
+)
+
+// Options 构造 Notifier 的运行时参数。Dir 仅 file 需要，SMTP 仅 smtp 需要
+type Options struct {
+	Driver string
+	// From 发件人，如 "Go Admin <noreply@example.com>"
+	From string
+	Dir  string
+	SMTP SMTPOptions
+}
+
+// New 按驱动名创建 Notifier。driver 为空时回落 log
+func New(opts Options) (Notifier, error) {
+	switch strings.ToLower(strings.TrimSpace(opts.Driver)) {
+	case "", DriverLog:
+		return NewLogNotifier(), nil
+	case DriverFile:
+		if opts.Dir == "" {
+			return nil, fmt.Errorf("notifier driver %q requires dir", DriverFile)
+		}
+		return NewFileNotifier(opts.Dir, opts.From), nil
+	case DriverSMTP:
+		if opts.SMTP.Host == "" || opts.From == "" {
+			return nil, fmt.Errorf("notifier driver %q requires host and from", DriverSMTP)
+		}
+		return NewSMTPNotifier(opts.SMTP, opts.From), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier driver %q (want %q, %q or %q)",
+			opts.Driver, DriverLog, DriverFile, DriverSMTP)
+	}
+}
@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileNotifier 每封邮件写成 dir 下的一个 .eml 文件，可以直接用邮件客户端打开，
+// 适合本地开发和集成测试断言邮件内容
+type FileNotifier struct {
+	dir  string
+	from string
+	now  func() time.Time
+}
+
+// NewFileNotifier 创建文件投递器，dir 不存在时在首次投递时创建
+func NewFileNotifier(dir, from string) *FileNotifier {
+	if from == "" {
+		from = "go_admin@localhost"
+	}
+	return &FileNotifier{dir: dir, from: from, now: time.Now}
+}
+
+// Notify 实现 Notifier
+func (n *FileNotifier) Notify(_ context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	now := n.now()
+	data, err := msg.encode(n.from, now)
+	if err != nil {
+		return fmt.Errorf("notifier: encode message: %w", err)
+	}
+	if err := os.MkdirAll(n.dir, 0o700); err != nil {
+		return fmt.Errorf("notifier: create mail dir: %w", err)
+	}
+
+	// 时间戳前缀让 ls 按投递顺序排列，随机后缀避免同一纳秒内的两封互相覆盖
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("notifier: generate file name: %w", err)
+	}
+	name := now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+	if err := os.WriteFile(filepath.Join(n.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("notifier: write message: %w", err)
+	}
+	return nil
+}
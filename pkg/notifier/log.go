@@ -0,0 +1,28 @@
+package notifier
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ayxworxfr/go_admin/pkg/logger"
+)
+
+// LogNotifier 把邮件整封打进日志，只用于本地开发：正文里可能有重置链接之类的凭据
+type LogNotifier struct{}
+
+// NewLogNotifier 创建日志投递器
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify 实现 Notifier
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	logger.Info(ctx, "Notification",
+		logger.String("to", strings.Join(msg.To, ", ")),
+		logger.String("subject", msg.Subject),
+		logger.String("body", msg.Body))
+	return nil
+}
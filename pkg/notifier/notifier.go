@@ -0,0 +1,63 @@
+// Package notifier 向用户投递通知（目前只有邮件）。业务代码只依赖 Notifier 接口，
+// 生产环境走 SMTP，本地开发用 log / file 把邮件落到日志或目录里直接查看，不需要真的邮件服务器。
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Notifier 投递通知
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// validate 拒绝空收件人和头部里的换行，防止收件人、主题被拼进额外的邮件头
+func (m Message) validate() error {
+	if len(m.To) == 0 {
+		return fmt.Errorf("notifier: message has no recipient")
+	}
+	for _, to := range m.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("notifier: invalid recipient %q: %w", to, err)
+		}
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return fmt.Errorf("notifier: subject must not contain line breaks")
+	}
+	return nil
+}
+
+// encode 生成 RFC 5322 格式的邮件内容，正文用 quoted-printable 编码以支持中文
+func (m Message) encode(from string, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_Validate(t *testing.T) {
+	assert.Error(t, Message{Subject: "hi"}.validate())
+	assert.Error(t, Message{To: []string{"not an address"}}.validate())
+	assert.Error(t, Message{To: []string{"a@example.com"}, Subject: "hi\r\nBcc: x@example.com"}.validate())
+	assert.NoError(t, Message{To: []string{"Alice <a@example.com>"}, Subject: "hi"}.validate())
+}
+
+func TestFileNotifier_WritesReadableMessage(t *testing.T) {
+	dir := t.TempDir()
+	n := NewFileNotifier(dir, "Go Admin <noreply@example.com>")
+	msg := Message{To: []string{"alice@example.com"}, Subject: "重置密码", Body: "点击链接重置密码：\nhttps://example.com/reset?token=abc"}
+	require.NoError(t, n.Notify(context.Background(), msg))
+	require.NoError(t, n.Notify(context.Background(), msg))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+
+	f, err := os.Open(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	defer f.Close()
+	parsed, err := mail.ReadMessage(f)
+	require.NoError(t, err)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "重置密码", subject)
+	assert.Equal(t, "alice@example.com", parsed.Header.Get("To"))
+}
+
+func TestNew_Drivers(t *testing.T) {
+	n, err := New(Options{})
+	require.NoError(t, err)
+	assert.IsType(t, &LogNotifier{}, n)
+
+	_, err = New(Options{Driver: DriverFile})
+	assert.Error(t, err)
+	_, err = New(Options{Driver: DriverSMTP, From: "noreply@example.com"})
+	assert.Error(t, err)
+	_, err = New(Options{Driver: "pigeon"})
+	assert.Error(t, err)
+}
+
+func TestSMTPNotifier_Delivers(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go serveSMTP(t, ln, received)
+
+	host, port := splitAddr(t, ln.Addr())
+	n := NewSMTPNotifier(SMTPOptions{Host: host, Port: port, TLS: TLSNone, Timeout: 5 * time.Second}, "Go Admin <noreply@example.com>")
+	require.NoError(t, n.Notify(context.Background(), Message{To: []string{"Bob <bob@example.com>"}, Subject: "hello", Body: "body"}))
+
+	select {
+	case cmds := <-received:
+		assert.Contains(t, cmds, "MAIL FROM:<noreply@example.com>")
+		assert.Contains(t, cmds, "RCPT TO:<bob@example.com>")
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp server did not receive the message")
+	}
+}
+
+func TestSMTPNotifier_RequiresStartTLS(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go serveSMTP(t, ln, make(chan []string, 1))
+
+	host, port := splitAddr(t, ln.Addr())
+	n := NewSMTPNotifier(SMTPOptions{Host: host, Port: port, Timeout: 5 * time.Second}, "noreply@example.com")
+	err = n.Notify(context.Background(), Message{To: []string{"bob@example.com"}, Subject: "hello"})
+	assert.ErrorContains(t, err, "STARTTLS")
+}
+
+func splitAddr(t *testing.T, addr net.Addr) (string, int) {
+	tcp, ok := addr.(*net.TCPAddr)
+	require.True(t, ok)
+	return tcp.IP.String(), tcp.Port
+}
+
+// serveSMTP 一个只够 net/smtp 客户端走完一次投递的最小 SMTP 服务器，不声明 STARTTLS
+func serveSMTP(t *testing.T, ln net.Listener, received chan<- []string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+
+	var cmds []string
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		cmds = append(cmds, cmd)
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 go ahead")
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+			}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			received <- cmds
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP 传输加密方式
+const (
+	// TLSStartTLS 明文连接后升级（587 端口），服务器不支持 STARTTLS 时拒绝发送
+	TLSStartTLS = "starttls"
+	// TLSImplicit 连接即 TLS（465 端口）
+	TLSImplicit = "implicit"
+	// TLSNone 不加密，只应在本机或内网的中继（如 MailHog）上使用
+	TLSNone = "none"
+)
+
+// SMTPOptions SMTP 服务器参数，Username 为空时不做认证
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// TLS 取 starttls / implicit / none，为空按 starttls
+	TLS     string
+	Timeout time.Duration
+}
+
+// SMTPNotifier 经 SMTP 服务器投递邮件，每封邮件一次连接
+type SMTPNotifier struct {
+	opts SMTPOptions
+	from string
+}
+
+// NewSMTPNotifier 创建 SMTP 投递器，端口为 0 时按加密方式取默认端口
+func NewSMTPNotifier(opts SMTPOptions, from string) *SMTPNotifier {
+	if opts.TLS == "" {
+		opts.TLS = TLSStartTLS
+	}
+	if opts.Port == 0 {
+		opts.Port = 587
+		if opts.TLS == TLSImplicit {
+			opts.Port = 465
+		}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &SMTPNotifier{opts: opts, from: from}
+}
+
+// Notify 实现 Notifier
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	sender, err := mail.ParseAddress(n.from)
+	if err != nil {
+		return fmt.Errorf("notifier: invalid from address %q: %w", n.from, err)
+	}
+	data, err := msg.encode(n.from, time.Now())
+	if err != nil {
+		return fmt.Errorf("notifier: encode message: %w", err)
+	}
+
+	client, err := n.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if n.opts.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("notifier: smtp server %s does not support STARTTLS", n.opts.Host)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: n.opts.Host}); err != nil {
+			return fmt.Errorf("notifier: starttls: %w", err)
+		}
+	}
+	if n.opts.Username != "" {
+		// PlainAuth 自己会拒绝在未加密连接上发送口令（localhost 除外）
+		if err := client.Auth(smtp.PlainAuth("", n.opts.Username, n.opts.Password, n.opts.Host)); err != nil {
+			return fmt.Errorf("notifier: smtp auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return fmt.Errorf("notifier: smtp MAIL FROM: %w", err)
+	}
+	for _, to := range msg.To {
+		addr, _ := mail.ParseAddress(to) // validate 已确认可解析
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("notifier: smtp RCPT TO %s: %w", addr.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("notifier: smtp DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("notifier: write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("notifier: smtp DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+func (n *SMTPNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(n.opts.Host, strconv.Itoa(n.opts.Port))
+	ctx, cancel := context.WithTimeout(ctx, n.opts.Timeout)
+	defer cancel()
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if n.opts.TLS == TLSImplicit {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: n.opts.Host}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("notifier: connect smtp server %s: %w", addr, err)
+	}
+	// 整个会话共用一个截止时间，服务器卡住时不会无限期占着调用方
+	_ = conn.SetDeadline(time.Now().Add(n.opts.Timeout))
+
+	client, err := smtp.NewClient(conn, n.opts.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("notifier: smtp handshake: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,331 @@
+// Package oidc 实现 OpenID Connect 授权码 + PKCE 登录的客户端一侧：发现配置、
+// 拼授权地址、用授权码换令牌、校验 id_token。
+//
+// 只覆盖登录需要的最小子集，不处理 access token 的续期与 userinfo 端点——
+// go_admin 拿到身份后签发自己的令牌，IdP 的令牌用完即弃。
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultHTTPTimeout = 10 * time.Second
+	// maxResponseSize IdP 响应体上限，防止异常响应占满内存
+	maxResponseSize = 1 << 20
+	// jwksRefreshInterval 遇到未知 kid 时重新拉取 JWKS 的最小间隔，
+	// 避免伪造 kid 的请求把每次校验都变成一次对 IdP 的请求
+	jwksRefreshInterval = time.Minute
+)
+
+var (
+	// ErrInvalidIDToken id_token 签名、签发方、受众、有效期或 nonce 校验失败
+	ErrInvalidIDToken = errors.New("invalid id_token")
+	// ErrExchangeFailed 授权码换令牌失败（授权码无效、已使用或 PKCE 校验不通过）
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+)
+
+// Config 接入一个 IdP 所需的客户端参数
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes 额外申请的 scope，openid 总会被加上
+	Scopes []string
+}
+
+// discovery /.well-known/openid-configuration 中用到的字段
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider 一个 IdP 的客户端。发现配置在首次使用时才拉取，IdP 暂时不可用不会阻断进程启动；
+// 拉取成功后缓存，并发安全
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	meta        *discovery
+	keys        map[string]any
+	keysFetched time.Time
+}
+
+// NewProvider 创建 IdP 客户端，client 为 nil 时使用带超时的默认客户端
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL 拼出跳转 IdP 的授权地址，challenge 为 PKCE code_challenge（S256）
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range p.cfg.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange 用授权码与 PKCE code_verifier 换取 id_token
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("build token request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &token)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("%w: %s %s", ErrExchangeFailed, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", fmt.Errorf("%w: no id_token in response", ErrExchangeFailed)
+	}
+	return token.IDToken, nil
+}
+
+// IDToken 校验通过的 id_token
+type IDToken struct {
+	Issuer  string
+	Subject string
+	Claims  jwt.MapClaims
+}
+
+// ClaimString 读取字符串类型的声明，不存在或类型不符时返回空串
+func (t *IDToken) ClaimString(name string) string {
+	value, _ := t.Claims[name].(string)
+	return value
+}
+
+// ClaimStrings 读取字符串或字符串数组类型的声明（如 groups），统一返回切片
+func (t *IDToken) ClaimStrings(name string) []string {
+	switch value := t.Claims[name].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// VerifyIDToken 校验 id_token 的签名（按 kid 取 IdP 公钥）、iss、aud、exp 与 nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, meta.JWKSURI, kid, token.Method.Alg())
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	return &IDToken{Issuer: meta.Issuer, Subject: subject, Claims: claims}, nil
+}
+
+// discover 拉取并缓存发现配置；返回的 issuer 必须与配置一致，防止被指向别的 IdP
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build discovery request failed: %w", err)
+	}
+	var meta discovery
+	status, err := p.doJSON(req, &meta)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery returned status %d", status)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery issuer mismatch: got %q, want %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is incomplete")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// publicKey 按 kid 取 IdP 公钥；缓存里没有时（IdP 轮换了密钥）按最小间隔重新拉取
+func (p *Provider) publicKey(ctx context.Context, jwksURI, kid, alg string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.keys[kid]
+	if !ok && time.Since(p.keysFetched) >= jwksRefreshInterval {
+		keys, err := p.fetchJWKS(ctx, jwksURI)
+		if err != nil {
+			return nil, err
+		}
+		p.keys = keys
+		p.keysFetched = time.Now()
+		key, ok = p.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if !keyMatchesAlg(key, alg) {
+		return nil, fmt.Errorf("key %q cannot verify %s", kid, alg)
+	}
+	return key, nil
+}
+
+func (p *Provider) fetchJWKS(ctx context.Context, jwksURI string) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build jwks request failed: %w", err)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned status %d", status)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// 无法识别的密钥类型直接跳过，不影响同一集合里的其它密钥
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// doJSON 发送请求并解析 JSON 响应体，返回 HTTP 状态码；非 2xx 时仍尝试解析（错误响应也是 JSON）
+func (p *Provider) doJSON(req *http.Request, out any) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request %s failed: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("read %s response failed: %w", req.URL.Path, err)
+	}
+	if err := json.Unmarshal(body, out); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("decode %s response failed: %w", req.URL.Path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// RandomString 生成 n 字节熵的 URL 安全随机串，用作 state、nonce 与 PKCE code_verifier
+func RandomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate random string failed: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// S256Challenge 按 RFC 7636 由 code_verifier 计算 S256 code_challenge
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,117 @@
+package oidc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/pkg/oidc"
+	"github.com/ayxworxfr/go_admin/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8000/login/callback"
+
+func newProvider(idp *oidctest.Server) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Issuer:      idp.URL,
+		ClientID:    idp.ClientID,
+		RedirectURL: redirectURL,
+		Scopes:      []string{"profile", "email"},
+	}, nil)
+}
+
+// login 走一遍授权 -> 回调，返回授权码
+func login(t *testing.T, idp *oidctest.Server, p *oidc.Provider, verifier, nonce string) string {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", nonce, oidc.S256Challenge(verifier))
+	require.NoError(t, err)
+	code, state, err := idp.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+	return code
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	idp := oidctest.NewServer("go-admin")
+	defer idp.Close()
+	idp.SetClaims(map[string]any{"sub": "u-42", "preferred_username": "alice", "groups": []string{"admins", "devs"}})
+	p := newProvider(idp)
+
+	verifier, err := oidc.RandomString(32)
+	require.NoError(t, err)
+	code := login(t, idp, p, verifier, "nonce-1")
+
+	raw, err := p.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+	token, err := p.VerifyIDToken(context.Background(), raw, "nonce-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "u-42", token.Subject)
+	assert.Equal(t, idp.URL, token.Issuer)
+	assert.Equal(t, "alice", token.ClaimString("preferred_username"))
+	assert.Equal(t, []string{"admins", "devs"}, token.ClaimStrings("groups"))
+	assert.Empty(t, token.ClaimStrings("missing"))
+}
+
+func TestProvider_ExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := oidctest.NewServer("go-admin")
+	defer idp.Close()
+	p := newProvider(idp)
+
+	code := login(t, idp, p, "right-verifier-right-verifier-right-verifier", "nonce")
+	_, err := p.Exchange(context.Background(), code, "wrong-verifier-wrong-verifier-wrong-verifier")
+	assert.ErrorIs(t, err, oidc.ErrExchangeFailed)
+}
+
+func TestProvider_CodeIsSingleUse(t *testing.T) {
+	idp := oidctest.NewServer("go-admin")
+	defer idp.Close()
+	p := newProvider(idp)
+
+	verifier := "verifier-verifier-verifier-verifier-verifier"
+	code := login(t, idp, p, verifier, "nonce")
+	_, err := p.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+	_, err = p.Exchange(context.Background(), code, verifier)
+	assert.ErrorIs(t, err, oidc.ErrExchangeFailed)
+}
+
+func TestProvider_VerifyRejectsNonceMismatch(t *testing.T) {
+	idp := oidctest.NewServer("go-admin")
+	defer idp.Close()
+	p := newProvider(idp)
+
+	verifier := "verifier-verifier-verifier-verifier-verifier"
+	code := login(t, idp, p, verifier, "nonce-a")
+	raw, err := p.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	_, err = p.VerifyIDToken(context.Background(), raw, "nonce-b")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestProvider_VerifyRejectsOtherAudience(t *testing.T) {
+	idp := oidctest.NewServer("other-client")
+	defer idp.Close()
+	issuing := newProvider(idp)
+
+	verifier := "verifier-verifier-verifier-verifier-verifier"
+	code := login(t, idp, issuing, verifier, "nonce")
+	raw, err := issuing.Exchange(context.Background(), code, verifier)
+	require.NoError(t, err)
+
+	// 同一个 IdP 给另一个客户端签发的 id_token 不能拿来登录本系统
+	p := oidc.NewProvider(oidc.Config{Issuer: idp.URL, ClientID: "go-admin", RedirectURL: redirectURL}, nil)
+	_, err = p.VerifyIDToken(context.Background(), raw, "nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer("go-admin")
+	defer idp.Close()
+
+	p := oidc.NewProvider(oidc.Config{Issuer: idp.URL + "/other", ClientID: "go-admin", RedirectURL: redirectURL}, nil)
+	_, err := p.AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.Error(t, err)
+}
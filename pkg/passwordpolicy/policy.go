@@ -0,0 +1,159 @@
+// Package passwordpolicy 密码强度规则：长度、字符类别、常见弱密码黑名单与过期时间。
+// 历史密码不可复用的检查需要比对哈希，由持有 PasswordHasher 的调用方完成，
+// 这里只提供 HistoryCount 这个参数。
+package passwordpolicy
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength 密码长度上限。慢哈希的耗时随输入增长，不设上限会被超长密码拖垮
+	MaxLength = 128
+	// MaxHistoryCount 历史密码检查的上限：每条历史都要做一次慢哈希比对
+	MaxHistoryCount = 24
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords 内置弱密码黑名单，按小写比较
+var commonPasswords = func() map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return set
+}()
+
+// Policy 密码策略，JSON 形态即系统设置 password_policy 的值
+type Policy struct {
+	MinLength      int  `json:"min_length"`
+	RequireUpper   bool `json:"require_upper"`
+	RequireLower   bool `json:"require_lower"`
+	RequireDigit   bool `json:"require_digit"`
+	RequireSpecial bool `json:"require_special"`
+	// HistoryCount 新密码不能与最近 N 次使用过的密码相同，0 表示不检查
+	HistoryCount int `json:"history_count"`
+	// MaxAgeDays 密码使用超过 N 天后登录时提示必须修改，0 表示永不过期
+	MaxAgeDays int `json:"max_age_days"`
+	// RejectCommon 拒绝内置黑名单里的常见弱密码
+	RejectCommon bool `json:"reject_common"`
+	// Denylist 额外禁止的密码（如公司名、产品名），不区分大小写
+	Denylist []string `json:"denylist"`
+}
+
+// Default 未配置策略时的默认值：至少 8 位且不在常见弱密码之列
+func Default() Policy {
+	return Policy{MinLength: 8, RejectCommon: true}
+}
+
+// Error 密码不满足策略，Violations 列出全部不满足的规则，便于前端一次性提示
+type Error struct {
+	Violations []string
+}
+
+func (e *Error) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Violations, "; ")
+}
+
+// Validate 检查密码是否满足策略；username 非空时禁止密码包含用户名
+func (p Policy) Validate(password, username string) error {
+	var violations []string
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, "must be at least "+strconv.Itoa(p.MinLength)+" characters")
+	}
+	if length > MaxLength {
+		violations = append(violations, "must be at most "+strconv.Itoa(MaxLength)+" characters")
+	}
+
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			special = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSpecial && !special {
+		violations = append(violations, "must contain a special character")
+	}
+
+	lowered := strings.ToLower(password)
+	if username != "" && len(username) >= 3 && strings.Contains(lowered, strings.ToLower(username)) {
+		violations = append(violations, "must not contain the username")
+	}
+	if p.denied(lowered) {
+		violations = append(violations, "is too common")
+	}
+
+	if len(violations) > 0 {
+		return &Error{Violations: violations}
+	}
+	return nil
+}
+
+func (p Policy) denied(lowered string) bool {
+	if p.RejectCommon {
+		if _, ok := commonPasswords[lowered]; ok {
+			return true
+		}
+	}
+	for _, word := range p.Denylist {
+		if strings.EqualFold(strings.TrimSpace(word), lowered) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired 按 MaxAgeDays 判断密码是否已过期。changedAt 为零值表示本地没有托管密码
+// （如外部身份开户的账号），不参与过期
+func (p Policy) Expired(changedAt, now time.Time) bool {
+	if p.MaxAgeDays <= 0 || changedAt.IsZero() {
+		return false
+	}
+	return now.After(changedAt.AddDate(0, 0, p.MaxAgeDays))
+}
+
+// Normalize 修正明显不合理的取值，避免错误配置让所有密码都无法通过或检查失效
+func (p Policy) Normalize() Policy {
+	if p.MinLength < 1 {
+		p.MinLength = 1
+	}
+	if p.MinLength > MaxLength {
+		p.MinLength = MaxLength
+	}
+	if p.HistoryCount < 0 {
+		p.HistoryCount = 0
+	}
+	if p.HistoryCount > MaxHistoryCount {
+		p.HistoryCount = MaxHistoryCount
+	}
+	if p.MaxAgeDays < 0 {
+		p.MaxAgeDays = 0
+	}
+	return p
+}
@@ -0,0 +1,67 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	var policyErr *Error
+	require.True(t, errors.As(err, &policyErr), "expected *Error, got %v", err)
+	return policyErr.Violations
+}
+
+func TestValidate_Default(t *testing.T) {
+	p := Default()
+
+	assert.NoError(t, p.Validate("correct horse battery", "alice"))
+	assert.Contains(t, violations(t, p.Validate("short", "")), "must be at least 8 characters")
+	assert.Contains(t, violations(t, p.Validate("Password123", "")), "is too common")
+	assert.Contains(t, violations(t, p.Validate(strings.Repeat("x", MaxLength+1), "")), "must be at most 128 characters")
+}
+
+func TestValidate_CharacterClasses(t *testing.T) {
+	p := Policy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSpecial: true}
+
+	assert.NoError(t, p.Validate("Xy7#pqrs", ""))
+	got := violations(t, p.Validate("abcdefgh", ""))
+	assert.ElementsMatch(t, []string{
+		"must contain an uppercase letter",
+		"must contain a digit",
+		"must contain a special character",
+	}, got)
+}
+
+func TestValidate_UsernameAndDenylist(t *testing.T) {
+	p := Policy{MinLength: 1, Denylist: []string{"GoAdmin2024"}}
+
+	assert.Contains(t, violations(t, p.Validate("my-Alice-pass", "alice")), "must not contain the username")
+	assert.Contains(t, violations(t, p.Validate("goadmin2024", "")), "is too common")
+	// 内置黑名单只在 RejectCommon 时生效
+	assert.NoError(t, p.Validate("password", ""))
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	p := Policy{MaxAgeDays: 90}
+
+	assert.False(t, p.Expired(now.AddDate(0, 0, -89), now))
+	assert.True(t, p.Expired(now.AddDate(0, 0, -91), now))
+	assert.False(t, p.Expired(time.Time{}, now), "zero changedAt never expires")
+	assert.False(t, Policy{}.Expired(now.AddDate(-10, 0, 0), now), "max_age_days=0 never expires")
+}
+
+func TestNormalize(t *testing.T) {
+	p := Policy{MinLength: -3, HistoryCount: -1, MaxAgeDays: -5}.Normalize()
+	assert.Equal(t, 1, p.MinLength)
+	assert.Zero(t, p.HistoryCount)
+	assert.Zero(t, p.MaxAgeDays)
+	assert.Equal(t, MaxLength, Policy{MinLength: 1000}.Normalize().MinLength)
+	assert.Equal(t, MaxHistoryCount, Policy{HistoryCount: 1000}.Normalize().HistoryCount)
+}
@@ -0,0 +1,327 @@
+// Package pathmatch 把 "METHOD:path" 形式的权限规则编译成按路径段组织的前缀树，
+// 匹配耗时只取决于请求路径的段数，与规则条数无关。
+//
+// 路径语法与路由一致：
+//   - 普通段按字面量匹配
+//   - ":name" 匹配任意一个非空段
+//   - "*name" 只能出现在末尾，匹配剩余的零到多段；名字可以省略，
+//     即沿用已有的 "/api/protected/user/*" 写法，同时匹配 /api/protected/user 本身
+//
+// 方法部分可以是单个方法、"GET|POST" 这样的列表，或 "*" 表示任意方法。
+// 规则以 "!" 开头表示拒绝，如 "!DELETE:/api/protected/user"。
+//
+// 多条规则同时匹配时按以下顺序取决定性的一条，它的效果就是结果：
+//  1. 字面量段多的优先
+//  2. 其次 ":name" 段多的优先
+//  3. 其次不带末尾通配的优先
+//  4. 其次列出具体方法的优先于 "*"
+//  5. 以上都相同时拒绝优先于允许
+//
+// 因此 "*:/api/protected/*" 加上 "!DELETE:/api/protected/user" 表示放开整个前缀、
+// 唯独不能删用户；反过来更具体的允许也能在大范围的拒绝里开口子。
+package pathmatch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Effect 规则的效果
+type Effect uint8
+
+const (
+	Allow Effect = iota
+	Deny
+)
+
+func (e Effect) String() string {
+	if e == Deny {
+		return "deny"
+	}
+	return "allow"
+}
+
+// Decision 一次匹配的结论
+type Decision struct {
+	// Matched 是否有规则匹配；没有任何规则匹配时按拒绝处理
+	Matched bool
+	Effect  Effect
+	// Rule 决定性规则的原文，Index 是它在 Rules() 中的下标
+	Rule  string
+	Index int
+}
+
+// Allowed 是否允许访问
+func (d Decision) Allowed() bool {
+	return d.Matched && d.Effect == Allow
+}
+
+// Matcher 编译后的规则集，构造完成后只读，可以并发使用
+type Matcher struct {
+	root  node
+	rules []string
+}
+
+type node struct {
+	static   map[string]*node
+	param    *node
+	catchAll []*entry // 挂在此处的 "*name" 规则
+	end      []*entry // 在此结束的规则
+}
+
+// entry 一条规则在树上的落点
+type entry struct {
+	methods methodSet
+	effect  Effect
+	rank    rank
+	index   int
+}
+
+// rank 规则的具体程度，按字段顺序逐个比较
+type rank struct {
+	literals int
+	params   int
+	exact    bool // 不带末尾通配
+	methods  bool // 列出了具体方法
+}
+
+func (r rank) compare(o rank) int {
+	switch {
+	case r.literals != o.literals:
+		return r.literals - o.literals
+	case r.params != o.params:
+		return r.params - o.params
+	case r.exact != o.exact:
+		if r.exact {
+			return 1
+		}
+		return -1
+	case r.methods != o.methods:
+		if r.methods {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+type methodSet struct {
+	any   bool
+	names map[string]struct{}
+}
+
+// Compile 编译一组规则，任一规则非法时返回错误
+func Compile(rules []string) (*Matcher, error) {
+	m := New()
+	for _, rule := range rules {
+		if err := m.Add(rule); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// New 创建空的 Matcher，配合 Add 逐条加入规则
+func New() *Matcher {
+	return &Matcher{}
+}
+
+// Add 加入一条规则。Add 不是并发安全的，应在开始 Match 之前加完
+func (m *Matcher) Add(rule string) error {
+	effect, methods, segments, err := parse(rule)
+	if err != nil {
+		return err
+	}
+
+	e := &entry{effect: effect, index: len(m.rules)}
+	e.methods.add(methods)
+	e.rank.methods = !e.methods.any
+	e.rank.exact = true
+
+	n := &m.root
+	for _, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			e.rank.exact = false
+			n.catchAll = append(n.catchAll, e)
+			m.rules = append(m.rules, rule)
+			return nil
+		case strings.HasPrefix(seg, ":"):
+			e.rank.params++
+			if n.param == nil {
+				n.param = &node{}
+			}
+			n = n.param
+		default:
+			e.rank.literals++
+			if n.static == nil {
+				n.static = make(map[string]*node)
+			}
+			child, ok := n.static[seg]
+			if !ok {
+				child = &node{}
+				n.static[seg] = child
+			}
+			n = child
+		}
+	}
+	n.end = append(n.end, e)
+	m.rules = append(m.rules, rule)
+	return nil
+}
+
+// Match 判断 method + path 是否被允许：有规则匹配，且决定性的那条是允许
+func (m *Matcher) Match(method, path string) bool {
+	return m.Lookup(method, path).Allowed()
+}
+
+// Lookup 返回 method + path 的匹配结论及决定性规则，供"为什么允许/拒绝"一类的排查使用
+func (m *Matcher) Lookup(method, path string) Decision {
+	if m == nil || !strings.HasPrefix(path, "/") {
+		return Decision{}
+	}
+	var best *entry
+	m.root.lookup(method, path[1:], &best)
+	if best == nil {
+		return Decision{}
+	}
+	return Decision{Matched: true, Effect: best.effect, Rule: m.rules[best.index], Index: best.index}
+}
+
+// Rules 返回编译进来的原始规则，供缓存序列化后重新编译
+func (m *Matcher) Rules() []string {
+	if m == nil {
+		return nil
+	}
+	return append([]string(nil), m.rules...)
+}
+
+// lookup 在 n 下匹配剩余路径 rest（已去掉前导 "/"），把匹配到的规则与 best 比较。
+// 拒绝规则可能藏在任何一条分支上，所以字面量、":name"、"*name" 三类分支都要走到底
+func (n *node) lookup(method, rest string, best **entry) {
+	consider(n.catchAll, method, best)
+
+	seg, tail, more := strings.Cut(rest, "/")
+	if child, ok := n.static[seg]; ok {
+		if more {
+			child.lookup(method, tail, best)
+		} else {
+			child.ends(method, best)
+		}
+	}
+	if n.param != nil && seg != "" {
+		if more {
+			n.param.lookup(method, tail, best)
+		} else {
+			n.param.ends(method, best)
+		}
+	}
+}
+
+// ends 路径恰好在 n 处结束：在此结束的规则，以及此处匹配零段的 "*name"
+func (n *node) ends(method string, best **entry) {
+	consider(n.end, method, best)
+	consider(n.catchAll, method, best)
+}
+
+func consider(entries []*entry, method string, best **entry) {
+	for _, e := range entries {
+		if !e.methods.allows(method) {
+			continue
+		}
+		if *best == nil {
+			*best = e
+			continue
+		}
+		cmp := e.rank.compare((*best).rank)
+		switch {
+		case cmp > 0:
+			*best = e
+		case cmp == 0 && e.effect == Deny && (*best).effect == Allow:
+			*best = e
+		case cmp == 0 && e.effect == (*best).effect && e.index < (*best).index:
+			*best = e
+		}
+	}
+}
+
+func (s *methodSet) add(methods []string) {
+	for _, method := range methods {
+		if method == "*" {
+			s.any = true
+			continue
+		}
+		if s.names == nil {
+			s.names = make(map[string]struct{})
+		}
+		s.names[method] = struct{}{}
+	}
+}
+
+func (s *methodSet) allows(method string) bool {
+	if s.any {
+		return true
+	}
+	_, ok := s.names[method]
+	return ok
+}
+
+// Validate 检查规则语法，不编译。供写入权限、scope 之前校验
+func Validate(rule string) error {
+	_, _, _, err := parse(rule)
+	return err
+}
+
+// Normalize 把规则的方法部分转成大写，"!" 前缀与路径原样保留
+func Normalize(rule string) (string, error) {
+	effect, methods, _, err := parse(rule)
+	if err != nil {
+		return "", err
+	}
+	_, path, _ := strings.Cut(rule, ":")
+	normalized := strings.Join(methods, "|") + ":" + path
+	if effect == Deny {
+		normalized = "!" + normalized
+	}
+	return normalized, nil
+}
+
+func parse(rule string) (Effect, []string, []string, error) {
+	effect := Allow
+	trimmed := strings.TrimSpace(rule)
+	if strings.HasPrefix(trimmed, "!") {
+		effect = Deny
+		trimmed = trimmed[1:]
+	}
+	rawMethods, path, ok := strings.Cut(trimmed, ":")
+	if !ok || rawMethods == "" {
+		return 0, nil, nil, fmt.Errorf("pathmatch: rule %q must be METHOD:path", rule)
+	}
+	methods := strings.Split(strings.ToUpper(rawMethods), "|")
+	for _, method := range methods {
+		if method == "" || strings.ContainsAny(method, " /:!") {
+			return 0, nil, nil, fmt.Errorf("pathmatch: rule %q has an invalid method %q", rule, method)
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		return 0, nil, nil, fmt.Errorf("pathmatch: path in rule %q must start with /", rule)
+	}
+
+	segments := strings.Split(path[1:], "/")
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		switch seg[0] {
+		case '*':
+			if i != len(segments)-1 {
+				return 0, nil, nil, fmt.Errorf("pathmatch: catch-all %q in rule %q must be the last segment", seg, rule)
+			}
+		case ':':
+			if len(seg) == 1 {
+				return 0, nil, nil, fmt.Errorf("pathmatch: parameter in rule %q has no name", rule)
+			}
+		}
+	}
+	return effect, methods, segments, nil
+}
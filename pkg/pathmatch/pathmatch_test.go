@@ -0,0 +1,185 @@
+package pathmatch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	m, err := Compile([]string{
+		"GET:/api/protected/user",
+		"GET|PUT:/api/protected/user/:id",
+		"*:/api/protected/role/*",
+		"GET:/files/*path",
+		"DELETE:/api/protected/user/:id/sessions/:sid",
+		"GET:/api/protected/user/current",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{"GET", "/api/protected/user", true},
+		{"POST", "/api/protected/user", false},
+		{"GET", "/api/protected/user/42", true},
+		{"PUT", "/api/protected/user/42", true},
+		{"DELETE", "/api/protected/user/42", false},
+		{"GET", "/api/protected/user/42/roles", false},
+		{"GET", "/api/protected/user/", false}, // ":id" 不匹配空段
+		// 字面量段与 ":id" 并存时都能命中
+		{"GET", "/api/protected/user/current", true},
+		{"PUT", "/api/protected/user/current", true},
+		{"DELETE", "/api/protected/user/7/sessions/abc", true},
+		{"DELETE", "/api/protected/user/7/sessions", false},
+		// 末尾通配匹配零到多段，但不是字符串前缀
+		{"POST", "/api/protected/role", true},
+		{"DELETE", "/api/protected/role/1/permissions", true},
+		{"GET", "/api/protected/roles", false},
+		{"GET", "/files/a/b/c.txt", true},
+		{"GET", "/files", true},
+		{"POST", "/files/a", false},
+		{"GET", "/other", false},
+		{"GET", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, m.Match(tt.method, tt.path))
+		})
+	}
+}
+
+func TestMatcher_Backtracks(t *testing.T) {
+	// 字面量分支走到底失败后要退回来尝试 ":name"
+	m, err := Compile([]string{"GET:/a/b/c", "GET:/a/:x/d"})
+	require.NoError(t, err)
+	assert.True(t, m.Match("GET", "/a/b/d"))
+	assert.True(t, m.Match("GET", "/a/b/c"))
+	assert.False(t, m.Match("GET", "/a/b/e"))
+}
+
+func TestMatcher_RootCatchAll(t *testing.T) {
+	m, err := Compile([]string{"*:/*"})
+	require.NoError(t, err)
+	assert.True(t, m.Match("PATCH", "/anything/at/all"))
+	assert.True(t, m.Match("GET", "/"))
+}
+
+func TestMatcher_Deny(t *testing.T) {
+	m, err := Compile([]string{
+		"*:/api/protected/*",
+		"!DELETE:/api/protected/user",
+		"!*:/api/protected/role/*",
+		"GET:/api/protected/role/list",
+		"!GET:/api/protected/user/:id",
+		"GET:/api/protected/user/:id",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{"GET", "/api/protected/user", true},
+		{"DELETE", "/api/protected/user", false}, // 更具体的拒绝
+		{"DELETE", "/api/protected/user/7", true},
+		{"POST", "/api/protected/role", false},    // 同为 role/* 以下，拒绝比 "/api/protected/*" 具体
+		{"GET", "/api/protected/role/list", true}, // 更具体的允许在拒绝里开口子
+		{"GET", "/api/protected/role/detail", false},
+		{"GET", "/api/protected/user/7", false}, // 具体程度相同，拒绝优先
+		{"PUT", "/api/protected/user/7", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, m.Match(tt.method, tt.path))
+		})
+	}
+}
+
+func TestMatcher_Precedence(t *testing.T) {
+	// 字面量段 > 参数段 > 不带通配 > 具体方法
+	m, err := Compile([]string{
+		"!GET:/a/:x",
+		"GET:/a/b",
+		"!GET:/c/*",
+		"GET:/c/:x",
+		"!*:/d",
+		"GET:/d",
+		"!GET:/e/*",
+		"*:/e/*",
+	})
+	require.NoError(t, err)
+	assert.True(t, m.Match("GET", "/a/b"))
+	assert.False(t, m.Match("GET", "/a/z"))
+	assert.True(t, m.Match("GET", "/c/1"))
+	assert.False(t, m.Match("GET", "/c/1/2"))
+	assert.True(t, m.Match("GET", "/d"))
+	assert.False(t, m.Match("POST", "/d"))
+	assert.False(t, m.Match("GET", "/e/1"))
+	assert.True(t, m.Match("POST", "/e/1"))
+}
+
+func TestMatcher_Lookup(t *testing.T) {
+	m, err := Compile([]string{"*:/api/*", "!DELETE:/api/user"})
+	require.NoError(t, err)
+
+	d := m.Lookup("DELETE", "/api/user")
+	assert.Equal(t, Decision{Matched: true, Effect: Deny, Rule: "!DELETE:/api/user", Index: 1}, d)
+	assert.False(t, d.Allowed())
+
+	d = m.Lookup("GET", "/api/user")
+	assert.Equal(t, Decision{Matched: true, Effect: Allow, Rule: "*:/api/*", Index: 0}, d)
+	assert.True(t, d.Allowed())
+
+	d = m.Lookup("GET", "/other")
+	assert.False(t, d.Matched)
+	assert.False(t, d.Allowed())
+}
+
+func TestMatcher_Empty(t *testing.T) {
+	var nilMatcher *Matcher
+	assert.False(t, nilMatcher.Match("GET", "/"))
+	assert.False(t, New().Match("GET", "/"))
+}
+
+func TestMatcher_Rules(t *testing.T) {
+	rules := []string{"GET:/a", "POST:/b/*"}
+	m, err := Compile(rules)
+	require.NoError(t, err)
+	assert.Equal(t, rules, m.Rules())
+}
+
+func TestValidate(t *testing.T) {
+	for _, rule := range []string{"GET:/a", "get|post:/a/:id", "*:/files/*path", "GET:/", "!DELETE:/a"} {
+		assert.NoError(t, Validate(rule), rule)
+	}
+	for _, rule := range []string{"/a", "GET:a", ":/a", "GET||POST:/a", "GET:/a/*x/b", "GET:/a/:/b", "!:/a", "GET|!POST:/a"} {
+		assert.Error(t, Validate(rule), rule)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize("get|Post:/a/:ID")
+	require.NoError(t, err)
+	assert.Equal(t, "GET|POST:/a/:ID", got)
+
+	got, err = Normalize("!delete:/a")
+	require.NoError(t, err)
+	assert.Equal(t, "!DELETE:/a", got)
+}
+
+func BenchmarkMatch(b *testing.B) {
+	rules := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		rules = append(rules, fmt.Sprintf("GET:/api/protected/resource%d/:id", i))
+	}
+	m, err := Compile(rules)
+	require.NoError(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match("GET", "/api/protected/resource999/42")
+	}
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -67,6 +68,36 @@ func (c *Client) Set(ctx context.Context, key, value string, ttl time.Duration)
 	return nil
 }
 
+// Get 读取字符串键；键不存在时返回 false 而不是 error
+func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
+	v, err := c.raw.Get(ctx, key).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("redis get %q: %w", key, err)
+	}
+	return v, true, nil
+}
+
+// SetNX 仅在键不存在时写入带 TTL 的字符串键，返回是否写入成功
+func (c *Client) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	ok, err := c.raw.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis setnx %q: %w", key, err)
+	}
+	return ok, nil
+}
+
+// Incr 自增整数键并返回自增后的值；键不存在时从 0 开始
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	n, err := c.raw.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("redis incr %q: %w", key, err)
+	}
+	return n, nil
+}
+
 // Exists 判断键是否存在
 func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
 	n, err := c.raw.Exists(ctx, key).Result()
@@ -76,6 +107,97 @@ func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
 	return n > 0, nil
 }
 
+// Del 删除键；键不存在不报错
+func (c *Client) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := c.raw.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("redis del %v: %w", keys, err)
+	}
+	return nil
+}
+
+// Expire 重设键的存活时间
+func (c *Client) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if err := c.raw.Expire(ctx, key, ttl).Err(); err != nil {
+		return fmt.Errorf("redis expire %q: %w", key, err)
+	}
+	return nil
+}
+
+// HSet 写入哈希字段
+func (c *Client) HSet(ctx context.Context, key, field, value string) error {
+	if err := c.raw.HSet(ctx, key, field, value).Err(); err != nil {
+		return fmt.Errorf("redis hset %q: %w", key, err)
+	}
+	return nil
+}
+
+// HGet 读取哈希字段；字段不存在时返回 false 而不是 error
+func (c *Client) HGet(ctx context.Context, key, field string) (string, bool, error) {
+	v, err := c.raw.HGet(ctx, key, field).Result()
+	if errors.Is(err, goredis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("redis hget %q: %w", key, err)
+	}
+	return v, true, nil
+}
+
+// 逐个比较守卫键（KEYS[2..]）与期望值（ARGV[4..]），全部相等才写入哈希字段并重置 TTL
+var hsetIfMatchScript = goredis.NewScript(`
+for i = 2, #KEYS do
+  if (redis.call("get", KEYS[i]) or "") ~= ARGV[i + 2] then
+    return 0
+  end
+end
+redis.call("hset", KEYS[1], ARGV[1], ARGV[2])
+if tonumber(ARGV[3]) > 0 then
+  redis.call("pexpire", KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+// HSetIfMatch 仅当 guards 里每个键的当前值都等于给定值（不存在的键按空串比较）时写入哈希字段，
+// 并把哈希的存活时间重置为 ttl（<=0 时不设置）。比较与写入在一个脚本里原子完成，返回是否写入
+func (c *Client) HSetIfMatch(ctx context.Context, key, field, value string, ttl time.Duration, guards map[string]string) (bool, error) {
+	keys := make([]string, 0, len(guards)+1)
+	args := make([]any, 0, len(guards)+3)
+	keys = append(keys, key)
+	args = append(args, field, value, ttl.Milliseconds())
+	for k, v := range guards {
+		keys = append(keys, k)
+		args = append(args, v)
+	}
+	n, err := hsetIfMatchScript.Run(ctx, c.raw, keys, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("redis hset %q: %w", key, err)
+	}
+	return n == 1, nil
+}
+
+// HGetAll 读取整个哈希；键不存在时返回空 map
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m, err := c.raw.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis hgetall %q: %w", key, err)
+	}
+	return m, nil
+}
+
+// HDel 删除哈希字段
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := c.raw.HDel(ctx, key, fields...).Err(); err != nil {
+		return fmt.Errorf("redis hdel %q: %w", key, err)
+	}
+	return nil
+}
+
 // Close 关闭底层连接池
 func (c *Client) Close() error {
 	if c == nil || c.raw == nil {
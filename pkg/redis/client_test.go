@@ -27,9 +27,108 @@ func TestClient_SetExistsClose(t *testing.T) {
 	ok, err = c.Exists(ctx, "missing")
 	require.NoError(t, err)
 	require.False(t, ok)
+
+	v, ok, err := c.Get(ctx, "k")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "1", v)
+
+	_, ok, err = c.Get(ctx, "missing")
+	require.NoError(t, err)
+	require.False(t, ok)
 }
 
 func TestNew_EmptyAddr(t *testing.T) {
 	_, err := New(Options{})
 	require.Error(t, err)
 }
+
+func TestClient_SetNX(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	c, err := New(Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	ok, err := c.SetNX(ctx, "once", "1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = c.SetNX(ctx, "once", "2", time.Minute)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, time.Minute, mr.TTL("once"))
+}
+
+func TestClient_Hash(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	c, err := New(Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	require.NoError(t, c.HSet(ctx, "h", "a", "1"))
+	require.NoError(t, c.HSet(ctx, "h", "b", "2"))
+	require.NoError(t, c.Expire(ctx, "h", time.Minute))
+	require.Equal(t, time.Minute, mr.TTL("h"))
+
+	v, ok, err := c.HGet(ctx, "h", "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "1", v)
+
+	_, ok, err = c.HGet(ctx, "h", "missing")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, c.HDel(ctx, "h", "a"))
+	all, err := c.HGetAll(ctx, "h")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"b": "2"}, all)
+
+	all, err = c.HGetAll(ctx, "nope")
+	require.NoError(t, err)
+	require.Empty(t, all)
+
+	require.NoError(t, c.Del(ctx, "h"))
+	require.False(t, mr.Exists("h"))
+}
+
+func TestClient_HSetIfMatch(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	c, err := New(Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	// 不存在的守卫键按空串比较
+	ok, err := c.HSetIfMatch(ctx, "h", "a", "1", time.Minute, map[string]string{"ver": ""})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, time.Minute, mr.TTL("h"))
+
+	_, err = c.Incr(ctx, "ver")
+	require.NoError(t, err)
+	ok, err = c.HSetIfMatch(ctx, "h", "a", "2", time.Minute, map[string]string{"ver": ""})
+	require.NoError(t, err)
+	require.False(t, ok, "守卫键变了就不写入")
+	v, _, err := c.HGet(ctx, "h", "a")
+	require.NoError(t, err)
+	require.Equal(t, "1", v)
+
+	ok, err = c.HSetIfMatch(ctx, "h", "a", "3", time.Minute, map[string]string{"ver": "1", "gen": ""})
+	require.NoError(t, err)
+	require.True(t, ok)
+	v, _, err = c.HGet(ctx, "h", "a")
+	require.NoError(t, err)
+	require.Equal(t, "3", v)
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+)
+
+// Publish 向频道发布一条消息。Redis pub/sub 只投递给发布时在线的订阅者，
+// 不保证送达，调用方需要有 TTL 之类的兜底
+func (c *Client) Publish(ctx context.Context, channel, message string) error {
+	if err := c.raw.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("redis publish %q: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscription 一个频道订阅，Close 后不再回调
+type Subscription struct {
+	close func() error
+	done  chan struct{}
+}
+
+// Close 退订并等待回调 goroutine 退出
+func (s *Subscription) Close() error {
+	err := s.close()
+	<-s.done
+	return err
+}
+
+// Subscribe 订阅频道，确认订阅成功后才返回；之后收到的消息在一个独立 goroutine 里
+// 按到达顺序交给 handle。断线时 go-redis 会自动重连并重新订阅，期间发布的消息会丢失
+func (c *Client) Subscribe(ctx context.Context, channel string, handle func(payload string)) (*Subscription, error) {
+	ps := c.raw.Subscribe(ctx, channel)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		return nil, fmt.Errorf("redis subscribe %q: %w", channel, err)
+	}
+
+	sub := &Subscription{close: ps.Close, done: make(chan struct{})}
+	messages := ps.Channel()
+	go func() {
+		defer close(sub.done)
+		for msg := range messages {
+			handle(msg.Payload)
+		}
+	}()
+	return sub, nil
+}
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_PublishSubscribe(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	c, err := New(Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	received := make(chan string, 2)
+	sub, err := c.Subscribe(ctx, "events", func(payload string) { received <- payload })
+	require.NoError(t, err)
+
+	require.NoError(t, c.Publish(ctx, "events", "a"))
+	require.NoError(t, c.Publish(ctx, "other", "ignored"))
+	require.NoError(t, c.Publish(ctx, "events", "b"))
+	for _, want := range []string{"a", "b"} {
+		select {
+		case got := <-received:
+			require.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("message %q not received", want)
+		}
+	}
+
+	require.NoError(t, sub.Close())
+	require.NoError(t, c.Publish(ctx, "events", "late"))
+	select {
+	case got := <-received:
+		t.Fatalf("received %q after close", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestClient_Incr(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	c, err := New(Options{Addr: mr.Addr()})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	n, err := c.Incr(ctx, "gen")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	n, err = c.Incr(ctx, "gen")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+}
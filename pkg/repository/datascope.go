@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"xorm.io/builder"
+)
+
+// DataScope 当前请求可见的数据范围（行级权限）。All 为 true 时不加任何条件；
+// 否则模型里标注 `datascope:"owner"` 的列在 UserIDs 内，或标注 `datascope:"dept"`
+// 的列在 DeptIDs 内，满足其一即可见。两类列都没有标注的模型不受影响。
+//
+// 只作用于 QueryBuilder（以及基于它的 Find/FindByID/FindAll/FindPage）的查询、计数与删除；
+// 按主键的 Update/Delete 和 Query 自定义 SQL 不过滤——写操作前通常会先 FindByID，
+// 范围外的记录在那一步就查不到了。
+type DataScope struct {
+	All     bool
+	UserIDs []uint64
+	DeptIDs []uint64
+}
+
+// ScopeResolver 计算当前请求的数据范围
+type ScopeResolver func(ctx context.Context) (*DataScope, error)
+
+type dataScopeKey struct{}
+
+// scopeState 同一请求内只解析一次数据范围；nil 表示显式取消了数据范围
+type scopeState struct {
+	once    sync.Once
+	resolve ScopeResolver
+	scope   *DataScope
+	err     error
+}
+
+// WithDataScope 为 ctx 挂上数据范围。resolve 延迟到第一次查询带数据范围的模型时才执行，
+// 同一个 ctx 派生出的查询共享结果；不涉及这类模型的请求不付出解析成本
+func WithDataScope(ctx context.Context, resolve ScopeResolver) context.Context {
+	return context.WithValue(ctx, dataScopeKey{}, &scopeState{resolve: resolve})
+}
+
+// WithoutDataScope 取消 ctx 上的数据范围，供系统内部的查询使用，
+// 如计算数据范围本身时要读取用户所在部门
+func WithoutDataScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dataScopeKey{}, (*scopeState)(nil))
+}
+
+// dataScopeFrom 取 ctx 上的数据范围，未设置或已取消时返回 nil
+func dataScopeFrom(ctx context.Context) (*DataScope, error) {
+	if ctx == nil {
+		return nil, nil
+	}
+	state, _ := ctx.Value(dataScopeKey{}).(*scopeState)
+	if state == nil {
+		return nil, nil
+	}
+	state.once.Do(func() {
+		state.scope, state.err = state.resolve(ctx)
+	})
+	return state.scope, state.err
+}
+
+// scopeColumns 模型上标注了数据范围的列
+type scopeColumns struct {
+	owner string
+	dept  string
+}
+
+var scopeCache sync.Map // reflect.Type -> scopeColumns
+
+func scopeColumnsOf[T any]() scopeColumns {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if cached, ok := scopeCache.Load(t); ok {
+		return cached.(scopeColumns)
+	}
+	var cols scopeColumns
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			col := columnFromXormTag(field.Tag.Get("xorm"))
+			if col == "" {
+				continue
+			}
+			switch field.Tag.Get("datascope") {
+			case "owner":
+				cols.owner = col
+			case "dept":
+				cols.dept = col
+			}
+		}
+	}
+	scopeCache.Store(t, cols)
+	return cols
+}
+
+// scopeCond 按 ctx 上的数据范围生成模型 T 的附加条件，无需过滤时返回 nil
+func scopeCond[T any](ctx context.Context) (builder.Cond, error) {
+	cols := scopeColumnsOf[T]()
+	if cols.owner == "" && cols.dept == "" {
+		return nil, nil
+	}
+	scope, err := dataScopeFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if scope == nil || scope.All {
+		return nil, nil
+	}
+
+	var conds []builder.Cond
+	if cols.owner != "" && len(scope.UserIDs) > 0 {
+		conds = append(conds, builder.In(quoteIdent(cols.owner), toAnySlice(scope.UserIDs)...))
+	}
+	if cols.dept != "" && len(scope.DeptIDs) > 0 {
+		conds = append(conds, builder.In(quoteIdent(cols.dept), toAnySlice(scope.DeptIDs)...))
+	}
+	if len(conds) == 0 {
+		// 范围内什么都没有：宁可查不到，也不能退化成不过滤
+		return builder.Expr("1 = 0"), nil
+	}
+	return builder.Or(conds...), nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scopedItem 标注了数据范围列的测试模型
+type scopedItem struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	Name    string `xorm:"varchar(64) notnull 'name'"`
+	OwnerID uint64 `xorm:"bigint 'owner_id'" datascope:"owner"`
+	DeptID  uint64 `xorm:"bigint 'dept_id'" datascope:"dept"`
+}
+
+func (scopedItem) TableName() string { return "repo_scoped_item" }
+
+func newScopedRepo(t *testing.T) *Repository[scopedItem] {
+	t.Helper()
+	repo := NewRepository[scopedItem](openTestDB(t, new(scopedItem)))
+	require.NoError(t, repo.BatchCreate(context.Background(), []scopedItem{
+		{Name: "mine", OwnerID: 1, DeptID: 10},
+		{Name: "same-dept", OwnerID: 2, DeptID: 10},
+		{Name: "other-dept", OwnerID: 3, DeptID: 20},
+		{Name: "no-dept", OwnerID: 4},
+	}))
+	return repo
+}
+
+func scoped(scope *DataScope) context.Context {
+	return WithDataScope(context.Background(), func(context.Context) (*DataScope, error) { return scope, nil })
+}
+
+func names(rows []scopedItem) []string {
+	out := make([]string, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.Name)
+	}
+	return out
+}
+
+func TestDataScopeFiltersQueries(t *testing.T) {
+	repo := newScopedRepo(t)
+
+	tests := []struct {
+		name  string
+		scope *DataScope
+		want  []string
+	}{
+		{"no scope", nil, []string{"mine", "same-dept", "other-dept", "no-dept"}},
+		{"all", &DataScope{All: true}, []string{"mine", "same-dept", "other-dept", "no-dept"}},
+		{"self", &DataScope{UserIDs: []uint64{1}}, []string{"mine"}},
+		{"dept or self", &DataScope{UserIDs: []uint64{4}, DeptIDs: []uint64{10}}, []string{"mine", "same-dept", "no-dept"}},
+		{"empty", &DataScope{}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := scoped(tt.scope)
+			rows, err := repo.QueryBuilder().OrderBy("id ASC").Find(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, names(rows))
+
+			count, err := repo.QueryBuilder().Count(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+}
+
+func TestDataScopeCombinesWithConditions(t *testing.T) {
+	repo := newScopedRepo(t)
+	ctx := scoped(&DataScope{DeptIDs: []uint64{10}})
+
+	// 链式条件里的 OR 不能把数据范围"或"掉
+	rows, err := repo.QueryBuilder().Eq("name", "mine").Or().Eq("name", "other-dept").Find(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mine"}, names(rows))
+
+	_, err = repo.FindByID(ctx, int64(3))
+	assert.ErrorIs(t, err, ErrNotFound, "范围外的记录按不存在处理")
+
+	// 删除同样受范围约束
+	require.NoError(t, repo.QueryBuilder().Like("name", "dept").Delete(ctx))
+	count, err := repo.QueryBuilder().Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count, "只应删掉范围内的 same-dept")
+}
+
+func TestDataScopeResolvedOnceAndBypassed(t *testing.T) {
+	repo := newScopedRepo(t)
+	calls := 0
+	ctx := WithDataScope(context.Background(), func(context.Context) (*DataScope, error) {
+		calls++
+		return &DataScope{UserIDs: []uint64{1}}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.QueryBuilder().Find(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, calls)
+
+	rows, err := repo.QueryBuilder().Find(WithoutDataScope(ctx))
+	require.NoError(t, err)
+	assert.Len(t, rows, 4)
+}
+
+func TestDataScopeResolveError(t *testing.T) {
+	repo := newScopedRepo(t)
+	boom := errors.New("boom")
+	ctx := WithDataScope(context.Background(), func(context.Context) (*DataScope, error) { return nil, boom })
+
+	_, err := repo.QueryBuilder().Find(ctx)
+	assert.ErrorIs(t, err, boom)
+
+	// 没有标注数据范围的模型不触发解析
+	plain := NewRepository[item](repo.db)
+	require.NoError(t, plain.db.Engine().Sync2(new(item)))
+	_, err = plain.QueryBuilder().Find(ctx)
+	assert.NoError(t, err)
+}
@@ -9,6 +9,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
 )
 
 var (
@@ -20,6 +21,8 @@ var (
 	ErrNoPrimaryKey = errors.New("model has no primary key field")
 	// ErrInvalidModel 传入的模型不是可用的结构体指针
 	ErrInvalidModel = errors.New("model must be a non-nil pointer to struct")
+	// ErrNotSoftDeletable 模型没有 xorm deleted 列，不支持恢复与清理
+	ErrNotSoftDeletable = errors.New("model does not support soft delete")
 )
 
 // 自定义上下文键类型，避免与其他包的键冲突
@@ -39,6 +42,12 @@ func New(engine *xorm.Engine) *DB {
 	return &DB{engine: engine}
 }
 
+// rowLocks 数据库是否支持 SELECT ... FOR UPDATE，xorm 只为 MySQL 与 PostgreSQL 生成
+func (db *DB) rowLocks() bool {
+	dbType := db.engine.Dialect().URI().DBType
+	return dbType == schemas.MYSQL || dbType == schemas.POSTGRES
+}
+
 // Engine 暴露底层引擎，仅供迁移/测试等基础设施使用。
 func (db *DB) Engine() *xorm.Engine {
 	return db.engine
@@ -328,6 +328,12 @@ func (qb *QueryBuilder[T]) applyFilters(filters []Condition) *QueryBuilder[T] {
 	return qb
 }
 
+// Filter 按 query 的非零字段追加条件，规则与 FindPage 相同（见 buildFiltersFromModel）。
+// 用于在列表接口的筛选条件上再叠加排序、游标等，例如按 id 分批导出
+func (qb *QueryBuilder[T]) Filter(query any) *QueryBuilder[T] {
+	return qb.applyFilters(buildFiltersFromModel(query))
+}
+
 // Eq 等于
 func (qb *QueryBuilder[T]) Eq(field string, value any) *QueryBuilder[T] {
 	return qb.where(field, OpEq, value)
@@ -391,7 +397,8 @@ func (qb *QueryBuilder[T]) Offset(offset int) *QueryBuilder[T] {
 	return qb
 }
 
-// ForUpdate 行锁 SELECT ... FOR UPDATE（须在事务内使用才有意义）
+// ForUpdate 行锁 SELECT ... FOR UPDATE（须在事务内使用才有意义）。
+// SQLite 没有行锁，写事务本身就独占整个库，这里直接忽略
 func (qb *QueryBuilder[T]) ForUpdate() *QueryBuilder[T] {
 	qb.forUpdate = true
 	return qb
@@ -400,8 +407,16 @@ func (qb *QueryBuilder[T]) ForUpdate() *QueryBuilder[T] {
 // Find 执行查询并返回列表（只跑 SELECT，不附带 COUNT）
 func (qb *QueryBuilder[T]) Find(ctx context.Context) ([]T, error) {
 	var rows []T
-	err := qb.db.withSession(ctx, func(session *xorm.Session) error {
-		qb.applySelect(session)
+	implicit, err := implicitCond[T](ctx)
+	if err != nil {
+		return nil, wrapDBErr("Find", err)
+	}
+	deleted, unscoped := readScope[T](ctx)
+	err = qb.db.withSession(ctx, func(session *xorm.Session) error {
+		if unscoped {
+			session.Unscoped()
+		}
+		qb.applySelect(session, andCond(implicit, deleted))
 		return session.Find(&rows)
 	})
 	return rows, wrapDBErr("Find", err)
@@ -423,8 +438,16 @@ func (qb *QueryBuilder[T]) First(ctx context.Context) (*T, error) {
 // Count 返回匹配条件的记录数（只跑 COUNT）
 func (qb *QueryBuilder[T]) Count(ctx context.Context) (int64, error) {
 	var total int64
-	err := qb.db.withSession(ctx, func(session *xorm.Session) error {
-		qb.applyWhere(session)
+	implicit, err := implicitCond[T](ctx)
+	if err != nil {
+		return 0, wrapDBErr("Count", err)
+	}
+	deleted, unscoped := readScope[T](ctx)
+	err = qb.db.withSession(ctx, func(session *xorm.Session) error {
+		if unscoped {
+			session.Unscoped()
+		}
+		qb.applyWhere(session, andCond(implicit, deleted))
 		n, err := session.Count(new(T))
 		total = n
 		return err
@@ -434,25 +457,34 @@ func (qb *QueryBuilder[T]) Count(ctx context.Context) (int64, error) {
 
 // Delete 按当前条件删除
 func (qb *QueryBuilder[T]) Delete(ctx context.Context) error {
-	err := qb.db.withSession(ctx, func(session *xorm.Session) error {
-		qb.applyWhere(session)
+	implicit, err := implicitCond[T](ctx)
+	if err != nil {
+		return wrapDBErr("Delete", err)
+	}
+	err = qb.db.withSession(ctx, func(session *xorm.Session) error {
+		qb.applyWhere(session, implicit)
 		_, err := session.Delete(new(T))
 		return err
 	})
 	return wrapDBErr("Delete", err)
 }
 
-func (qb *QueryBuilder[T]) applyWhere(session *xorm.Session) {
-	if len(qb.nodes) == 0 {
-		return
-	}
-	if cond := toBuilderCond(qb.nodes); cond != nil {
+// applyWhere 写入链式条件；implicit 为仓储自动追加的租户与数据范围条件（见 implicitCond），
+// 与链式条件以 AND 连接
+func (qb *QueryBuilder[T]) applyWhere(session *xorm.Session, implicit builder.Cond) {
+	cond := toBuilderCond(qb.nodes)
+	switch {
+	case cond != nil && implicit != nil:
+		session.Where(builder.And(cond, implicit))
+	case cond != nil:
 		session.Where(cond)
+	case implicit != nil:
+		session.Where(implicit)
 	}
 }
 
-func (qb *QueryBuilder[T]) applySelect(session *xorm.Session) {
-	qb.applyWhere(session)
+func (qb *QueryBuilder[T]) applySelect(session *xorm.Session, implicit builder.Cond) {
+	qb.applyWhere(session, implicit)
 	if qb.orderBy != "" {
 		session.OrderBy(qb.orderBy)
 	}
@@ -461,7 +493,7 @@ func (qb *QueryBuilder[T]) applySelect(session *xorm.Session) {
 	} else if qb.offset > 0 {
 		session.Limit(-1, qb.offset)
 	}
-	if qb.forUpdate {
+	if qb.forUpdate && qb.db.rowLocks() {
 		session.ForUpdate()
 	}
 }
@@ -77,3 +77,23 @@ func TestQueryBuilderOrChainBuildsNodes(t *testing.T) {
 		t.Fatalf("third node should be AND-joined")
 	}
 }
+
+func TestQueryBuilderFilterUsesTaggedFields(t *testing.T) {
+	type listQuery struct {
+		Name   string `xorm:"name op=like"`
+		Status int    `xorm:"status op=eq"`
+		Limit  int
+	}
+	qb := &QueryBuilder[struct{}]{nextJoin: joinAnd}
+	qb.Filter(&listQuery{Name: "al", Limit: 10}).Gt("id", 5)
+
+	if len(qb.nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(qb.nodes))
+	}
+	if c := qb.nodes[0].cond; c.Field != "name" || c.Op != OpLike {
+		t.Fatalf("unexpected first condition %+v", c)
+	}
+	if c := qb.nodes[1].cond; c.Field != "id" || c.Op != OpGt {
+		t.Fatalf("unexpected second condition %+v", c)
+	}
+}
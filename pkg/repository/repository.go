@@ -36,6 +36,7 @@ func (r *Repository[T]) Create(ctx context.Context, model *T) error {
 	if model == nil {
 		return ErrInvalidModel
 	}
+	stampTenant(ctx, model)
 	err := r.db.withSession(ctx, func(session *xorm.Session) error {
 		_, err := session.Insert(model)
 		return err
@@ -43,8 +44,9 @@ func (r *Repository[T]) Create(ctx context.Context, model *T) error {
 	return wrapDBErr("Create", err)
 }
 
-// Update 按主键更新非空字段
-func (r *Repository[T]) Update(ctx context.Context, model *T) error {
+// Update 按主键更新非空字段；mustCols 列出即使为零值也要写入的列（如布尔开关置 false）。
+// ctx 上挂了租户时只更新该租户的记录，主键属于别的租户时不报错也不生效
+func (r *Repository[T]) Update(ctx context.Context, model *T, mustCols ...string) error {
 	if model == nil {
 		return ErrInvalidModel
 	}
@@ -52,19 +54,29 @@ func (r *Repository[T]) Update(ctx context.Context, model *T) error {
 	if err != nil {
 		return err
 	}
+	stampTenant(ctx, model)
+	tenant := tenantCond[T](ctx)
 	err = r.db.withSession(ctx, func(session *xorm.Session) error {
-		_, err := session.ID(pk).Update(model)
+		session.ID(pk)
+		if tenant != nil {
+			session.Where(tenant)
+		}
+		_, err := session.MustCols(mustCols...).Update(model)
 		return err
 	})
 	return wrapDBErr("Update", err)
 }
 
-// Delete 按模型主键（或非零字段条件）删除
+// Delete 按模型主键（或非零字段条件）删除，ctx 上挂了租户时只删该租户的记录
 func (r *Repository[T]) Delete(ctx context.Context, model *T) error {
 	if model == nil {
 		return ErrInvalidModel
 	}
+	tenant := tenantCond[T](ctx)
 	err := r.db.withSession(ctx, func(session *xorm.Session) error {
+		if tenant != nil {
+			session.Where(tenant)
+		}
 		_, err := session.Delete(model)
 		return err
 	})
@@ -128,6 +140,9 @@ func (r *Repository[T]) BatchCreate(ctx context.Context, models []T) error {
 	if len(models) == 0 {
 		return nil
 	}
+	for i := range models {
+		stampTenant(ctx, &models[i])
+	}
 	err := r.db.withSession(ctx, func(session *xorm.Session) error {
 		_, err := session.Insert(&models)
 		return err
@@ -140,7 +155,8 @@ func (r *Repository[T]) QueryBuilder() *QueryBuilder[T] {
 	return NewQueryBuilder[T](r.db)
 }
 
-// Query 执行自定义 SQL 并将结果扫描进 []T（用于 CTE 等复杂查询）
+// Query 执行自定义 SQL 并将结果扫描进 []T（用于 CTE 等复杂查询）。
+// 不追加租户与数据范围条件，需要时由 SQL 自己带上
 func (r *Repository[T]) Query(ctx context.Context, sql string, args ...any) ([]T, error) {
 	var rows []T
 	err := r.db.withSession(ctx, func(session *xorm.Session) error {
@@ -21,17 +21,23 @@ type item struct {
 
 func (item) TableName() string { return "repo_item" }
 
-func newTestRepo(t *testing.T) *Repository[item] {
+// openTestDB 每个用例独立的内存库，按 beans 建表，各测试在它之上准备自己的数据
+func openTestDB(t *testing.T, beans ...any) *DB {
 	t.Helper()
-	// 每个用例独立内存库；MaxOpenConns=1 避免多连接看不到 :memory: 表结构
+	// MaxOpenConns=1 避免多连接看不到 :memory: 表结构
 	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
 	engine, err := xorm.NewEngine("sqlite", dsn)
 	require.NoError(t, err)
 	engine.SetMaxOpenConns(1)
 	t.Cleanup(func() { _ = engine.Close() })
 
-	require.NoError(t, engine.Sync2(new(item)))
-	return NewRepository[item](New(engine))
+	require.NoError(t, engine.Sync2(beans...))
+	return New(engine)
+}
+
+func newTestRepo(t *testing.T) *Repository[item] {
+	t.Helper()
+	return NewRepository[item](openTestDB(t, new(item)))
 }
 
 func TestCRUDAndFindSemantics(t *testing.T) {
@@ -183,3 +189,20 @@ func TestNestedTransactionReusesOuter(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(2), count)
 }
+
+// TestForUpdateIgnoredWithoutRowLocks SQLite 不支持 FOR UPDATE，带行锁的查询照常执行
+func TestForUpdateIgnoredWithoutRowLocks(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	require.NoError(t, repo.Create(ctx, &item{Name: "locked", Score: 1}))
+
+	err := repo.Transaction(ctx, func(txCtx context.Context) error {
+		got, err := repo.QueryBuilder().Eq("name", "locked").ForUpdate().First(txCtx)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, 1, got.Score)
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,27 @@
+// Package repotest 为依赖 repository.DB 的测试提供基于内存 sqlite 的库，
+// 每个用例独立建库，用例结束自动关闭。
+package repotest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ayxworxfr/go_admin/pkg/repository"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+	"xorm.io/xorm"
+)
+
+// NewDB 按 beans 建表并返回以测试名隔离的内存库
+func NewDB(t testing.TB, beans ...any) *repository.DB {
+	t.Helper()
+	// MaxOpenConns=1 避免多连接看不到 :memory: 表结构
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	engine, err := xorm.NewEngine("sqlite", dsn)
+	require.NoError(t, err)
+	engine.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = engine.Close() })
+
+	require.NoError(t, engine.Sync2(beans...))
+	return repository.New(engine)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// 软删除：模型里带 xorm `deleted` 标签的列记录删除时间。xorm 本身会
+//   - 把 Delete 改写成写入删除时间的 UPDATE；
+//   - 在查询、计数与更新上排除已删除的记录。
+//
+// 仓储在此之上按 ctx 提供查询模式：WithDeleted 连同已删除的记录一起查，OnlyDeleted 只查已删除的，
+// 作用于 QueryBuilder（以及 Find/FindByID/FindAll/FindPage）的查询与计数。写操作不受模式影响：
+// Update 改不到已删除的记录，要先 Restore；物理删除只有 Purge 一个入口。
+// 没有 `deleted` 列的模型照旧物理删除，模式对它们没有意义。
+
+// zeroDeleteTime xorm 对未删除记录的另一种表示（批量插入时写入零值而不是 NULL），与 xorm 内部取值一致
+const zeroDeleteTime = "0001-01-01 00:00:00"
+
+type deletedKey struct{}
+
+type deletedMode uint8
+
+const (
+	excludeDeleted deletedMode = iota
+	includeDeleted
+	onlyDeleted
+)
+
+// WithDeleted 之后经由仓储的查询连同已软删除的记录一起返回
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedKey{}, includeDeleted)
+}
+
+// OnlyDeleted 之后经由仓储的查询只返回已软删除的记录，供回收站列表与恢复前的校验使用
+func OnlyDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedKey{}, onlyDeleted)
+}
+
+func deletedModeOf(ctx context.Context) deletedMode {
+	if ctx == nil {
+		return excludeDeleted
+	}
+	mode, _ := ctx.Value(deletedKey{}).(deletedMode)
+	return mode
+}
+
+var deletedCache sync.Map // reflect.Type -> string
+
+// deletedColumnOf 模型上带 `deleted` 标签的列名，模型不支持软删除时返回空串
+func deletedColumnOf[T any]() string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if cached, ok := deletedCache.Load(t); ok {
+		return cached.(string)
+	}
+	var col string
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("xorm")
+			if !containsToken(tag, "deleted") {
+				continue
+			}
+			if col = columnFromXormTag(tag); col != "" {
+				break
+			}
+		}
+	}
+	deletedCache.Store(t, col)
+	return col
+}
+
+// deletedCond 已软删除记录的条件，是 xorm 排除条件的取反
+func deletedCond(col string) builder.Cond {
+	quoted := quoteIdent(col)
+	return builder.And(builder.NotNull{quoted}, builder.Neq{quoted: zeroDeleteTime})
+}
+
+// readScope 查询与计数在 implicitCond 之外的软删除处理：unscoped 为 true 时放开 xorm 自带的排除，
+// cond 为只查已删除记录时追加的条件
+func readScope[T any](ctx context.Context) (cond builder.Cond, unscoped bool) {
+	mode := deletedModeOf(ctx)
+	col := deletedColumnOf[T]()
+	if mode == excludeDeleted || col == "" {
+		return nil, false
+	}
+	if mode == onlyDeleted {
+		return deletedCond(col), true
+	}
+	return nil, true
+}
+
+// Restore 恢复一条已软删除的记录。记录不存在、未被删除或属于别的租户时返回 ErrNotFound；
+// 模型不支持软删除时返回 ErrNotSoftDeletable
+func (r *Repository[T]) Restore(ctx context.Context, id any) error {
+	col := deletedColumnOf[T]()
+	if col == "" {
+		return ErrNotSoftDeletable
+	}
+	tenant := tenantCond[T](ctx)
+	var affected int64
+	err := r.db.withSession(ctx, func(session *xorm.Session) error {
+		session.Unscoped().Table(new(T)).ID(id).Where(deletedCond(col))
+		if tenant != nil {
+			session.And(tenant)
+		}
+		n, err := session.Update(map[string]any{col: nil})
+		affected = n
+		return err
+	})
+	if err != nil {
+		return wrapDBErr("Restore", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Purge 物理删除主键在 ids 内、且已软删除的记录，未删除的记录不受影响。
+// 关联数据由调用方在同一事务里先行清理；模型不支持软删除时返回 ErrNotSoftDeletable
+func (r *Repository[T]) Purge(ctx context.Context, ids any) error {
+	col := deletedColumnOf[T]()
+	if col == "" {
+		return ErrNotSoftDeletable
+	}
+	meta, err := metaOf[T]()
+	if err != nil {
+		return err
+	}
+	cond := builder.And(builder.In(quoteIdent(meta.pkColumn), toAnySlice(ids)...), deletedCond(col))
+	if tenant := tenantCond[T](ctx); tenant != nil {
+		cond = cond.And(tenant)
+	}
+	err = r.db.withSession(ctx, func(session *xorm.Session) error {
+		_, err := session.Unscoped().Where(cond).Delete(new(T))
+		return err
+	})
+	return wrapDBErr("Purge", err)
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// softItem 带软删除列与租户列的测试模型
+type softItem struct {
+	ID         int64     `xorm:"pk autoincr 'id'"`
+	TenantID   uint64    `xorm:"bigint notnull default 0 index 'tenant_id'" tenant:"id"`
+	Name       string    `xorm:"varchar(64) notnull 'name'"`
+	DeleteTime time.Time `xorm:"deleted 'delete_time'"`
+}
+
+func (softItem) TableName() string { return "repo_soft_item" }
+
+// newSoftRepo 租户 A 有 alice、bob、carol，租户 B 有 dave；软删除 A 的 bob 与 B 的 dave
+func newSoftRepo(t *testing.T) (*Repository[softItem], map[string]int64) {
+	t.Helper()
+	repo := NewRepository[softItem](openTestDB(t, new(softItem)))
+	ids := make(map[string]int64)
+	// 按固定顺序插入，ID 顺序与名字顺序一致
+	seed := []struct {
+		tenantID uint64
+		names    []string
+	}{{tenantA, []string{"alice", "bob", "carol"}}, {tenantB, []string{"dave"}}}
+	for _, group := range seed {
+		ctx := WithTenant(context.Background(), group.tenantID)
+		for _, name := range group.names {
+			row := &softItem{Name: name}
+			require.NoError(t, repo.Create(ctx, row))
+			ids[name] = row.ID
+		}
+	}
+	require.NoError(t, repo.DeleteByID(WithTenant(context.Background(), tenantA), ids["bob"]))
+	require.NoError(t, repo.DeleteByID(WithTenant(context.Background(), tenantB), ids["dave"]))
+	return repo, ids
+}
+
+func softNamesOf(rows []softItem) []string {
+	out := make([]string, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.Name)
+	}
+	return out
+}
+
+func TestSoftDeleteQueryModes(t *testing.T) {
+	repo, _ := newSoftRepo(t)
+	ctx := WithTenant(context.Background(), tenantA)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		{"default excludes deleted", ctx, []string{"alice", "carol"}},
+		{"with deleted", WithDeleted(ctx), []string{"alice", "bob", "carol"}},
+		{"only deleted", OnlyDeleted(ctx), []string{"bob"}},
+		{"only deleted across tenants", OnlyDeleted(WithAllTenants(ctx)), []string{"bob", "dave"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := repo.QueryBuilder().OrderBy("id").Find(tt.ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, softNamesOf(rows))
+
+			total, err := repo.QueryBuilder().Count(tt.ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), total)
+		})
+	}
+}
+
+func TestSoftDeleteFindByID(t *testing.T) {
+	repo, ids := newSoftRepo(t)
+	ctx := WithTenant(context.Background(), tenantA)
+
+	_, err := repo.FindByID(ctx, ids["bob"])
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	got, err := repo.FindByID(OnlyDeleted(ctx), ids["bob"])
+	require.NoError(t, err)
+	assert.False(t, got.DeleteTime.IsZero())
+
+	_, err = repo.FindByID(OnlyDeleted(ctx), ids["alice"])
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSoftDeleteRestore(t *testing.T) {
+	repo, ids := newSoftRepo(t)
+	ctx := WithTenant(context.Background(), tenantA)
+
+	require.NoError(t, repo.Restore(ctx, ids["bob"]))
+	got, err := repo.FindByID(ctx, ids["bob"])
+	require.NoError(t, err)
+	assert.True(t, got.DeleteTime.IsZero())
+
+	// 未删除的记录、别的租户的记录都恢复不了
+	assert.ErrorIs(t, repo.Restore(ctx, ids["alice"]), ErrNotFound)
+	assert.ErrorIs(t, repo.Restore(ctx, ids["dave"]), ErrNotFound)
+	_, err = repo.FindByID(WithTenant(context.Background(), tenantB), ids["dave"])
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSoftDeletePurge(t *testing.T) {
+	repo, ids := newSoftRepo(t)
+	ctx := WithTenant(context.Background(), tenantA)
+
+	// 未删除的 alice 与别的租户的 dave 不受影响
+	require.NoError(t, repo.Purge(ctx, []int64{ids["alice"], ids["bob"], ids["dave"]}))
+
+	rows, err := repo.QueryBuilder().OrderBy("id").Find(WithDeleted(WithAllTenants(ctx)))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "carol", "dave"}, softNamesOf(rows))
+}
+
+func TestSoftDeleteUnsupportedModel(t *testing.T) {
+	repo := newTenantRepo(t)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, repo.Restore(ctx, 1), ErrNotSoftDeletable)
+	assert.ErrorIs(t, repo.Purge(ctx, []int64{1}), ErrNotSoftDeletable)
+
+	// 查询模式对没有软删除列的模型不起作用，物理删除照旧
+	require.NoError(t, repo.DeleteByID(ctx, 1))
+	total, err := repo.QueryBuilder().Count(WithDeleted(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"xorm.io/builder"
+)
+
+// 租户隔离：模型里标注 `tenant:"id"` 的列存放租户 ID，ctx 上挂了租户时仓储自动
+//   - 在 QueryBuilder（以及 Find/FindByID/FindAll/FindPage）的查询、计数、删除上追加 tenant_id = ?；
+//   - 在按主键的 Update/Delete 上追加同样的条件，别的租户的记录改不到也删不掉；
+//   - 在 Create/BatchCreate/Update 时把该列写成 ctx 上的租户，调用方填的值会被覆盖。
+//
+// 与 DataScope 不同，租户条件对按主键的写操作同样生效：数据范围只是"看不看得到"，
+// 租户是硬隔离。Query 自定义 SQL 仍不过滤，写 SQL 的一方自己带上 tenant_id。
+//
+// ctx 上没有租户（定时任务、命令行工具）或显式跨租户（WithAllTenants）时不过滤也不改写。
+
+type tenantKey struct{}
+
+// tenantState nil 表示跨租户
+type tenantState struct {
+	id uint64
+}
+
+// WithTenant 为 ctx 挂上租户，之后经由仓储的读写都限定在该租户内
+func WithTenant(ctx context.Context, tenantID uint64) context.Context {
+	return context.WithValue(ctx, tenantKey{}, &tenantState{id: tenantID})
+}
+
+// WithAllTenants 取消 ctx 上的租户限定，供平台管理员跨租户操作使用
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, (*tenantState)(nil))
+}
+
+// TenantOf 返回 ctx 上的租户；scoped=false 表示不按租户过滤（未设置或已跨租户）
+func TenantOf(ctx context.Context) (tenantID uint64, scoped bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	state, _ := ctx.Value(tenantKey{}).(*tenantState)
+	if state == nil {
+		return 0, false
+	}
+	return state.id, true
+}
+
+// tenantColumn 模型上标注了租户的列，index 为 -1 表示模型不分租户
+type tenantColumn struct {
+	name  string
+	index int
+}
+
+var tenantCache sync.Map // reflect.Type -> tenantColumn
+
+func tenantColumnOf[T any]() tenantColumn {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if cached, ok := tenantCache.Load(t); ok {
+		return cached.(tenantColumn)
+	}
+	col := tenantColumn{index: -1}
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Tag.Get("tenant") != "id" {
+				continue
+			}
+			if name := columnFromXormTag(field.Tag.Get("xorm")); name != "" {
+				col = tenantColumn{name: name, index: i}
+				break
+			}
+		}
+	}
+	tenantCache.Store(t, col)
+	return col
+}
+
+// tenantCond 按 ctx 上的租户生成模型 T 的附加条件，无需过滤时返回 nil
+func tenantCond[T any](ctx context.Context) builder.Cond {
+	col := tenantColumnOf[T]()
+	if col.index < 0 {
+		return nil
+	}
+	tenantID, scoped := TenantOf(ctx)
+	if !scoped {
+		return nil
+	}
+	return builder.Eq{quoteIdent(col.name): tenantID}
+}
+
+// stampTenant 把 ctx 上的租户写进模型的租户列
+func stampTenant[T any](ctx context.Context, model *T) {
+	col := tenantColumnOf[T]()
+	if col.index < 0 {
+		return
+	}
+	tenantID, scoped := TenantOf(ctx)
+	if !scoped {
+		return
+	}
+	field := reflect.ValueOf(model).Elem().Field(col.index)
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(tenantID)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(int64(tenantID))
+	}
+}
+
+// implicitCond 仓储自动追加的条件：租户隔离与数据范围，两者以 AND 连接
+func implicitCond[T any](ctx context.Context) (builder.Cond, error) {
+	scope, err := scopeCond[T](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return andCond(tenantCond[T](ctx), scope), nil
+}
+
+// andCond 以 AND 连接两个可能为 nil 的条件
+func andCond(a, b builder.Cond) builder.Cond {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	default:
+		return builder.And(a, b)
+	}
+}
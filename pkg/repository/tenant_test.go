@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantItem 标注了租户列的测试模型
+type tenantItem struct {
+	ID       int64  `xorm:"pk autoincr 'id'"`
+	TenantID uint64 `xorm:"bigint notnull default 0 index 'tenant_id'" tenant:"id"`
+	Name     string `xorm:"varchar(64) notnull 'name'"`
+	Score    int    `xorm:"int 'score'"`
+	OwnerID  uint64 `xorm:"bigint 'owner_id'" datascope:"owner"`
+}
+
+func (tenantItem) TableName() string { return "repo_tenant_item" }
+
+const (
+	tenantA uint64 = 1
+	tenantB uint64 = 2
+)
+
+// newTenantRepo 两个租户各两条记录，名字在租户之间重复
+func newTenantRepo(t *testing.T) *Repository[tenantItem] {
+	t.Helper()
+	repo := NewRepository[tenantItem](openTestDB(t, new(tenantItem)))
+	for _, tenantID := range []uint64{tenantA, tenantB} {
+		ctx := WithTenant(context.Background(), tenantID)
+		require.NoError(t, repo.BatchCreate(ctx, []tenantItem{
+			{Name: "alice", OwnerID: 1},
+			{Name: "bob", OwnerID: 2},
+		}))
+	}
+	return repo
+}
+
+func tenantsOf(rows []tenantItem) []uint64 {
+	out := make([]uint64, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, r.TenantID)
+	}
+	return out
+}
+
+func TestTenantStampedOnCreate(t *testing.T) {
+	repo := newTenantRepo(t)
+	ctx := WithTenant(context.Background(), tenantB)
+
+	// 调用方填写的租户被 ctx 上的租户覆盖
+	row := &tenantItem{TenantID: tenantA, Name: "carol"}
+	require.NoError(t, repo.Create(ctx, row))
+	assert.Equal(t, tenantB, row.TenantID)
+
+	got, err := repo.FindByID(context.Background(), row.ID)
+	require.NoError(t, err)
+	assert.Equal(t, tenantB, got.TenantID)
+
+	// 没有租户的 ctx 不改写
+	plain := &tenantItem{TenantID: 7, Name: "dave"}
+	require.NoError(t, repo.Create(context.Background(), plain))
+	assert.Equal(t, uint64(7), plain.TenantID)
+}
+
+func TestTenantFiltersQueries(t *testing.T) {
+	repo := newTenantRepo(t)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []uint64
+	}{
+		{"tenant a", WithTenant(context.Background(), tenantA), []uint64{tenantA, tenantA}},
+		{"tenant b", WithTenant(context.Background(), tenantB), []uint64{tenantB, tenantB}},
+		{"unknown tenant", WithTenant(context.Background(), 99), []uint64{}},
+		{"no tenant", context.Background(), []uint64{tenantA, tenantA, tenantB, tenantB}},
+		{"all tenants", WithAllTenants(WithTenant(context.Background(), tenantA)), []uint64{tenantA, tenantA, tenantB, tenantB}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := repo.QueryBuilder().OrderBy("id ASC").Find(tt.ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tenantsOf(rows))
+
+			count, err := repo.QueryBuilder().Count(tt.ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), count)
+		})
+	}
+
+	// 按非零字段查询：名字在两个租户里都有，限定租户后不再是 ErrMultiple
+	ctxA := WithTenant(context.Background(), tenantA)
+	got, err := repo.Find(ctxA, &tenantItem{Name: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, tenantA, got.TenantID)
+	_, err = repo.Find(context.Background(), &tenantItem{Name: "alice"})
+	assert.ErrorIs(t, err, ErrMultiple)
+
+	rows, total, err := repo.FindPage(ctxA, &tenantItem{}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []uint64{tenantA, tenantA}, tenantsOf(rows))
+}
+
+func TestTenantIsolatesWritesByPrimaryKey(t *testing.T) {
+	repo := newTenantRepo(t)
+	ctxA := WithTenant(context.Background(), tenantA)
+	ctxB := WithTenant(context.Background(), tenantB)
+
+	foreign, err := repo.QueryBuilder().First(ctxB)
+	require.NoError(t, err)
+
+	_, err = repo.FindByID(ctxA, foreign.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "别的租户的记录按不存在处理")
+
+	// 拿着别的租户的主键更新：不生效，也不会把记录挪进自己的租户
+	require.NoError(t, repo.Update(ctxA, &tenantItem{ID: foreign.ID, Score: 100}))
+	got, err := repo.FindByID(ctxB, foreign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.Score)
+	assert.Equal(t, tenantB, got.TenantID)
+
+	require.NoError(t, repo.DeleteByID(ctxA, foreign.ID))
+	_, err = repo.FindByID(ctxB, foreign.ID)
+	require.NoError(t, err, "别的租户的记录删不掉")
+
+	require.NoError(t, repo.QueryBuilder().Delete(ctxA))
+	count, err := repo.QueryBuilder().Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count, "只应删掉租户 a 的记录")
+
+	// 本租户内的按主键写操作照常生效
+	require.NoError(t, repo.Update(ctxB, &tenantItem{ID: foreign.ID, Score: 5}))
+	got, err = repo.FindByID(ctxB, foreign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, got.Score)
+}
+
+func TestTenantCombinesWithDataScope(t *testing.T) {
+	repo := newTenantRepo(t)
+	ctx := WithTenant(scoped(&DataScope{UserIDs: []uint64{1}}), tenantB)
+
+	rows, err := repo.QueryBuilder().Find(ctx)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "alice", rows[0].Name)
+	assert.Equal(t, tenantB, rows[0].TenantID)
+}
+
+func TestTenantInTransaction(t *testing.T) {
+	repo := newTenantRepo(t)
+	ctx := WithTenant(context.Background(), tenantA)
+
+	err := repo.Transaction(ctx, func(txCtx context.Context) error {
+		if err := repo.Create(txCtx, &tenantItem{Name: "erin"}); err != nil {
+			return err
+		}
+		rows, err := repo.QueryBuilder().Find(txCtx)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, []uint64{tenantA, tenantA, tenantA}, tenantsOf(rows))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestTenantOf(t *testing.T) {
+	_, ok := TenantOf(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithTenant(context.Background(), tenantB)
+	id, ok := TenantOf(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, tenantB, id)
+
+	_, ok = TenantOf(WithAllTenants(ctx))
+	assert.False(t, ok, "跨租户不按租户过滤")
+}
@@ -0,0 +1,50 @@
+package resettoken
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_RoundTrip(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Now()
+	token := s.Issue(42, "hash-v1", now.Add(30*time.Minute))
+
+	id, err := UserID(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), id)
+	assert.NoError(t, s.Verify(token, "hash-v1", now))
+}
+
+func TestSigner_StateChangeInvalidates(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Now()
+	token := s.Issue(42, "hash-v1", now.Add(30*time.Minute))
+	assert.ErrorIs(t, s.Verify(token, "hash-v2", now), ErrInvalid)
+}
+
+func TestSigner_Expired(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Now()
+	token := s.Issue(42, "hash-v1", now.Add(30*time.Minute))
+	assert.ErrorIs(t, s.Verify(token, "hash-v1", now.Add(31*time.Minute)), ErrExpired)
+}
+
+func TestSigner_RejectsTampering(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Now()
+	token := s.Issue(42, "hash-v1", now.Add(30*time.Minute))
+
+	assert.ErrorIs(t, NewSigner("other").Verify(token, "hash-v1", now), ErrInvalid)
+
+	// 改写载荷里的用户 ID，签名不再匹配
+	forged := s.Issue(43, "hash-v1", now.Add(30*time.Minute))
+	assert.ErrorIs(t, s.Verify(forged[:20]+token[20:], "hash-v1", now), ErrInvalid)
+
+	_, err := UserID("not-a-token")
+	assert.ErrorIs(t, err, ErrMalformed)
+	assert.ErrorIs(t, s.Verify(token+"A", "hash-v1", now), ErrMalformed)
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 驱动名（与 file.storage.driver 配置值对齐）
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// Options 构造 Storage 的运行时参数。Root 仅 local 需要，S3 仅 s3 需要
+type Options struct {
+	Driver string
+	Root   string
+	S3     S3Options
+}
+
+// New 按驱动名创建 Storage。driver 为空时回落 local
+func New(opts Options) (Storage, error) {
+	switch strings.ToLower(strings.TrimSpace(opts.Driver)) {
+	case "", DriverLocal:
+		if opts.Root == "" {
+			return nil, fmt.Errorf("storage driver %q requires root", DriverLocal)
+		}
+		return NewLocal(opts.Root), nil
+	case DriverS3:
+		if opts.S3.Endpoint == "" || opts.S3.Bucket == "" {
+			return nil, fmt.Errorf("storage driver %q requires endpoint and bucket", DriverS3)
+		}
+		return NewS3(opts.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q (want %q or %q)", opts.Driver, DriverLocal, DriverS3)
+	}
+}
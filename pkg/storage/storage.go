@@ -0,0 +1,42 @@
+// Package storage 保存上传文件的内容。业务代码只依赖 Storage 接口、按键存取字节流，
+// 元数据（文件名、大小、类型、哈希）由调用方自己落库。
+//
+// 提供本地磁盘和 S3 兼容对象存储（AWS S3、MinIO 等）两种实现；S3 只用到 PUT/GET/DELETE
+// 三个接口，直接按 SigV4 签名发 HTTP 请求，不为此引入完整的 SDK。
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotFound 键对应的对象不存在
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidKey 键为空、是绝对路径或含 ".." 段
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage 按键存取对象。键是 "/" 分隔的相对路径，同一个键重复 Put 会覆盖
+type Storage interface {
+	// Put 写入对象，size 为内容长度（未知时传 -1），contentType 为空时由实现决定默认值
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open 读取对象，调用方负责 Close；不存在时返回 ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，不存在时不报错
+	Delete(ctx context.Context, key string) error
+}
+
+// validateKey 键由调用方生成，这里仍然拒绝可能逃出存储根目录的写法
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
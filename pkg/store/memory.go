@@ -85,6 +85,24 @@ func (s *Memory[K, V]) SetUntil(key K, value V, expiresAt time.Time) {
 	s.SetWithTTL(key, value, time.Until(expiresAt))
 }
 
+// SetIfAbsentUntil 仅当键不存在（或已过期）时写入，返回是否写入成功；
+// 判断与写入在同一把锁内完成，可用作一次性标记（语义同 Redis SET NX）。
+// expiresAt 已过去时不写入并返回 false。
+func (s *Memory[K, V]) SetIfAbsentUntil(key K, value V, expiresAt time.Time) bool {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.m[key]; ok && !isExpired(e.expiresAt) {
+		return false
+	}
+	s.m[key] = entry[V]{value: value, expiresAt: time.Now().Add(ttl)}
+	s.cleanupLocked()
+	return true
+}
+
 // Delete 删除指定键
 func (s *Memory[K, V]) Delete(key K) {
 	s.mu.Lock()
@@ -92,6 +110,17 @@ func (s *Memory[K, V]) Delete(key K) {
 	delete(s.m, key)
 }
 
+// DeleteFunc 删除所有满足 match 的键，用于按键的一部分批量失效（如某个用户的全部条目）
+func (s *Memory[K, V]) DeleteFunc(match func(key K) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.m {
+		if match(k) {
+			delete(s.m, k)
+		}
+	}
+}
+
 // Clear 清空全部键
 func (s *Memory[K, V]) Clear() {
 	s.mu.Lock()
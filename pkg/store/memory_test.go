@@ -1,6 +1,7 @@
 package store
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -65,6 +66,17 @@ func TestMemory_Clear(t *testing.T) {
 	require.Equal(t, 0, s.Len())
 }
 
+func TestMemory_DeleteFunc(t *testing.T) {
+	s := NewMemory[string, int](time.Minute)
+	s.Set("u1:a", 1)
+	s.Set("u1:b", 2)
+	s.Set("u2:a", 3)
+	s.DeleteFunc(func(k string) bool { return strings.HasPrefix(k, "u1:") })
+	require.False(t, s.Has("u1:a"))
+	require.False(t, s.Has("u1:b"))
+	require.True(t, s.Has("u2:a"))
+}
+
 func TestMemory_CleanupOnWrite(t *testing.T) {
 	s := NewMemory[string, int](0)
 	s.SetWithTTL("old", 1, 5*time.Millisecond)
@@ -73,3 +85,16 @@ func TestMemory_CleanupOnWrite(t *testing.T) {
 	require.False(t, s.Has("old"))
 	require.True(t, s.Has("new"))
 }
+
+func TestMemory_SetIfAbsentUntil(t *testing.T) {
+	s := NewMemory[string, struct{}](0)
+	require.True(t, s.SetIfAbsentUntil("k", struct{}{}, time.Now().Add(time.Hour)))
+	require.False(t, s.SetIfAbsentUntil("k", struct{}{}, time.Now().Add(time.Hour)))
+
+	require.False(t, s.SetIfAbsentUntil("past", struct{}{}, time.Now().Add(-time.Second)))
+	require.False(t, s.Has("past"))
+
+	s.SetWithTTL("short", struct{}{}, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	require.True(t, s.SetIfAbsentUntil("short", struct{}{}, time.Now().Add(time.Hour)))
+}
@@ -0,0 +1,71 @@
+package tabular
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// utf8BOM Excel 靠它识别 UTF-8 编码的 CSV，否则中文会乱码。写出时带上，读取时去掉
+const utf8BOM = "\ufeff"
+
+func readCSV(r io.Reader, maxRows int) ([][]string, error) {
+	br := bufio.NewReader(r)
+	if head, err := br.Peek(len(utf8BOM)); err == nil && string(head) == utf8BOM {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		// csv.Reader 跳过空行，按行号补齐，保证下标与行号对应
+		line, _ := reader.FieldPos(0)
+		if line > maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+		for len(rows) < line-1 {
+			rows = append(rows, []string{})
+		}
+		rows = append(rows, record)
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+// Write 以 = + - @ 开头的单元格前面加单引号，防止表格软件把导出的数据当公式执行（CSV 注入）
+func (c *csvWriter) Write(row []string) error {
+	escaped := make([]string, len(row))
+	for i, cell := range row {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cell = "'" + cell
+		}
+		escaped[i] = cell
+	}
+	return c.w.Write(escaped)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,94 @@
+// Package tabular 读写批量导入导出用的表格文件，支持 CSV 与 XLSX。
+//
+// 只覆盖导入导出需要的那部分：读取时取第一个工作表的全部单元格文本，写入时逐行流式输出
+// 纯文本单元格。样式、公式、多工作表都不处理；XLSX 直接用 archive/zip + encoding/xml
+// 实现，不为此引入完整的电子表格库。
+package tabular
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format 表格文件格式
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// 格式与文件内容错误
+var (
+	ErrUnsupportedFormat = errors.New("unsupported table format")
+	ErrMalformed         = errors.New("malformed table file")
+)
+
+// ParseFormat 解析格式名，大小写不敏感，空串按 CSV 处理
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// FormatOf 按文件扩展名判断格式
+func FormatOf(filename string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(filename), ".")
+	if ext == "" {
+		return "", ErrUnsupportedFormat
+	}
+	return ParseFormat(ext)
+}
+
+// ContentType 格式对应的 MIME 类型，用于下载响应
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ReadAll 读取全部行。第 i 个元素对应文件里的第 i+1 行，中间的空行保留为空切片，
+// 调用方据此报告行号；每行的列数可能不同。
+// maxRows 为文件行数上限（含表头与中间的空行），有内容的行超出时返回 ErrMalformed：
+// 行号来自上传的文件，不设上限时一个很大的行号就能让补齐空行耗尽内存
+func ReadAll(r io.Reader, format Format, maxRows int) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, maxRows)
+	case FormatXLSX:
+		return readXLSX(r, maxRows)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func tooManyRows(maxRows int) error {
+	return fmt.Errorf("%w: more than %d rows", ErrMalformed, maxRows)
+}
+
+// Writer 逐行写出表格，Close 之后输出才完整
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// NewWriter 创建 format 格式的写入器，Close 不会关闭 w
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
@@ -0,0 +1,162 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMaxRows = 100
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Format
+		wantErr  bool
+	}{
+		{"users.csv", FormatCSV, false},
+		{"USERS.XLSX", FormatXLSX, false},
+		{"users.xls", "", true},
+		{"users", "", true},
+	}
+	for _, tt := range tests {
+		got, err := FormatOf(tt.filename)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, ErrUnsupportedFormat, tt.filename)
+			continue
+		}
+		require.NoError(t, err, tt.filename)
+		assert.Equal(t, tt.want, got, tt.filename)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"username", "email", "roles"},
+		{"alice", "alice@example.com", "ADMIN,USER"},
+		{"张三", "a<b>&\"c\"@example.com", ""},
+		{"multi\nline", "", "x"},
+	}
+	for _, format := range []Format{FormatCSV, FormatXLSX} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format)
+			require.NoError(t, err)
+			for _, row := range rows {
+				require.NoError(t, w.Write(row))
+			}
+			require.NoError(t, w.Close())
+
+			got, err := ReadAll(&buf, format, testMaxRows)
+			require.NoError(t, err)
+			assert.Equal(t, rows, got)
+		})
+	}
+}
+
+func TestCSV_EscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV)
+	require.NoError(t, err)
+	require.NoError(t, w.Write([]string{"=1+1", "@SUM(A1)", "plain", ""}))
+	require.NoError(t, w.Close())
+
+	assert.True(t, strings.HasPrefix(buf.String(), utf8BOM))
+	got, err := ReadAll(&buf, FormatCSV, testMaxRows)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"'=1+1", "'@SUM(A1)", "plain", ""}}, got)
+}
+
+func TestCSV_KeepsLineNumbers(t *testing.T) {
+	got, err := ReadAll(strings.NewReader("a,b\n\nc\n"), FormatCSV, testMaxRows)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {}, {"c"}}, got)
+}
+
+func TestCSV_Malformed(t *testing.T) {
+	_, err := ReadAll(strings.NewReader("a,\"b\n"), FormatCSV, testMaxRows)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+// TestXLSX_SharedStringsAndSparseCells 表格软件保存的文件：共享字符串、富文本、跳过的行与列、科学计数法的数字
+func TestXLSX_SharedStringsAndSparseCells(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>username</t></si><si><r><t>ali</t></r><r><t>ce</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>phone</t></is></c></row>` +
+			`<row r="3"><c r="A3" t="s"><v>1</v></c><c r="C3"><v>1.3800138E10</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	got, err := ReadAll(&buf, FormatXLSX, testMaxRows)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"username", "", "phone"},
+		{},
+		{"alice", "", "13800138000"},
+	}, got)
+}
+
+func TestXLSX_Malformed(t *testing.T) {
+	_, err := ReadAll(strings.NewReader("not a zip"), FormatXLSX, testMaxRows)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestCSV_TooManyRows(t *testing.T) {
+	_, err := ReadAll(strings.NewReader("a\nb\nc\n"), FormatCSV, 2)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+// TestXLSX_RowNumberLimit 巨大的行号在补齐空行之前就被拒绝；设置过格式的空行不受限制
+func TestXLSX_RowNumberLimit(t *testing.T) {
+	_, err := ReadAll(xlsxWithRows(t, `<row r="2147483647"><c r="A2147483647" t="inlineStr"><is><t>x</t></is></c></row>`), FormatXLSX, testMaxRows)
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	_, err = ReadAll(xlsxWithRows(t, strings.Repeat(`<row><c t="inlineStr"><is><t>x</t></is></c></row>`, testMaxRows+1)), FormatXLSX, testMaxRows)
+	assert.ErrorIs(t, err, ErrMalformed)
+
+	got, err := ReadAll(xlsxWithRows(t, `<row r="1"><c r="A1" t="inlineStr"><is><t>x</t></is></c></row><row r="1048576"><c r="A1048576" s="1"/></row>`), FormatXLSX, testMaxRows)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"x"}}, got)
+}
+
+func TestXLSX_ColumnLimit(t *testing.T) {
+	_, err := ReadAll(xlsxWithRows(t, `<row r="1"><c r="`+strings.Repeat("Z", 20)+`1"><v>1</v></c></row>`), FormatXLSX, testMaxRows)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+// xlsxWithRows 只含一个工作表的最小 XLSX，rows 为 sheetData 里的内容
+func xlsxWithRows(t *testing.T, rows string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + rows + `</sheetData></worksheet>`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return &buf
+}
+
+func TestColumnName(t *testing.T) {
+	for i, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, name, columnName(i))
+		got, err := columnIndex(name + "7")
+		require.NoError(t, err)
+		assert.Equal(t, i, got)
+	}
+}
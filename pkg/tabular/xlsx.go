@@ -0,0 +1,312 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxPartSize 单个 XLSX 部件解压后的上限。上传大小由调用方限制，这里防的是压缩比极高的恶意文件
+	maxPartSize = 32 << 20
+	// maxColumns XLSX 的列数上限（XFD 列）
+	maxColumns = 16384
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText 共享字符串与内联字符串共用的结构：纯文本在 <t> 里，富文本拆成多个 <r><t>
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t *xlsxText) String() string {
+	if len(t.R) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	b.WriteString(t.T)
+	for _, r := range t.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string    `xml:"r,attr"`
+			T  string    `xml:"t,attr"`
+			V  string    `xml:"v"`
+			IS *xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(r io.Reader, maxRows int) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	sheetFile, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, fmt.Errorf("%w: worksheet not found", ErrMalformed)
+	}
+	var sheet xlsxSheet
+	if err := decodePart(sheetFile, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		var cells []string
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.R != "" {
+				if col, err = columnIndex(c.R); err != nil {
+					return nil, err
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			value, err := cellValue(c.T, c.V, c.IS, shared.Items)
+			if err != nil {
+				return nil, err
+			}
+			cells[col] = value
+		}
+		// 表格软件会为设置过格式的空行写出 <row>，这类行不占位，也就不受行数上限约束
+		if !hasValue(cells) {
+			continue
+		}
+
+		// 省略了行号的行紧接上一行
+		index := row.R - 1
+		if row.R <= 0 {
+			index = len(rows)
+		}
+		if index >= maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+		for len(rows) <= index {
+			rows = append(rows, []string{})
+		}
+		rows[index] = cells
+	}
+	return rows, nil
+}
+
+func hasValue(cells []string) bool {
+	for _, c := range cells {
+		if c != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// firstSheetPath 工作簿里第一个工作表的部件路径，工作簿信息不全时退回默认路径
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	wb, ok1 := files["xl/workbook.xml"]
+	rf, ok2 := files["xl/_rels/workbook.xml.rels"]
+	if !ok1 || !ok2 || decodePart(wb, &workbook) != nil || decodePart(rf, &rels) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		// Target 相对 xl/ 目录，也可能写成以 / 开头的包内绝对路径
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+func decodePart(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if len(data) > maxPartSize {
+		return fmt.Errorf("%w: %s is too large", ErrMalformed, f.Name)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, f.Name, err)
+	}
+	return nil
+}
+
+func cellValue(typ, v string, inline *xlsxText, shared []xlsxText) (string, error) {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || i < 0 || i >= len(shared) {
+			return "", fmt.Errorf("%w: bad shared string index %q", ErrMalformed, v)
+		}
+		return shared[i].String(), nil
+	case "inlineStr":
+		if inline == nil {
+			return "", nil
+		}
+		return inline.String(), nil
+	case "", "n":
+		// 长数字（手机号之类）会被表格软件存成科学计数法，还原成普通写法
+		if strings.ContainsAny(v, "eE") {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return strconv.FormatFloat(f, 'f', -1, 64), nil
+			}
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// columnIndex 单元格引用（如 "AB12"）的列号，从 0 开始
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' || col > maxColumns {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+	}
+	if col == 0 || col > maxColumns {
+		return 0, fmt.Errorf("%w: bad cell reference %q", ErrMalformed, ref)
+	}
+	return col - 1, nil
+}
+
+// columnName columnIndex 的逆运算
+func columnName(index int) string {
+	var name []byte
+	for index >= 0 {
+		name = append([]byte{byte('A' + index%26)}, name...)
+		index = index/26 - 1
+	}
+	return string(name)
+}
+
+// xlsx 写出时的固定部件：只有一个名为 Sheet1 的工作表，单元格全部是内联字符串，不需要共享字符串表
+var xlsxStaticParts = []struct{ name, body string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+	buf   bytes.Buffer
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxStaticParts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	// 工作表放在最后一个部件，行数据边写边压缩，不需要先在内存里攒齐
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	head := xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	if _, err := io.WriteString(sheet, head); err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	x.row++
+	x.buf.Reset()
+	fmt.Fprintf(&x.buf, `<row r="%d">`, x.row)
+	for i, cell := range row {
+		fmt.Fprintf(&x.buf, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(i), x.row)
+		if err := xml.EscapeText(&x.buf, []byte(cell)); err != nil {
+			return err
+		}
+		x.buf.WriteString(`</t></is></c>`)
+	}
+	x.buf.WriteString(`</row>`)
+	_, err := x.sheet.Write(x.buf.Bytes())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
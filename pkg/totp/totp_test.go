@@ -0,0 +1,71 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量（取 8 位结果的后 6 位）
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := Code(secret, time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, code, "t=%d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+
+	code, err := Code(secret, now)
+	require.NoError(t, err)
+	step, ok := Validate(secret, code, now, 1)
+	require.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// 上一个时间步的口令在 skew=1 时仍然有效，skew=0 时无效
+	prev, err := Code(secret, now.Add(-Period))
+	require.NoError(t, err)
+	_, ok = Validate(secret, prev, now, 1)
+	assert.True(t, ok)
+	_, ok = Validate(secret, prev, now, 0)
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 1)
+	assert.False(t, ok)
+	_, ok = Validate("not-base32!", code, now, 1)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Go Admin", "alice", "JBSWY3DPEHPK3PXP")
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/Go%20Admin:alice?"))
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", q.Get("secret"))
+	assert.Equal(t, "Go Admin", q.Get("issuer"))
+	assert.Equal(t, "6", q.Get("digits"))
+	assert.Equal(t, "30", q.Get("period"))
+}